@@ -0,0 +1,52 @@
+package colibri
+
+import (
+	"errors"
+	"net/url"
+)
+
+// ErrInvalidBasicAuth is returned when the value is not a valid BasicAuth.
+var ErrInvalidBasicAuth = errors.New("invalid basic auth")
+
+// BasicAuth specifies the credentials of the HTTP Basic authentication.
+type BasicAuth struct {
+	// Username specifies the user name.
+	Username string `json:"username"`
+
+	// Password specifies the password.
+	Password string `json:"password,omitempty"`
+}
+
+// Clone returns a copy of the credentials.
+func (auth *BasicAuth) Clone() *BasicAuth {
+	if auth == nil {
+		return nil
+	}
+
+	newAuth := *auth
+	return &newAuth
+}
+
+func toBasicAuth(value any) (*BasicAuth, error) {
+	authMap, ok := value.(map[string]any)
+	if !ok {
+		return nil, ErrInvalidBasicAuth
+	}
+
+	auth := &BasicAuth{}
+	if err := processRaw(authMap, auth); err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// sameHostCredentials removes the credentials of the rules if the host of their URL
+// is not the host of u, so the credentials are only sent to the host of the original request.
+func sameHostCredentials(rules *Rules, u *url.URL) {
+	if (rules.URL == nil) || (u == nil) || (rules.URL.Host == u.Host) {
+		return
+	}
+
+	rules.BasicAuth = nil
+	rules.BearerToken = ""
+}
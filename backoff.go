@@ -0,0 +1,189 @@
+package colibri
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// Names of the backoff strategies in the raw rules, see the Backoff field of the Rules structure.
+const (
+	BackoffFixed        = "fixed"
+	BackoffExponential  = "exponential"
+	BackoffDecorrelated = "decorrelated"
+)
+
+// ErrInvalidBackoff is returned when the value is not a valid backoff strategy.
+var ErrInvalidBackoff = errors.New("invalid backoff")
+
+// Backoff calculates the wait time before each retry of an HTTP request.
+type Backoff interface {
+	// Backoff returns the wait time before the retry, attempt is the number of the retry
+	// starting at 1 and last is the wait time before the previous retry, zero for the first retry.
+	Backoff(attempt int, last time.Duration) time.Duration
+}
+
+// BackoffFunc is a function that implements the Backoff interface.
+type BackoffFunc func(attempt int, last time.Duration) time.Duration
+
+func (f BackoffFunc) Backoff(attempt int, last time.Duration) time.Duration {
+	return f(attempt, last)
+}
+
+// FixedBackoff waits the same time before each retry.
+type FixedBackoff struct {
+	// Delay specifies the wait time.
+	Delay time.Duration
+}
+
+func (b *FixedBackoff) Backoff(int, time.Duration) time.Duration {
+	return b.Delay
+}
+
+func (b *FixedBackoff) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{"type": BackoffFixed, "delay": fromDuration(b.Delay)})
+}
+
+// ExponentialBackoff multiplies the wait time by Factor on each retry, starting with Base.
+type ExponentialBackoff struct {
+	// Base specifies the wait time before the first retry.
+	Base time.Duration
+
+	// Max specifies the maximum wait time. If it is zero, there is no limit.
+	Max time.Duration
+
+	// Factor specifies the multiplier of each retry. If it is less than 1, 2 is used.
+	Factor float64
+
+	// Jitter specifies whether the wait time is a random value between zero and the calculated one,
+	// so the retries of several clients are spread out.
+	Jitter bool
+}
+
+func (b *ExponentialBackoff) Backoff(attempt int, _ time.Duration) time.Duration {
+	factor := b.Factor
+	if factor < 1 {
+		factor = 2
+	}
+
+	d := float64(b.Base) * math.Pow(factor, float64(max(attempt-1, 0)))
+	if (b.Max > 0) && (d > float64(b.Max)) {
+		d = float64(b.Max)
+	} else if d > math.MaxInt64 {
+		d = math.MaxInt64
+	}
+
+	wait := time.Duration(d)
+	if b.Jitter && (wait > 0) {
+		wait = rand.N(wait + 1)
+	}
+	return wait
+}
+
+func (b *ExponentialBackoff) MarshalJSON() ([]byte, error) {
+	raw := map[string]any{"type": BackoffExponential, "base": fromDuration(b.Base)}
+	if b.Max != 0 {
+		raw["max"] = fromDuration(b.Max)
+	}
+
+	if b.Factor != 0 {
+		raw["factor"] = b.Factor
+	}
+
+	if b.Jitter {
+		raw["jitter"] = true
+	}
+	return json.Marshal(raw)
+}
+
+// DecorrelatedBackoff waits a random time between Base and three times the previous wait time,
+// the "decorrelated jitter" strategy.
+type DecorrelatedBackoff struct {
+	// Base specifies the minimum wait time.
+	Base time.Duration
+
+	// Max specifies the maximum wait time. If it is zero, there is no limit.
+	Max time.Duration
+}
+
+func (b *DecorrelatedBackoff) Backoff(_ int, last time.Duration) time.Duration {
+	upper := max(3*last, b.Base)
+
+	wait := b.Base
+	if upper > b.Base {
+		wait += rand.N(upper - b.Base + 1)
+	}
+
+	if (b.Max > 0) && (wait > b.Max) {
+		wait = b.Max
+	}
+	return wait
+}
+
+func (b *DecorrelatedBackoff) MarshalJSON() ([]byte, error) {
+	raw := map[string]any{"type": BackoffDecorrelated, "base": fromDuration(b.Base)}
+	if b.Max != 0 {
+		raw["max"] = fromDuration(b.Max)
+	}
+	return json.Marshal(raw)
+}
+
+// toBackoff returns the backoff of the raw value, e.g. {"type": "exponential", "base": 1000, "max": 30000}.
+// The durations are in milliseconds.
+func toBackoff(value any) (Backoff, error) {
+	backoffMap, ok := value.(map[string]any)
+	if !ok {
+		return nil, ErrInvalidBackoff
+	}
+
+	var (
+		durations = make(map[string]time.Duration)
+		name      string
+		factor    float64
+		jitter    bool
+	)
+	for key, v := range backoffMap {
+		var err error
+		switch strings.ToLower(key) {
+		case "type":
+			name, ok = v.(string)
+			if !ok {
+				err = ErrMustBeString
+			}
+		case "delay", "base", "max":
+			durations[strings.ToLower(key)], err = toDuration(v)
+		case "factor":
+			switch f := v.(type) {
+			case float64:
+				factor = f
+			case int:
+				factor = float64(f)
+			default:
+				err = ErrMustBeNumber
+			}
+		case "jitter":
+			if jitter, ok = v.(bool); !ok {
+				err = ErrInvalidBackoff
+			}
+		default:
+			err = ErrInvalidBackoff
+		}
+
+		if err != nil {
+			return nil, AddError(nil, key, err)
+		}
+	}
+
+	switch strings.ToLower(name) {
+	case BackoffFixed:
+		return &FixedBackoff{Delay: durations["delay"]}, nil
+	case BackoffExponential:
+		return &ExponentialBackoff{Base: durations["base"], Max: durations["max"], Factor: factor, Jitter: jitter}, nil
+	case BackoffDecorrelated:
+		return &DecorrelatedBackoff{Base: durations["base"], Max: durations["max"]}, nil
+	}
+	return nil, ErrInvalidBackoff
+}
@@ -0,0 +1,100 @@
+package colibri
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	b := &ExponentialBackoff{Base: 100 * time.Millisecond, Max: time.Second}
+
+	tests := []struct {
+		Attempt int
+		Want    time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{5, time.Second},
+		{100, time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := b.Backoff(tt.Attempt, 0); got != tt.Want {
+			t.Fatalf("got %v, want %v", got, tt.Want)
+		}
+	}
+
+	b.Jitter = true
+	for i := 0; i < 100; i++ {
+		if got := b.Backoff(3, 0); (got < 0) || (got > 400*time.Millisecond) {
+			t.Fatalf("got %v, want <= %v", got, 400*time.Millisecond)
+		}
+	}
+}
+
+func TestDecorrelatedBackoff(t *testing.T) {
+	var (
+		b    = &DecorrelatedBackoff{Base: 100 * time.Millisecond, Max: time.Second}
+		last time.Duration
+	)
+	for attempt := 1; attempt < 20; attempt++ {
+		wait := b.Backoff(attempt, last)
+		if (wait < b.Base) || (wait > b.Max) || (wait > max(3*last, b.Base)) {
+			t.Fatalf("got %v, last %v", wait, last)
+		}
+		last = wait
+	}
+}
+
+func TestToBackoff(t *testing.T) {
+	tests := []struct {
+		Raw     string
+		Want    Backoff
+		WantErr bool
+	}{
+		{`{"type": "fixed", "delay": 500}`, &FixedBackoff{Delay: 500 * time.Millisecond}, false},
+		{`{"type": "Exponential", "base": 100, "max": 5000, "factor": 1.5, "jitter": true}`, &ExponentialBackoff{Base: 100 * time.Millisecond, Max: 5 * time.Second, Factor: 1.5, Jitter: true}, false},
+		{`{"type": "decorrelated", "base": 100, "max": 5000}`, &DecorrelatedBackoff{Base: 100 * time.Millisecond, Max: 5 * time.Second}, false},
+		{`{"type": "unknown"}`, nil, true},
+		{`{"type": "fixed", "delay": "1s"}`, nil, true},
+		{`"exponential"`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Raw, func(t *testing.T) {
+			var raw any
+			if err := json.Unmarshal([]byte(tt.Raw), &raw); err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := toBackoff(raw)
+			if (err != nil) != tt.WantErr {
+				t.Fatalf("got %v, want %v", err, tt.WantErr)
+			} else if !reflect.DeepEqual(b, tt.Want) {
+				t.Fatalf("got %v, want %v", b, tt.Want)
+			}
+
+			if tt.Want == nil {
+				return
+			}
+
+			// The backoff is marshaled in the same format.
+			data, err := json.Marshal(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got any
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if b, err := toBackoff(got); (err != nil) || !reflect.DeepEqual(b, tt.Want) {
+				t.Fatalf("got %v %v, want %v", b, err, tt.Want)
+			}
+		})
+	}
+}
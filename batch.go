@@ -0,0 +1,121 @@
+package colibri
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+)
+
+// DefaultBatchConcurrency default maximum number of rules extracted at the same time by ExtractAll.
+const DefaultBatchConcurrency = 4
+
+// BatchOptions configures an ExtractAll.
+type BatchOptions struct {
+	// Context specifies the context of the batch, when it is canceled the pending rules
+	// are not extracted. If it is nil, context.Background is used.
+	Context context.Context
+
+	// Concurrency specifies the maximum number of rules extracted at the same time.
+	// If it is less than 1, DefaultBatchConcurrency is used.
+	Concurrency int
+
+	// OnOutput is called with the output of each rules as soon as it is extracted,
+	// in the order in which they complete. The calls are not concurrent.
+	OnOutput func(rules *Rules, output *Output, err error)
+
+	// Sink receives the output of each rules as soon as it is extracted.
+	// The errors of the sink are added to the errors of the rules.
+	// The sink is not closed when the batch ends.
+	Sink Sink
+}
+
+// ExtractAll extracts the data of many independent rules concurrently, e.g. hundreds of seed URLs,
+// and returns their outputs in the order of the rules.
+//
+// The rules share the components of c, so Delay and RobotsTxt are respected across all the requests
+// to the same host. The errors are stored by the index of the rules. If the context is canceled,
+// the outputs of the rules not extracted are nil and its error is returned.
+func (c *Colibri) ExtractAll(rules []*Rules, opts *BatchOptions) ([]*Output, error) {
+	if opts == nil {
+		opts = &BatchOptions{}
+	}
+
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = DefaultBatchConcurrency
+	}
+
+	if !c.life.begin() {
+		return make([]*Output, len(rules)), ErrShutdown
+	}
+	defer c.life.end()
+
+	var (
+		outputs = make([]*Output, len(rules))
+		errs    = make([]error, len(rules))
+		pool    = newWorkerPool(ctx, concurrency, c.workerMetrics())
+		mu      sync.Mutex
+	)
+
+	var shutdown bool
+	for i, r := range rules {
+		if r == nil {
+			errs[i] = ErrRulesIsNil
+			continue
+		}
+
+		if c.life.closed() {
+			shutdown = true
+			break
+		}
+
+		ok := pool.Go(func(context.Context) {
+			runRules := r.Clone()
+			output, err := c.Extract(runRules)
+			ReleaseRules(runRules)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if opts.OnOutput != nil {
+				opts.OnOutput(r, output, err)
+			}
+
+			if opts.Sink != nil {
+				if sinkErr := opts.Sink.Write(r, output, err); sinkErr != nil {
+					err = errors.Join(err, sinkErr)
+				}
+			}
+			outputs[i], errs[i] = output, err
+		}, func(err *PanicError) {
+			errs[i] = err
+		})
+		if !ok {
+			break
+		}
+	}
+	pool.Wait()
+
+	var result error
+	for i, err := range errs {
+		if err != nil {
+			result = AddError(result, strconv.Itoa(i), err)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		result = errors.Join(result, err)
+	}
+
+	// The sink is flushed once the rules in progress are written.
+	if shutdown {
+		result = errors.Join(result, ErrShutdown, flushSink(opts.Sink))
+	}
+	return outputs, result
+}
@@ -0,0 +1,79 @@
+package colibri
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestExtractAll(t *testing.T) {
+	c := New()
+	c.Client = &testClient{}
+	c.Parser = &testParser{}
+
+	rules := make([]*Rules, 10)
+	for i := range rules {
+		rules[i] = &Rules{
+			URL:       mustNewURL(fmt.Sprintf("http://example.com/%d", i)),
+			Selectors: []*Selector{{Name: "title", Expr: "//title"}},
+			Extra:     map[string]any{},
+		}
+	}
+	rules[3].Extra["doErr"] = errors.New("do error")
+	rules = append(rules, nil)
+
+	var completed int
+	outputs, err := c.ExtractAll(rules, &BatchOptions{
+		Concurrency: 3,
+		OnOutput: func(_ *Rules, _ *Output, _ error) {
+			completed++
+		},
+	})
+
+	var errs *Errs
+	if !errors.As(err, &errs) {
+		t.Fatalf("got %v, want *Errs", err)
+	}
+
+	if e, _ := errs.Get("10"); !errors.Is(e, ErrRulesIsNil) {
+		t.Fatalf("got %v, want %v", e, ErrRulesIsNil)
+	}
+
+	if e, _ := errs.Get("3"); e == nil {
+		t.Fatal("error expected")
+	}
+
+	if completed != 10 {
+		t.Fatalf("got %v, want %v", completed, 10)
+	}
+
+	want := map[string]any{"title": "test"}
+	for i, output := range outputs {
+		if (i == 3) || (i == 10) {
+			if output != nil {
+				t.Fatalf("got %v, want nil", output)
+			}
+			continue
+		}
+
+		if (output == nil) || !reflect.DeepEqual(output.Data, want) {
+			t.Fatalf("got %v, want %v", output, want)
+		}
+	}
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		outputs, err := c.ExtractAll(rules[:2], &BatchOptions{Context: ctx})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("got %v, want %v", err, context.Canceled)
+		}
+
+		if (outputs[0] != nil) || (outputs[1] != nil) {
+			t.Fatal("not extracted rules expected")
+		}
+	})
+}
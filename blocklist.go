@@ -0,0 +1,77 @@
+package colibri
+
+import (
+	"errors"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// ErrBlocked is returned when the host or the address of a URL is in the Blocklist.
+var ErrBlocked = errors.New("URL blocked")
+
+// Blocklist specifies the hosts and addresses that cannot be requested, see the Blocklist field of Colibri.
+// It protects against requests to internal addresses when the rules are not trusted,
+// e.g. selectors with Follow that find URLs of the local network.
+type Blocklist struct {
+	// Hosts contains the blocked hosts.
+	// "example.com" only blocks the host example.com, ".example.com" also blocks its subdomains
+	// and "*.example.com" only blocks its subdomains.
+	Hosts []string
+
+	// Networks contains the blocked IP addresses and CIDR ranges, e.g. "10.0.0.0/8".
+	// The values that are not valid are ignored.
+	Networks []string
+
+	// Private specifies whether the loopback, private, link-local, multicast
+	// and unspecified addresses are blocked.
+	Private bool
+}
+
+// Check returns ErrBlocked if the host of the URL is blocked.
+// If the host is an IP address, the address is checked, see CheckIP.
+// The addresses of the host names are checked by the Client when they are resolved.
+func (b *Blocklist) Check(u *url.URL) error {
+	if (b == nil) || (u == nil) {
+		return nil
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return b.CheckIP(addr)
+	}
+
+	if (len(b.Hosts) > 0) && (&Scope{Domains: b.Hosts}).matchDomain(host) {
+		return ErrBlocked
+	}
+
+	// The host names of the loopback interface are not resolved by the Client.
+	if b.Private && ((host == "localhost") || strings.HasSuffix(host, ".localhost")) {
+		return ErrBlocked
+	}
+	return nil
+}
+
+// CheckIP returns ErrBlocked if the IP address is blocked.
+func (b *Blocklist) CheckIP(addr netip.Addr) error {
+	if b == nil {
+		return nil
+	}
+	addr = addr.Unmap()
+
+	if b.Private && (addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsMulticast() || addr.IsUnspecified()) {
+		return ErrBlocked
+	}
+
+	for _, network := range b.Networks {
+		if prefix, err := netip.ParsePrefix(network); err == nil {
+			if prefix.Contains(addr) {
+				return ErrBlocked
+			}
+		} else if ip, err := netip.ParseAddr(network); (err == nil) && (ip.Unmap() == addr) {
+			return ErrBlocked
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package colibri
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestBlocklist(t *testing.T) {
+	b := &Blocklist{
+		Hosts:    []string{"example.com", "*.example.org"},
+		Networks: []string{"203.0.113.0/24", "2001:db8::1"},
+		Private:  true,
+	}
+
+	tests := []struct {
+		URL     string
+		Blocked bool
+	}{
+		{"http://example.com", true},
+		{"http://EXAMPLE.com./a", true},
+		{"http://sub.example.com", false},
+		{"http://example.org", false},
+		{"http://sub.example.org", true},
+		{"http://203.0.113.7", true},
+		{"http://198.51.100.7", false},
+		{"http://[2001:db8::1]:8080", true},
+		{"http://[::ffff:203.0.113.7]", true},
+		{"http://127.0.0.1", true},
+		{"http://10.1.2.3", true},
+		{"http://169.254.169.254", true},
+		{"http://[::1]", true},
+		{"http://0.0.0.0", true},
+		{"http://localhost:8080", true},
+		{"http://api.localhost", true},
+		{"http://8.8.8.8", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.URL, func(t *testing.T) {
+			err := b.Check(mustNewURL(tt.URL))
+			if blocked := errors.Is(err, ErrBlocked); blocked != tt.Blocked {
+				t.Fatalf("got %v, want %v", blocked, tt.Blocked)
+			}
+		})
+	}
+
+	var nilBlocklist *Blocklist
+	if err := nilBlocklist.CheckIP(netip.MustParseAddr("127.0.0.1")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBlocklistDo(t *testing.T) {
+	c := New()
+	c.Client = &testClient{}
+	c.Blocklist = &Blocklist{Private: true}
+
+	_, err := c.Do(&Rules{URL: mustNewURL("http://127.0.0.1/admin")})
+	if !errors.Is(err, ErrBlocked) {
+		t.Fatalf("got %v, want %v", err, ErrBlocked)
+	}
+
+	if code := ErrorCode(err); code != CodeBlocked {
+		t.Fatalf("got %v, want %v", code, CodeBlocked)
+	}
+
+	if _, err := c.Do(&Rules{URL: mustNewURL("http://example.com")}); err != nil {
+		t.Fatal(err)
+	}
+}
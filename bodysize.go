@@ -0,0 +1,28 @@
+package colibri
+
+import "fmt"
+
+// BodySizeError is returned when the response body exceeds ResponseBodySize,
+// it can be compared with ErrResponseBodySize using errors.Is.
+type BodySizeError struct {
+	// Limit is the maximum size of the body, the ResponseBodySize of the rules.
+	Limit int64
+
+	// Read is the number of bytes of the body read before the limit was exceeded.
+	Read int64
+
+	// Size is the Content-Length of the response, -1 if it is unknown.
+	Size int64
+}
+
+func (err *BodySizeError) Error() string {
+	if err.Size >= 0 {
+		return fmt.Sprintf("%v: %d bytes, limit %d bytes", ErrResponseBodySize, err.Size, err.Limit)
+	}
+	return fmt.Sprintf("%v: limit %d bytes exceeded after reading %d bytes", ErrResponseBodySize, err.Limit, err.Read)
+}
+
+// Unwrap returns ErrResponseBodySize.
+func (err *BodySizeError) Unwrap() error {
+	return ErrResponseBodySize
+}
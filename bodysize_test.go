@@ -0,0 +1,30 @@
+package colibri
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBodySizeError(t *testing.T) {
+	tests := []struct {
+		Err  *BodySizeError
+		Want string
+	}{
+		{&BodySizeError{Limit: 10, Size: 20}, "response body too large: 20 bytes, limit 10 bytes"},
+		{&BodySizeError{Limit: 10, Read: 10, Size: -1}, "response body too large: limit 10 bytes exceeded after reading 10 bytes"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.Err.Error(); got != tt.Want {
+			t.Fatalf("got %v, want %v", got, tt.Want)
+		}
+
+		if !errors.Is(tt.Err, ErrResponseBodySize) {
+			t.Fatalf("got %v, want %v", tt.Err, ErrResponseBodySize)
+		}
+
+		if code := ErrorCode(tt.Err); code != CodeBodySize {
+			t.Fatalf("got %v, want %v", code, CodeBodySize)
+		}
+	}
+}
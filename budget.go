@@ -0,0 +1,116 @@
+package colibri
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExceeded is returned when the requests or the bytes of a host exceed the Budget.
+var ErrBudgetExceeded = errors.New("host budget exceeded")
+
+// Budget limits the requests made and the bytes fetched from each host within a time window,
+// see the Budget field of Colibri.
+type Budget struct {
+	// MaxRequests specifies the maximum number of requests to a host in each window.
+	// If it is zero, the requests are not limited.
+	MaxRequests int
+
+	// MaxBytes specifies the maximum number of bytes read from the response bodies of a host
+	// in each window. The requests are refused once the limit is reached.
+	// If it is zero, the bytes are not limited.
+	MaxBytes int64
+
+	// Window specifies the duration of the window, the counters of a host are reset
+	// when its window ends. If it is zero, the window does not end until Clear.
+	Window time.Duration
+
+	// Wait specifies whether the requests that exceed the budget wait for the next window,
+	// otherwise they return ErrBudgetExceeded. It is ignored if Window is zero.
+	Wait bool
+
+	mu    sync.Mutex
+	hosts map[string]*hostBudget
+}
+
+type hostBudget struct {
+	start    time.Time
+	requests int
+	bytes    int64
+}
+
+// host returns the budget of the host of the URL, the mutex must be locked.
+func (b *Budget) host(u *url.URL) *hostBudget {
+	if b.hosts == nil {
+		b.hosts = make(map[string]*hostBudget)
+	}
+
+	name := strings.ToLower(u.Hostname())
+	h, ok := b.hosts[name]
+	if !ok || ((b.Window > 0) && (time.Since(h.start) >= b.Window)) {
+		h = &hostBudget{start: time.Now()}
+		b.hosts[name] = h
+	}
+	return h
+}
+
+// reserve counts a request to the URL. If the budget of the host is exceeded, it returns
+// ErrBudgetExceeded or, with Wait, waits for the next window or for the context to be done.
+func (b *Budget) reserve(ctx context.Context, u *url.URL) error {
+	if (b == nil) || (u == nil) {
+		return nil
+	}
+
+	for {
+		b.mu.Lock()
+		h := b.host(u)
+		exceeded := ((b.MaxRequests > 0) && (h.requests >= b.MaxRequests)) ||
+			((b.MaxBytes > 0) && (h.bytes >= b.MaxBytes))
+
+		if !exceeded {
+			h.requests++
+			b.mu.Unlock()
+			return nil
+		}
+
+		wait := b.Window - time.Since(h.start)
+		b.mu.Unlock()
+
+		if !b.Wait || (b.Window <= 0) {
+			return ErrBudgetExceeded
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// addBytes counts the bytes read from a response body of the URL.
+func (b *Budget) addBytes(u *url.URL, n int64) {
+	if (b == nil) || (u == nil) || (n <= 0) || (b.MaxBytes <= 0) {
+		return
+	}
+
+	b.mu.Lock()
+	b.host(u).bytes += n
+	b.mu.Unlock()
+}
+
+// Clear resets the budgets of the hosts.
+func (b *Budget) Clear() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	clear(b.hosts)
+	b.mu.Unlock()
+}
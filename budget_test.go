@@ -0,0 +1,83 @@
+package colibri
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBudgetRequests(t *testing.T) {
+	c := New()
+	c.Client = &testClient{}
+	c.Budget = &Budget{MaxRequests: 2}
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.Do(&Rules{URL: mustNewURL("http://example.com")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := c.Do(&Rules{URL: mustNewURL("http://EXAMPLE.com/a")})
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("got %v, want %v", err, ErrBudgetExceeded)
+	}
+
+	if code := ErrorCode(err); code != CodeBudget {
+		t.Fatalf("got %v, want %v", code, CodeBudget)
+	}
+
+	// The budget is by host.
+	if _, err := c.Do(&Rules{URL: mustNewURL("http://example.org")}); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Clear()
+	if _, err := c.Do(&Rules{URL: mustNewURL("http://example.com")}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBudgetBytes(t *testing.T) {
+	b := &Budget{MaxBytes: 10}
+	u := mustNewURL("http://example.com")
+
+	if err := b.reserve(context.Background(), u); err != nil {
+		t.Fatal(err)
+	}
+	b.addBytes(u, 10)
+
+	if err := b.reserve(context.Background(), u); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("got %v, want %v", err, ErrBudgetExceeded)
+	}
+}
+
+func TestBudgetWindow(t *testing.T) {
+	b := &Budget{MaxRequests: 1, Window: 50 * time.Millisecond, Wait: true}
+	u := mustNewURL("http://example.com")
+
+	if err := b.reserve(context.Background(), u); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if err := b.reserve(context.Background(), u); err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Fatalf("got %v, want >= %v", elapsed, 40*time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := b.reserve(ctx, u); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	b.Wait = false
+	if err := b.reserve(context.Background(), u); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("got %v, want %v", err, ErrBudgetExceeded)
+	}
+}
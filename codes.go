@@ -0,0 +1,123 @@
+package colibri
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// Error codes are machine-readable categories of the errors stored in Errs, see ErrorCode.
+const (
+	// CodeRobotsDenied is the code of the requests not allowed by robots.txt.
+	CodeRobotsDenied = "ROBOTS_DENIED"
+
+	// CodeTimeout is the code of the requests that exceeded their time limit.
+	CodeTimeout = "TIMEOUT"
+
+	// CodeParse is the code of the responses that could not be parsed.
+	CodeParse = "PARSE"
+
+	// CodeExprInvalid is the code of the selector expressions that could not be evaluated.
+	CodeExprInvalid = "EXPR_INVALID"
+
+	// CodeStatus is the code of the responses with an unexpected status code, see ErrStatusCode.
+	CodeStatus = "STATUS"
+
+	// CodeBodySize is the code of the responses whose body exceeds ResponseBodySize.
+	CodeBodySize = "BODY_SIZE"
+
+	// CodeContentType is the code of the responses whose Content-Type is not supported.
+	CodeContentType = "CONTENT_TYPE"
+
+	// CodeMaxRedirects is the code of the requests that exceeded the maximum number of redirects.
+	CodeMaxRedirects = "MAX_REDIRECTS"
+
+	// CodeOutOfScope is the code of the URLs out of the Scope of the rules.
+	CodeOutOfScope = "OUT_OF_SCOPE"
+
+	// CodePanic is the code of the panics recovered, see PanicError.
+	CodePanic = "PANIC"
+
+	// CodeBudget is the code of the requests refused by the Budget of Colibri.
+	CodeBudget = "BUDGET_EXCEEDED"
+
+	// CodeBlocked is the code of the URLs blocked by the Blocklist of Colibri.
+	CodeBlocked = "BLOCKED"
+)
+
+// Coder is implemented by the errors that provide their code.
+type Coder interface {
+	// Code returns the machine-readable code of the error.
+	Code() string
+}
+
+// CodeError is an error with a code.
+type CodeError struct {
+	code string
+	err  error
+}
+
+// WithCode returns the error with the code, the message of the error is not modified.
+// If err is nil, nil is returned.
+func WithCode(code string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &CodeError{code: code, err: err}
+}
+
+func (err *CodeError) Code() string {
+	return err.code
+}
+
+func (err *CodeError) Error() string {
+	return err.err.Error()
+}
+
+func (err *CodeError) Unwrap() error {
+	return err.err
+}
+
+// ErrorCode returns the code of the error, returns an empty string if the error has no code.
+// The code is obtained from the first error of the chain that implements Coder,
+// otherwise it is inferred from the errors of this package, the context deadline and the network timeouts.
+func ErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var coder Coder
+	if errors.As(err, &coder) {
+		return coder.Code()
+	}
+
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) {
+		return CodePanic
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return CodeTimeout
+	}
+
+	switch {
+	case errors.Is(err, ErrRobotstxtRestriction):
+		return CodeRobotsDenied
+	case errors.Is(err, ErrStatusCode):
+		return CodeStatus
+	case errors.Is(err, ErrResponseBodySize):
+		return CodeBodySize
+	case errors.Is(err, ErrContentType):
+		return CodeContentType
+	case errors.Is(err, ErrMaxRedirects):
+		return CodeMaxRedirects
+	case errors.Is(err, ErrOutOfScope):
+		return CodeOutOfScope
+	case errors.Is(err, ErrBudgetExceeded):
+		return CodeBudget
+	case errors.Is(err, ErrBlocked):
+		return CodeBlocked
+	}
+	return ""
+}
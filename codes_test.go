@@ -0,0 +1,65 @@
+package colibri
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		Err  error
+		Code string
+	}{
+		{nil, ""},
+		{errors.New("test err"), ""},
+		{ErrRobotstxtRestriction, CodeRobotsDenied},
+		{context.DeadlineExceeded, CodeTimeout},
+		{fmt.Errorf("%w: %d", ErrStatusCode, 404), CodeStatus},
+		{ErrResponseBodySize, CodeBodySize},
+		{ErrContentType, CodeContentType},
+		{ErrMaxRedirects, CodeMaxRedirects},
+		{ErrOutOfScope, CodeOutOfScope},
+		{ErrBudgetExceeded, CodeBudget},
+		{ErrBlocked, CodeBlocked},
+		{newPanicError("test panic"), CodePanic},
+		{WithCode(CodeParse, errors.New("test err")), CodeParse},
+		{fmt.Errorf("wrapped: %w", WithCode("CUSTOM", ErrStatusCode)), "CUSTOM"},
+	}
+
+	for _, tt := range tests {
+		if code := ErrorCode(tt.Err); code != tt.Code {
+			t.Fatalf("%v: got %v, want %v", tt.Err, code, tt.Code)
+		}
+	}
+}
+
+func TestErrsCode(t *testing.T) {
+	errs := AddError(nil, "robots", ErrRobotstxtRestriction).(*Errs)
+	errs.Add("other", errors.New("test err"))
+	errs.AddCode("custom", "CUSTOM", errors.New("custom err"))
+	errs.Add("nested", (&Errs{}).Add("url", WithCode(CodeParse, errors.New("parse err"))))
+
+	if code, ok := errs.Code("robots"); !ok || (code != CodeRobotsDenied) {
+		t.Fatalf("got %v, want %v", code, CodeRobotsDenied)
+	}
+
+	if _, ok := errs.Code("other"); ok {
+		t.Fatal("error without code")
+	}
+
+	got, err := json.Marshal(errs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"custom":{"code":"CUSTOM","error":"custom err"},` +
+		`"nested":{"url":{"code":"PARSE","error":"parse err"}},` +
+		`"other":"test err",` +
+		`"robots":{"code":"ROBOTS_DENIED","error":"page not accessible due to robots.txt restriction"}}`
+	if string(got) != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
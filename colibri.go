@@ -3,12 +3,16 @@
 package colibri
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 )
 
@@ -39,11 +43,34 @@ var (
 	// ErrRulesIsNil returned when rules are nil.
 	ErrRulesIsNil = errors.New("rules is nil")
 
+	// ErrURLIsNil returned when the URL of the rules is nil.
+	ErrURLIsNil = errors.New("URL is nil")
+
 	// ErrMaxRedirects are returned when the redirect limit is reached.
 	ErrMaxRedirects = errors.New("max redirects limit reached")
 
 	// ErrRobotstxtRestriction is returned when the page cannot be accessed due to robots.txt restrictions.
 	ErrRobotstxtRestriction = errors.New("page not accessible due to robots.txt restriction")
+
+	// ErrAlreadyVisited is returned when the URL has already been visited.
+	ErrAlreadyVisited = errors.New("URL already visited")
+
+	// ErrDuplicateContent is returned when the content of the response has already been seen.
+	ErrDuplicateContent = errors.New("duplicate content")
+
+	// ErrNearDuplicate is returned when the textual content of the response is similar to the content
+	// of a page already processed, see NearDuplicates. It wraps ErrDuplicateContent.
+	ErrNearDuplicate = fmt.Errorf("near-%w", ErrDuplicateContent)
+
+	// ErrMediaIsNil is returned when a selector with Asset is used and Media is nil.
+	ErrMediaIsNil = errors.New("media is nil")
+
+	// ErrStatusCode is returned when the status code of the response is not allowed by the rules,
+	// see the FailOnStatus and AllowedStatusCodes fields of the Rules structure.
+	ErrStatusCode = errors.New("unexpected status code")
+
+	// ErrMaxDuration is returned when the Extract exceeds the MaxDuration of the rules.
+	ErrMaxDuration = errors.New("max duration exceeded")
 )
 
 type (
@@ -86,6 +113,7 @@ type (
 	}
 
 	// Delay manages the delay between each HTTP request.
+	// It can also be used to limit the rate of HTTP requests to each host.
 	Delay interface {
 		// Wait waits for the previous HTTP request to the same URL and stores
 		// the timestamp, then starts the calculated delay with the timestamp
@@ -111,6 +139,124 @@ type (
 		Clear()
 	}
 
+	// Visited stores the URLs that have already been requested.
+	Visited interface {
+		// Visit marks the URL as visited and returns true
+		// if the URL had already been visited.
+		Visit(u *url.URL) bool
+
+		// Clear cleans the fields of the structure.
+		Clear()
+	}
+
+	// Hasher is implemented by the responses that can compute the hash of their body.
+	Hasher interface {
+		// Hash returns the hash of the response body.
+		Hash() (string, error)
+	}
+
+	// Attempter is implemented by the responses that record the number of requests
+	// made to obtain them, including the retries.
+	Attempter interface {
+		// Attempts returns the number of requests made.
+		Attempts() int
+	}
+
+	// BodySizer is implemented by the responses that count the bytes read from their body.
+	BodySizer interface {
+		// BodySize returns the number of bytes read from the body.
+		BodySize() int64
+	}
+
+	// ByteCounter is implemented by the responses that count the bytes read from their body
+	// with the CountBytes method of Colibri while they are read, so the bytes of the requests
+	// made with Do, e.g. the ones of robots.txt, are also counted. Extract does not count them again.
+	ByteCounter interface {
+		// CountsBytes returns true if the bytes read from the body are counted by the response.
+		CountsBytes() bool
+	}
+
+	// Truncater is implemented by the responses whose body can be truncated by the ResponseBodySize,
+	// see the ParseTruncated field of the Rules.
+	Truncater interface {
+		// Truncated returns true if the body exceeded the ResponseBodySize and was read up to the limit.
+		Truncated() bool
+	}
+
+	// BodyBufferer is implemented by the responses that keep their body in memory,
+	// see the BufferBody and IncludeBody fields of the Rules.
+	BodyBufferer interface {
+		// BufferedBody returns the body kept in memory, returns nil if the body was not kept.
+		BufferedBody() []byte
+	}
+
+	// NodeCacher is implemented by the responses that store their parsed root node,
+	// so the selectors can be found again without requesting and parsing them, see ExtractResponse.
+	// The node is only stored if the rules have CacheNode.
+	NodeCacher interface {
+		// CachedNode returns the root node stored, nil if there is none.
+		CachedNode() Node
+
+		// CacheNode stores the root node of the response.
+		CacheNode(node Node)
+
+		// Invalidate removes the root node stored,
+		// the following extractions request and parse the response again.
+		Invalidate()
+	}
+
+	// RedirectTracer is implemented by the responses that record the details of each redirect.
+	RedirectTracer interface {
+		// RedirectChain returns the redirects followed, in order.
+		RedirectChain() []*Redirect
+	}
+
+	// Redirect represents a redirect response of a redirect chain.
+	Redirect struct {
+		// URL of the request that was redirected.
+		URL *url.URL
+
+		// StatusCode of the redirect response.
+		StatusCode int
+
+		// Location contains the Location header of the redirect response.
+		Location string
+
+		// SetCookie contains the Set-Cookie headers of the redirect response.
+		SetCookie []string
+	}
+
+	// Duplicates stores the hashes of the response bodies that have already been seen.
+	Duplicates interface {
+		// Seen marks the hash as seen and returns true
+		// if the hash had already been seen.
+		Seen(hash string) bool
+
+		// Clear cleans the fields of the structure.
+		Clear()
+	}
+
+	// Media downloads and stores the assets found by the selectors with Asset.
+	Media interface {
+		// Store downloads the assets of the rules and returns the location in which each one
+		// is stored, in the same order as the rules. The location of the assets that
+		// could not be stored is empty.
+		Store(resp Response, rules []*Rules) ([]string, error)
+
+		// Clear cleans the fields of the structure.
+		Clear()
+	}
+
+	// Sink receives the outputs of the extractions, see CrawlOptions and BatchOptions.
+	Sink interface {
+		// Write writes the output or the error of the extraction of the rules.
+		// The rules must not be retained after Write returns.
+		Write(rules *Rules, output *Output, err error) error
+
+		// Close writes the pending outputs and releases the resources of the sink.
+		Close() error
+	}
+
 	// Parser represents a parser of the response content.
 	Parser interface {
 		// Match returns true if the Content-Type is supported by the parser.
@@ -130,14 +276,103 @@ type Output struct {
 
 	// Data contains the data extracted by the selectors.
 	Data map[string]any
+
+	// Noindex reports whether the page must not be indexed according to its robots meta tags
+	// or X-Robots-Tag header, it is only set if the rules have RespectNofollow.
+	// Crawl does not send these outputs to OnOutput and Sink.
+	Noindex bool
+
+	// FetchDuration is the time elapsed obtaining the response.
+	FetchDuration time.Duration
+
+	// ParseDuration is the time elapsed parsing the response.
+	ParseDuration time.Duration
+
+	// BodySize is the number of bytes read from the response body,
+	// if the response does not implement BodySizer it is the Content-Length.
+	BodySize int64
+
+	// Attempts is the number of requests made to obtain the response, including the retries.
+	// It is zero if the output was not obtained with Extract.
+	Attempts int
+
+	// Truncated reports whether the body exceeded the ResponseBodySize and the data
+	// was extracted from the content read up to the limit, see the ParseTruncated field of the Rules.
+	Truncated bool
+
+	// Body contains the response body read by the parser, see the IncludeBody field of the Rules.
+	Body []byte
+
+	// BodyHash is the SHA-256 hash of Body in hexadecimal.
+	BodyHash string
+
+	// ContentType is the Content-Type of the response, it is only set if the rules have IncludeBody.
+	ContentType string
 }
 
 // Serializable returns the value of the output as a map for easy storage or transmission.
+// The durations are expressed in milliseconds.
 func (out *Output) Serializable() map[string]any {
-	return map[string]any{
+	m := map[string]any{
 		"response": out.Response.Serializable(),
 		"data":     out.Data,
 	}
+
+	if out.Attempts > 0 {
+		meta := map[string]any{
+			"fetchDuration": fromDuration(out.FetchDuration),
+			"parseDuration": fromDuration(out.ParseDuration),
+			"bodySize":      out.BodySize,
+			"attempts":      out.Attempts,
+		}
+
+		if out.Truncated {
+			meta["truncated"] = true
+		}
+		m["meta"] = meta
+	}
+
+	if out.Body != nil {
+		m["body"] = string(out.Body)
+		m["bodyHash"] = out.BodyHash
+	}
+
+	if out.ContentType != "" {
+		m["contentType"] = out.ContentType
+	}
+	return m
+}
+
+// responseMetrics sets the BodySize, Attempts and Truncated of the output with the values of the response.
+func (out *Output) responseMetrics() {
+	out.Attempts = 1
+	if a, ok := out.Response.(Attempter); ok {
+		out.Attempts = a.Attempts()
+	}
+
+	if s, ok := out.Response.(BodySizer); ok {
+		out.BodySize = s.BodySize()
+	} else if n, err := strconv.ParseInt(out.Response.Header().Get("Content-Length"), 10, 64); err == nil {
+		out.BodySize = n
+	}
+
+	if t, ok := out.Response.(Truncater); ok {
+		out.Truncated = t.Truncated()
+	}
+}
+
+// includeBody sets the Body, BodyHash and ContentType of the output with the body kept by the response.
+func (out *Output) includeBody() {
+	out.ContentType = out.Response.Header().Get("Content-Type")
+
+	if b, ok := out.Response.(BodyBufferer); ok {
+		out.Body = b.BufferedBody()
+	}
+
+	if out.Body != nil {
+		sum := sha256.Sum256(out.Body)
+		out.BodyHash = hex.EncodeToString(sum[:])
+	}
 }
 
 func (out *Output) MarshalJSON() ([]byte, error) {
@@ -146,22 +381,94 @@ func (out *Output) MarshalJSON() ([]byte, error) {
 
 // Colibri makes HTTP requests and parses the content of the response based on rules.
 type Colibri struct {
-	Client    Client
-	Delay     Delay
-	RobotsTxt RobotsTxt
-	Parser    Parser
+	Client     Client
+	Delay      Delay
+	RobotsTxt  RobotsTxt
+	Visited    Visited
+	Duplicates Duplicates
+	Media      Media
+	Parser     Parser
+
+	// ResponseCache specifies the maximum number of parsed responses cached during an Extract,
+	// so the selectors that follow the same URL do not request and parse it again.
+	// If it is zero, the responses are not cached.
+	ResponseCache int
+
+	// OnFollowed is called with the output of each URL followed by the selectors as soon as
+	// it is extracted, e.g. to display the progress or store the outputs incrementally.
+	// The URLs already visited or with duplicate content are not reported.
+	// With the Concurrency of the selectors it can be called concurrently.
+	OnFollowed func(u *url.URL, out *Output, err error)
+
+	// Budget limits the requests and the bytes of each host, see the Budget structure.
+	// If it is nil, the hosts are not limited.
+	Budget *Budget
+
+	// Blocklist specifies the hosts and addresses that cannot be requested, see the Blocklist structure.
+	// It is checked before each request and by the Client on redirects and when the hosts are resolved.
+	Blocklist *Blocklist
+
+	// NearDuplicates stores the fingerprints of the textual content of the pages processed,
+	// the pages similar to one already processed return ErrNearDuplicate and their selectors
+	// are not extracted nor followed, see the NearDuplicates structure.
+	// If it is nil, the pages are not compared.
+	NearDuplicates *NearDuplicates
+
+	workers  *workerMetrics
+	progress *progressState
+	counters *statsCounters
+	life     *lifecycle
 }
 
 // New returns a new empty Colibri structure.
 func New() *Colibri {
-	return &Colibri{}
+	return &Colibri{
+		workers:  &workerMetrics{},
+		progress: &progressState{},
+		counters: &statsCounters{},
+		life:     newLifecycle(),
+	}
+}
+
+// Progress returns the progress of the crawl running or of the last crawl, e.g. the number
+// of pending and completed URLs, see Crawl. It can be called while the crawl is running.
+// The progress is only available if c was created with New.
+func (c *Colibri) Progress() Progress {
+	cr := c.progress.get()
+	if cr == nil {
+		return Progress{}
+	}
+	return cr.progress()
+}
+
+// WorkerStats returns the metrics of the workers of Crawl, ExtractAll and the selectors
+// with Follow and Concurrency, e.g. the queue depth and the utilization.
+// The metrics are only available if c was created with New.
+func (c *Colibri) WorkerStats() WorkerStats {
+	return c.workerMetrics().stats()
+}
+
+func (c *Colibri) workerMetrics() *workerMetrics {
+	if c == nil {
+		return nil
+	}
+	return c.workers
+}
+
+// followed calls OnFollowed with the output of the followed URL.
+func (c *Colibri) followed(u *url.URL, out *Output, err error) {
+	if (c == nil) || (c.OnFollowed == nil) || errors.Is(err, ErrAlreadyVisited) || errors.Is(err, ErrDuplicateContent) {
+		return
+	}
+	c.OnFollowed(u, out, err)
 }
 
 // Do makes an HTTP request based on the rules.
+// The panics are recovered and returned as a *PanicError.
 func (c *Colibri) Do(rules *Rules) (resp Response, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			err = fmt.Errorf("%v", r)
+			err = newPanicError(r)
 		}
 	}()
 
@@ -172,11 +479,32 @@ func (c *Colibri) Do(rules *Rules) (resp Response, err error) {
 	if rules == nil {
 		return nil, ErrRulesIsNil
 	}
+	checkReleased(rules.released)
+
+	if !c.life.begin() {
+		return nil, ErrShutdown
+	}
+	defer c.life.end()
+
+	// The time limit of the extraction has been exceeded, see the MaxDuration field of the Rules structure.
+	if err := rules.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	// The placeholders of the rules are replaced in a copy, so the rules can be reused.
+	if iRules := interpolateRules(rules); iRules != nil {
+		defer ReleaseRules(iRules)
+		rules = iRules
+	}
 
 	if rules.Header == nil {
 		rules.Header = http.Header{}
 	}
 
+	if err := applyProfile(rules); err != nil {
+		return nil, err
+	}
+
 	if rules.Header.Get("User-Agent") == "" {
 		rules.Header.Set("User-Agent", DefaultUserAgent)
 	}
@@ -196,13 +524,22 @@ func (c *Colibri) Do(rules *Rules) (resp Response, err error) {
 		rules.Timeout = DefaultTimeout
 	}
 
+	if err := c.Blocklist.Check(rules.URL); err != nil {
+		return nil, err
+	}
+
 	if (c.RobotsTxt != nil) && !rules.IgnoreRobotsTxt {
 		err := c.RobotsTxt.IsAllowed(c, rules)
 		if err != nil {
+			c.countRobots(rules.URL, err)
 			return nil, err
 		}
 	}
 
+	if err := c.Budget.reserve(rules.Context(), rules.URL); err != nil {
+		return nil, err
+	}
+
 	if (c.Delay != nil) && (rules.Delay > 0) {
 		c.Delay.Wait(rules.URL, rules.Delay)
 		defer c.Delay.Done(rules.URL)
@@ -213,41 +550,217 @@ func (c *Colibri) Do(rules *Rules) (resp Response, err error) {
 	if (c.Delay != nil) && (resp != nil) {
 		c.Delay.Stamp(resp.URL())
 	}
+
+	if (err == nil) && !allowedStatus(rules, resp.StatusCode()) {
+		if body := resp.Body(); body != nil {
+			body.Close()
+		}
+		err = fmt.Errorf("%w: %d", ErrStatusCode, resp.StatusCode())
+	}
+
+	c.countRequest(rules.URL, resp, err)
 	return resp, err
 }
 
+// allowedStatus returns true if the status code is allowed by the rules.
+func allowedStatus(rules *Rules, code int) bool {
+	if len(rules.AllowedStatusCodes) > 0 {
+		for _, allowed := range rules.AllowedStatusCodes {
+			if allowed == code {
+				return true
+			}
+		}
+		return false
+	}
+	return !rules.FailOnStatus || ((code >= 200) && (code <= 299))
+}
+
 // Extract makes the HTTP request and parses the content of the response based on the rules.
+//
+// If the rules specify SaveTo or their context has a writer, see WithWriter, the response body
+// is stored instead of being parsed and Output.Data contains the KeyFile and KeySize values.
+// The panics are recovered and returned as a *PanicError.
 func (c *Colibri) Extract(rules *Rules) (output *Output, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			err = fmt.Errorf("%v", r)
+			err = newPanicError(r)
 		}
 	}()
 
-	if c.Parser == nil {
+	if rules == nil {
+		return nil, ErrRulesIsNil
+	}
+	checkReleased(rules.released)
+
+	// The context of the extraction is set in a copy of the rules, so the rules of the caller are not modified.
+	extractRules := *rules
+	rules = &extractRules
+
+	if (c.Parser == nil) && !isDownload(rules) {
 		return nil, ErrParserIsNil
 	}
 
+	if (len(rules.Session) > 0) && !inSession(rules.Context()) {
+		if err := c.startSession(rules); err != nil {
+			return nil, err
+		}
+	}
+
+	// The root node stored in the response by ExtractResponse.
+	cached := cachedNodeFrom(rules)
+
+	// The URLs requested again by the selectors with OnErrorRetry were already visited.
+	retried := retriedFrom(rules)
+
+	if (c.Visited != nil) && (rules.URL != nil) && (cached == nil) && !retried && c.Visited.Visit(rules.URL) {
+		return nil, ErrAlreadyVisited
+	}
+
+	// The deadline is shared by the requests of the selectors until the Extract ends.
+	if rules.MaxDuration > 0 {
+		deadlineCtx, cancel := context.WithTimeout(rules.Context(), rules.MaxDuration)
+		rules.SetContext(deadlineCtx)
+		defer func() {
+			if (err != nil) && errors.Is(deadlineCtx.Err(), context.DeadlineExceeded) {
+				err = errors.Join(WithCode(CodeTimeout, ErrMaxDuration), err)
+			}
+			cancel()
+		}()
+	}
+
+	// The outputs of the followed rules are shared by the selectors until the Extract ends.
+	if followCacheFrom(rules.Context()) == nil {
+		rules.SetContext(context.WithValue(rules.Context(), followCacheKey{}, newFollowCache()))
+	}
+
+	// The cache is shared by the rules of the selectors until the Extract ends.
+	if (c.ResponseCache > 0) && (responseCacheFrom(rules.Context()) == nil) {
+		rules.SetContext(context.WithValue(rules.Context(), responseCacheKey{}, newResponseCache(c.ResponseCache)))
+	}
+
 	output = &Output{}
 
-	output.Response, err = c.Do(rules)
-	if err != nil {
-		return nil, err
+	var (
+		cache   = responseCacheFrom(rules.Context())
+		parent  Node
+		fetched bool
+	)
+	if !isDownload(rules) && (len(rules.Selectors) > 0) {
+		if cached != nil {
+			output.Response, parent = cached.resp, cached.node
+		} else if output.Response, parent = cache.get(rules); output.Response != nil {
+			c.countCacheHit()
+		}
+	}
+
+	if output.Response == nil {
+		if rules.Preflight && ((rules.Method == "") || (rules.Method == http.MethodGet)) {
+			// The HEAD response is returned with the reason why the GET request was skipped.
+			if output.Response, err = c.preflight(rules); err != nil {
+				return output, err
+			}
+		}
+
+		start := time.Now()
+		output.Response, err = c.Do(rules)
+		output.FetchDuration = time.Since(start)
+		if err != nil {
+			return nil, err
+		}
+		fetched = true
+
+		if isDownload(rules) {
+			output.Data, err = download(rules, output.Response)
+			output.responseMetrics()
+			c.countResponseBytes(rules.URL, output.Response, output.BodySize)
+			return output, err
+		}
 	}
 
 	if len(rules.Selectors) > 0 {
-		var parent Node
-		parent, err = c.Parser.Parse(rules, output.Response)
+		if cached == nil {
+			if err = c.checkDuplicate(output.Response); err != nil {
+				return output, err
+			}
+		}
+
+		// The selectors with Asset use the Media of c and the ones with Follow its OnFollowed and workers.
+		if colibriFrom(rules.Context()) != c {
+			rules.SetContext(context.WithValue(rules.Context(), colibriKey{}, c))
+		}
+
+		if parent == nil {
+			start := time.Now()
+			parent, err = c.Parser.Parse(rules, output.Response)
+			output.ParseDuration = time.Since(start)
+			if (err != nil) && (ErrorCode(err) == "") {
+				err = WithCode(CodeParse, err)
+			}
+
+			if body := output.Response.Body(); body != nil {
+				body.Close()
+			}
+
+			if err == nil {
+				err = c.checkNearDuplicate(parent)
+			}
+
+			if err == nil {
+				cache.add(rules, output.Response, parent)
+				if nc, ok := output.Response.(NodeCacher); ok && rules.CacheNode {
+					nc.CacheNode(parent)
+				}
+			}
+		}
 
 		if err == nil {
+			if rules.RespectNofollow {
+				output.Noindex = applyRobots(rules, output.Response, parent)
+			}
 			output.Data, err = FindSelectors(rules, output.Response, parent)
 		}
 	}
+
+	output.responseMetrics()
+	if rules.IncludeBody {
+		output.includeBody()
+	}
+
+	if fetched {
+		c.countResponseBytes(rules.URL, output.Response, output.BodySize)
+	}
 	return output, err
 }
 
-// Clear cleans the fields of the structure.
+// checkDuplicate returns ErrDuplicateContent if the hash of the response body
+// has already been seen, in that case the body is closed.
+// Responses that do not implement Hasher are not checked.
+func (c *Colibri) checkDuplicate(resp Response) error {
+	h, ok := resp.(Hasher)
+	if (c.Duplicates == nil) || !ok {
+		return nil
+	}
+
+	hash, err := h.Hash()
+	if err != nil {
+		return err
+	}
+
+	if c.Duplicates.Seen(hash) {
+		if body := resp.Body(); body != nil {
+			body.Close()
+		}
+		return ErrDuplicateContent
+	}
+	return nil
+}
+
+// Clear cleans the fields of the structure and resets the Stats.
 func (c *Colibri) Clear() {
+	c.counters.clear()
+	c.Budget.Clear()
+	c.NearDuplicates.Clear()
+
 	if c.Client != nil {
 		c.Client.Clear()
 	}
@@ -260,6 +773,18 @@ func (c *Colibri) Clear() {
 		c.RobotsTxt.Clear()
 	}
 
+	if c.Visited != nil {
+		c.Visited.Clear()
+	}
+
+	if c.Duplicates != nil {
+		c.Duplicates.Clear()
+	}
+
+	if c.Media != nil {
+		c.Media.Clear()
+	}
+
 	if c.Parser != nil {
 		c.Parser.Clear()
 	}
@@ -1,12 +1,14 @@
 package colibri
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
 	"net/url"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 )
@@ -18,14 +20,42 @@ var (
 		Type: "xpath",
 		Selectors: []*Selector{
 			{
-				Name:    "urls",
-				Expr:    "//a/@href",
-				All:     true,
-				Follow:  true,
-				Method:  "get",
-				Proxy:   mustNewURL("http://proxy.example.com:8080"),
-				Header:  http.Header{"User-Agent": {"test/0.2.0"}},
-				Timeout: 5 * time.Second,
+				Name:        "urls",
+				Expr:        "//a/@href",
+				All:         true,
+				Follow:      true,
+				Concurrency: 4,
+				Submit:      url.Values{"q": {"colibri"}},
+				Var:         true,
+				Method:      "get",
+				URL:         "/api/items/${value}",
+				Proxy:       mustNewURL("http://proxy.example.com:8080"),
+				Header:      http.Header{"User-Agent": {"test/0.2.0"}},
+				Form:        url.Values{"page": {"2"}},
+				Timeout:     5 * time.Second,
+				Priority:    1,
+				SaveTo:      "downloads",
+				OnError:     "retry",
+				Paginate: &Pagination{
+					Next:     "//a[@rel='next']/@href",
+					MaxPages: 3,
+					StopWhen: "//p[@class='end']",
+					URL:      "/items?cursor=${cursor}",
+					Cursor:   "//next",
+					Step:     20,
+				},
+				Routes: map[string]*Rules{
+					"/product/": {
+						Selectors: []*Selector{
+							{
+								Name:  "price",
+								Expr:  "//span[@class='price']",
+								Extra: map[string]any{},
+							},
+						},
+						Extra: map[string]any{},
+					},
+				},
 				Selectors: []*Selector{
 					{
 						Name:  "title",
@@ -42,23 +72,77 @@ var (
 	}
 
 	testRules = &Rules{
-		Method:           "GET",
-		URL:              mustNewURL("http://example.com"),
-		Proxy:            mustNewURL("http://proxy.example.com:8080"),
-		Header:           http.Header{"User-Agent": {"test/0.2.0"}},
-		Timeout:          2500000 * time.Nanosecond,
-		Cookies:          true,
-		IgnoreRobotsTxt:  true,
-		Delay:            1500000 * time.Nanosecond,
-		Redirects:        3,
-		ResponseBodySize: 5000,
-		Selectors:        []*Selector{testSelector},
+		Method:             "GET",
+		URL:                mustNewURL("http://example.com"),
+		Proxy:              mustNewURL("http://proxy.example.com:8080"),
+		Header:             http.Header{"User-Agent": {"test/0.2.0"}},
+		Form:               url.Values{"page": {"1"}},
+		JSON:               map[string]any{"query": "colibri", "limit": float64(10)},
+		Body:               "<query>colibri</query>",
+		BasicAuth:          &BasicAuth{Username: "colibri", Password: "secret"},
+		BearerToken:        "token",
+		Profile:            "chrome",
+		Timeout:            2500000 * time.Nanosecond,
+		MaxDuration:        time.Minute,
+		Cookies:            true,
+		IgnoreRobotsTxt:    true,
+		RespectNofollow:    true,
+		Delay:              1500000 * time.Nanosecond,
+		Redirects:          3,
+		RedirectPolicy:     &RedirectPolicy{SameHost: true, Status: []int{301, 308}},
+		ResponseBodySize:   5000,
+		ParseTruncated:     true,
+		BufferBody:         4096,
+		IncludeBody:        true,
+		IncludeTLS:         true,
+		CacheNode:          true,
+		Preflight:          true,
+		FailOnStatus:       true,
+		AllowedStatusCodes: []int{200, 404},
+		BestEffort:         true,
+		Debug:              true,
+		RetryAfter:         2,
+		Backoff:            &ExponentialBackoff{Base: time.Second, Max: 30 * time.Second, Jitter: true},
+		TLS:                &TLS{InsecureSkipVerify: true, MinVersion: "1.2", Fingerprint: "chrome"},
+		Normalize:          &Normalize{StripFragment: true, StripParams: []string{"utm_*"}},
+		Scope:              &Scope{Schemes: []string{"http"}, Domains: []string{".example.com"}},
+		SaveTo:             "page.html",
+		Session: []*Rules{
+			{
+				Method: "POST",
+				URL:    mustNewURL("http://example.com/login"),
+				Form:   url.Values{"user": {"colibri"}},
+				Extra:  map[string]any{},
+			},
+		},
+		Selectors: []*Selector{testSelector},
 		Extra: map[string]any{
 			"token": float64(505),
 		},
 	}
 )
 
+func init() {
+	// The routes of the unmarshaled selectors are compiled.
+	testSelector.Selectors[0].compileRoutes()
+}
+
+// deleteMeta removes the metadata of the serialized outputs, since the durations change on each extraction.
+func deleteMeta(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		delete(v, "meta")
+		for _, value := range v {
+			deleteMeta(value)
+		}
+	case []any:
+		for _, value := range v {
+			deleteMeta(value)
+		}
+	}
+	return v
+}
+
 func mustNewURL(rawURL string) *url.URL {
 	u, _ := url.Parse(rawURL)
 	return u
@@ -338,7 +422,11 @@ func TestExtract(t *testing.T) {
 					t.Fatal("Parser Parse")
 				}
 
-				if !reflect.DeepEqual(output.Serializable(), wantOut) {
+				if (output.Attempts != 1) || (output.FetchDuration <= 0) || (output.ParseDuration <= 0) {
+					t.Fatalf("got %v, want metrics", output)
+				}
+
+				if !reflect.DeepEqual(deleteMeta(output.Serializable()), wantOut) {
 					t.Fatal("not equal")
 				}
 				return
@@ -378,8 +466,73 @@ func TestExtract(t *testing.T) {
 			t.Fatal("not equal")
 		}
 	})
+
+	t.Run("Output.Body", func(t *testing.T) {
+		out := &Output{Response: &testBufferedResponse{body: []byte("body")}}
+		out.includeBody()
+
+		m := out.Serializable()
+		if body := m["body"]; body != "body" {
+			t.Fatalf("got %v, want %v", body, "body")
+		}
+
+		// SHA-256 of "body".
+		if want := "230d8358dc8e8890b4c58deeb62912ee2f20357ae92a5cc861b98e68fe31acb5"; m["bodyHash"] != want {
+			t.Fatalf("got %v, want %v", m["bodyHash"], want)
+		}
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		c := New()
+		c.Client = &testClient{}
+		c.Parser = &testParser{}
+		c.ResponseCache = 1
+
+		ctx := context.WithValue(context.Background(), testCtxKey{}, true)
+		rules := &Rules{
+			URL:             mustNewURL("http://example.com"),
+			RespectNofollow: true,
+			Selectors: []*Selector{
+				{Name: "id", Expr: "//id", Var: true},
+				{Name: "title", Expr: "//title"},
+			},
+		}
+		rules.SetContext(ctx)
+
+		if _, err := c.Extract(rules); err != nil {
+			t.Fatal(err)
+		}
+
+		// The context of the caller's rules is not modified by the extraction.
+		if rules.Context() != ctx {
+			t.Fatal("context modified")
+		}
+	})
+
+	t.Run("Nil", func(t *testing.T) {
+		c := New()
+		c.Client = &testClient{}
+		c.Parser = &testParser{}
+		c.ResponseCache = 1
+
+		output, err := c.Extract(nil)
+		if !errors.Is(err, ErrRulesIsNil) {
+			t.Fatalf("got %v, want %v", err, ErrRulesIsNil)
+		} else if output != nil {
+			t.Fatalf("got %v, want %v", output, nil)
+		}
+	})
 }
 
+type testCtxKey struct{}
+
+type testBufferedResponse struct {
+	testResponse
+	body []byte
+}
+
+func (resp *testBufferedResponse) BufferedBody() []byte { return resp.body }
+
 func TestUserAgent(t *testing.T) {
 	c := New()
 	c.Client = &testClient{}
@@ -416,6 +569,38 @@ func TestUserAgent(t *testing.T) {
 	}
 }
 
+func TestProfile(t *testing.T) {
+	c := New()
+	c.Client = &testClient{}
+
+	rules := &Rules{Profile: "Firefox", Header: http.Header{"Accept-Language": {"es"}}}
+	if _, err := c.Do(rules); err != nil {
+		t.Fatal(err)
+	}
+
+	if rules.Header.Get("User-Agent") != Profiles["firefox"].Get("User-Agent") {
+		t.Fatal("not equal")
+	}
+
+	if rules.Header.Get("Accept") != Profiles["firefox"].Get("Accept") {
+		t.Fatal("not equal")
+	}
+
+	if rules.Header.Get("Accept-Language") != "es" {
+		t.Fatal("not equal")
+	}
+
+	_, err := c.Do(&Rules{Profile: "unknown"})
+	errs, ok := err.(*Errs)
+	if !ok {
+		t.Fatal(err)
+	}
+
+	if pErr, _ := errs.Get(KeyProfile); !errors.Is(pErr, ErrUnknownProfile) {
+		t.Fatal(err)
+	}
+}
+
 func TestClear(t *testing.T) {
 	var (
 		c      = New()
@@ -611,7 +796,9 @@ func (r *testRobots) Clear() {
 	r.ClearUsed = true
 }
 
+// testParser can be used concurrently, e.g. by ExtractAll.
 type testParser struct {
+	mu        sync.Mutex
 	ParseUsed bool
 	ClearUsed bool
 }
@@ -619,7 +806,9 @@ type testParser struct {
 func (p *testParser) Match(_ string) bool { return true }
 
 func (p *testParser) Parse(rules *Rules, _ Response) (Node, error) {
+	p.mu.Lock()
 	p.ParseUsed = true
+	p.mu.Unlock()
 
 	if err := rules.Extra["parserErr"]; err != nil {
 		return nil, err.(error)
@@ -630,7 +819,9 @@ func (p *testParser) Parse(rules *Rules, _ Response) (Node, error) {
 }
 
 func (p *testParser) Clear() {
+	p.mu.Lock()
 	p.ClearUsed = true
+	p.mu.Unlock()
 }
 
 type testNode struct {
@@ -0,0 +1,45 @@
+// Package colibriexpvar publishes the counters of Colibri through expvar, so they can be
+// scraped from the /debug/vars endpoint without adding a metrics dependency.
+//
+// Importing this package registers the expvar handler, see the expvar package.
+package colibriexpvar
+
+import (
+	"expvar"
+
+	"github.com/gonzxlez/colibri"
+)
+
+// Publish publishes the variable name with the value returned by Vars each time it is read.
+// As expvar.Publish, it panics if the name is already in use.
+func Publish(name string, c *colibri.Colibri) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return Vars(c)
+	}))
+}
+
+// Vars returns the Stats, the WorkerStats and the Progress of Colibri, and the reports of the
+// components that implement colibri.Reporter, e.g. the Client, the Delay and the RobotsTxt.
+func Vars(c *colibri.Colibri) map[string]any {
+	vars := map[string]any{
+		"stats":    c.Stats(),
+		"workers":  c.WorkerStats(),
+		"progress": c.Progress(),
+	}
+
+	components := map[string]any{
+		"client":     c.Client,
+		"delay":      c.Delay,
+		"robotsTxt":  c.RobotsTxt,
+		"visited":    c.Visited,
+		"duplicates": c.Duplicates,
+		"media":      c.Media,
+		"parser":     c.Parser,
+	}
+	for name, component := range components {
+		if r, ok := component.(colibri.Reporter); ok {
+			vars[name] = r.Report()
+		}
+	}
+	return vars
+}
@@ -0,0 +1,54 @@
+package colibriexpvar
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gonzxlez/colibri"
+)
+
+type testDelay struct{}
+
+func (testDelay) Wait(_ *url.URL, _ time.Duration) {}
+
+func (testDelay) Done(_ *url.URL) {}
+
+func (testDelay) Stamp(_ *url.URL) {}
+
+func (testDelay) Clear() {}
+
+func (testDelay) Report() any { return map[string]any{"hosts": 1} }
+
+func TestPublish(t *testing.T) {
+	c := colibri.New()
+	c.Delay = testDelay{}
+
+	Publish("colibri", c)
+
+	v := expvar.Get("colibri")
+	if v == nil {
+		t.Fatal("variable not published")
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal([]byte(v.String()), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"stats", "workers", "progress", "delay"} {
+		if _, ok := got[key]; !ok {
+			t.Fatalf("got %v, want key %q", got, key)
+		}
+	}
+
+	if _, ok := got["client"]; ok {
+		t.Fatalf("got %v, want no client", got)
+	}
+
+	if delay := got["delay"].(map[string]any); delay["hosts"] != float64(1) {
+		t.Fatalf("got %v, want %v", delay["hosts"], 1)
+	}
+}
@@ -0,0 +1,215 @@
+package colibrimigrate
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gonzxlez/colibri"
+	"github.com/gonzxlez/colibri/webextractor"
+)
+
+const (
+	gotWantFormat       = "got %v, want %v"
+	prefixGotWantFormat = "%v: got %v, want %v"
+)
+
+func TestCSSToXPath(t *testing.T) {
+	tests := []struct {
+		CSS     string
+		Want    string
+		WantErr error
+	}{
+		{"a", "//a", nil},
+		{"div > a.next", "//div/a[contains(concat(' ', normalize-space(@class), ' '), ' next ')]", nil},
+		{"#main li", "//*[@id='main']//li", nil},
+		{"a[rel=next][href]", "//a[@rel='next'][@href]", nil},
+		{`link[rel="canonical"]`, "//link[@rel='canonical']", nil},
+		{"a:first-child", "", ErrUnsupportedCSS},
+		{"a, b", "", ErrUnsupportedCSS},
+		{"> a", "", ErrUnsupportedCSS},
+		{"", "", ErrUnsupportedCSS},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.CSS, func(t *testing.T) {
+			got, err := cssToXPath(tt.CSS)
+			if !errors.Is(err, tt.WantErr) {
+				t.Fatalf(gotWantFormat, err, tt.WantErr)
+			} else if got != tt.Want {
+				t.Fatalf(gotWantFormat, got, tt.Want)
+			}
+		})
+	}
+}
+
+func TestFromColly(t *testing.T) {
+	rules, err := FromColly(&CollyConfig{
+		StartURL:       "https://example.com",
+		AllowedDomains: []string{"example.com"},
+		UserAgent:      "colibri",
+		MaxDepth:       3,
+		Delay:          time.Second,
+		Parallelism:    2,
+		OnHTML: []*CollyHandler{
+			{Name: "title", Selector: "h1"},
+			{Name: "links", Selector: "a.product", Follow: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (rules.URL.String() != "https://example.com") || (rules.Delay != time.Second) ||
+		(rules.Header.Get("User-Agent") != "colibri") || !reflect.DeepEqual(rules.Scope.Domains, []string{"example.com"}) {
+		t.Fatalf(gotWantFormat, rules, "the collector options")
+	}
+
+	// The links are followed two levels, the start URL has depth 1.
+	depth := 0
+	for selectors := rules.Selectors; len(selectors) > 0; depth++ {
+		if selectors[0].Name != "title" {
+			t.Fatalf(prefixGotWantFormat, "Name", selectors[0].Name, "title")
+		}
+
+		if len(selectors) == 1 {
+			break
+		}
+
+		links := selectors[1]
+		if !links.Follow || (links.Concurrency != 2) || (links.Type != xpathExpr) {
+			t.Fatalf(gotWantFormat, links, "a selector with Follow")
+		}
+		selectors = links.Selectors
+	}
+
+	if depth != 2 {
+		t.Fatalf(prefixGotWantFormat, "depth", depth, 2)
+	}
+
+	if _, err := FromColly(&CollyConfig{}); !errors.Is(err, ErrNoStartURL) {
+		t.Fatalf(gotWantFormat, err, ErrNoStartURL)
+	}
+
+	// The depth is unlimited in colly.
+	if _, err := FromColly(&CollyConfig{StartURL: "https://example.com"}); !errors.Is(err, ErrUnlimitedDepth) {
+		t.Fatalf(gotWantFormat, err, ErrUnlimitedDepth)
+	}
+
+	t.Run("SharedLevels", func(t *testing.T) {
+		rules, err := FromColly(&CollyConfig{
+			StartURL: "https://example.com",
+			MaxDepth: 4,
+			OnHTML: []*CollyHandler{
+				{Name: "products", Selector: "a.product", Follow: true},
+				{Name: "categories", Selector: "a.category", Follow: true},
+				{Name: "next", Selector: "a.next", Follow: true},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The selectors with Follow of each level share the selectors of the next level.
+		for selectors := rules.Selectors; len(selectors) > 0; selectors = selectors[0].Selectors {
+			if len(selectors) != 3 {
+				t.Fatalf(prefixGotWantFormat, "len", len(selectors), 3)
+			}
+
+			for _, selector := range selectors[1:] {
+				if (len(selector.Selectors) > 0) && (&selector.Selectors[0] != &selectors[0].Selectors[0]) {
+					t.Fatal("nested selectors not shared")
+				}
+			}
+		}
+	})
+}
+
+func TestFromScrapy(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<html><body><h1>Products</h1><a class="product" href="/p/1">1</a><a class="product" href="/p/2">2</a></body></html>`))
+		default:
+			w.Write([]byte(`<html><body><h1>Product ` + r.URL.Path[3:] + `</h1><span class="tag">go</span><span class="tag">web</span></body></html>`))
+		}
+	}))
+	defer ts.Close()
+
+	raw := `{
+		"name": "products",
+		"start_urls": ["` + ts.URL + `"],
+		"custom_settings": {"DOWNLOAD_DELAY": 0.5, "ROBOTSTXT_OBEY": false, "USER_AGENT": "colibri"},
+		"fields": {
+			"title": "h1::text",
+			"tags": {"css": "span.tag::text", "all": true}
+		},
+		"follow": ["a.product::attr(href)"]
+	}`
+
+	var spider ScrapySpider
+	if err := json.Unmarshal([]byte(raw), &spider); err != nil {
+		t.Fatal(err)
+	}
+
+	rulesList, err := FromScrapy(&spider)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(rulesList) != 1 {
+		t.Fatalf(prefixGotWantFormat, "len", len(rulesList), 1)
+	}
+
+	rules := rulesList[0]
+	if (rules.Delay != 500*time.Millisecond) || !rules.IgnoreRobotsTxt || (rules.Header.Get("User-Agent") != "colibri") {
+		t.Fatalf(gotWantFormat, rules, "the spider settings")
+	}
+
+	we, err := webextractor.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	we.Delay = nil     // Deactivate Delay
+	we.RobotsTxt = nil // Deactivate RobotsTxt
+
+	output, err := we.Extract(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if title := output.Data["title"]; title != "Products" {
+		t.Fatalf(prefixGotWantFormat, "title", title, "Products")
+	}
+
+	var products []any
+	for _, followed := range output.Data["follow0"].([]any) {
+		products = append(products, followed.(map[string]any)["data"])
+	}
+
+	want := []any{
+		map[string]any{"title": "Product 1", "tags": []any{"go", "web"}},
+		map[string]any{"title": "Product 2", "tags": []any{"go", "web"}},
+	}
+	if !reflect.DeepEqual(products, want) {
+		t.Fatalf(gotWantFormat, products, want)
+	}
+
+	spider.Fields["title"] = ScrapyField{CSS: "h1::first-letter"}
+	if _, err := FromScrapy(&spider); !errors.Is(err, ErrUnsupportedCSS) {
+		t.Fatalf(gotWantFormat, err, ErrUnsupportedCSS)
+	}
+
+	spider.CustomSettings = map[string]any{"DOWNLOAD_DELAY": "fast"}
+	_, err = FromScrapy(&spider)
+
+	var errs *colibri.Errs
+	if !errors.As(err, &errs) {
+		t.Fatalf(gotWantFormat, err, "*colibri.Errs")
+	} else if err, _ := errs.Get("DOWNLOAD_DELAY"); !errors.Is(err, colibri.ErrMustBeNumber) {
+		t.Fatalf(gotWantFormat, err, colibri.ErrMustBeNumber)
+	}
+}
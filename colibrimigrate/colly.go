@@ -0,0 +1,146 @@
+// Package colibrimigrate converts the configurations of other scraping frameworks,
+// colly collectors and Scrapy spiders, into colibri Rules to ease the migration to colibri.
+package colibrimigrate
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gonzxlez/colibri"
+)
+
+var (
+	// ErrNoStartURL is returned when the configuration does not have a start URL.
+	ErrNoStartURL = errors.New("start URL is missing")
+
+	// ErrUnlimitedDepth is returned when the MaxDepth of the configuration is not greater than zero,
+	// the depth of the followed links is unlimited in colly but the rules are nested up to a depth.
+	ErrUnlimitedDepth = errors.New("unlimited MaxDepth is not supported")
+)
+
+// CollyConfig contains the options of a colly Collector, its LimitRule and its OnHTML callbacks.
+type CollyConfig struct {
+	// StartURL is the URL visited first, the argument of Collector.Visit.
+	StartURL string `json:"startURL"`
+
+	// AllowedDomains contains the domains that can be visited, only the hosts are allowed, not their subdomains.
+	AllowedDomains []string `json:"allowedDomains,omitempty"`
+
+	// UserAgent is the User-Agent of the requests.
+	UserAgent string `json:"userAgent,omitempty"`
+
+	// MaxDepth limits the depth of the links followed, the start URL has depth 1.
+	// Unlike colly, where zero means unlimited depth, it is required: FromColly returns
+	// ErrUnlimitedDepth if it is not greater than zero, since the followed selectors are nested up to it.
+	MaxDepth int `json:"maxDepth,omitempty"`
+
+	// MaxBodySize is the maximum size of the response bodies.
+	MaxBodySize int `json:"maxBodySize,omitempty"`
+
+	// IgnoreRobotsTxt specifies whether robots.txt is ignored.
+	IgnoreRobotsTxt bool `json:"ignoreRobotsTxt,omitempty"`
+
+	// Delay is the Delay of the LimitRule.
+	Delay time.Duration `json:"delay,omitempty"`
+
+	// Parallelism is the Parallelism of the LimitRule, it is the Concurrency of the links followed.
+	Parallelism int `json:"parallelism,omitempty"`
+
+	// OnHTML contains the OnHTML callbacks.
+	OnHTML []*CollyHandler `json:"onHTML,omitempty"`
+}
+
+// CollyHandler describes an OnHTML callback.
+type CollyHandler struct {
+	// Name is the name of the value extracted by the callback.
+	Name string `json:"name"`
+
+	// Selector is the goquery selector of the callback.
+	Selector string `json:"selector"`
+
+	// Attr is the attribute read with e.Attr, if it is empty the text of the element is read with e.Text.
+	Attr string `json:"attr,omitempty"`
+
+	// All specifies whether the values of all the elements are extracted, e.g. with e.ForEach.
+	All bool `json:"all,omitempty"`
+
+	// Follow specifies whether the callback visits the links found, e.g. with e.Request.Visit(e.Attr("href")),
+	// if Attr is empty the href attribute is used. The other callbacks are run in the pages visited.
+	Follow bool `json:"follow,omitempty"`
+}
+
+// FromColly returns the rules of the collector configuration.
+// The callbacks with Follow are selectors with Follow whose nested selectors are the rest of the callbacks,
+// and the callbacks with Follow again until the MaxDepth. The selectors with Follow of the same depth
+// share their nested selectors, so the rules must not be released, see colibri.ReleaseRules.
+func FromColly(config *CollyConfig) (*colibri.Rules, error) {
+	if config.StartURL == "" {
+		return nil, ErrNoStartURL
+	} else if config.MaxDepth <= 0 {
+		return nil, ErrUnlimitedDepth
+	}
+
+	u, err := url.Parse(config.StartURL)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := &colibri.Rules{
+		Method:           http.MethodGet,
+		URL:              u,
+		ResponseBodySize: config.MaxBodySize,
+		IgnoreRobotsTxt:  config.IgnoreRobotsTxt,
+		Delay:            config.Delay,
+		Extra:            make(map[string]any),
+	}
+
+	if config.UserAgent != "" {
+		rules.Header = http.Header{"User-Agent": {config.UserAgent}}
+	}
+
+	if len(config.AllowedDomains) > 0 {
+		rules.Scope = &colibri.Scope{Domains: append([]string(nil), config.AllowedDomains...)}
+	}
+
+	// The selectors are built from the deepest level, the pages that do not follow links.
+	var selectors []*colibri.Selector
+	for depth := config.MaxDepth; depth > 0; depth-- {
+		if selectors, err = collySelectors(config, selectors, depth < config.MaxDepth); err != nil {
+			return nil, err
+		}
+	}
+
+	rules.Selectors = selectors
+	return rules, nil
+}
+
+// collySelectors returns the selectors of the callbacks of a level, the callbacks with Follow
+// are included if follow is true and their nested selectors are the selectors of the next level.
+func collySelectors(config *CollyConfig, next []*colibri.Selector, follow bool) ([]*colibri.Selector, error) {
+	var selectors []*colibri.Selector
+	for _, handler := range config.OnHTML {
+		if handler.Follow && !follow {
+			continue
+		}
+
+		attr := handler.Attr
+		if handler.Follow && (attr == "") {
+			attr = "href"
+		}
+
+		selector, err := newSelector(handler.Name, handler.Selector, attr, handler.All || handler.Follow)
+		if err != nil {
+			return nil, err
+		}
+
+		if handler.Follow {
+			selector.Follow = true
+			selector.Concurrency = config.Parallelism
+			selector.Selectors = next
+		}
+		selectors = append(selectors, selector)
+	}
+	return selectors, nil
+}
@@ -0,0 +1,179 @@
+package colibrimigrate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gonzxlez/colibri"
+)
+
+// Types of the selector expressions, see the parsers of webextractor.
+const (
+	xpathExpr   = "xpath"
+	cssSelector = "css"
+)
+
+// ErrUnsupportedCSS is returned when a CSS selector with an attribute cannot be converted to XPath,
+// only type, id, class and attribute selectors with the descendant and child combinators are supported.
+var ErrUnsupportedCSS = errors.New("unsupported CSS selector")
+
+// newSelector returns a selector of the CSS expression. The text of the elements is found with a CSS selector,
+// since the value of the elements is their text, and the attributes with an XPath expression.
+func newSelector(name, css, attr string, all bool) (*colibri.Selector, error) {
+	selector := &colibri.Selector{
+		Name:  name,
+		Expr:  strings.TrimSpace(css),
+		Type:  cssSelector,
+		All:   all,
+		Extra: make(map[string]any),
+	}
+
+	if attr == "" {
+		return selector, nil
+	}
+
+	expr, err := cssToXPath(selector.Expr)
+	if err != nil {
+		return nil, err
+	}
+
+	selector.Expr = expr + "/@" + attr
+	selector.Type = xpathExpr
+	return selector, nil
+}
+
+// pseudoElement splits the Scrapy pseudo-elements of the expression,
+// returns the attribute of ::attr(name), "" for ::text or without a pseudo-element.
+func pseudoElement(expr string) (css, attr string, err error) {
+	css, pseudo, ok := strings.Cut(expr, "::")
+	if !ok || (pseudo == "text") {
+		return css, "", nil
+	}
+
+	if attr, ok = strings.CutPrefix(pseudo, "attr("); ok && strings.HasSuffix(attr, ")") {
+		return css, strings.TrimSpace(strings.TrimSuffix(attr, ")")), nil
+	}
+	return "", "", fmt.Errorf("%w: %s", ErrUnsupportedCSS, expr)
+}
+
+// cssToXPath converts the CSS selector to an XPath expression.
+func cssToXPath(css string) (string, error) {
+	var (
+		b         strings.Builder
+		axis      = "//"
+		fields    = strings.Fields(strings.ReplaceAll(css, ">", " > "))
+		compounds int
+	)
+
+	for _, field := range fields {
+		if field == ">" {
+			if compounds == 0 {
+				return "", fmt.Errorf("%w: %s", ErrUnsupportedCSS, css)
+			}
+			axis = "/"
+			continue
+		}
+
+		step, err := compoundToXPath(field)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s", err, css)
+		}
+
+		b.WriteString(axis)
+		b.WriteString(step)
+		axis = "//"
+		compounds++
+	}
+
+	if (compounds == 0) || (axis == "/") {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedCSS, css)
+	}
+	return b.String(), nil
+}
+
+// compoundToXPath converts a compound selector, e.g. a.link#home[rel=next], to an XPath step.
+func compoundToXPath(compound string) (string, error) {
+	var (
+		tag        = "*"
+		predicates []string
+		rest       = compound
+	)
+
+	if i := strings.IndexAny(rest, ".#["); i != 0 {
+		if i < 0 {
+			i = len(rest)
+		}
+		tag, rest = rest[:i], rest[i:]
+	}
+
+	if !isName(tag) && (tag != "*") {
+		return "", ErrUnsupportedCSS
+	}
+
+	for rest != "" {
+		switch rest[0] {
+		case '.', '#':
+			end := strings.IndexAny(rest[1:], ".#[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+
+			name := rest[1 : end+1]
+			if !isName(name) {
+				return "", ErrUnsupportedCSS
+			}
+
+			if rest[0] == '#' {
+				predicates = append(predicates, fmt.Sprintf("@id='%s'", name))
+			} else {
+				predicates = append(predicates, fmt.Sprintf("contains(concat(' ', normalize-space(@class), ' '), ' %s ')", name))
+			}
+			rest = rest[end+1:]
+
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return "", ErrUnsupportedCSS
+			}
+
+			attr, value, hasValue := strings.Cut(rest[1:end], "=")
+			if !isName(attr) {
+				return "", ErrUnsupportedCSS
+			}
+
+			if !hasValue {
+				predicates = append(predicates, "@"+attr)
+			} else {
+				value = strings.Trim(value, `"'`)
+				if strings.ContainsRune(value, '\'') {
+					return "", ErrUnsupportedCSS
+				}
+				predicates = append(predicates, fmt.Sprintf("@%s='%s'", attr, value))
+			}
+			rest = rest[end+1:]
+
+		default:
+			return "", ErrUnsupportedCSS
+		}
+	}
+
+	step := tag
+	for _, predicate := range predicates {
+		step += "[" + predicate + "]"
+	}
+	return step, nil
+}
+
+func isName(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if !(('a' <= r) && (r <= 'z')) && !(('A' <= r) && (r <= 'Z')) && !(('0' <= r) && (r <= '9')) && (r != '-') && (r != '_') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,220 @@
+package colibrimigrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gonzxlez/colibri"
+)
+
+// ScrapySpider contains the attributes of a Scrapy spider, e.g. decoded from JSON or YAML.
+type ScrapySpider struct {
+	// Name is the name of the spider.
+	Name string `json:"name"`
+
+	// AllowedDomains contains the domains that can be visited, including their subdomains.
+	AllowedDomains []string `json:"allowed_domains,omitempty"`
+
+	// StartURLs contains the URLs visited first.
+	StartURLs []string `json:"start_urls"`
+
+	// CustomSettings contains the settings of the spider. The DOWNLOAD_DELAY, DOWNLOAD_TIMEOUT,
+	// DOWNLOAD_MAXSIZE, USER_AGENT, DEFAULT_REQUEST_HEADERS, ROBOTSTXT_OBEY and CONCURRENT_REQUESTS
+	// settings are converted, the rest are ignored.
+	CustomSettings map[string]any `json:"custom_settings,omitempty"`
+
+	// Fields contains the fields of the items and their CSS expressions,
+	// with the ::text and ::attr(name) pseudo-elements, e.g. "h1::text" or "a::attr(href)".
+	Fields map[string]ScrapyField `json:"fields"`
+
+	// Follow contains the CSS expressions of the links followed, as response.follow,
+	// the fields are extracted from the pages followed. If the expression does not
+	// have the ::attr(name) pseudo-element, the href attribute is used.
+	Follow []string `json:"follow,omitempty"`
+}
+
+// ScrapyField is a field of the items of a ScrapySpider. In JSON it can be the CSS expression,
+// the value of get(), or an object with the expression and whether all the values are extracted.
+type ScrapyField struct {
+	// CSS is the CSS expression of the field.
+	CSS string `json:"css"`
+
+	// All specifies whether the values of all the elements are extracted, the value of getall().
+	All bool `json:"all,omitempty"`
+}
+
+func (field *ScrapyField) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &field.CSS); err == nil {
+		return nil
+	}
+
+	type rawField ScrapyField
+	return json.Unmarshal(b, (*rawField)(field))
+}
+
+// FromScrapy returns the rules of each start URL of the spider.
+func FromScrapy(spider *ScrapySpider) ([]*colibri.Rules, error) {
+	if len(spider.StartURLs) == 0 {
+		return nil, ErrNoStartURL
+	}
+
+	base := &colibri.Rules{Method: http.MethodGet, Extra: make(map[string]any)}
+	if len(spider.AllowedDomains) > 0 {
+		base.Scope = &colibri.Scope{}
+		for _, domain := range spider.AllowedDomains {
+			base.Scope.Domains = append(base.Scope.Domains, "."+strings.TrimPrefix(domain, "."))
+		}
+	}
+
+	concurrency, err := scrapySettings(base, spider.CustomSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := scrapyFields(spider.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	selectors := fields
+	for i, expr := range spider.Follow {
+		css, attr, err := pseudoElement(expr)
+		if err != nil {
+			return nil, err
+		}
+
+		if attr == "" {
+			attr = "href"
+		}
+
+		follow, err := newSelector(fmt.Sprintf("follow%d", i), css, attr, true)
+		if err != nil {
+			return nil, err
+		}
+
+		follow.Follow = true
+		follow.Concurrency = concurrency
+		follow.Selectors = colibri.CloneSelectors(fields)
+		selectors = append(selectors, follow)
+	}
+
+	var rulesList []*colibri.Rules
+	for _, rawURL := range spider.StartURLs {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, err
+		}
+
+		rules := base.Clone()
+		rules.URL = u
+		rules.Selectors = colibri.CloneSelectors(selectors)
+		rulesList = append(rulesList, rules)
+	}
+	return rulesList, nil
+}
+
+// scrapyFields returns the selectors of the fields sorted by name.
+func scrapyFields(fields map[string]ScrapyField) ([]*colibri.Selector, error) {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	selectors := make([]*colibri.Selector, 0, len(names))
+	for _, name := range names {
+		css, attr, err := pseudoElement(fields[name].CSS)
+		if err != nil {
+			return nil, err
+		}
+
+		selector, err := newSelector(name, css, attr, fields[name].All)
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, selector)
+	}
+	return selectors, nil
+}
+
+// scrapySettings sets the settings in the rules and returns the CONCURRENT_REQUESTS.
+func scrapySettings(rules *colibri.Rules, settings map[string]any) (int, error) {
+	var concurrency int
+	for key, value := range settings {
+		var err error
+		switch key {
+		case "DOWNLOAD_DELAY":
+			rules.Delay, err = scrapySeconds(value)
+		case "DOWNLOAD_TIMEOUT":
+			rules.Timeout, err = scrapySeconds(value)
+		case "DOWNLOAD_MAXSIZE":
+			rules.ResponseBodySize, err = scrapyInt(value)
+		case "CONCURRENT_REQUESTS":
+			concurrency, err = scrapyInt(value)
+		case "ROBOTSTXT_OBEY":
+			obey, ok := value.(bool)
+			if !ok {
+				err = colibri.ErrNotAssignable
+			}
+			rules.IgnoreRobotsTxt = !obey
+		case "USER_AGENT":
+			ua, ok := value.(string)
+			if !ok {
+				err = colibri.ErrMustBeString
+				break
+			}
+			scrapyHeader(rules).Set("User-Agent", ua)
+		case "DEFAULT_REQUEST_HEADERS":
+			headers, ok := value.(map[string]any)
+			if !ok {
+				err = colibri.ErrInvalidHeader
+				break
+			}
+
+			for k, v := range headers {
+				if scrapyHeader(rules).Get(k) == "" {
+					scrapyHeader(rules).Set(k, fmt.Sprint(v))
+				}
+			}
+		}
+
+		if err != nil {
+			return 0, colibri.AddError(nil, key, err)
+		}
+	}
+	return concurrency, nil
+}
+
+func scrapyHeader(rules *colibri.Rules) http.Header {
+	if rules.Header == nil {
+		rules.Header = make(http.Header)
+	}
+	return rules.Header
+}
+
+func scrapySeconds(value any) (time.Duration, error) {
+	seconds, ok := value.(float64)
+	if !ok {
+		n, err := scrapyInt(value)
+		if err != nil {
+			return 0, err
+		}
+		seconds = float64(n)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+func scrapyInt(value any) (int, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case float64:
+		return int(v), nil
+	}
+	return 0, colibri.ErrMustBeNumber
+}
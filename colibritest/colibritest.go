@@ -0,0 +1,385 @@
+// Package colibritest provides implementations of the Colibri interfaces for testing,
+// so the integrations with Colibri can be tested without making HTTP requests.
+package colibritest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gonzxlez/colibri"
+)
+
+// New returns a new Colibri structure with a Client, Delay, RobotsTxt and Parser of this package.
+func New() *colibri.Colibri {
+	c := colibri.New()
+	c.Client = NewClient()
+	c.Delay = &Delay{}
+	c.RobotsTxt = &RobotsTxt{}
+	c.Parser = &Parser{}
+	return c
+}
+
+// Response is a colibri.Response with fixed values.
+type Response struct {
+	// RequestURL is the URL returned by URL.
+	RequestURL *url.URL
+
+	// Code is the status code, if it is zero 200 is returned.
+	Code int
+
+	// HTTPHeader is the header returned by Header.
+	HTTPHeader http.Header
+
+	// Content is the content of the body, each call to Body returns a new reader of it.
+	Content string
+
+	// RedirectURLs are the URLs returned by Redirects.
+	RedirectURLs []*url.URL
+
+	// Serialized specifies the value returned by Serializable,
+	// if it is nil the URL and the status code are returned.
+	Serialized map[string]any
+
+	// Colibri is used by Do and Extract.
+	Colibri *colibri.Colibri
+}
+
+func (resp *Response) URL() *url.URL {
+	return resp.RequestURL
+}
+
+func (resp *Response) StatusCode() int {
+	if resp.Code == 0 {
+		return http.StatusOK
+	}
+	return resp.Code
+}
+
+func (resp *Response) Header() http.Header {
+	if resp.HTTPHeader == nil {
+		resp.HTTPHeader = http.Header{}
+	}
+	return resp.HTTPHeader
+}
+
+func (resp *Response) Body() io.ReadCloser {
+	return io.NopCloser(strings.NewReader(resp.Content))
+}
+
+// BodySize returns the size of the Content. See the colibri.BodySizer interface.
+func (resp *Response) BodySize() int64 {
+	return int64(len(resp.Content))
+}
+
+func (resp *Response) Redirects() []*url.URL {
+	return resp.RedirectURLs
+}
+
+func (resp *Response) Serializable() map[string]any {
+	if resp.Serialized != nil {
+		return resp.Serialized
+	}
+
+	m := map[string]any{"code": resp.StatusCode()}
+	if resp.RequestURL != nil {
+		m["url"] = resp.RequestURL.String()
+	}
+	return m
+}
+
+func (resp *Response) Do(rules *colibri.Rules) (colibri.Response, error) {
+	return resp.Colibri.Do(rules)
+}
+
+func (resp *Response) Extract(rules *colibri.Rules) (*colibri.Output, error) {
+	return resp.Colibri.Extract(rules)
+}
+
+// Client is a colibri.Client that returns the responses and errors added for each URL.
+// The URLs without a response are answered with a 404 Not Found response.
+type Client struct {
+	mu        sync.Mutex
+	responses map[string]*Response
+	errs      map[string]error
+	requests  []*url.URL
+	cleared   bool
+}
+
+// NewClient returns a new Client structure.
+func NewClient() *Client {
+	return &Client{
+		responses: make(map[string]*Response),
+		errs:      make(map[string]error),
+	}
+}
+
+// Handle adds the response of the URL.
+func (client *Client) Handle(rawURL string, resp *Response) {
+	client.mu.Lock()
+	client.responses[rawURL] = resp
+	client.mu.Unlock()
+}
+
+// HandleString adds a response of the URL with the Content-Type and the content.
+func (client *Client) HandleString(rawURL, contentType, content string) {
+	client.Handle(rawURL, &Response{
+		HTTPHeader: http.Header{"Content-Type": {contentType}},
+		Content:    content,
+	})
+}
+
+// HandleError adds the error returned by the requests to the URL.
+func (client *Client) HandleError(rawURL string, err error) {
+	client.mu.Lock()
+	client.errs[rawURL] = err
+	client.mu.Unlock()
+}
+
+func (client *Client) Do(c *colibri.Colibri, rules *colibri.Rules) (colibri.Response, error) {
+	if rules.URL == nil {
+		return nil, errors.New("URL is nil")
+	}
+
+	rawURL := rules.URL.String()
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	client.requests = append(client.requests, rules.URL)
+	if err := client.errs[rawURL]; err != nil {
+		return nil, err
+	}
+
+	resp := Response{Code: http.StatusNotFound}
+	if handled := client.responses[rawURL]; handled != nil {
+		resp = *handled
+		resp.HTTPHeader = handled.HTTPHeader.Clone()
+	}
+
+	resp.RequestURL = rules.URL
+	resp.Colibri = c
+	return &resp, nil
+}
+
+// Requests returns the URLs requested, in order.
+func (client *Client) Requests() []*url.URL {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	return append([]*url.URL(nil), client.requests...)
+}
+
+// Cleared returns true if Clear has been called.
+func (client *Client) Cleared() bool {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	return client.cleared
+}
+
+func (client *Client) Clear() {
+	client.mu.Lock()
+	client.cleared = true
+	client.requests = nil
+	client.mu.Unlock()
+}
+
+// Delay is a colibri.Delay that does not wait and counts the calls of each method.
+type Delay struct {
+	mu                   sync.Mutex
+	waits, dones, stamps int
+	cleared              bool
+}
+
+func (d *Delay) Wait(_ *url.URL, _ time.Duration) {
+	d.mu.Lock()
+	d.waits++
+	d.mu.Unlock()
+}
+
+func (d *Delay) Done(_ *url.URL) {
+	d.mu.Lock()
+	d.dones++
+	d.mu.Unlock()
+}
+
+func (d *Delay) Stamp(_ *url.URL) {
+	d.mu.Lock()
+	d.stamps++
+	d.mu.Unlock()
+}
+
+// Calls returns the number of calls to Wait, Done and Stamp.
+func (d *Delay) Calls() (waits, dones, stamps int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.waits, d.dones, d.stamps
+}
+
+// Cleared returns true if Clear has been called.
+func (d *Delay) Cleared() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.cleared
+}
+
+func (d *Delay) Clear() {
+	d.mu.Lock()
+	d.cleared = true
+	d.mu.Unlock()
+}
+
+// RobotsTxt is a colibri.RobotsTxt that disallows the URLs whose path starts with
+// one of the Disallow paths.
+type RobotsTxt struct {
+	// Disallow contains the disallowed paths.
+	Disallow []string
+
+	mu      sync.Mutex
+	cleared bool
+}
+
+func (robots *RobotsTxt) IsAllowed(_ *colibri.Colibri, rules *colibri.Rules) error {
+	for _, path := range robots.Disallow {
+		if strings.HasPrefix(rules.URL.Path, path) {
+			return colibri.ErrRobotstxtRestriction
+		}
+	}
+	return nil
+}
+
+// Cleared returns true if Clear has been called.
+func (robots *RobotsTxt) Cleared() bool {
+	robots.mu.Lock()
+	defer robots.mu.Unlock()
+
+	return robots.cleared
+}
+
+func (robots *RobotsTxt) Clear() {
+	robots.mu.Lock()
+	robots.cleared = true
+	robots.mu.Unlock()
+}
+
+// Parser is a colibri.Parser that parses the response body as JSON, see Node.
+type Parser struct {
+	mu      sync.Mutex
+	parsed  int
+	cleared bool
+}
+
+// Match returns true for any Content-Type.
+func (p *Parser) Match(_ string) bool {
+	return true
+}
+
+func (p *Parser) Parse(_ *colibri.Rules, resp colibri.Response) (colibri.Node, error) {
+	p.mu.Lock()
+	p.parsed++
+	p.mu.Unlock()
+
+	body := resp.Body()
+	if body == nil {
+		return &Node{}, nil
+	}
+	defer body.Close()
+
+	var value any
+	if err := json.NewDecoder(body).Decode(&value); (err != nil) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return &Node{value: value}, nil
+}
+
+// Parsed returns the number of responses parsed.
+func (p *Parser) Parsed() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.parsed
+}
+
+// Cleared returns true if Clear has been called.
+func (p *Parser) Cleared() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.cleared
+}
+
+func (p *Parser) Clear() {
+	p.mu.Lock()
+	p.cleared = true
+	p.mu.Unlock()
+}
+
+// Node is a colibri.Node of a JSON value.
+// The expressions of the selectors are paths of object keys separated by dots, e.g. "user.name",
+// FindAll returns a node for each element of an array.
+type Node struct {
+	value any
+}
+
+// NewNode returns a new Node of the value.
+func NewNode(value any) *Node {
+	return &Node{value: value}
+}
+
+func (node *Node) Find(selector *colibri.Selector) (colibri.Node, error) {
+	value, ok := node.lookup(selector.Expr)
+	if !ok {
+		return nil, nil
+	}
+	return &Node{value: value}, nil
+}
+
+func (node *Node) FindAll(selector *colibri.Selector) ([]colibri.Node, error) {
+	value, ok := node.lookup(selector.Expr)
+	if !ok {
+		return nil, nil
+	}
+
+	values, ok := value.([]any)
+	if !ok {
+		return []colibri.Node{&Node{value: value}}, nil
+	}
+
+	nodes := make([]colibri.Node, 0, len(values))
+	for _, v := range values {
+		nodes = append(nodes, &Node{value: v})
+	}
+	return nodes, nil
+}
+
+func (node *Node) Value() any {
+	return node.value
+}
+
+// lookup returns the value of the path, "" and "." return the value of the node.
+func (node *Node) lookup(path string) (any, bool) {
+	value := node.value
+	if (path == "") || (path == ".") {
+		return value, true
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		if value, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
@@ -0,0 +1,221 @@
+package colibritest
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gonzxlez/colibri"
+)
+
+const (
+	gotWantFormat       = "got %v, want %v"
+	prefixGotWantFormat = "%v: got %v, want %v"
+)
+
+func mustNewURL(rawURL string) *url.URL {
+	u, _ := url.Parse(rawURL)
+	return u
+}
+
+func TestColibri(t *testing.T) {
+	c := New()
+
+	client := c.Client.(*Client)
+	client.HandleString("https://example.com", "application/json", `{
+		"title": "Example Domain",
+		"links": ["https://example.com/a", "https://example.com/b"]
+	}`)
+	client.HandleString("https://example.com/a", "application/json", `{"title": "A"}`)
+	client.HandleError("https://example.com/b", errors.New("test err"))
+
+	c.RobotsTxt.(*RobotsTxt).Disallow = []string{"/private"}
+
+	output, err := c.Extract(&colibri.Rules{
+		Method: "GET",
+		URL:    mustNewURL("https://example.com"),
+		Selectors: []*colibri.Selector{
+			{Name: "title", Expr: "title"},
+			{Name: "missing", Expr: "missing.key"},
+			{
+				Name:      "links",
+				Expr:      "links",
+				All:       true,
+				Follow:    true,
+				Selectors: []*colibri.Selector{{Name: "title", Expr: "title"}},
+			},
+		},
+	})
+
+	var errs *colibri.Errs
+	if !errors.As(err, &errs) {
+		t.Fatalf(gotWantFormat, err, "*colibri.Errs")
+	}
+
+	if _, ok := errs.Get("links"); !ok {
+		t.Fatalf(prefixGotWantFormat, "links", err, "error")
+	}
+
+	// The durations change on each extraction.
+	for _, link := range output.Data["links"].([]any) {
+		meta := link.(map[string]any)["meta"].(map[string]any)
+		if (meta["attempts"] != 1) || (meta["bodySize"] != int64(14)) {
+			t.Fatalf(prefixGotWantFormat, "meta", meta, "attempts 1 and bodySize 14")
+		}
+		delete(link.(map[string]any), "meta")
+	}
+
+	want := map[string]any{
+		"title":   "Example Domain",
+		"missing": nil,
+		"links": []any{
+			map[string]any{
+				"response": map[string]any{"url": "https://example.com/a", "code": 200},
+				"data":     map[string]any{"title": "A"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(output.Data, want) {
+		t.Fatalf(gotWantFormat, output.Data, want)
+	}
+
+	var got []string
+	for _, u := range client.Requests() {
+		got = append(got, u.String())
+	}
+
+	wantRequests := []string{"https://example.com", "https://example.com/a", "https://example.com/b"}
+	if !reflect.DeepEqual(got, wantRequests) {
+		t.Fatalf(prefixGotWantFormat, "Requests", got, wantRequests)
+	}
+
+	if waits, _, _ := c.Delay.(*Delay).Calls(); waits != 3 {
+		t.Fatalf(prefixGotWantFormat, "Waits", waits, 3)
+	}
+
+	if parsed := c.Parser.(*Parser).Parsed(); parsed != 2 {
+		t.Fatalf(prefixGotWantFormat, "Parsed", parsed, 2)
+	}
+
+	t.Run("NotFound", func(t *testing.T) {
+		resp, err := c.Do(&colibri.Rules{Method: "GET", URL: mustNewURL("https://example.com/404")})
+		if err != nil {
+			t.Fatal(err)
+		} else if resp.StatusCode() != http.StatusNotFound {
+			t.Fatalf(gotWantFormat, resp.StatusCode(), http.StatusNotFound)
+		}
+	})
+
+	t.Run("RobotsTxt", func(t *testing.T) {
+		_, err := c.Do(&colibri.Rules{Method: "GET", URL: mustNewURL("https://example.com/private/a")})
+		if !errors.Is(err, colibri.ErrRobotstxtRestriction) {
+			t.Fatalf(gotWantFormat, err, colibri.ErrRobotstxtRestriction)
+		}
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		c.Clear()
+		if !client.Cleared() || !c.Delay.(*Delay).Cleared() || !c.RobotsTxt.(*RobotsTxt).Cleared() || !c.Parser.(*Parser).Cleared() {
+			t.Fatal("not cleared")
+		}
+	})
+}
+
+func TestServer(t *testing.T) {
+	ts := NewServer(map[string]*Fixture{
+		"/": {Body: "<html><body>colibri</body></html>"},
+		"/json": {
+			Status:      http.StatusCreated,
+			ContentType: "application/json",
+			Header:      http.Header{"X-Test": {"test"}},
+			Body:        `{"title": "colibri"}`,
+		},
+	})
+	defer ts.Close()
+
+	tests := []struct {
+		Path        string
+		Status      int
+		ContentType string
+		Body        string
+	}{
+		{"/", http.StatusOK, "text/html; charset=utf-8", "<html><body>colibri</body></html>"},
+		{"/json", http.StatusCreated, "application/json", `{"title": "colibri"}`},
+		{"/404", http.StatusNotFound, "text/plain; charset=utf-8", "404 page not found\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Path, func(t *testing.T) {
+			resp, err := http.Get(ts.URL + tt.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.StatusCode != tt.Status {
+				t.Fatalf(prefixGotWantFormat, "Status", resp.StatusCode, tt.Status)
+			} else if got := resp.Header.Get("Content-Type"); got != tt.ContentType {
+				t.Fatalf(prefixGotWantFormat, "Content-Type", got, tt.ContentType)
+			} else if string(body) != tt.Body {
+				t.Fatalf(prefixGotWantFormat, "Body", string(body), tt.Body)
+			}
+		})
+	}
+
+	if n := len(ts.Requests()); n != len(tests) {
+		t.Fatalf(prefixGotWantFormat, "Requests", n, len(tests))
+	}
+}
+
+func TestRunSelectorTests(t *testing.T) {
+	RunSelectorTests(t, New(), "testdata/selectors")
+}
+
+func TestUpdateGolden(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"rules.json", "product.json"} {
+		b, err := os.ReadFile(filepath.Join("testdata/selectors", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, name), b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runSelectorTests(t, New(), dir, true)
+
+	got, err := os.ReadFile(filepath.Join(dir, "product"+GoldenSuffix))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := os.ReadFile(filepath.Join("testdata/selectors", "product"+GoldenSuffix))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(got) != string(want) {
+		t.Fatalf(gotWantFormat, string(got), string(want))
+	}
+
+	changes, err := goldenDiff(want, []byte(`{"data": {"name": "Colibri", "price": 9.99, "tags": ["go", "scraping"]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if wantChanges := []colibri.Change{{Path: "data.price", Old: 10.5, New: 9.99}}; !reflect.DeepEqual(changes, wantChanges) {
+		t.Fatalf(gotWantFormat, changes, wantChanges)
+	}
+}
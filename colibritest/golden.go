@@ -0,0 +1,198 @@
+package colibritest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gonzxlez/colibri"
+)
+
+// GoldenSuffix is the suffix of the golden files of the fixtures, see RunSelectorTests.
+const GoldenSuffix = ".golden.json"
+
+// FixtureURL is the URL of the fixtures when the rules do not have a URL, see RunSelectorTests.
+const FixtureURL = "https://example.com/"
+
+var updateGolden = flag.Bool("update-golden", false, "update the golden files of colibritest.RunSelectorTests")
+
+// RunSelectorTests runs a subtest for each fixture of the directory, e.g. the HTML or JSON files
+// of the pages of a site. The rules file of the directory, rules.json, rules.yaml or rules.yml, is extracted
+// with c from the content of the fixture, served at the URL of the rules with the Content-Type of its extension.
+// If the rules do not have a URL, the fixture is served at FixtureURL followed by its name.
+//
+// The data and the errors of the output are compared with the golden file of the fixture,
+// named after the fixture with the GoldenSuffix extension, e.g. product.html and product.golden.json.
+// The golden files are created or updated instead of compared when the tests are run with the -update-golden flag,
+// only the test binaries of the packages that import colibritest define it:
+//
+//	go test -run TestShopRules . -update-golden
+//
+// The Client, Delay and RobotsTxt of c are replaced by the ones of this package, the Parser of c is used.
+// Visited, Duplicates and NearDuplicates are not used.
+func RunSelectorTests(t *testing.T, c *colibri.Colibri, dir string) {
+	t.Helper()
+	runSelectorTests(t, c, dir, *updateGolden)
+}
+
+func runSelectorTests(t *testing.T, c *colibri.Colibri, dir string, update bool) {
+	t.Helper()
+
+	rulesName, fixtures, err := readFixtures(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := colibri.LoadRulesFile(rulesName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, fixture := range fixtures {
+		t.Run(fixture, func(t *testing.T) {
+			got, err := extractFixture(c, rules, filepath.Join(dir, fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			goldenName := filepath.Join(dir, strings.TrimSuffix(fixture, filepath.Ext(fixture))+GoldenSuffix)
+			if update {
+				if err := os.WriteFile(goldenName, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(goldenName)
+			if err != nil {
+				t.Fatalf("%v, run the tests with -update-golden to create it", err)
+			}
+
+			if changes, err := goldenDiff(want, got); err != nil {
+				t.Fatal(err)
+			} else if len(changes) > 0 {
+				t.Errorf("output does not match %s:\n%s", goldenName, formatChanges(changes))
+			}
+		})
+	}
+}
+
+// readFixtures returns the path of the rules file of the directory and the names of its fixtures, sorted.
+func readFixtures(dir string) (string, []string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var (
+		rulesName string
+		fixtures  []string
+	)
+	for _, entry := range entries {
+		name := entry.Name()
+		switch {
+		case entry.IsDir(), strings.HasSuffix(name, GoldenSuffix), strings.HasPrefix(name, "."):
+			continue
+		case strings.TrimSuffix(name, filepath.Ext(name)) == "rules":
+			rulesName = filepath.Join(dir, name)
+		default:
+			fixtures = append(fixtures, name)
+		}
+	}
+
+	if rulesName == "" {
+		return "", nil, fmt.Errorf("colibritest: %s does not have a rules file", dir)
+	}
+
+	sort.Strings(fixtures)
+	return rulesName, fixtures, nil
+}
+
+// extractFixture extracts the rules from the content of the fixture
+// and returns the indented JSON of the data and the errors of the output.
+func extractFixture(c *colibri.Colibri, src *colibri.Rules, name string) ([]byte, error) {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := src.Clone()
+	defer colibri.ReleaseRules(rules)
+
+	if rules.URL == nil {
+		if rules.URL, err = url.Parse(FixtureURL + filepath.Base(name)); err != nil {
+			return nil, err
+		}
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(content)
+	}
+
+	client := NewClient()
+	client.HandleString(rules.URL.String(), contentType, string(content))
+
+	fc := c.With(
+		colibri.WithClient(client),
+		colibri.WithDelay(&Delay{}),
+		colibri.WithRobotsTxt(&RobotsTxt{}),
+		colibri.WithVisited(nil),
+		colibri.WithDuplicates(nil),
+		colibri.WithNearDuplicates(nil),
+	)
+
+	golden := make(map[string]any)
+	output, err := fc.Extract(rules)
+	if output != nil {
+		golden["data"] = output.Data
+	}
+
+	if err != nil {
+		var m json.Marshaler
+		if errors.As(err, &m) {
+			golden["errors"] = m
+		} else {
+			golden["errors"] = err.Error()
+		}
+	}
+
+	b, err := json.MarshalIndent(golden, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// goldenDiff returns the fields of the golden JSON that differ from the JSON obtained.
+func goldenDiff(want, got []byte) ([]colibri.Change, error) {
+	var wantMap, gotMap map[string]any
+	if err := json.Unmarshal(want, &wantMap); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(got, &gotMap); err != nil {
+		return nil, err
+	}
+	return colibri.Diff(wantMap, gotMap), nil
+}
+
+func formatChanges(changes []colibri.Change) string {
+	var b bytes.Buffer
+	for _, change := range changes {
+		want, _ := json.Marshal(change.Old)
+		got, _ := json.Marshal(change.New)
+		fmt.Fprintf(&b, "\t%s: got %s, want %s\n", change.Path, got, want)
+	}
+	return b.String()
+}
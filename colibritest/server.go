@@ -0,0 +1,98 @@
+package colibritest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// Fixture is a response served by a Server.
+type Fixture struct {
+	// Status is the status code, if it is zero 200 is used.
+	Status int
+
+	// ContentType is the Content-Type header, if it is empty it is detected from the body.
+	ContentType string
+
+	// Header contains additional headers.
+	Header http.Header
+
+	// Body is the response body.
+	Body string
+}
+
+// Server is an HTTP test server that serves a Fixture for each path
+// and records the requests received.
+// The paths without a Fixture are answered with 404 Not Found.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	fixtures map[string]*Fixture
+	requests []*http.Request
+}
+
+// NewServer starts and returns a new Server with the fixtures of each path.
+// The caller should call Close when finished, to shut it down.
+func NewServer(fixtures map[string]*Fixture) *Server {
+	s := &Server{fixtures: make(map[string]*Fixture)}
+	for path, fixture := range fixtures {
+		s.fixtures[path] = fixture
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// NewFileServer starts and returns a new HTTP test server that serves the files of the directory.
+// The caller should call Close when finished, to shut it down.
+func NewFileServer(dir string) *httptest.Server {
+	return httptest.NewServer(http.FileServer(http.Dir(dir)))
+}
+
+// Handle adds or replaces the fixture of the path.
+func (s *Server) Handle(path string, fixture *Fixture) {
+	s.mu.Lock()
+	s.fixtures[path] = fixture
+	s.mu.Unlock()
+}
+
+// Requests returns the requests received, in order. Their bodies are not available.
+func (s *Server) Requests() []*http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*http.Request(nil), s.requests...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Clone(r.Context()))
+	fixture, ok := s.fixtures[r.URL.Path]
+	s.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	for key, values := range fixture.Header {
+		w.Header()[key] = values
+	}
+
+	contentType := fixture.ContentType
+	if contentType == "" {
+		contentType = http.DetectContentType([]byte(fixture.Body))
+	}
+	w.Header().Set("Content-Type", contentType)
+
+	status := fixture.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+
+	if r.Method != http.MethodHead {
+		w.Write([]byte(fixture.Body))
+	}
+}
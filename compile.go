@@ -0,0 +1,95 @@
+package colibri
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrNoCompiler is returned when no compiler is registered for the type of the expression, see RegisterCompiler.
+var ErrNoCompiler = errors.New("no compiler for the expression type")
+
+// CompileFunc compiles a selector expression, e.g. an XPath expression or a CSS selector.
+type CompileFunc func(expr string) (any, error)
+
+var (
+	compilersMu sync.RWMutex
+	compilers   = make(map[string]CompileFunc)
+)
+
+// RegisterCompiler registers the function that compiles the expressions of the type, e.g. "xpath".
+// The types are case-insensitive, registering a type again replaces its function.
+//
+// The expressions of the selectors whose Type has a compiler are compiled when the rules are unmarshaled,
+// the invalid expressions return an error. The parsers obtain the compiled expressions with Selector.Compiled,
+// so they are not compiled again on every Find and FindAll.
+func RegisterCompiler(exprType string, compile CompileFunc) {
+	compilersMu.Lock()
+	defer compilersMu.Unlock()
+
+	compilers[strings.ToLower(exprType)] = compile
+}
+
+// compiler returns the compiler of the type, nil if it is not registered.
+func compiler(exprType string) CompileFunc {
+	compilersMu.RLock()
+	defer compilersMu.RUnlock()
+
+	return compilers[strings.ToLower(exprType)]
+}
+
+// compiledExpr is the compiled form of the expression of a selector.
+type compiledExpr struct {
+	exprType string
+	expr     string
+	value    any
+}
+
+// exprCache stores the compiled expression of a selector, it is shared with the clones
+// of the selector and can be read while they are found concurrently.
+type exprCache struct {
+	atomic.Pointer[compiledExpr]
+}
+
+// Compiled returns the expression of the selector compiled as the type.
+// The selectors whose Type has a compiler are compiled when the rules are unmarshaled and the compiled
+// expression is stored in them and in their clones, so it is not compiled again on every Find and FindAll.
+// Returns ErrNoCompiler if no compiler is registered for the type.
+func (sel *Selector) Compiled(exprType string) (any, error) {
+	var c *compiledExpr
+	if sel.compiled != nil {
+		c = sel.compiled.Load()
+	}
+
+	if (c != nil) && (c.expr == sel.Expr) && strings.EqualFold(c.exprType, exprType) {
+		return c.value, nil
+	}
+
+	compile := compiler(exprType)
+	if compile == nil {
+		return nil, ErrNoCompiler
+	}
+
+	value, err := compile(sel.Expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if sel.compiled != nil {
+		sel.compiled.Store(&compiledExpr{exprType: exprType, expr: sel.Expr, value: value})
+	}
+	return value, nil
+}
+
+// compile compiles the expression of the selector if its Type has a compiler,
+// the expressions with placeholders are compiled when they are found, once the placeholders are replaced.
+func (sel *Selector) compile() error {
+	if (sel.Type == "") || strings.Contains(sel.Expr, "${") || (compiler(sel.Type) == nil) {
+		return nil
+	}
+
+	sel.compiled = &exprCache{}
+	_, err := sel.Compiled(sel.Type)
+	return err
+}
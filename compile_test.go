@@ -0,0 +1,61 @@
+package colibri
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestCompiled(t *testing.T) {
+	var calls int
+	RegisterCompiler("compileTest", func(expr string) (any, error) {
+		calls++
+		if expr == "invalid" {
+			return nil, errors.New("invalid expression")
+		}
+		return "compiled:" + expr, nil
+	})
+
+	var rules Rules
+	if err := json.Unmarshal([]byte(`{"selectors": {"a": {"expr": "title", "type": "compileTest"}, "b": {"expr": "${name}", "type": "compileTest"}}}`), &rules); err != nil {
+		t.Fatal(err)
+	}
+	defer ReleaseSelectors(rules.Selectors)
+
+	selectors := selectorsMap(rules.Selectors)
+	if calls != 1 {
+		t.Fatalf("got %v, want %v", calls, 1)
+	}
+
+	clone := selectors["a"].Clone()
+	defer ReleaseSelector(clone)
+
+	for _, sel := range []*Selector{selectors["a"], clone} {
+		value, err := sel.Compiled("COMPILETEST")
+		if err != nil {
+			t.Fatal(err)
+		} else if value != "compiled:title" {
+			t.Fatalf("got %v, want %v", value, "compiled:title")
+		}
+	}
+
+	if calls != 1 {
+		t.Fatalf("got %v, want %v", calls, 1)
+	}
+
+	// The expression is compiled again when it changes.
+	clone.Expr = "h1"
+	if value, _ := clone.Compiled("compileTest"); value != "compiled:h1" {
+		t.Fatalf("got %v, want %v", value, "compiled:h1")
+	}
+
+	if _, err := selectors["a"].Compiled("unknown"); !errors.Is(err, ErrNoCompiler) {
+		t.Fatalf("got %v, want %v", err, ErrNoCompiler)
+	}
+
+	var invalid Rules
+	err := json.Unmarshal([]byte(`{"selectors": {"a": {"expr": "invalid", "type": "compileTest"}}}`), &invalid)
+	if err == nil {
+		t.Fatalf("got %v, want an error", err)
+	}
+}
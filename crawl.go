@@ -0,0 +1,505 @@
+package colibri
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultCheckpointInterval default interval between crawl checkpoints.
+const DefaultCheckpointInterval = time.Minute
+
+// CrawlOptions configures a crawl.
+type CrawlOptions struct {
+	// Context specifies the context of the crawl, when it is canceled the crawl
+	// state is stored and the crawl is stopped. If it is nil, the context of the
+	// rules is used.
+	Context context.Context
+
+	// OnOutput is called with the output of each crawled page.
+	OnOutput func(output *Output, err error)
+
+	// Sink receives the output of each crawled page. If the sink returns an error,
+	// the crawl state is stored and the crawl is stopped.
+	// The sink is not closed when the crawl ends.
+	Sink Sink
+
+	// Score returns the priority of the rules queued in the crawl frontier.
+	// If it is nil, the priority of the rules is used, see the Priority field
+	// of the Rules and Selector structures.
+	Score func(rules *Rules) int
+
+	// Checkpoint specifies the path of the file in which the crawl state is stored.
+	// If it is empty, the crawl state is not stored.
+	Checkpoint string
+
+	// CheckpointInterval specifies the interval between crawl checkpoints.
+	CheckpointInterval time.Duration
+
+	// OnProgress is called with the progress of the crawl after each crawled page,
+	// see Colibri.Progress.
+	OnProgress func(progress Progress)
+
+	// Concurrency specifies the maximum number of pages crawled at the same time.
+	// If it is less than 2, the pages are crawled one by one in order of priority.
+	// OnOutput and Sink are not called concurrently.
+	Concurrency int
+}
+
+// Crawl extracts the data of the rules and of each URL found by the selectors with Follow.
+//
+// Unlike Extract, the followed URLs are not extracted in place: they are added to the
+// crawl frontier and their outputs are sent to OnOutput, while the output of the page
+// that found them stores the URLs. Use Visited to avoid crawling the same URL twice
+// and Duplicates to skip the pages whose content has already been seen.
+// The URLs with the highest priority are crawled first.
+// With Concurrency, several pages are crawled at the same time in a worker pool.
+//
+// If Checkpoint is specified, the pending frontier, the visited URLs, the seen hashes and
+// the Delay timestamps are periodically stored in the file so the crawl can be resumed with
+// ResumeCrawl. Visited, Duplicates and Delay are stored only if they implement json.Marshaler.
+// The checkpoint is removed when the crawl is completed.
+func (c *Colibri) Crawl(rules *Rules, opts *CrawlOptions) error {
+	if rules == nil {
+		return ErrRulesIsNil
+	}
+
+	cr := &crawl{}
+	if opts != nil {
+		cr.score = opts.Score
+	}
+
+	cr.push(rules.Clone())
+	return c.crawl(rules.Context(), cr, opts)
+}
+
+// ResumeCrawl resumes the crawl stored in the checkpoint file at path.
+// If opts does not specify a Checkpoint, path is used.
+func (c *Colibri) ResumeCrawl(path string, opts *CrawlOptions) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var state checkpoint
+	if err := json.Unmarshal(b, &state); err != nil {
+		return err
+	}
+
+	if v, ok := c.Visited.(json.Unmarshaler); ok && (state.Visited != nil) {
+		if err := v.UnmarshalJSON(state.Visited); err != nil {
+			return err
+		}
+	}
+
+	if d, ok := c.Duplicates.(json.Unmarshaler); ok && (state.Duplicates != nil) {
+		if err := d.UnmarshalJSON(state.Duplicates); err != nil {
+			return err
+		}
+	}
+
+	if d, ok := c.Delay.(json.Unmarshaler); ok && (state.Delay != nil) {
+		if err := d.UnmarshalJSON(state.Delay); err != nil {
+			return err
+		}
+	}
+
+	newOpts := CrawlOptions{Checkpoint: path}
+	if opts != nil {
+		newOpts = *opts
+		if newOpts.Checkpoint == "" {
+			newOpts.Checkpoint = path
+		}
+	}
+
+	revisit := make(map[int]bool, len(state.Revisit))
+	for _, i := range state.Revisit {
+		revisit[i] = true
+	}
+
+	cr := &crawl{}
+	for i, rules := range state.Pending {
+		cr.pushItem(rules, revisit[i])
+	}
+
+	cr.score = newOpts.Score
+	return c.crawl(context.Background(), cr, &newOpts)
+}
+
+func (c *Colibri) crawl(ctx context.Context, cr *crawl, opts *CrawlOptions) error {
+	if opts == nil {
+		opts = &CrawlOptions{}
+	}
+
+	if opts.Context != nil {
+		ctx = opts.Context
+	}
+
+	if !c.life.begin() {
+		return ErrShutdown
+	}
+	defer c.life.end()
+
+	interval := opts.CheckpointInterval
+	if interval <= 0 {
+		interval = DefaultCheckpointInterval
+	}
+
+	cr.stats.start = time.Now()
+	c.progress.set(cr)
+
+	var (
+		crawlCtx = context.WithValue(ctx, crawlKey{}, cr)
+		pool     = newWorkerPool(crawlCtx, opts.Concurrency, c.workerMetrics())
+		done     = make(chan struct{}, 1)
+		last     = time.Now()
+
+		// mu protects the calls to OnOutput and Sink, and stopErr.
+		mu      sync.Mutex
+		stopErr error
+	)
+
+	// stop waits for the pages being crawled and stores the crawl state,
+	// on Shutdown the sink is flushed.
+	stop := func(err error) error {
+		pool.Wait()
+		if c.life.closed() {
+			err = errors.Join(err, flushSink(opts.Sink))
+		}
+
+		if opts.Checkpoint != "" {
+			err = errors.Join(err, c.saveCheckpoint(opts.Checkpoint, cr))
+		}
+		return err
+	}
+
+	// signal wakes up the crawl loop when a page ends.
+	signal := func() {
+		select {
+		case done <- struct{}{}:
+		default:
+		}
+	}
+
+	for {
+		// The next page is chosen when a worker is free, so it can be one of the
+		// pages queued by the pages being crawled.
+		if !pool.acquire() {
+			return stop(ctx.Err())
+		}
+
+		mu.Lock()
+		err := stopErr
+		mu.Unlock()
+		if err != nil {
+			pool.release()
+			return stop(err)
+		}
+
+		if c.life.closed() {
+			pool.release()
+			return stop(ErrShutdown)
+		}
+
+		if (opts.Checkpoint != "") && (time.Since(last) >= interval) {
+			if err := c.saveCheckpoint(opts.Checkpoint, cr); err != nil {
+				pool.release()
+				pool.Wait()
+				return err
+			}
+			last = time.Now()
+		}
+
+		item := cr.pop()
+		if item == nil {
+			pool.release()
+			if cr.running() == 0 {
+				break
+			}
+
+			<-done
+			continue
+		}
+
+		pool.run(func(taskCtx context.Context) {
+			defer signal()
+
+			rules := item.rules
+			cr.setContext(item, taskCtx)
+
+			// The URLs of the interrupted pages were already marked as visited.
+			extractor := c
+			if item.revisit {
+				extractor = c.With(WithVisited(nil))
+			}
+
+			output, err := extractor.Extract(rules)
+			if (ctx.Err() != nil) || errors.Is(err, ErrShutdown) {
+				// The extraction was interrupted, the rules remain pending.
+				cr.requeue(item)
+				return
+			}
+
+			skip := errors.Is(err, ErrAlreadyVisited) || errors.Is(err, ErrDuplicateContent) ||
+				((output != nil) && output.Noindex)
+
+			if skip {
+				cr.stats.add(output, nil)
+			} else {
+				cr.stats.add(output, err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if !skip && (opts.OnOutput != nil) {
+				opts.OnOutput(output, err)
+			}
+
+			if !skip && (opts.Sink != nil) {
+				if err := opts.Sink.Write(rules, output, err); (err != nil) && (stopErr == nil) {
+					stopErr = err
+				}
+			}
+
+			cr.finish(item)
+			if opts.OnProgress != nil {
+				opts.OnProgress(cr.progress())
+			}
+			ReleaseRules(rules)
+		}, func(err *PanicError) {
+			mu.Lock()
+			if stopErr == nil {
+				stopErr = err
+			}
+			mu.Unlock()
+
+			cr.requeue(item)
+			signal()
+		})
+	}
+	pool.Wait()
+
+	// The callbacks of the last pages can fail after the frontier is empty.
+	if stopErr != nil {
+		if opts.Checkpoint != "" {
+			return errors.Join(stopErr, c.saveCheckpoint(opts.Checkpoint, cr))
+		}
+		return stopErr
+	}
+
+	if opts.Checkpoint != "" {
+		if err := os.Remove(opts.Checkpoint); (err != nil) && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveCheckpoint stores the crawl state in the file,
+// the file is replaced only when the state has been completely written.
+func (c *Colibri) saveCheckpoint(path string, cr *crawl) error {
+	var (
+		state checkpoint
+		err   error
+	)
+
+	for i, item := range cr.snapshot() {
+		state.Pending = append(state.Pending, item.rules)
+		if item.revisit {
+			state.Revisit = append(state.Revisit, i)
+		}
+	}
+
+	defer func() {
+		for _, rules := range state.Pending {
+			ReleaseRules(rules)
+		}
+	}()
+
+	if v, ok := c.Visited.(json.Marshaler); ok {
+		if state.Visited, err = v.MarshalJSON(); err != nil {
+			return err
+		}
+	}
+
+	if d, ok := c.Duplicates.(json.Marshaler); ok {
+		if state.Duplicates, err = d.MarshalJSON(); err != nil {
+			return err
+		}
+	}
+
+	if d, ok := c.Delay.(json.Marshaler); ok {
+		if state.Delay, err = d.MarshalJSON(); err != nil {
+			return err
+		}
+	}
+
+	b, err := json.Marshal(&state)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+type checkpoint struct {
+	Pending []*Rules `json:"pending"`
+
+	// Revisit contains the indexes of the pending rules whose extraction was interrupted,
+	// their URLs are not checked against Visited, since they were already marked as visited.
+	Revisit []int `json:"revisit,omitempty"`
+
+	Visited    json.RawMessage `json:"visited,omitempty"`
+	Duplicates json.RawMessage `json:"duplicates,omitempty"`
+	Delay      json.RawMessage `json:"delay,omitempty"`
+}
+
+type crawlKey struct{}
+
+// crawl stores the frontier of a crawl.
+// The rules with the highest priority are crawled first,
+// rules with the same priority are crawled in the order they were queued.
+type crawl struct {
+	mu      sync.Mutex
+	pending crawlQueue
+	seq     uint64
+	score   func(rules *Rules) int
+
+	// active contains the items being crawled.
+	active map[uint64]*crawlItem
+
+	stats crawlProgress
+}
+
+type crawlItem struct {
+	rules *Rules
+	seq   uint64
+
+	// revisit specifies whether the extraction of the item was interrupted,
+	// so its URL is not checked against Visited.
+	revisit bool
+}
+
+func crawlFrom(ctx context.Context) *crawl {
+	cr, _ := ctx.Value(crawlKey{}).(*crawl)
+	return cr
+}
+
+func (cr *crawl) push(rules *Rules) {
+	cr.pushItem(rules, false)
+}
+
+func (cr *crawl) pushItem(rules *Rules, revisit bool) {
+	if cr.score != nil {
+		rules.Priority = cr.score(rules)
+	}
+
+	cr.mu.Lock()
+	cr.seq++
+	heap.Push(&cr.pending, &crawlItem{rules: rules, seq: cr.seq, revisit: revisit})
+	cr.mu.Unlock()
+}
+
+// requeue queues an item being crawled again keeping its position in the queue,
+// the item is revisited since its URL was already marked as visited.
+func (cr *crawl) requeue(item *crawlItem) {
+	cr.mu.Lock()
+	delete(cr.active, item.seq)
+	item.revisit = true
+	heap.Push(&cr.pending, item)
+	cr.mu.Unlock()
+}
+
+// pop returns the next item to crawl, the item is active until finish or requeue are called.
+func (cr *crawl) pop() *crawlItem {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	if len(cr.pending) == 0 {
+		return nil
+	}
+
+	item := heap.Pop(&cr.pending).(*crawlItem)
+	if cr.active == nil {
+		cr.active = make(map[uint64]*crawlItem)
+	}
+	cr.active[item.seq] = item
+	return item
+}
+
+// finish removes the item from the items being crawled.
+func (cr *crawl) finish(item *crawlItem) {
+	cr.mu.Lock()
+	delete(cr.active, item.seq)
+	cr.mu.Unlock()
+}
+
+// setContext sets the context of the rules of the item being crawled,
+// the rules can be copied at the same time by snapshot.
+func (cr *crawl) setContext(item *crawlItem, ctx context.Context) {
+	cr.mu.Lock()
+	item.rules.SetContext(ctx)
+	cr.mu.Unlock()
+}
+
+// running returns the number of items being crawled.
+func (cr *crawl) running() int {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	return len(cr.active)
+}
+
+// snapshot returns the pending items and the items being crawled in the order they will be crawled.
+// The items being crawled are returned as revisited, since their URLs were already marked as visited.
+// The rules are copies, the workers can modify or release the originals.
+func (cr *crawl) snapshot() []crawlItem {
+	cr.mu.Lock()
+	items := append(crawlQueue(nil), cr.pending...)
+	for _, item := range cr.active {
+		items = append(items, &crawlItem{rules: item.rules, seq: item.seq, revisit: true})
+	}
+
+	sort.Sort(items)
+
+	result := make([]crawlItem, 0, len(items))
+	for _, item := range items {
+		result = append(result, crawlItem{rules: item.rules.Clone(), seq: item.seq, revisit: item.revisit})
+	}
+	cr.mu.Unlock()
+	return result
+}
+
+// crawlQueue implements heap.Interface.
+type crawlQueue []*crawlItem
+
+func (q crawlQueue) Len() int { return len(q) }
+
+func (q crawlQueue) Less(i, j int) bool {
+	if q[i].rules.Priority != q[j].rules.Priority {
+		return q[i].rules.Priority > q[j].rules.Priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q crawlQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *crawlQueue) Push(x any) { *q = append(*q, x.(*crawlItem)) }
+
+func (q *crawlQueue) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return item
+}
@@ -0,0 +1,376 @@
+package colibri
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testCrawlRules = &Rules{
+	Selectors: []*Selector{
+		{Name: "title", Expr: "//title"},
+		{
+			Name:   "links",
+			Expr:   "//a/@href",
+			All:    true,
+			Follow: true,
+			Selectors: []*Selector{
+				{Name: "title", Expr: "//title"},
+			},
+		},
+	},
+}
+
+func TestCrawl(t *testing.T) {
+	c := New()
+	c.Client = &testClient{}
+	c.Parser = &testParser{}
+
+	var outputs []map[string]any
+	err := c.Crawl(testCrawlRules, &CrawlOptions{
+		OnOutput: func(output *Output, err error) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			outputs = append(outputs, output.Data)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []map[string]any{
+		{"title": "test", "links": []any{"http://example.com/test"}},
+		{"title": "test"},
+	}
+
+	if !reflect.DeepEqual(outputs, want) {
+		t.Fatal("not equal")
+	}
+
+	if err := c.Crawl(nil, nil); !errors.Is(err, ErrRulesIsNil) {
+		t.Fatal(err)
+	}
+}
+
+func TestResumeCrawl(t *testing.T) {
+	var (
+		path        = filepath.Join(t.TempDir(), "checkpoint.json")
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	defer cancel()
+
+	c := New()
+	c.Client = &testClient{}
+	c.Parser = &testParser{}
+
+	var n int
+	err := c.Crawl(testCrawlRules, &CrawlOptions{
+		Context:    ctx,
+		Checkpoint: path,
+		OnOutput: func(_ *Output, _ error) {
+			n++
+			cancel()
+		},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+
+	if n != 1 {
+		t.Fatalf("got %v outputs, want %v", n, 1)
+	}
+
+	var data []map[string]any
+	err = c.ResumeCrawl(path, &CrawlOptions{
+		OnOutput: func(output *Output, err error) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			data = append(data, output.Data)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(data, []map[string]any{{"title": "test"}}) {
+		t.Fatal("not equal")
+	}
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("checkpoint not removed")
+	}
+}
+
+// testVisited is a Visited that can be stored in a checkpoint.
+type testVisited struct {
+	mu   sync.Mutex
+	urls map[string]bool
+}
+
+func (v *testVisited) Visit(u *url.URL) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.urls == nil {
+		v.urls = make(map[string]bool)
+	}
+
+	visited := v.urls[u.String()]
+	v.urls[u.String()] = true
+	return visited
+}
+
+func (v *testVisited) Clear() {
+	v.mu.Lock()
+	v.urls = nil
+	v.mu.Unlock()
+}
+
+func (v *testVisited) MarshalJSON() ([]byte, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return json.Marshal(v.urls)
+}
+
+func (v *testVisited) UnmarshalJSON(b []byte) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return json.Unmarshal(b, &v.urls)
+}
+
+// cancelClient cancels the crawl while the followed page is being requested.
+type cancelClient struct {
+	cancel context.CancelFunc
+}
+
+func (client *cancelClient) Do(c *Colibri, rules *Rules) (Response, error) {
+	if (client.cancel != nil) && (rules.URL != nil) && (rules.URL.Path == "/test") {
+		client.cancel()
+		client.cancel = nil
+		return nil, context.Canceled
+	}
+	return &testResponse{c: c}, nil
+}
+
+func (client *cancelClient) Clear() {}
+
+func TestResumeCrawlInterrupted(t *testing.T) {
+	var (
+		path        = filepath.Join(t.TempDir(), "checkpoint.json")
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	defer cancel()
+
+	c := New()
+	c.Client = &cancelClient{cancel: cancel}
+	c.Parser = &testParser{}
+	c.Visited = &testVisited{}
+
+	var n int
+	err := c.Crawl(testCrawlRules, &CrawlOptions{
+		Context:    ctx,
+		Checkpoint: path,
+		OnOutput:   func(_ *Output, _ error) { n++ },
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+
+	if n != 1 {
+		t.Fatalf("got %v outputs, want %v", n, 1)
+	}
+
+	// The followed page was marked as visited before it was interrupted,
+	// the checkpoint restores it and the page is not skipped.
+	var data []map[string]any
+	err = c.ResumeCrawl(path, &CrawlOptions{
+		OnOutput: func(output *Output, err error) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			data = append(data, output.Data)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(data, []map[string]any{{"title": "test"}}) {
+		t.Fatalf("got %v, want %v", data, []map[string]any{{"title": "test"}})
+	}
+
+	// The page is marked as visited again.
+	if !c.Visited.Visit(mustNewURL("http://example.com/test")) {
+		t.Fatal("URL not visited")
+	}
+}
+
+// pagesNode is a testNode whose FindAll finds the paths of n pages.
+type pagesNode struct {
+	testNode
+	n int
+}
+
+func (node *pagesNode) FindAll(_ *Selector) ([]Node, error) {
+	nodes := make([]Node, 0, node.n)
+	for i := 0; i < node.n; i++ {
+		nodes = append(nodes, &testNode{value: "/page/" + strconv.Itoa(i)})
+	}
+	return nodes, nil
+}
+
+type pagesParser struct{ testParser }
+
+func (p *pagesParser) Parse(_ *Rules, _ Response) (Node, error) { return &pagesNode{n: 20}, nil }
+
+func TestCrawlCheckpointConcurrency(t *testing.T) {
+	c := New()
+	c.Client = &testClient{}
+	c.Parser = &pagesParser{}
+
+	// The checkpoint is stored while the pages are crawled and released by the workers.
+	var n int
+	err := c.Crawl(testCrawlRules, &CrawlOptions{
+		Concurrency:        4,
+		Checkpoint:         filepath.Join(t.TempDir(), "checkpoint.json"),
+		CheckpointInterval: time.Nanosecond,
+		OnOutput:           func(_ *Output, _ error) { n++ },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 21 {
+		t.Fatalf("got %v outputs, want %v", n, 21)
+	}
+}
+
+func TestCrawlPriority(t *testing.T) {
+	cr := &crawl{}
+	cr.push(&Rules{URL: mustNewURL("http://example.com/page/2")})
+	cr.push(&Rules{URL: mustNewURL("http://example.com/product/1"), Priority: 10})
+	cr.push(&Rules{URL: mustNewURL("http://example.com/page/3")})
+	cr.push(&Rules{URL: mustNewURL("http://example.com/product/2"), Priority: 10})
+
+	want := []string{
+		"http://example.com/product/1",
+		"http://example.com/product/2",
+		"http://example.com/page/2",
+		"http://example.com/page/3",
+	}
+
+	var snapshot []string
+	for _, item := range cr.snapshot() {
+		snapshot = append(snapshot, item.rules.URL.String())
+	}
+
+	if !reflect.DeepEqual(snapshot, want) {
+		t.Fatal("snapshot not equal")
+	}
+
+	var result []string
+	for item := cr.pop(); item != nil; item = cr.pop() {
+		result = append(result, item.rules.URL.String())
+	}
+
+	if !reflect.DeepEqual(result, want) {
+		t.Fatal("not equal")
+	}
+
+	t.Run("Score", func(t *testing.T) {
+		cr := &crawl{score: func(rules *Rules) int { return len(rules.URL.Path) }}
+		cr.push(&Rules{URL: mustNewURL("http://example.com/a")})
+		cr.push(&Rules{URL: mustNewURL("http://example.com/abc")})
+
+		if item := cr.pop(); item.rules.URL.Path != "/abc" {
+			t.Fatal(item.rules.URL)
+		}
+	})
+}
+
+type testSink struct {
+	outputs []*Output
+	err     error
+}
+
+func (sink *testSink) Write(_ *Rules, output *Output, _ error) error {
+	sink.outputs = append(sink.outputs, output)
+	return sink.err
+}
+
+func (sink *testSink) Close() error { return nil }
+
+func TestCrawlSink(t *testing.T) {
+	c := New()
+	c.Client = &testClient{}
+	c.Parser = &testParser{}
+
+	sink := &testSink{}
+	if err := c.Crawl(testCrawlRules, &CrawlOptions{Sink: sink}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sink.outputs) != 2 {
+		t.Fatal("not equal")
+	}
+
+	testErr := errors.New("test err")
+
+	sink = &testSink{err: testErr}
+	if err := c.Crawl(testCrawlRules, &CrawlOptions{Sink: sink}); !errors.Is(err, testErr) {
+		t.Fatal(err)
+	}
+
+	if len(sink.outputs) != 1 {
+		t.Fatal("not equal")
+	}
+}
+
+func TestCrawlProgress(t *testing.T) {
+	c := New()
+	c.Client = &testClient{}
+	c.Parser = &testParser{}
+
+	var progress []Progress
+	err := c.Crawl(testCrawlRules, &CrawlOptions{
+		OnProgress: func(p Progress) {
+			progress = append(progress, p)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(progress) != 2 {
+		t.Fatalf("got %v, want %v", len(progress), 2)
+	}
+
+	// The first page queues the followed URL.
+	if (progress[0].Completed != 1) || (progress[0].Pending != 1) || (progress[0].InFlight != 0) {
+		t.Fatalf("got %+v", progress[0])
+	}
+
+	if got := c.Progress(); (got.Completed != 2) || (got.Pending != 0) || (got.Failed != 0) || (got.Remaining != 0) {
+		t.Fatalf("got %+v", got)
+	}
+
+	rules := testCrawlRules.Clone()
+	rules.Extra["doErr"] = errors.New("do error")
+	if err := c.Crawl(rules, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := c.Progress(); (got.Completed != 0) || (got.Failed != 1) {
+		t.Fatalf("got %+v", got)
+	}
+}
@@ -0,0 +1,461 @@
+package colibri
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidCurl is returned when the curl command line cannot be parsed.
+	ErrInvalidCurl = errors.New("invalid curl command")
+
+	// ErrCurlOption is returned when the curl command line has an option that is not supported.
+	ErrCurlOption = errors.New("unsupported curl option")
+)
+
+// curlIgnored contains the curl options without effect on the request or that colibri applies by default,
+// e.g. --compressed, since the bodies are decompressed by the client.
+var curlIgnored = map[string]bool{
+	"--compressed": true,
+	"-s":           true,
+	"--silent":     true,
+	"-S":           true,
+	"--show-error": true,
+	"-v":           true,
+	"--verbose":    true,
+	"-i":           true,
+	"--include":    true,
+	"-L":           true,
+	"--location":   true,
+	"-g":           true,
+	"--globoff":    true,
+	"--http1.1":    true,
+	"--http2":      true,
+}
+
+// ParseCurl returns the rules of the request of a curl command line, e.g. copied from the browser devtools.
+// The method, URL, headers, cookies, basic authentication, proxy, timeout and insecure options are supported.
+// The data sent with -d, --data, --data-raw, --data-binary or --data-urlencode is stored in the JSON field
+// if it is a JSON value, in the Form field if it is URL-encoded and the Content-Type is not another one,
+// otherwise in the Body field, e.g. an XML document. With -G the data is sent as the query of the URL.
+//
+// Returns ErrCurlOption if the command has an option that is not supported.
+func ParseCurl(command string) (*Rules, error) {
+	args, err := splitCurl(command)
+	if err != nil {
+		return nil, err
+	}
+
+	if (len(args) == 0) || (args[0] != "curl") {
+		return nil, fmt.Errorf("%w: the command is not curl", ErrInvalidCurl)
+	}
+
+	var (
+		rules  = &Rules{Header: make(http.Header), Extra: make(map[string]any)}
+		data   []string
+		get    bool
+		rawURL string
+	)
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+
+		name, value, inline := strings.Cut(arg, "=")
+		if !strings.HasPrefix(arg, "--") || !inline {
+			name = arg
+		}
+
+		if curlIgnored[name] {
+			continue
+		}
+
+		switch name {
+		case "-G", "--get":
+			get = true
+			continue
+		case "-I", "--head":
+			rules.Method = http.MethodHead
+			continue
+		case "-k", "--insecure":
+			if rules.TLS == nil {
+				rules.TLS = &TLS{}
+			}
+			rules.TLS.InsecureSkipVerify = true
+			continue
+		}
+
+		if !strings.HasPrefix(arg, "-") {
+			rawURL = arg
+			continue
+		}
+
+		if !inline {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%w: %s requires a value", ErrInvalidCurl, name)
+			}
+			i++
+			value = args[i]
+		}
+
+		switch name {
+		case "--url":
+			rawURL = value
+		case "-X", "--request":
+			rules.Method = strings.ToUpper(value)
+		case "-H", "--header":
+			key, v, _ := strings.Cut(value, ":")
+			rules.Header.Add(strings.TrimSpace(key), strings.TrimSpace(v))
+		case "-A", "--user-agent":
+			rules.Header.Set("User-Agent", value)
+		case "-e", "--referer":
+			rules.Header.Set("Referer", value)
+		case "-b", "--cookie":
+			rules.Header.Add("Cookie", value)
+		case "-u", "--user":
+			username, password, _ := strings.Cut(value, ":")
+			rules.BasicAuth = &BasicAuth{Username: username, Password: password}
+		case "-x", "--proxy":
+			if rules.Proxy, err = url.Parse(value); err != nil {
+				return nil, err
+			}
+		case "-m", "--max-time":
+			seconds, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s %s", ErrInvalidCurl, name, value)
+			}
+			rules.Timeout = time.Duration(seconds * float64(time.Second))
+		case "-d", "--data", "--data-binary", "--data-ascii":
+			if strings.HasPrefix(value, "@") {
+				return nil, fmt.Errorf("%w: %s with a file", ErrCurlOption, name)
+			}
+			data = append(data, value)
+		case "--data-raw":
+			data = append(data, value)
+		case "--data-urlencode":
+			data = append(data, curlURLEncode(value))
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrCurlOption, name)
+		}
+	}
+
+	if rawURL == "" {
+		return nil, fmt.Errorf("%w: the URL is missing", ErrInvalidCurl)
+	}
+
+	if rules.URL, err = url.Parse(rawURL); err != nil {
+		return nil, err
+	}
+
+	if len(rules.Header) == 0 {
+		rules.Header = nil
+	}
+
+	if err := curlData(rules, data, get); err != nil {
+		return nil, err
+	}
+
+	if rules.Method == "" {
+		rules.Method = http.MethodGet
+		if (len(data) > 0) && !get {
+			rules.Method = http.MethodPost
+		}
+	}
+	return rules, nil
+}
+
+// curlData sets the data of the command in the JSON, the Form or the Body of the rules.
+// The data is only stored in the Form if its fields are encoded again as they are, so the body is sent unchanged.
+func curlData(rules *Rules, data []string, get bool) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	body := strings.Join(data, "&")
+	if trimmed := strings.TrimSpace(body); !get && json.Valid([]byte(trimmed)) &&
+		(strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) {
+		return json.Unmarshal([]byte(trimmed), &rules.JSON)
+	}
+
+	form, err := url.ParseQuery(body)
+	if !get && ((err != nil) || !encodedForm(body) || !formContentType(rules.Header)) {
+		rules.Body = body
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCurl, err)
+	}
+
+	// The query of the URL is sent with the data, since the Form replaces it in the GET requests.
+	if get {
+		query := rules.URL.Query()
+		for key, values := range form {
+			query[key] = append(query[key], values...)
+		}
+		form = query
+		rules.URL.RawQuery = ""
+	}
+	rules.Form = form
+	return nil
+}
+
+// encodedForm returns true if each field of the body is a name=value pair encoded as url.Values does.
+func encodedForm(body string) bool {
+	for _, field := range strings.Split(body, "&") {
+		rawName, rawValue, ok := strings.Cut(field, "=")
+		if !ok {
+			return false
+		}
+
+		name, nameErr := url.QueryUnescape(rawName)
+		value, valueErr := url.QueryUnescape(rawValue)
+		if (nameErr != nil) || (valueErr != nil) ||
+			(url.QueryEscape(name) != rawName) || (url.QueryEscape(value) != rawValue) {
+			return false
+		}
+	}
+	return true
+}
+
+// formContentType returns true if the Content-Type of the header is empty or application/x-www-form-urlencoded.
+func formContentType(header http.Header) bool {
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return (err == nil) && (mediaType == "application/x-www-form-urlencoded")
+}
+
+// curlURLEncode returns the value of --data-urlencode encoded, "name=content" encodes the content.
+func curlURLEncode(value string) string {
+	if name, content, ok := strings.Cut(value, "="); ok {
+		return name + "=" + url.QueryEscape(content)
+	}
+	return url.QueryEscape(value)
+}
+
+// Curl returns the curl command line of the request of the rules, e.g. to reproduce it for debugging.
+// The placeholders of the variables are not replaced.
+func (rules *Rules) Curl() (string, error) {
+	if rules.URL == nil {
+		return "", ErrURLIsNil
+	}
+
+	var (
+		args = []string{"curl"}
+		u    = rules.URL
+		data string
+	)
+
+	method := rules.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	if rules.Form != nil {
+		if (rules.JSON != nil) || (rules.Body != "") || (method == http.MethodGet) || (method == http.MethodHead) {
+			u = rules.URL.ResolveReference(&url.URL{})
+			u.RawQuery = rules.Form.Encode()
+		} else {
+			data = rules.Form.Encode()
+		}
+	}
+
+	header := rules.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	if rules.JSON != nil {
+		b, err := json.Marshal(rules.JSON)
+		if err != nil {
+			return "", err
+		}
+		data = string(b)
+
+		if header.Get("Content-Type") == "" {
+			header.Set("Content-Type", "application/json")
+		}
+	} else if rules.Body != "" {
+		data = rules.Body
+	}
+
+	// Without -X, curl sends a GET request or a POST request if there is data.
+	switch {
+	case method == http.MethodHead:
+		args = append(args, "-I")
+	case (method == http.MethodGet) && (data == ""):
+	case (method == http.MethodPost) && (data != ""):
+	default:
+		args = append(args, "-X", method)
+	}
+	args = append(args, curlQuote(u.String()))
+
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, value := range header[key] {
+			args = append(args, "-H", curlQuote(key+": "+value))
+		}
+	}
+
+	if rules.BearerToken != "" {
+		args = append(args, "-H", curlQuote("Authorization: Bearer "+rules.BearerToken))
+	} else if rules.BasicAuth != nil {
+		args = append(args, "-u", curlQuote(rules.BasicAuth.Username+":"+rules.BasicAuth.Password))
+	}
+
+	if data != "" {
+		args = append(args, "--data-raw", curlQuote(data))
+	}
+
+	if rules.Proxy != nil {
+		args = append(args, "-x", curlQuote(rules.Proxy.String()))
+	}
+
+	if rules.Timeout > 0 {
+		args = append(args, "-m", strconv.FormatFloat(rules.Timeout.Seconds(), 'f', -1, 64))
+	}
+
+	if (rules.TLS != nil) && rules.TLS.InsecureSkipVerify {
+		args = append(args, "-k")
+	}
+	return strings.Join(args, " "), nil
+}
+
+// curlQuote returns the value quoted for a POSIX shell.
+func curlQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// splitCurl splits the command line into its arguments as a POSIX shell does, with single quotes,
+// double quotes, $'...' strings of the browser devtools, backslash escapes and line continuations.
+func splitCurl(command string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inArg   bool
+	)
+
+	for i := 0; i < len(command); i++ {
+		ch := command[i]
+		switch {
+		case (ch == ' ') || (ch == '\t') || (ch == '\n') || (ch == '\r'):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+
+		case ch == '\\':
+			inArg = true
+			if i+1 < len(command) {
+				i++
+				if (command[i] == '\n') || (command[i] == '\r') {
+					// Line continuation.
+					inArg = current.Len() > 0
+					continue
+				}
+				current.WriteByte(command[i])
+			}
+
+		case ch == '\'':
+			inArg = true
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated quote", ErrInvalidCurl)
+			}
+			current.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+
+		case (ch == '$') && (i+1 < len(command)) && (command[i+1] == '\''):
+			inArg = true
+			n, err := ansiCString(command[i+2:], &current)
+			if err != nil {
+				return nil, err
+			}
+			i += n + 1
+
+		case ch == '"':
+			inArg = true
+			closed := false
+			for i++; i < len(command); i++ {
+				if command[i] == '"' {
+					closed = true
+					break
+				}
+
+				if (command[i] == '\\') && (i+1 < len(command)) && strings.IndexByte("\"\\$`\n", command[i+1]) >= 0 {
+					i++
+				}
+				current.WriteByte(command[i])
+			}
+
+			if !closed {
+				return nil, fmt.Errorf("%w: unterminated quote", ErrInvalidCurl)
+			}
+
+		default:
+			inArg = true
+			current.WriteByte(ch)
+		}
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// ansiCString writes the content of a $'...' string until its closing quote,
+// returns the number of bytes consumed including the closing quote.
+func ansiCString(s string, b *strings.Builder) (int, error) {
+	escapes := map[byte]byte{'n': '\n', 't': '\t', 'r': '\r', '\\': '\\', '\'': '\'', '"': '"'}
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\'':
+			return i + 1, nil
+		case '\\':
+			if i+1 >= len(s) {
+				break
+			}
+			i++
+
+			if esc, ok := escapes[s[i]]; ok {
+				b.WriteByte(esc)
+			} else if (s[i] == 'x') && (i+2 < len(s)) {
+				if v, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+					b.WriteByte(byte(v))
+					i += 2
+					continue
+				}
+				b.WriteString(`\x`)
+			} else if (s[i] == 'u') && (i+4 < len(s)) {
+				if v, err := strconv.ParseUint(s[i+1:i+5], 16, 16); err == nil {
+					b.WriteRune(rune(v))
+					i += 4
+					continue
+				}
+				b.WriteString(`\u`)
+			} else {
+				b.WriteByte('\\')
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return 0, fmt.Errorf("%w: unterminated quote", ErrInvalidCurl)
+}
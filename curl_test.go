@@ -0,0 +1,173 @@
+package colibri
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseCurl(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Command string
+		Want    *Rules
+		WantErr error
+	}{
+		{
+			"get",
+			`curl 'https://example.com/search?q=colibri' -H 'Accept: text/html' -H 'Accept-Language: es' --compressed`,
+			&Rules{
+				Method: http.MethodGet,
+				URL:    mustNewURL("https://example.com/search?q=colibri"),
+				Header: http.Header{"Accept": {"text/html"}, "Accept-Language": {"es"}},
+			},
+			nil,
+		},
+		{
+			"devtools",
+			"curl 'https://example.com/api' \\\n  -H 'content-type: application/json' \\\n  --data-raw $'{\"name\":\"it\\'s\"}'",
+			&Rules{
+				Method: http.MethodPost,
+				URL:    mustNewURL("https://example.com/api"),
+				Header: http.Header{"Content-Type": {"application/json"}},
+				JSON:   map[string]any{"name": "it's"},
+			},
+			nil,
+		},
+		{
+			"form",
+			`curl -X PUT "https://example.com/login" -d user=gonzxlez --data-urlencode "pass=a b" -u admin:secret -m 2.5 -k`,
+			&Rules{
+				Method:    http.MethodPut,
+				URL:       mustNewURL("https://example.com/login"),
+				Form:      url.Values{"user": {"gonzxlez"}, "pass": {"a b"}},
+				BasicAuth: &BasicAuth{Username: "admin", Password: "secret"},
+				Timeout:   2500 * time.Millisecond,
+				TLS:       &TLS{InsecureSkipVerify: true},
+			},
+			nil,
+		},
+		{
+			"get data",
+			`curl -G https://example.com/search?lang=es -d q=colibri -A colibri -x http://proxy:8080`,
+			&Rules{
+				Method: http.MethodGet,
+				URL:    mustNewURL("https://example.com/search"),
+				Header: http.Header{"User-Agent": {"colibri"}},
+				Form:   url.Values{"lang": {"es"}, "q": {"colibri"}},
+				Proxy:  mustNewURL("http://proxy:8080"),
+			},
+			nil,
+		},
+		{
+			"xml",
+			`curl https://example.com/soap -H 'Content-Type: text/xml' -d '<q a="1">x&y</q>'`,
+			&Rules{
+				Method: http.MethodPost,
+				URL:    mustNewURL("https://example.com/soap"),
+				Header: http.Header{"Content-Type": {"text/xml"}},
+				Body:   `<q a="1">x&y</q>`,
+			},
+			nil,
+		},
+		{
+			"raw data",
+			`curl https://example.com/items --data-raw 'tags=a,b&q=x y'`,
+			&Rules{
+				Method: http.MethodPost,
+				URL:    mustNewURL("https://example.com/items"),
+				Body:   "tags=a,b&q=x y",
+			},
+			nil,
+		},
+		{"not curl", `wget https://example.com`, nil, ErrInvalidCurl},
+		{"no URL", `curl -H 'Accept: */*'`, nil, ErrInvalidCurl},
+		{"unterminated", `curl 'https://example.com`, nil, ErrInvalidCurl},
+		{"unsupported", `curl -o page.html https://example.com`, nil, ErrCurlOption},
+		{"file", `curl -d @body.json https://example.com`, nil, ErrCurlOption},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			rules, err := ParseCurl(tt.Command)
+			if !errors.Is(err, tt.WantErr) {
+				t.Fatalf("got %v, want %v", err, tt.WantErr)
+			} else if err != nil {
+				return
+			}
+
+			tt.Want.Extra = map[string]any{}
+			if !reflect.DeepEqual(rules, tt.Want) {
+				t.Fatalf("got %+v, want %+v", rules, tt.Want)
+			}
+		})
+	}
+}
+
+func TestRulesCurl(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Rules *Rules
+		Want  string
+	}{
+		{
+			"get",
+			&Rules{Method: http.MethodGet, URL: mustNewURL("https://example.com"), Header: http.Header{"Accept": {"text/html"}}},
+			`curl 'https://example.com' -H 'Accept: text/html'`,
+		},
+		{
+			"json",
+			&Rules{Method: http.MethodPost, URL: mustNewURL("https://example.com/api"), JSON: map[string]any{"name": "it's"}},
+			`curl 'https://example.com/api' -H 'Content-Type: application/json' --data-raw '{"name":"it'\''s"}'`,
+		},
+		{
+			"form",
+			&Rules{Method: http.MethodGet, URL: mustNewURL("https://example.com/search"), Form: url.Values{"q": {"colibri"}}, BearerToken: "token"},
+			`curl 'https://example.com/search?q=colibri' -H 'Authorization: Bearer token'`,
+		},
+		{
+			"xml",
+			&Rules{Method: http.MethodPost, URL: mustNewURL("https://example.com/soap"), Header: http.Header{"Content-Type": {"text/xml"}}, Body: `<q a="1">it's</q>`},
+			`curl 'https://example.com/soap' -H 'Content-Type: text/xml' --data-raw '<q a="1">it'\''s</q>'`,
+		},
+		{
+			"delete",
+			&Rules{Method: http.MethodDelete, URL: mustNewURL("https://example.com/item/1"), Timeout: 1500 * time.Millisecond},
+			`curl -X DELETE 'https://example.com/item/1' -m 1.5`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			got, err := tt.Rules.Curl()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.Want {
+				t.Fatalf("got %v, want %v", got, tt.Want)
+			}
+
+			// The command is parsed to the same request.
+			rules, err := ParseCurl(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if again, _ := rules.Curl(); again != got {
+				t.Fatalf("got %v, want %v", again, got)
+			}
+
+			if rules.Body != tt.Rules.Body {
+				t.Fatalf("got %v, want %v", rules.Body, tt.Rules.Body)
+			}
+		})
+	}
+
+	if _, err := (&Rules{}).Curl(); !errors.Is(err, ErrURLIsNil) {
+		t.Fatalf("got %v, want %v", err, ErrURLIsNil)
+	}
+}
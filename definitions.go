@@ -0,0 +1,169 @@
+package colibri
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	KeyDefinitions = "definitions"
+
+	// KeyRef is the key of the selectors that reference a definition.
+	KeyRef = "$ref"
+)
+
+var (
+	// ErrInvalidDefinitions is returned when the definitions are not a valid selector value.
+	ErrInvalidDefinitions = errors.New("invalid definitions")
+
+	// ErrUnknownDefinition is returned when a selector references a definition that does not exist.
+	ErrUnknownDefinition = errors.New("unknown definition")
+
+	// ErrCircularRef is returned when a definition references itself, directly or through other definitions.
+	ErrCircularRef = errors.New("circular definition reference")
+)
+
+// resolveDefinitions replaces the selectors of the raw rules that reference a definition,
+// e.g. {"$ref": "productCard"}, with a copy of the definition.
+// The other keys of the selector override the ones of the definition.
+// The definitions are removed from the raw rules.
+func resolveDefinitions(raw map[string]any) error {
+	key, rawDefinitions := lookupKey(raw, KeyDefinitions)
+	if key == "" {
+		return nil
+	}
+	delete(raw, key)
+
+	definitions, ok := rawDefinitions.(map[string]any)
+	if !ok {
+		return AddError(nil, key, ErrInvalidDefinitions)
+	}
+
+	r := &refResolver{definitions: definitions}
+	return r.selectors(raw)
+}
+
+type refResolver struct {
+	definitions map[string]any
+	stack       []string
+}
+
+// selectors resolves the references of the nested selectors of the raw rules or selector.
+func (r *refResolver) selectors(raw map[string]any) error {
+	_, rawSelectors := lookupKey(raw, KeySelectors)
+
+	selectors, ok := rawSelectors.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	var errs error
+	for name, value := range selectors {
+		selector, err := r.selector(value)
+		if err != nil {
+			errs = AddError(errs, name, err)
+			continue
+		}
+		selectors[name] = selector
+	}
+	return errs
+}
+
+// selector returns the raw selector with its reference and the references of its nested selectors resolved.
+func (r *refResolver) selector(value any) (any, error) {
+	selector, ok := value.(map[string]any)
+	if !ok {
+		return value, nil
+	}
+
+	if key, rawRef := lookupKey(selector, KeyRef); key != "" {
+		ref, ok := rawRef.(string)
+		if !ok {
+			return nil, AddError(nil, key, ErrMustBeString)
+		}
+
+		definition, err := r.definition(ref)
+		if err != nil {
+			return nil, err
+		}
+
+		// The keys of the selector override the ones of the definition regardless of their case.
+		delete(selector, key)
+		for k, v := range selector {
+			if defKey, _ := lookupKey(definition, k); defKey != "" {
+				delete(definition, defKey)
+			}
+			definition[k] = v
+		}
+		selector = definition
+	}
+
+	return selector, r.selectors(selector)
+}
+
+// definition returns a copy of the definition with its references resolved.
+func (r *refResolver) definition(ref string) (map[string]any, error) {
+	for _, name := range r.stack {
+		if name == ref {
+			return nil, ErrCircularRef
+		}
+	}
+
+	value, ok := r.definitions[ref]
+	if !ok {
+		return nil, ErrUnknownDefinition
+	}
+
+	var definition map[string]any
+	switch v := value.(type) {
+	case string:
+		definition = map[string]any{KeyExpr: v}
+	case map[string]any:
+		definition = copyRaw(v).(map[string]any)
+	default:
+		return nil, ErrInvalidSelector
+	}
+
+	r.stack = append(r.stack, ref)
+	defer func() { r.stack = r.stack[:len(r.stack)-1] }()
+
+	resolved, err := r.selector(definition)
+	if err != nil {
+		return nil, err
+	}
+	return resolved.(map[string]any), nil
+}
+
+// lookupKey returns the key that matches the name regardless of case and its value,
+// returns an empty key if it does not exist.
+func lookupKey(raw map[string]any, name string) (string, any) {
+	if value, ok := raw[name]; ok {
+		return name, value
+	}
+
+	for key, value := range raw {
+		if strings.EqualFold(key, name) {
+			return key, value
+		}
+	}
+	return "", nil
+}
+
+// copyRaw returns a deep copy of the maps and slices of a raw JSON value.
+func copyRaw(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for key, value := range v {
+			m[key] = copyRaw(value)
+		}
+		return m
+	case []any:
+		s := make([]any, len(v))
+		for i, value := range v {
+			s[i] = copyRaw(value)
+		}
+		return s
+	}
+	return value
+}
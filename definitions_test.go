@@ -0,0 +1,110 @@
+package colibri
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDefinitions(t *testing.T) {
+	rawRules := []byte(`{
+		"URL": "http://example.com",
+		"Definitions": {
+			"price": "//span[@class='price']",
+			"productCard": {
+				"expr": "//div[@class='card']",
+				"all": true,
+				"selectors": {
+					"name": "//h2",
+					"price": {"$ref": "price"}
+				}
+			}
+		},
+		"Selectors": {
+			"products": {"$ref": "productCard"},
+			"featured": {"$ref": "productCard", "Expr": "//div[@class='featured']", "all": false}
+		}
+	}`)
+
+	want := map[string]any{
+		"URL": "http://example.com",
+		"selectors": map[string]any{
+			"products": map[string]any{
+				"expr": "//div[@class='card']",
+				"all":  true,
+				"selectors": map[string]any{
+					"name":  map[string]any{"expr": "//h2"},
+					"price": map[string]any{"expr": "//span[@class='price']"},
+				},
+			},
+			"featured": map[string]any{
+				"expr": "//div[@class='featured']",
+				"selectors": map[string]any{
+					"name":  map[string]any{"expr": "//h2"},
+					"price": map[string]any{"expr": "//span[@class='price']"},
+				},
+			},
+		},
+	}
+
+	rules := &Rules{}
+	if err := json.Unmarshal(rawRules, rules); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	errTests := []struct {
+		Name     string
+		RawRules string
+		Key      string
+		Err      error
+	}{
+		{
+			"unknown",
+			`{"Definitions": {}, "Selectors": {"title": {"$ref": "title"}}}`,
+			"title",
+			ErrUnknownDefinition,
+		},
+		{
+			"circular",
+			`{"Definitions": {"a": {"$ref": "b"}, "b": {"$ref": "a"}}, "Selectors": {"title": {"$ref": "a"}}}`,
+			"title",
+			ErrCircularRef,
+		},
+		{
+			"invalid",
+			`{"Definitions": "title", "Selectors": {"title": "//title"}}`,
+			"Definitions",
+			ErrInvalidDefinitions,
+		},
+	}
+
+	for _, tt := range errTests {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := json.Unmarshal([]byte(tt.RawRules), &Rules{})
+
+			var errs *Errs
+			if !errors.As(err, &errs) {
+				t.Fatalf("got %v, want *Errs", err)
+			}
+
+			if e, _ := errs.Get(tt.Key); !errors.Is(e, tt.Err) {
+				t.Fatalf("got %v, want %v", e, tt.Err)
+			}
+		})
+	}
+}
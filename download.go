@@ -0,0 +1,159 @@
+package colibri
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// KeyFile is the key of the Output.Data field that stores the path of the downloaded file.
+	KeyFile = "file"
+
+	// KeySize is the key of the Output.Data field that stores the number of bytes downloaded.
+	KeySize = "size"
+)
+
+// ProgressFunc reports the progress of a download,
+// total is -1 when the size of the response body is unknown.
+type ProgressFunc func(rules *Rules, written, total int64)
+
+type (
+	writerKey   struct{}
+	progressKey struct{}
+)
+
+// WithWriter returns a copy of ctx in which the rules write the response body
+// to w instead of parsing it. The writer takes precedence over the SaveTo field of the rules.
+func WithWriter(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, writerKey{}, w)
+}
+
+// WithProgress returns a copy of ctx in which the downloads report their progress to fn.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// isDownload returns true if the response body of the rules must be downloaded.
+func isDownload(rules *Rules) bool {
+	if rules.SaveTo != "" {
+		return true
+	}
+
+	_, ok := rules.Context().Value(writerKey{}).(io.Writer)
+	return ok
+}
+
+// download writes the response body to the writer of the context or to the SaveTo file.
+// The file is replaced only when the response body has been completely written.
+// The responses with a status code other than 2xx return ErrStatusCode,
+// unless the status code is in the AllowedStatusCodes of the rules.
+func download(rules *Rules, resp Response) (map[string]any, error) {
+	body := resp.Body()
+	if body == nil {
+		return nil, nil
+	}
+	defer body.Close()
+
+	if code := resp.StatusCode(); ((code < 200) || (code > 299)) && (len(rules.AllowedStatusCodes) == 0) {
+		return nil, fmt.Errorf("%w: %d", ErrStatusCode, code)
+	}
+
+	pw := &progressWriter{
+		rules:    rules,
+		total:    -1,
+		progress: func(*Rules, int64, int64) {},
+	}
+
+	if fn, ok := rules.Context().Value(progressKey{}).(ProgressFunc); ok && (fn != nil) {
+		pw.progress = fn
+	}
+
+	if resp.Header() != nil {
+		if n, err := strconv.ParseInt(resp.Header().Get("Content-Length"), 10, 64); err == nil {
+			pw.total = n
+		}
+	}
+
+	if w, ok := rules.Context().Value(writerKey{}).(io.Writer); ok {
+		pw.w = w
+		_, err := io.Copy(pw, body)
+		return map[string]any{KeySize: pw.written}, err
+	}
+
+	name, err := downloadPath(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+
+	pw.w = f
+	_, err = io.Copy(pw, body)
+	if err = errors.Join(err, f.Close()); err != nil {
+		return nil, err
+	}
+
+	if err := os.Rename(f.Name(), name); err != nil {
+		return nil, err
+	}
+	return map[string]any{KeyFile: name, KeySize: pw.written}, nil
+}
+
+// downloadPath returns the path of the file in which the response body is stored.
+// If SaveTo is a directory, the name of the file is the last element of the URL path
+// followed by a hash of the URL, so the URLs with the same last element are not overwritten,
+// e.g. "/a/index.html" is stored in "index-<hash>.html".
+func downloadPath(rules *Rules) (string, error) {
+	info, err := os.Stat(rules.SaveTo)
+	if errors.Is(err, os.ErrNotExist) {
+		return rules.SaveTo, nil
+	} else if err != nil {
+		return "", err
+	}
+
+	if !info.IsDir() {
+		return rules.SaveTo, nil
+	}
+
+	name, rawURL := "index", ""
+	if rules.URL != nil {
+		if base := path.Base(rules.URL.Path); (base != "/") && (base != ".") {
+			name = base
+		}
+		rawURL = rules.URL.String()
+	}
+
+	sum := sha256.Sum256([]byte(rawURL))
+	ext := path.Ext(name)
+	name = strings.TrimSuffix(name, ext) + "-" + hex.EncodeToString(sum[:8]) + ext
+	return filepath.Join(rules.SaveTo, name), nil
+}
+
+// progressWriter counts the bytes written and reports the progress.
+type progressWriter struct {
+	w        io.Writer
+	rules    *Rules
+	written  int64
+	total    int64
+	progress ProgressFunc
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	pw.written += int64(n)
+	pw.progress(pw.rules, pw.written, pw.total)
+	return n, err
+}
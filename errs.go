@@ -28,17 +28,24 @@ func AddError(errs error, key string, err error) error {
 }
 
 // Errs is a structure that stores and manages errors.
+// Each error is stored with an optional machine-readable code, see ErrorCode.
 type Errs struct {
-	rw   sync.RWMutex
-	data map[string]error
+	rw    sync.RWMutex
+	data  map[string]error
+	codes map[string]string
 }
 
-// Add adds an error to the error set.
+// Add adds an error to the error set with the code returned by ErrorCode.
 // If the key or error is null, no operation is performed.
 // If there is already an error stored with the same key,
 // the error is stored with the key + # + key number.
 // Returns a pointer to the updated error structure.
 func (errs *Errs) Add(key string, err error) *Errs {
+	return errs.AddCode(key, ErrorCode(err), err)
+}
+
+// AddCode adds an error to the error set with the code, see Add.
+func (errs *Errs) AddCode(key, code string, err error) *Errs {
 	if (key == "") || (err == nil) {
 		return errs
 	}
@@ -57,6 +64,12 @@ func (errs *Errs) Add(key string, err error) *Errs {
 	}
 
 	errs.data[key] = err
+	if code != "" {
+		if errs.codes == nil {
+			errs.codes = make(map[string]string)
+		}
+		errs.codes[key] = code
+	}
 	errs.rw.Unlock()
 	return errs
 }
@@ -71,6 +84,15 @@ func (errs *Errs) Get(key string) (err error, ok bool) {
 	return err, ok
 }
 
+// Code returns the code of the error associated with a key and
+// a boolean indicating whether the error has a code.
+func (errs *Errs) Code(key string) (code string, ok bool) {
+	errs.rw.RLock()
+	code, ok = errs.codes[key]
+	errs.rw.RUnlock()
+	return code, ok
+}
+
 // Error returns a string representation of errors stored in JSON format.
 func (errs *Errs) Error() string {
 	b, _ := errs.MarshalJSON()
@@ -78,17 +100,22 @@ func (errs *Errs) Error() string {
 }
 
 // MarshalJSON returns the JSON representation of the stored errors.
+// The errors with a code are represented as an object with the code and error fields.
 func (errs *Errs) MarshalJSON() ([]byte, error) {
 	errs.rw.Lock()
 	defer errs.rw.Unlock()
 
 	errsMap := make(map[string]any, len(errs.data))
 	for key, err := range errs.data {
+		var value any = err.Error()
 		if e, ok := err.(json.Marshaler); ok {
-			errsMap[key] = e
-			continue
+			value = e
+		}
+
+		if code, ok := errs.codes[key]; ok {
+			value = map[string]any{"code": code, "error": value}
 		}
-		errsMap[key] = err.Error()
+		errsMap[key] = value
 	}
 	return json.Marshal(errsMap)
 }
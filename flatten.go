@@ -0,0 +1,130 @@
+package colibri
+
+import "strings"
+
+// FlattenMap returns the nested maps of data as a single map whose keys
+// are the keys of each level joined by sep (e.g. "product_price").
+// The rest of the values, including slices, are not modified.
+func FlattenMap(data map[string]any, sep string) map[string]any {
+	result := make(map[string]any, len(data))
+	flattenMap(result, "", data, sep)
+	return result
+}
+
+func flattenMap(result map[string]any, prefix string, data map[string]any, sep string) {
+	for key, value := range data {
+		if prefix != "" {
+			key = strings.Join([]string{prefix, key}, sep)
+		}
+
+		if m, ok := value.(map[string]any); ok && (len(m) > 0) {
+			flattenMap(result, key, m, sep)
+			continue
+		}
+		result[key] = value
+	}
+}
+
+// Flatten returns the data of the output as flat rows in which the keys are the paths
+// of the values, the names of the nested selectors are separated by dots (e.g. "product.price").
+//
+// Each element found by a selector with All or Follow produces its own row and the values
+// of the other selectors are repeated in each row. The elements of sibling selectors with All
+// are combined by index. Only the data of the outputs of the followed URLs is used.
+// The keys of each row are joined by FlattenMap.
+func (out *Output) Flatten() []map[string]any {
+	values := expandRows(out.Data)
+
+	rows := make([]map[string]any, 0, len(values))
+	for _, value := range values {
+		rows = append(rows, FlattenMap(value.(map[string]any), "."))
+	}
+
+	if (len(rows) == 1) && (len(rows[0]) == 0) {
+		return nil
+	}
+	return rows
+}
+
+// expandRows returns the values of the rows in which each element of the slices
+// and each followed output is replaced by its own value, so the maps do not contain slices.
+func expandRows(value any) []any {
+	switch v := value.(type) {
+	case map[string]any:
+		if data, ok := followedData(v); ok {
+			return expandRows(data)
+		}
+
+		rows := []map[string]any{{}}
+		for key, elem := range v {
+			rows = zipRows(rows, key, expandRows(elem))
+		}
+
+		values := make([]any, 0, len(rows))
+		for _, row := range rows {
+			values = append(values, row)
+		}
+		return values
+
+	case []any:
+		var values []any
+		for _, elem := range v {
+			values = append(values, expandRows(elem)...)
+		}
+		return values
+	}
+	return []any{value}
+}
+
+// zipRows sets the values of the key in the rows by index, a single row or value is combined
+// with all the values or rows of the other side. The empty maps are not set.
+func zipRows(rows []map[string]any, key string, values []any) []map[string]any {
+	n := max(len(rows), len(values))
+	zipped := make([]map[string]any, n)
+
+	for i := range zipped {
+		var row map[string]any
+		if len(rows) == 1 {
+			row = rows[0]
+		} else if i < len(rows) {
+			row = rows[i]
+		}
+
+		zipped[i] = make(map[string]any, len(row)+1)
+		for k, v := range row {
+			zipped[i][k] = v
+		}
+
+		var value any
+		if len(values) == 1 {
+			value = values[0]
+		} else if i < len(values) {
+			value = values[i]
+		} else {
+			continue
+		}
+
+		if m, ok := value.(map[string]any); ok && (len(m) == 0) {
+			continue
+		}
+		zipped[i][key] = value
+	}
+	return zipped
+}
+
+// followedData returns the data of the value if it is the output of a followed URL,
+// see the Serializable method of the Output structure. The outputs are recognized by
+// the URL of their response and their data, the rest of their keys, e.g. meta, are optional.
+func followedData(value map[string]any) (map[string]any, bool) {
+	response, ok := value["response"].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+
+	if _, ok := response["url"].(string); !ok {
+		return nil, false
+	}
+
+	data, ok := value["data"].(map[string]any)
+	return data, ok
+}
@@ -0,0 +1,83 @@
+package colibri
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlatten(t *testing.T) {
+	tests := []struct {
+		Name string
+		Data map[string]any
+		Want []map[string]any
+	}{
+		{"nil", nil, nil},
+		{"scalars", map[string]any{"title": "Example", "price": 10.5}, []map[string]any{{"title": "Example", "price": 10.5}}},
+		{
+			"nested",
+			map[string]any{"product": map[string]any{"name": "A", "seller": map[string]any{"name": "B"}}},
+			[]map[string]any{{"product.name": "A", "product.seller.name": "B"}},
+		},
+		{
+			"all",
+			map[string]any{
+				"title":  "Example",
+				"names":  []any{"A", "B"},
+				"prices": []any{1, 2},
+				"empty":  []any{},
+			},
+			[]map[string]any{
+				{"title": "Example", "names": "A", "prices": 1},
+				{"title": "Example", "names": "B", "prices": 2},
+			},
+		},
+		{
+			"nestedAll",
+			map[string]any{
+				"title": "Example",
+				"products": []any{
+					map[string]any{"name": "A", "tags": []any{"x", "y"}},
+					map[string]any{"name": "B"},
+				},
+			},
+			[]map[string]any{
+				{"title": "Example", "products.name": "A", "products.tags": "x"},
+				{"title": "Example", "products.name": "A", "products.tags": "y"},
+				{"title": "Example", "products.name": "B"},
+			},
+		},
+		{
+			"follow",
+			map[string]any{
+				"links": []any{
+					map[string]any{"response": map[string]any{"url": "https://example.com/a"}, "data": map[string]any{"title": "A"}},
+					map[string]any{"response": map[string]any{"url": "https://example.com/b"}, "data": map[string]any{"title": "B"}},
+				},
+			},
+			[]map[string]any{{"links.title": "A"}, {"links.title": "B"}},
+		},
+		{
+			"followMeta",
+			map[string]any{
+				"links": []any{
+					map[string]any{
+						"response":    map[string]any{"url": "https://example.com/a", "code": 200},
+						"data":        map[string]any{"title": "A"},
+						"meta":        map[string]any{"attempts": 1},
+						"contentType": "text/html",
+					},
+				},
+			},
+			[]map[string]any{{"links.title": "A"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			output := &Output{Data: tt.Data}
+			if got := output.Flatten(); !reflect.DeepEqual(got, tt.Want) {
+				t.Fatal("not equal")
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package colibri
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+type followCacheKey struct{}
+
+// errFollowAborted is returned to the waiters of an extraction that panicked.
+var errFollowAborted = errors.New("followed extraction aborted")
+
+// followCache contains the outputs of the rules followed during an Extract,
+// so the URLs followed by several selectors with the same rules are extracted once
+// and their output is shared. Only the outputs without errors of the GET requests are stored.
+//
+// The rules that are followed at the same time, e.g. by parallel Follow branches, are extracted once
+// and the waiters share the output and the error of the extraction.
+type followCache struct {
+	mu      sync.Mutex
+	outputs map[string]*Output
+	calls   map[string]*followCall
+}
+
+// followCall is an extraction in progress.
+type followCall struct {
+	done chan struct{}
+	out  *Output
+	err  error
+
+	// deps contains the calls started or waited by the extraction,
+	// they are used to detect the waits that would never end.
+	deps []*followCall
+}
+
+type followCallKey struct{}
+
+// reaches returns true if the call is the target or depends on it, the mutex must be locked.
+func (call *followCall) reaches(target *followCall) bool {
+	if target == nil {
+		return false
+	} else if call == target {
+		return true
+	}
+
+	for _, dep := range call.deps {
+		if dep.reaches(target) {
+			return true
+		}
+	}
+	return false
+}
+
+func newFollowCache() *followCache {
+	return &followCache{
+		outputs: make(map[string]*Output),
+		calls:   make(map[string]*followCall),
+	}
+}
+
+func followCacheFrom(ctx context.Context) *followCache {
+	fc, _ := ctx.Value(followCacheKey{}).(*followCache)
+	return fc
+}
+
+// extract returns the stored output of the rules or extracts them,
+// if the rules are being extracted it waits for the extraction.
+func (fc *followCache) extract(resp Response, rules *Rules) (*Output, error) {
+	if (fc == nil) || !cacheableRules(rules) {
+		return resp.Extract(rules)
+	}
+
+	key, ok := followKey(rules)
+	if !ok {
+		return resp.Extract(rules)
+	}
+
+	// parent is the extraction that follows the rules.
+	parent, _ := rules.Context().Value(followCallKey{}).(*followCall)
+
+	fc.mu.Lock()
+	if out, found := fc.outputs[key]; found {
+		fc.mu.Unlock()
+		colibriFrom(rules.Context()).countCacheHit()
+		return out, nil
+	}
+
+	if call, found := fc.calls[key]; found {
+		// The extraction depends on the parent, e.g. a page that follows itself, so it is not waited.
+		if call.reaches(parent) {
+			fc.mu.Unlock()
+			return resp.Extract(rules)
+		}
+
+		if parent != nil {
+			parent.deps = append(parent.deps, call)
+		}
+		fc.mu.Unlock()
+		colibriFrom(rules.Context()).countCacheHit()
+
+		select {
+		case <-call.done:
+			return call.out, call.err
+		case <-rules.Context().Done():
+			return nil, rules.Context().Err()
+		}
+	}
+
+	call := &followCall{done: make(chan struct{})}
+	fc.calls[key] = call
+	if parent != nil {
+		parent.deps = append(parent.deps, call)
+	}
+	fc.mu.Unlock()
+
+	// The waiters are released even if the extraction panics.
+	finished := false
+	defer func() {
+		if !finished {
+			call.err = errFollowAborted
+		}
+
+		fc.mu.Lock()
+		if (call.err == nil) && (call.out != nil) {
+			fc.outputs[key] = call.out
+		}
+		delete(fc.calls, key)
+		fc.mu.Unlock()
+		close(call.done)
+	}()
+
+	callRules := *rules
+	callRules.SetContext(context.WithValue(rules.Context(), followCallKey{}, call))
+	call.out, call.err = resp.Extract(&callRules)
+	finished = true
+	return call.out, call.err
+}
+
+// followKey returns the key of the rules, the rules and the variables used by their selectors.
+func followKey(rules *Rules) (string, bool) {
+	b, err := json.Marshal(struct {
+		Rules *Rules
+		Vars  map[string]any
+	}{rules, VarsFrom(rules.Context())})
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
@@ -0,0 +1,92 @@
+package colibri
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type extractResponse struct {
+	testResponse
+	extract func(rules *Rules) (*Output, error)
+}
+
+func (resp *extractResponse) Extract(rules *Rules) (*Output, error) { return resp.extract(rules) }
+
+func TestFollowCacheCoalesce(t *testing.T) {
+	var (
+		fc    = newFollowCache()
+		calls atomic.Int64
+		resp  = &extractResponse{extract: func(*Rules) (*Output, error) {
+			calls.Add(1)
+			time.Sleep(20 * time.Millisecond)
+			return &Output{Data: map[string]any{"n": 1}}, nil
+		}}
+	)
+
+	var (
+		wg      sync.WaitGroup
+		outputs = make([]*Output, 4)
+	)
+	for i := range outputs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			out, err := fc.extract(resp, &Rules{URL: mustNewURL("http://example.com/a")})
+			if err != nil {
+				t.Error(err)
+			}
+			outputs[i] = out
+		}()
+	}
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("got %v, want %v", n, 1)
+	}
+
+	for _, out := range outputs {
+		if out != outputs[0] {
+			t.Fatal("output not shared")
+		}
+	}
+}
+
+func TestFollowCacheCycle(t *testing.T) {
+	var (
+		fc    = newFollowCache()
+		calls int
+		resp  *extractResponse
+	)
+	resp = &extractResponse{extract: func(rules *Rules) (*Output, error) {
+		// The page follows itself once, the extraction in progress is not waited.
+		if calls++; calls == 1 {
+			cRules := rules.Clone()
+			if _, err := fc.extract(resp, cRules); err != nil {
+				return nil, err
+			}
+		}
+		return &Output{Data: map[string]any{}}, nil
+	}}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		if _, err := fc.extract(resp, &Rules{URL: mustNewURL("http://example.com/a")}); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("deadlock")
+	}
+
+	if calls != 2 {
+		t.Fatalf("got %v, want %v", calls, 2)
+	}
+}
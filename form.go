@@ -0,0 +1,102 @@
+package colibri
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrNotForm is returned when a node found by a selector with Submit is not a form.
+var ErrNotForm = errors.New("node is not a form")
+
+// Form represents an HTML form that can be submitted.
+type Form struct {
+	// Action is the URL to which the form is submitted, it can be relative.
+	Action string
+
+	// Method is the HTTP method of the form submission.
+	Method string
+
+	// Values contains the values of the fields of the form, including the hidden inputs.
+	Values url.Values
+}
+
+// FormNode is a Node that represents a form.
+type FormNode interface {
+	Node
+
+	// Form returns the form of the node.
+	Form() (*Form, error)
+}
+
+// Rules returns a copy of src that submits the form. The action is resolved against base
+// and the fields of the form are overridden by the values.
+func (form *Form) Rules(src *Rules, base *url.URL, values url.Values) (*Rules, error) {
+	action, err := url.Parse(form.Action)
+	if err != nil {
+		return nil, err
+	}
+
+	if base != nil {
+		action = base.ResolveReference(action)
+	}
+	action.Fragment = ""
+
+	method := strings.ToUpper(form.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	newRules := src.Clone()
+	newRules.URL = action
+	newRules.Method = method
+
+	newRules.Form = cloneValues(form.Values)
+	if newRules.Form == nil {
+		newRules.Form = url.Values{}
+	}
+
+	for key, value := range values {
+		newRules.Form[key] = append([]string(nil), value...)
+	}
+	return newRules, nil
+}
+
+// formSelector submits the forms of the nodes with the values.
+func formSelector(rules *Rules, resp Response, values url.Values, nodes ...Node) ([]any, error) {
+	var (
+		formRules []*Rules
+		errs      error
+	)
+
+	for i, node := range nodes {
+		formNode, ok := node.(FormNode)
+		if !ok {
+			errs = AddError(errs, strconv.Itoa(i), ErrNotForm)
+			continue
+		}
+
+		form, err := formNode.Form()
+		if err != nil {
+			errs = AddError(errs, strconv.Itoa(i), err)
+			continue
+		}
+
+		fRules, err := form.Rules(rules, resp.URL(), values)
+		if err != nil {
+			errs = AddError(errs, strconv.Itoa(i), err)
+			continue
+		}
+		formRules = append(formRules, fRules)
+	}
+
+	if errs != nil {
+		for _, fRules := range formRules {
+			ReleaseRules(fRules)
+		}
+		return nil, errs
+	}
+	return follow(resp, formRules, 0)
+}
@@ -0,0 +1,134 @@
+package colibri
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// ImagesExpr is the type of the selectors that find the images of the node of their parent selector,
+// the images are found by the parser, see ImageNode. The expression of these selectors is not used.
+//
+// The value of each image contains its src and the URLs of its srcset resolved against the URL
+// of the response, its alt, its width and its height. With Asset, the images are stored with the Media
+// and the locations in which they are stored are returned instead.
+const ImagesExpr = "images"
+
+// ErrNotImageNode is returned when the node of a selector of type images does not implement ImageNode.
+var ErrNotImageNode = errors.New("node does not support images")
+
+// Image represents an image of a document.
+type Image struct {
+	// Src is the URL of the image, it can be relative to the URL of the response.
+	Src string
+
+	// Srcset contains the candidate images of the srcset, e.g. the ones of the sources of a picture.
+	Srcset []*ImageCandidate
+
+	// Alt is the alternative text of the image.
+	Alt string
+
+	// Width and Height are the dimensions of the image, they are zero if they are not specified.
+	Width, Height int
+}
+
+// ImageCandidate is a candidate image of a srcset.
+type ImageCandidate struct {
+	// URL is the URL of the image, it can be relative to the URL of the response.
+	URL string
+
+	// Descriptor is the width or pixel density descriptor of the image, e.g. "640w" or "2x".
+	Descriptor string
+
+	// Media is the media condition of the source of the candidate, e.g. "(min-width: 800px)".
+	Media string
+
+	// Type is the MIME type of the source of the candidate, e.g. "image/webp".
+	Type string
+}
+
+// ImageNode is a Node that contains images, e.g. the img and picture elements of an HTML document.
+type ImageNode interface {
+	Node
+
+	// Images returns the images of the node and of its descendants in document order.
+	Images() []*Image
+}
+
+// imagesSelector returns the images of the node, the first one if the selector does not have All.
+func imagesSelector(src *Rules, resp Response, selector *Selector, parent Node) (any, error) {
+	imageNode, ok := parent.(ImageNode)
+	if !ok {
+		return nil, ErrNotImageNode
+	}
+
+	images := imageNode.Images()
+	if !selector.All && (len(images) > 1) {
+		images = images[:1]
+	}
+
+	var (
+		result []any
+		srcs   []any
+	)
+	for _, image := range images {
+		srcset := make([]any, 0, len(image.Srcset))
+		for _, candidate := range image.Srcset {
+			srcset = append(srcset, map[string]any{
+				"url":        resolveURL(resp, candidate.URL),
+				"descriptor": candidate.Descriptor,
+				"media":      candidate.Media,
+				"type":       candidate.Type,
+			})
+		}
+
+		imageSrc := resolveURL(resp, image.Src)
+		result = append(result, map[string]any{
+			"src":    imageSrc,
+			"srcset": srcset,
+			"alt":    image.Alt,
+			"width":  image.Width,
+			"height": image.Height,
+		})
+
+		if imageSrc != "" {
+			srcs = append(srcs, imageSrc)
+		}
+	}
+
+	if selector.Asset {
+		rules := selectorRules(src, selector)
+		defer ReleaseRules(rules)
+
+		found, err := assetSelector(rules, resp, srcs...)
+		if !selector.All {
+			if len(found) == 0 {
+				return nil, err
+			}
+			return found[0], err
+		}
+		return found, err
+	}
+
+	if len(result) == 0 {
+		return nil, nil
+	} else if !selector.All {
+		return result[0], nil
+	}
+	return result, nil
+}
+
+// resolveURL resolves the URL against the URL of the response,
+// the URLs that cannot be parsed are returned as they are.
+func resolveURL(resp Response, rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return ""
+	}
+
+	u, err := url.Parse(rawURL)
+	if (err != nil) || (resp.URL() == nil) {
+		return rawURL
+	}
+	return resp.URL().ResolveReference(u).String()
+}
@@ -0,0 +1,115 @@
+package colibri
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type imagesNode struct {
+	testNode
+	images []*Image
+}
+
+func (node *imagesNode) Images() []*Image { return node.images }
+
+type imagesParser struct{}
+
+func (p *imagesParser) Match(_ string) bool { return true }
+
+func (p *imagesParser) Parse(_ *Rules, _ Response) (Node, error) {
+	return &imagesNode{images: []*Image{
+		{
+			Src:    "/img/a.jpg",
+			Srcset: []*ImageCandidate{{URL: "a.webp", Descriptor: "2x", Type: "image/webp"}},
+			Alt:    "A",
+			Width:  640,
+			Height: 480,
+		},
+		{Src: "https://cdn.example.org/b.png"},
+	}}, nil
+}
+
+func (p *imagesParser) Clear() {}
+
+// testMedia stores the assets in their URL.
+type testMedia struct{}
+
+func (m *testMedia) Store(_ Response, rules []*Rules) ([]string, error) {
+	locations := make([]string, 0, len(rules))
+	for _, r := range rules {
+		locations = append(locations, "stored:"+r.URL.String())
+	}
+	return locations, nil
+}
+
+func (m *testMedia) Clear() {}
+
+func TestImagesSelector(t *testing.T) {
+	c := New()
+	c.Client = &testClient{}
+	c.Parser = &imagesParser{}
+	c.Media = &testMedia{}
+
+	imageA := map[string]any{
+		"src": "http://example.com/img/a.jpg",
+		"srcset": []any{
+			map[string]any{"url": "http://example.com/a.webp", "descriptor": "2x", "media": "", "type": "image/webp"},
+		},
+		"alt":    "A",
+		"width":  640,
+		"height": 480,
+	}
+	imageB := map[string]any{
+		"src":    "https://cdn.example.org/b.png",
+		"srcset": []any{},
+		"alt":    "",
+		"width":  0,
+		"height": 0,
+	}
+
+	tests := []struct {
+		Name     string
+		Selector *Selector
+		Want     any
+	}{
+		{"all", &Selector{Name: "images", Type: "images", All: true}, []any{imageA, imageB}},
+		{"first", &Selector{Name: "images", Type: "Images"}, imageA},
+		{"assets", &Selector{Name: "images", Type: "images", All: true, Asset: true}, []any{"stored:http://example.com/img/a.jpg", "stored:https://cdn.example.org/b.png"}},
+		{"first asset", &Selector{Name: "images", Type: "images", Asset: true}, "stored:http://example.com/img/a.jpg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			output, err := c.Extract(&Rules{
+				URL:       mustNewURL("http://example.com"),
+				Selectors: []*Selector{tt.Selector},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := output.Data["images"]; !reflect.DeepEqual(got, tt.Want) {
+				t.Fatalf("got %v, want %v", got, tt.Want)
+			}
+		})
+	}
+
+	t.Run("not image node", func(t *testing.T) {
+		c := New()
+		c.Client = &testClient{}
+		c.Parser = &testParser{}
+
+		_, err := c.Extract(&Rules{
+			URL:       mustNewURL("http://example.com"),
+			Selectors: []*Selector{{Name: "images", Type: "images"}},
+		})
+
+		var errs *Errs
+		if !errors.As(err, &errs) {
+			t.Fatalf("got %v, want %v", err, "*Errs")
+		} else if err, _ := errs.Get("images"); !errors.Is(err, ErrNotImageNode) {
+			t.Fatalf("got %v, want %v", err, ErrNotImageNode)
+		}
+	})
+}
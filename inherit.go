@@ -0,0 +1,95 @@
+package colibri
+
+import (
+	"net/http"
+	"reflect"
+)
+
+// Inherit returns new rules with the fields of base overridden by the fields of rules
+// that have a non-zero value, so the common rules (header, delay, proxy, politeness, ...)
+// are defined once and the rules of each site only specify what differs.
+//
+// Header and Extra are merged key by key, the keys of rules replace the ones of base.
+// A header key without values or an Extra key with a nil value removes the key of base.
+// The Selectors are merged by name and the rest of the fields are replaced.
+// Since the zero values do not override, a bool field enabled in base cannot be disabled.
+func (rules *Rules) Inherit(base *Rules) *Rules {
+	if base == nil {
+		return rules.Clone()
+	}
+
+	result := base.Clone()
+	derived := rules.Clone()
+
+	derived.Header = inheritHeader(result.Header, derived.Header)
+	derived.Extra = inheritExtra(result.Extra, derived.Extra)
+	derived.Selectors = inheritSelectors(result.Selectors, derived.Selectors)
+
+	rResult := reflect.ValueOf(result).Elem()
+	rDerived := reflect.ValueOf(derived).Elem()
+	for i := 0; i < rDerived.NumField(); i++ {
+		field := rResult.Field(i)
+		if value := rDerived.Field(i); field.CanSet() && !value.IsZero() {
+			field.Set(value)
+		}
+	}
+	return result
+}
+
+// inheritHeader merges the header into the base header, the values of the header replace the ones of base.
+func inheritHeader(base, header http.Header) http.Header {
+	if header == nil {
+		return base
+	} else if base == nil {
+		base = http.Header{}
+	}
+
+	for key, values := range header {
+		if len(values) == 0 {
+			base.Del(key)
+			continue
+		}
+		base[http.CanonicalHeaderKey(key)] = values
+	}
+	return base
+}
+
+// inheritExtra merges the extra data into the base extra data.
+func inheritExtra(base, extra map[string]any) map[string]any {
+	if base == nil {
+		base = make(map[string]any)
+	}
+
+	for key, value := range extra {
+		if value == nil {
+			delete(base, key)
+			continue
+		}
+		base[key] = value
+	}
+	return base
+}
+
+// inheritSelectors merges the selectors into the base selectors, the selectors replace the base ones with the same name.
+func inheritSelectors(base, selectors []*Selector) []*Selector {
+	if len(selectors) == 0 {
+		return base
+	}
+
+	for _, selector := range selectors {
+		replaced := false
+		for i, baseSelector := range base {
+			if baseSelector.Name == selector.Name {
+				ReleaseSelector(baseSelector)
+				base[i] = selector
+				replaced = true
+				break
+			}
+		}
+
+		if !replaced {
+			base = append(base, selector)
+		}
+	}
+	return base
+}
@@ -0,0 +1,62 @@
+package colibri
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRules_Inherit(t *testing.T) {
+	base := &Rules{
+		Method:   "GET",
+		Proxy:    mustNewURL("http://proxy.example.com:8080"),
+		Header:   http.Header{"User-Agent": {"colibri/base"}, "Accept": {"text/html"}, "X-Debug": {"1"}},
+		Delay:    time.Second,
+		Cookies:  true,
+		Priority: 1,
+		Selectors: []*Selector{
+			{Name: "title", Expr: "//title", Extra: map[string]any{}},
+			{Name: "description", Expr: "//meta[@name='description']/@content", Extra: map[string]any{}},
+		},
+		Extra: map[string]any{"source": "base", "debug": true},
+	}
+
+	site := &Rules{
+		URL:       mustNewURL("https://example.com"),
+		Header:    http.Header{"User-Agent": {"colibri/example"}, "X-Debug": nil},
+		Delay:     2 * time.Second,
+		Selectors: []*Selector{{Name: "title", Expr: "//h1", Extra: map[string]any{}}},
+		Extra:     map[string]any{"source": "example", "debug": nil},
+	}
+
+	want := &Rules{
+		Method:   "GET",
+		URL:      mustNewURL("https://example.com"),
+		Proxy:    mustNewURL("http://proxy.example.com:8080"),
+		Header:   http.Header{"User-Agent": {"colibri/example"}, "Accept": {"text/html"}},
+		Delay:    2 * time.Second,
+		Cookies:  true,
+		Priority: 1,
+		Selectors: []*Selector{
+			{Name: "title", Expr: "//h1", Extra: map[string]any{}},
+			{Name: "description", Expr: "//meta[@name='description']/@content", Extra: map[string]any{}},
+		},
+		Extra: map[string]any{"source": "example"},
+	}
+
+	got := site.Inherit(base)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatal("not equal")
+	}
+
+	if len(base.Header) != 3 || base.Extra["source"] != "base" || base.Selectors[0].Expr != "//title" {
+		t.Fatal("base modified")
+	}
+
+	t.Run("nil", func(t *testing.T) {
+		if !reflect.DeepEqual(site.Inherit(nil), site) {
+			t.Fatal("not equal")
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package colibri
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// LinksExpr is the type of the selectors that find the links of the node of their parent selector,
+// the links are found by the parser, see LinkNode. The expression of these selectors is not used.
+//
+// The value of each link contains its url resolved against the URL of the response, its text,
+// its rel attribute and whether it is marked with rel="nofollow". With Follow, the URLs of the links are followed.
+const LinksExpr = "links"
+
+// ErrNotLinkNode is returned when the node of a selector of type links does not implement LinkNode.
+var ErrNotLinkNode = errors.New("node does not support links")
+
+// Link represents a link of a document.
+type Link struct {
+	// URL is the href of the link, it can be relative to the URL of the response.
+	URL string
+
+	// Text is the text of the link.
+	Text string
+
+	// Rel is the rel attribute of the link.
+	Rel string
+}
+
+// Nofollow returns true if the rel attribute of the link contains nofollow.
+func (link *Link) Nofollow() bool {
+	for _, rel := range strings.Fields(link.Rel) {
+		if strings.EqualFold(rel, "nofollow") {
+			return true
+		}
+	}
+	return false
+}
+
+// LinkNode is a Node that contains links, e.g. the anchors of an HTML document.
+type LinkNode interface {
+	Node
+
+	// Links returns the links of the node and of its descendants in document order.
+	Links() []*Link
+}
+
+// linksSelector returns the links of the node, the first one if the selector does not have All.
+// The links whose URL cannot be parsed are not included.
+func linksSelector(src *Rules, resp Response, selector *Selector, parent Node) (any, error) {
+	linkNode, ok := parent.(LinkNode)
+	if !ok {
+		return nil, ErrNotLinkNode
+	}
+
+	var (
+		result []any
+		urls   []any
+	)
+	for _, link := range linkNode.Links() {
+		u, err := url.Parse(strings.TrimSpace(link.URL))
+		if err != nil {
+			continue
+		}
+
+		if base := resp.URL(); base != nil {
+			u = base.ResolveReference(u)
+		}
+
+		result = append(result, map[string]any{
+			"url":      u.String(),
+			"text":     link.Text,
+			"rel":      link.Rel,
+			"nofollow": link.Nofollow(),
+		})
+		urls = append(urls, u.String())
+	}
+
+	if !selector.All && (len(result) > 1) {
+		result, urls = result[:1], urls[:1]
+	}
+
+	if selector.Follow {
+		rules := selectorRules(src, selector)
+		defer ReleaseRules(rules)
+
+		return followSelector(rules, resp, selector, urls...)
+	}
+
+	if len(result) == 0 {
+		return nil, nil
+	} else if !selector.All {
+		return result[0], nil
+	}
+	return result, nil
+}
@@ -0,0 +1,100 @@
+package colibri
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type linksNode struct {
+	testNode
+	links []*Link
+}
+
+func (node *linksNode) Links() []*Link { return node.links }
+
+type linksParser struct{}
+
+func (p *linksParser) Match(_ string) bool { return true }
+
+func (p *linksParser) Parse(_ *Rules, _ Response) (Node, error) {
+	return &linksNode{links: []*Link{
+		{URL: "/a", Text: "A", Rel: ""},
+		{URL: "https://example.org/b", Text: "B", Rel: "NoFollow noopener"},
+		{URL: "%zz", Text: "invalid"},
+	}}, nil
+}
+
+func (p *linksParser) Clear() {}
+
+func TestLinksSelector(t *testing.T) {
+	c := New()
+	c.Client = &testClient{}
+	c.Parser = &linksParser{}
+
+	linkA := map[string]any{"url": "http://example.com/a", "text": "A", "rel": "", "nofollow": false}
+	linkB := map[string]any{"url": "https://example.org/b", "text": "B", "rel": "NoFollow noopener", "nofollow": true}
+
+	tests := []struct {
+		Name     string
+		Selector *Selector
+		Want     any
+	}{
+		{"all", &Selector{Name: "links", Type: "links", All: true}, []any{linkA, linkB}},
+		{"first", &Selector{Name: "links", Type: "LINKS"}, linkA},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			output, err := c.Extract(&Rules{
+				URL:       mustNewURL("http://example.com"),
+				Selectors: []*Selector{tt.Selector},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := output.Data["links"]; !reflect.DeepEqual(got, tt.Want) {
+				t.Fatalf("got %v, want %v", got, tt.Want)
+			}
+		})
+	}
+
+	t.Run("follow", func(t *testing.T) {
+		output, err := c.Extract(&Rules{
+			URL: mustNewURL("http://example.com"),
+			Selectors: []*Selector{{
+				Name:      "links",
+				Type:      "links",
+				All:       true,
+				Follow:    true,
+				Selectors: []*Selector{{Name: "title", Expr: "title"}},
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if found, _ := output.Data["links"].([]any); len(found) != 2 {
+			t.Fatalf("got %v, want %v", len(found), 2)
+		}
+	})
+
+	t.Run("not link node", func(t *testing.T) {
+		c := New()
+		c.Client = &testClient{}
+		c.Parser = &testParser{}
+
+		_, err := c.Extract(&Rules{
+			URL:       mustNewURL("http://example.com"),
+			Selectors: []*Selector{{Name: "links", Type: "links"}},
+		})
+
+		var errs *Errs
+		if !errors.As(err, &errs) {
+			t.Fatalf("got %v, want %v", err, "*Errs")
+		} else if err, _ := errs.Get("links"); !errors.Is(err, ErrNotLinkNode) {
+			t.Fatalf("got %v, want %v", err, ErrNotLinkNode)
+		}
+	})
+}
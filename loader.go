@@ -0,0 +1,144 @@
+package colibri
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KeyInclude is the key of the files included by a rules file, see LoadRules.
+const KeyInclude = "include"
+
+var (
+	// ErrInvalidInclude is returned when the include value is not a path or a list of paths.
+	ErrInvalidInclude = errors.New("invalid include")
+
+	// ErrCircularInclude is returned when a rules file includes itself, directly or through other files.
+	ErrCircularInclude = errors.New("circular include")
+)
+
+// LoadRules reads the rules file from fsys. Files with the .yaml or .yml extension are decoded as YAML,
+// the rest as JSON.
+//
+// The include field lists the paths of other rules files, relative to the file that includes them,
+// whose fields are merged in order before the fields of the file.
+// Objects such as Selectors, Definitions or Header are merged key by key,
+// the rest of the values are replaced by the ones of the file that includes them.
+// Included files can include other files.
+func LoadRules(fsys fs.FS, name string) (*Rules, error) {
+	raw, err := loadRaw(fsys, path.Clean(name), nil)
+	if err != nil {
+		return nil, err
+	}
+	return rulesFromRaw(raw)
+}
+
+// LoadRulesFile reads the rules file from the local path, see LoadRules.
+func LoadRulesFile(name string) (*Rules, error) {
+	return LoadRules(osFS{}, filepath.ToSlash(name))
+}
+
+// rulesFromRaw returns the rules of the raw rules loaded by loadRaw.
+func rulesFromRaw(raw map[string]any) (*Rules, error) {
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := &Rules{}
+	if err := json.Unmarshal(b, rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// loadRaw returns the raw rules of the file merged with the files it includes.
+// The stack contains the files that are being loaded.
+func loadRaw(fsys fs.FS, name string, stack []string) (map[string]any, error) {
+	for _, loading := range stack {
+		if loading == name {
+			return nil, AddError(nil, name, ErrCircularInclude)
+		}
+	}
+	stack = append(stack, name)
+
+	b, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]any
+	switch strings.ToLower(path.Ext(name)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &raw)
+	default:
+		err = json.Unmarshal(b, &raw)
+	}
+
+	if err != nil {
+		return nil, AddError(nil, name, err)
+	} else if raw == nil {
+		raw = make(map[string]any)
+	}
+
+	key, rawInclude := lookupKey(raw, KeyInclude)
+	if key == "" {
+		return raw, nil
+	}
+	delete(raw, key)
+
+	includes, err := toStrings(rawInclude)
+	if err != nil {
+		return nil, AddError(nil, name, ErrInvalidInclude)
+	}
+
+	result := make(map[string]any)
+	for _, include := range includes {
+		if !path.IsAbs(include) {
+			include = path.Join(path.Dir(name), include)
+		}
+
+		included, err := loadRaw(fsys, include, stack)
+		if err != nil {
+			return nil, err
+		}
+		mergeRaw(result, included)
+	}
+
+	mergeRaw(result, raw)
+	return result, nil
+}
+
+// mergeRaw merges the raw values of src into dst, the objects are merged key by key
+// regardless of the case of the keys and the rest of the values are replaced.
+func mergeRaw(dst, src map[string]any) {
+	for key, value := range src {
+		dstKey, dstValue := lookupKey(dst, key)
+
+		srcMap, srcOk := value.(map[string]any)
+		dstMap, dstOk := dstValue.(map[string]any)
+		if srcOk && dstOk {
+			mergeRaw(dstMap, srcMap)
+			continue
+		}
+
+		if dstKey != "" {
+			delete(dst, dstKey)
+		}
+		dst[key] = value
+	}
+}
+
+// osFS is a fs.FS of the local file system whose names are paths,
+// unlike os.DirFS it allows absolute paths and paths outside of a directory.
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
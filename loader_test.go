@@ -0,0 +1,106 @@
+package colibri
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadRules(t *testing.T) {
+	fsys := fstest.MapFS{
+		"shared/base.json": {Data: []byte(`{
+			"Header": {"User-Agent": "colibri/base", "Accept": "text/html"},
+			"Delay": 1000,
+			"Selectors": {"title": "//title"}
+		}`)},
+		"shared/product.yaml": {Data: []byte(`
+include: base.json
+delay: 2000
+definitions:
+  price: "//span[@class='price']"
+selectors:
+  price:
+    $ref: price
+`)},
+		"sites/example.json": {Data: []byte(`{
+			"include": ["../shared/product.yaml"],
+			"URL": "https://example.com",
+			"header": {"User-Agent": "colibri/example"},
+			"selectors": {"title": "//h1"}
+		}`)},
+		"loop/a.json": {Data: []byte(`{"include": "b.json"}`)},
+		"loop/b.json": {Data: []byte(`{"include": "a.json"}`)},
+	}
+
+	rules, err := LoadRules(fsys, "sites/example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rules.URL.String() != "https://example.com" {
+		t.Fatalf("got %v, want %v", rules.URL, "https://example.com")
+	}
+
+	wantHeader := http.Header{"User-Agent": {"colibri/example"}, "Accept": {"text/html"}}
+	if !reflect.DeepEqual(rules.Header, wantHeader) {
+		t.Fatalf("got %v, want %v", rules.Header, wantHeader)
+	}
+
+	if rules.Delay.Milliseconds() != 2000 {
+		t.Fatalf("got %v, want %v", rules.Delay, "2s")
+	}
+
+	got := make(map[string]string)
+	for _, selector := range rules.Selectors {
+		got[selector.Name] = selector.Expr
+	}
+
+	want := map[string]string{"title": "//h1", "price": "//span[@class='price']"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	t.Run("circular", func(t *testing.T) {
+		_, err := LoadRules(fsys, "loop/a.json")
+
+		var errs *Errs
+		if !errors.As(err, &errs) {
+			t.Fatalf("got %v, want *Errs", err)
+		}
+
+		if e, _ := errs.Get("loop/a.json"); !errors.Is(e, ErrCircularInclude) {
+			t.Fatalf("got %v, want %v", e, ErrCircularInclude)
+		}
+	})
+
+	t.Run("notExist", func(t *testing.T) {
+		if _, err := LoadRules(fsys, "sites/missing.json"); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("got %v, want %v", err, os.ErrNotExist)
+		}
+	})
+
+	t.Run("file", func(t *testing.T) {
+		dir := t.TempDir()
+		for _, name := range []string{"shared/base.json", "shared/product.yaml", "sites/example.json"} {
+			path := filepath.Join(dir, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := os.WriteFile(path, fsys[name].Data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		rules, err := LoadRulesFile(filepath.Join(dir, "sites", "example.json"))
+		if err != nil {
+			t.Fatal(err)
+		} else if len(rules.Selectors) != 2 {
+			t.Fatalf("got %v, want %v", len(rules.Selectors), 2)
+		}
+	})
+}
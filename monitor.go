@@ -0,0 +1,179 @@
+package colibri
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultMonitorInterval default interval between monitor runs.
+const DefaultMonitorInterval = time.Hour
+
+// MonitorOptions configures a monitor.
+type MonitorOptions struct {
+	// Context specifies the context of the monitor, when it is canceled the monitor is stopped.
+	// If it is nil, context.Background is used.
+	Context context.Context
+
+	// Interval specifies the interval between runs.
+	Interval time.Duration
+
+	// OnChange is called with the fields of the output data that changed since the previous run.
+	OnChange func(rules *Rules, output *Output, changes []Change)
+
+	// OnError is called with the errors of each run.
+	OnError func(rules *Rules, err error)
+}
+
+// Change represents a field of the output data that changed between two runs.
+type Change struct {
+	// Path of the field, the keys of the maps and the indices
+	// of the slices are separated by dots (e.g. "products.0.price").
+	Path string `json:"path"`
+
+	// Old value of the field, it is nil if the field was added.
+	Old any `json:"old"`
+
+	// New value of the field, it is nil if the field was removed.
+	New any `json:"new"`
+}
+
+// Monitor extracts the data of the rules every Interval and reports the fields
+// of the output data that changed since the previous run to OnChange.
+// The first run stores the initial data and does not report changes.
+//
+// Visited, Duplicates and NearDuplicates are not used, since the same URLs are requested on each run.
+// Monitor blocks until the context is canceled and returns its error.
+func (c *Colibri) Monitor(opts *MonitorOptions, rules ...*Rules) error {
+	if opts == nil {
+		opts = &MonitorOptions{}
+	}
+
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = DefaultMonitorInterval
+	}
+
+	m := *c
+	m.Visited = nil
+	m.Duplicates = nil
+	m.NearDuplicates = nil
+
+	var (
+		previous = make([]map[string]any, len(rules))
+		ticker   = time.NewTicker(interval)
+	)
+	defer ticker.Stop()
+
+	for {
+		for i, r := range rules {
+			if (ctx.Err() != nil) || c.life.closed() {
+				break
+			}
+
+			if r == nil {
+				continue
+			}
+
+			runRules := r.Clone()
+			output, err := m.Extract(runRules)
+			ReleaseRules(runRules)
+
+			if err != nil {
+				if opts.OnError != nil {
+					opts.OnError(r, err)
+				}
+				continue
+			}
+
+			if previous[i] != nil {
+				if changes := Diff(previous[i], output.Data); (len(changes) > 0) && (opts.OnChange != nil) {
+					opts.OnChange(r, output, changes)
+				}
+			}
+
+			previous[i] = output.Data
+			if previous[i] == nil {
+				previous[i] = map[string]any{}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.life.done():
+			return ErrShutdown
+		case <-ticker.C:
+		}
+	}
+}
+
+// Diff returns the fields that changed between the old and the new data sorted by path.
+// Maps and slices are compared field by field, the rest of the values with reflect.DeepEqual.
+func Diff(old, new map[string]any) []Change {
+	var changes []Change
+	diff("", old, new, &changes)
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+func diff(path string, old, new any, changes *[]Change) {
+	switch oldValue := old.(type) {
+	case map[string]any:
+		newValue, ok := new.(map[string]any)
+		if !ok {
+			break
+		}
+
+		for key, value := range oldValue {
+			diff(joinPath(path, key), value, newValue[key], changes)
+		}
+
+		for key, value := range newValue {
+			if _, ok := oldValue[key]; !ok {
+				diff(joinPath(path, key), nil, value, changes)
+			}
+		}
+		return
+
+	case []any:
+		newValue, ok := new.([]any)
+		if !ok {
+			break
+		}
+
+		for i := 0; i < max(len(oldValue), len(newValue)); i++ {
+			var o, n any
+			if i < len(oldValue) {
+				o = oldValue[i]
+			}
+
+			if i < len(newValue) {
+				n = newValue[i]
+			}
+			diff(joinPath(path, strconv.Itoa(i)), o, n, changes)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(old, new) {
+		*changes = append(*changes, Change{Path: path, Old: old, New: new})
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
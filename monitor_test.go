@@ -0,0 +1,48 @@
+package colibri
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Old, New map[string]any
+		Want     []Change
+	}{
+		{"equal", map[string]any{"price": 10.5}, map[string]any{"price": 10.5}, nil},
+		{
+			"changed",
+			map[string]any{"price": 10.5, "stock": true},
+			map[string]any{"price": 9.99, "stock": true},
+			[]Change{{Path: "price", Old: 10.5, New: 9.99}},
+		},
+		{
+			"nested",
+			map[string]any{"products": []any{map[string]any{"price": "1"}, map[string]any{"price": "2"}}},
+			map[string]any{"products": []any{map[string]any{"price": "1"}, map[string]any{"price": "3"}, "new"}},
+			[]Change{
+				{Path: "products.1.price", Old: "2", New: "3"},
+				{Path: "products.2", Old: nil, New: "new"},
+			},
+		},
+		{
+			"addedRemoved",
+			map[string]any{"a": 1, "b": map[string]any{"c": 2}},
+			map[string]any{"a": 1, "d": 3},
+			[]Change{
+				{Path: "b", Old: map[string]any{"c": 2}, New: nil},
+				{Path: "d", Old: nil, New: 3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			if got := Diff(tt.Old, tt.New); !reflect.DeepEqual(got, tt.Want) {
+				t.Fatal("not equal")
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package colibri
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ShingleSize is the number of consecutive words of each feature of the Simhash fingerprint.
+const ShingleSize = 3
+
+// DefaultMaxDistance is the MaxDistance used by NearDuplicates if it is zero.
+const DefaultMaxDistance = 3
+
+// Simhash returns the simhash fingerprint of the text, similar texts have fingerprints
+// that differ in a few bits. The features are the shingles of ShingleSize words,
+// lowercased and without punctuation. It returns 0 and false if the text has no words.
+func Simhash(text string) (uint64, bool) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		return 0, false
+	}
+
+	size := min(ShingleSize, len(words))
+
+	var weights [64]int
+	for i := 0; i+size <= len(words); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:i+size], " ")))
+		sum := h.Sum64()
+
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var fingerprint uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			fingerprint |= 1 << bit
+		}
+	}
+	return fingerprint, true
+}
+
+// NearDuplicates stores the Simhash fingerprints of the textual content of the pages processed,
+// see the NearDuplicates field of Colibri. The pages whose fingerprint is within MaxDistance
+// of a fingerprint already stored are near-duplicates, e.g. the pages of a template that only
+// differ in a few words, and their selectors are not extracted nor followed.
+type NearDuplicates struct {
+	// MaxDistance specifies the maximum number of different bits, the Hamming distance,
+	// between the fingerprints of near-duplicate pages. If it is zero, DefaultMaxDistance is used,
+	// if it is negative only the pages with the same fingerprint are near-duplicates.
+	MaxDistance int
+
+	mu sync.Mutex
+
+	// blocks indexes the fingerprints by each of the MaxDistance+1 blocks of bits,
+	// two fingerprints within MaxDistance have at least one equal block.
+	blocks []map[uint64][]uint64
+}
+
+// distance returns the maximum distance between near-duplicate fingerprints.
+func (nd *NearDuplicates) distance() int {
+	switch {
+	case nd.MaxDistance == 0:
+		return DefaultMaxDistance
+	case nd.MaxDistance < 0:
+		return 0
+	}
+	return min(nd.MaxDistance, 63)
+}
+
+// block returns the i-th of the n blocks of bits of the fingerprint.
+func block(fingerprint uint64, i, n int) uint64 {
+	start, end := i*64/n, (i+1)*64/n
+	return (fingerprint >> start) & (1<<(end-start) - 1)
+}
+
+// Similar returns true if a fingerprint within MaxDistance of the fingerprint has already been stored,
+// otherwise the fingerprint is stored and it returns false.
+func (nd *NearDuplicates) Similar(fingerprint uint64) bool {
+	nd.mu.Lock()
+	defer nd.mu.Unlock()
+
+	n := nd.distance() + 1
+	if len(nd.blocks) != n {
+		nd.blocks = make([]map[uint64][]uint64, n)
+		for i := range nd.blocks {
+			nd.blocks[i] = make(map[uint64][]uint64)
+		}
+	}
+
+	for i, index := range nd.blocks {
+		for _, stored := range index[block(fingerprint, i, n)] {
+			if bits.OnesCount64(stored^fingerprint) < n {
+				return true
+			}
+		}
+	}
+
+	for i, index := range nd.blocks {
+		key := block(fingerprint, i, n)
+		index[key] = append(index[key], fingerprint)
+	}
+	return false
+}
+
+// Clear removes the fingerprints stored.
+func (nd *NearDuplicates) Clear() {
+	if nd == nil {
+		return
+	}
+
+	nd.mu.Lock()
+	nd.blocks = nil
+	nd.mu.Unlock()
+}
+
+// VisibleTextNode is a Node that returns the text visible to the readers of the document,
+// e.g. the text of an HTML document without the content of its script, style and noscript elements.
+type VisibleTextNode interface {
+	Node
+
+	// VisibleText returns the visible text of the node and of its descendants.
+	VisibleText() string
+}
+
+// checkNearDuplicate returns ErrNearDuplicate if the textual content of the root node is similar
+// to the content of a page already processed. The content is the visible text of the nodes that
+// implement VisibleTextNode, otherwise their value. The nodes whose value is not text are not checked.
+func (c *Colibri) checkNearDuplicate(node Node) error {
+	if c.NearDuplicates == nil {
+		return nil
+	}
+
+	var text string
+	if textNode, ok := node.(VisibleTextNode); ok {
+		text = textNode.VisibleText()
+	} else if text, ok = node.Value().(string); !ok {
+		return nil
+	}
+
+	if fingerprint, ok := Simhash(text); ok && c.NearDuplicates.Similar(fingerprint) {
+		return ErrNearDuplicate
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package colibri
+
+import (
+	"errors"
+	"math/bits"
+	"testing"
+)
+
+// textParser returns a node whose value is the text of the rules.
+type textParser struct{}
+
+func (p *textParser) Match(_ string) bool { return true }
+
+func (p *textParser) Parse(rules *Rules, _ Response) (Node, error) {
+	return &testNode{value: rules.Extra["text"]}, nil
+}
+
+func (p *textParser) Clear() {}
+
+func TestSimhash(t *testing.T) {
+	var (
+		page    = "Colibri is a Go package for web scraping, it extracts data from pages with rules in JSON. The rules contain the selectors of the data and the URLs to follow. Price: 10 dollars."
+		similar = "Colibri is a Go package for web scraping, it extracts data from pages with rules in JSON. The rules contain the selectors of the data and the URLs to follow. Price: 12 dollars."
+		other   = "The hummingbird is a small bird that can hover in mid-air by rapidly flapping its wings, it feeds on the nectar of flowers and on insects."
+	)
+
+	a, _ := Simhash(page)
+	b, _ := Simhash(similar)
+	o, _ := Simhash(other)
+
+	if d := bits.OnesCount64(a ^ b); d > 12 {
+		t.Fatalf("got distance %v between similar texts", d)
+	}
+
+	if d := bits.OnesCount64(a ^ o); d <= 12 {
+		t.Fatalf("got distance %v between different texts", d)
+	}
+
+	// The case and punctuation are ignored.
+	if c, _ := Simhash("COLIBRI is a Go package... for web scraping!"); c == a {
+		t.Fatal("different texts with the same fingerprint")
+	} else if d, _ := Simhash("colibri is a go package for web scraping"); c != d {
+		t.Fatalf("got %v, want %v", c, d)
+	}
+
+	if _, ok := Simhash(" ,. "); ok {
+		t.Fatalf("got %v, want %v", ok, false)
+	}
+}
+
+func TestNearDuplicatesSimilar(t *testing.T) {
+	nd := &NearDuplicates{MaxDistance: 2}
+
+	const fingerprint = 0xF0F0_0000_FFFF_0001
+	tests := []struct {
+		Name        string
+		Fingerprint uint64
+		Want        bool
+	}{
+		{"new", fingerprint, false},
+		{"same", fingerprint, true},
+		{"one bit", fingerprint ^ 1<<40, true},
+		{"two bits", fingerprint ^ (1<<3 | 1<<63), true},
+		{"three bits", fingerprint ^ (1<<3 | 1<<30 | 1<<63), false},
+		{"near the last stored", fingerprint ^ (1<<3 | 1<<30 | 1<<62 | 1<<63), true},
+	}
+
+	for _, tt := range tests {
+		if got := nd.Similar(tt.Fingerprint); got != tt.Want {
+			t.Fatalf("%v: got %v, want %v", tt.Name, got, tt.Want)
+		}
+	}
+
+	nd.Clear()
+	if nd.Similar(fingerprint) {
+		t.Fatal("fingerprint not cleared")
+	}
+
+	exact := &NearDuplicates{MaxDistance: -1}
+	if exact.Similar(fingerprint) || exact.Similar(fingerprint^1) || !exact.Similar(fingerprint) {
+		t.Fatal("want only equal fingerprints")
+	}
+}
+
+func TestExtractNearDuplicate(t *testing.T) {
+	c := New()
+	c.Client = &testClient{}
+	c.Parser = &textParser{}
+	c.NearDuplicates = &NearDuplicates{}
+
+	newRules := func(text any) *Rules {
+		return &Rules{
+			URL:       mustNewURL("http://example.com"),
+			Selectors: []*Selector{{Name: "title", Expr: "title"}},
+			Extra:     map[string]any{"text": text},
+		}
+	}
+
+	const page = "Product page of the shop. Name: colibri. Description: a small bird with a long beak. Shipping in three days to all the countries of the world."
+	if _, err := c.Extract(newRules(page)); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := c.Extract(newRules(page + " Sold out."))
+	if !errors.Is(err, ErrNearDuplicate) || !errors.Is(err, ErrDuplicateContent) {
+		t.Fatalf("got %v, want %v", err, ErrNearDuplicate)
+	} else if output.Data != nil {
+		t.Fatalf("got %v, want %v", output.Data, nil)
+	}
+
+	// The values that are not text are not compared.
+	for i := 0; i < 2; i++ {
+		if _, err := c.Extract(newRules(map[string]any{"name": "colibri"})); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := c.Extract(newRules("The hummingbird feeds on the nectar of flowers and on small insects.")); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Clear()
+	if _, err := c.Extract(newRules(page)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,11 +1,20 @@
 package colibri
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 )
 
+// CookieExpr is the type of the selectors whose expression is the name of a cookie
+// set by the response. These selectors do not depend on the parser.
+const CookieExpr = "cookie"
+
 type Node interface {
 	// Find finds the first child node that matches the selector.
 	Find(selector *Selector) (Node, error)
@@ -17,6 +26,21 @@ type Node interface {
 	Value() any
 }
 
+// NavigableNode is a Node that allows navigating the document tree relative to it.
+// Parsers whose content does not have a tree structure do not implement it.
+type NavigableNode interface {
+	Node
+
+	// Parent returns the parent node, returns nil if the node is the root.
+	Parent() Node
+
+	// NextSibling returns the next sibling node, returns nil if it does not exist.
+	NextSibling() Node
+
+	// Children returns the child nodes.
+	Children() []Node
+}
+
 func FindSelectors(rules *Rules, resp Response, parent Node) (map[string]any, error) {
 	if (resp == nil) || (parent == nil) {
 		return nil, nil
@@ -24,40 +48,197 @@ func FindSelectors(rules *Rules, resp Response, parent Node) (map[string]any, er
 
 	var (
 		result = make(map[string]any)
+		vars   map[string]any
 		errs   error
 	)
+
+	// The selectors with Var are found first, so their values are available to the rest.
 	for _, selector := range rules.Selectors {
-		found, err := findSelector(rules, resp, selector, parent)
+		if !selector.Var {
+			continue
+		}
+
+		found, ok, err := findSelectorOnError(rules, resp, selector, parent)
 		if err != nil {
 			errs = AddError(errs, selector.Name, err)
 			continue
+		} else if !ok {
+			continue
 		}
 		result[selector.Name] = found
+
+		if vars == nil {
+			vars = make(map[string]any)
+		}
+		vars[selector.Name] = found
+	}
+
+	if vars != nil {
+		// The variables are set in a copy of the rules, so the rules of the caller are not modified.
+		varsRules := *rules
+		varsRules.SetContext(WithVars(rules.Context(), vars))
+		rules = &varsRules
+	}
+
+	for _, selector := range rules.Selectors {
+		if selector.Var {
+			continue
+		}
+
+		found, ok, err := findSelectorOnError(rules, resp, selector, parent)
+		if err != nil {
+			errs = AddError(errs, selector.Name, err)
+		}
+
+		if ok {
+			result[selector.Name] = found
+		}
 	}
 	return result, errs
 }
 
+// findSelectorOnError finds the selector and applies its OnError policy.
+// Returns true if the value is added to the result, the error is nil if it is discarded by the policy.
+func findSelectorOnError(rules *Rules, resp Response, selector *Selector, parent Node) (any, bool, error) {
+	found, err := findSelector(rules, resp, selector, parent)
+
+	// The selectors with partial results are not retried, since the values already found would be lost.
+	// A retry that finds nothing does not hide the error.
+	if strings.EqualFold(selector.OnError, OnErrorRetry) {
+		var last time.Duration
+		for i := 0; (i < SelectorRetries) && (err != nil) && !keepPartial(rules, selector, found); i++ {
+			if rules.Backoff != nil {
+				last = rules.Backoff.Backoff(i+1, last)
+				if waitErr := sleepContext(rules.Context(), last); waitErr != nil {
+					break
+				}
+			}
+
+			retryFound, retryErr := findSelector(retryRules(rules), resp, selector, parent)
+			if (retryErr == nil) && (retryFound == nil) {
+				break
+			}
+			found, err = retryFound, retryErr
+		}
+	}
+
+	if err == nil {
+		return found, true, nil
+	}
+
+	switch strings.ToLower(selector.OnError) {
+	case OnErrorSkip:
+		return nil, false, nil
+	case OnErrorNull:
+		return nil, true, nil
+	}
+	return found, keepPartial(rules, selector, found), selectorError(rules, resp.URL(), err, selector.Name)
+}
+
+type retryKey struct{}
+
+// retryRules returns a copy of the rules whose context marks the requests of the selectors as retries,
+// so the URLs that they request are extracted again even if they were visited, see retriedFrom.
+func retryRules(rules *Rules) *Rules {
+	retry := *rules
+	retry.SetContext(context.WithValue(rules.Context(), retryKey{}, true))
+	return &retry
+}
+
+// retriedFrom returns true if the rules are requested again by a selector with OnErrorRetry.
+// The mark is removed from the context, so the URLs followed by the retried rules are not retried.
+func retriedFrom(rules *Rules) bool {
+	if (rules == nil) || (rules.Context().Value(retryKey{}) != true) {
+		return false
+	}
+
+	rules.SetContext(context.WithValue(rules.Context(), retryKey{}, false))
+	return true
+}
+
+// sleepContext waits for the duration or until the context is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// keepPartial returns true if the result of a selector with errors is kept.
+// The outputs of the followed URLs are always kept when other URLs fail or are out of scope,
+// the rest of the results are only kept if the rules have BestEffort.
+func keepPartial(rules *Rules, selector *Selector, found any) bool {
+	switch partial := found.(type) {
+	case nil:
+		return false
+	case []any:
+		return (selector.Follow || rules.BestEffort) && (len(partial) > 0)
+	case map[string]any:
+		return rules.BestEffort && (len(partial) > 0)
+	}
+	return rules.BestEffort
+}
+
 func findSelector(src *Rules, resp Response, selector *Selector, parent Node) (any, error) {
+	if strings.EqualFold(selector.Type, CookieExpr) {
+		return cookieSelector(resp, selector), nil
+	}
+
+	if strings.EqualFold(selector.Type, LinksExpr) {
+		return linksSelector(src, resp, selector, parent)
+	}
+
+	if strings.EqualFold(selector.Type, ImagesExpr) {
+		return imagesSelector(src, resp, selector, parent)
+	}
+
+	if selector.Paginate != nil {
+		return paginateSelector(src, resp, selector, parent)
+	}
+
 	if selector.All {
 		return findAllSelector(src, resp, selector, parent)
 	}
 
-	child, err := parent.Find(selector)
+	child, err := parent.Find(interpolateSelector(src, selector))
 	if err != nil {
-		return nil, err
+		return nil, exprError(err)
 	} else if child == nil {
 		return nil, nil
 	}
 
+	if selector.Asset {
+		rules := selectorRules(src, selector)
+		defer ReleaseRules(rules)
+
+		found, err := assetSelector(rules, resp, child.Value())
+		if len(found) == 0 {
+			return nil, err
+		}
+		return found[0], err
+	}
+
 	if selector.Follow {
-		rules := selector.Rules(src)
+		rules := selectorRules(src, selector)
 		defer ReleaseRules(rules)
 
-		return followSelector(rules, resp, child.Value())
+		return followSelector(rules, resp, selector, child.Value())
+	}
+
+	if selector.Submit != nil {
+		rules := selectorRules(src, selector)
+		defer ReleaseRules(rules)
+
+		return formSelector(rules, resp, selector.Submit, child)
 	}
 
 	if len(selector.Selectors) > 0 {
-		rules := selector.Rules(src)
+		rules := selectorRules(src, selector)
 		defer ReleaseRules(rules)
 
 		return FindSelectors(rules, resp, child)
@@ -66,24 +247,36 @@ func findSelector(src *Rules, resp Response, selector *Selector, parent Node) (a
 }
 
 func findAllSelector(src *Rules, resp Response, selector *Selector, parent Node) ([]any, error) {
-	children, err := parent.FindAll(selector)
+	children, err := parent.FindAll(interpolateSelector(src, selector))
 	if err != nil {
-		return nil, err
+		return nil, exprError(err)
+	}
+
+	if selector.Submit != nil {
+		rules := selectorRules(src, selector)
+		defer ReleaseRules(rules)
+
+		return formSelector(rules, resp, selector.Submit, children...)
 	}
 
 	var (
 		result []any
 		errs   error
 	)
-	if !selector.Follow && (len(selector.Selectors) > 0) {
-		rules := selector.Rules(src)
+	if !selector.Follow && !selector.Asset && (len(selector.Selectors) > 0) {
+		rules := selectorRules(src, selector)
 		defer ReleaseRules(rules)
 
+		ctx := rules.Context()
 		for i, child := range children {
+			rules.SetContext(withSelectorPath(ctx, strconv.Itoa(i)))
+
 			found, err := FindSelectors(rules, resp, child)
 			if err != nil {
 				errs = AddError(errs, strconv.Itoa(i), err)
-				continue
+				if !rules.BestEffort {
+					continue
+				}
 			}
 			result = append(result, found)
 		}
@@ -95,16 +288,227 @@ func findAllSelector(src *Rules, resp Response, selector *Selector, parent Node)
 		result = append(result, child.Value())
 	}
 
+	if selector.Asset {
+		rules := selectorRules(src, selector)
+		defer ReleaseRules(rules)
+
+		return assetSelector(rules, resp, result...)
+	}
+
 	if selector.Follow {
-		rules := selector.Rules(src)
+		rules := selectorRules(src, selector)
 		defer ReleaseRules(rules)
 
-		return followSelector(rules, resp, result...)
+		return followSelector(rules, resp, selector, result...)
 	}
 	return result, errs
 }
 
-func followSelector(rules *Rules, resp Response, rawURL ...any) ([]any, error) {
+// ValueVar is the variable of the value found by a selector with Follow and URL,
+// see the URL field of the Selector structure.
+const ValueVar = "value"
+
+// followSelector follows the URLs with the rules of the selector or of its routes,
+// up to the Concurrency of the selector URLs are extracted at the same time.
+func followSelector(rules *Rules, resp Response, selector *Selector, rawURL ...any) ([]any, error) {
+	// The URL template of the selectors created without UnmarshalJSON is not validated.
+	if (rules.URL == nil) && (selector.URL != "") {
+		if _, err := url.Parse(selector.URL); err != nil {
+			return nil, err
+		}
+	}
+
+	var followRules []*Rules
+	if rules.URL != nil {
+		followRules = templateRules(rules, resp, rawURL...)
+	} else {
+		urls, errs := resolveURLs(rules, resp, rawURL...)
+		if errs != nil {
+			return nil, errs
+		}
+		urls = nofollowURLs(rules, urls)
+
+		followRules = make([]*Rules, 0, len(urls))
+		for _, u := range urls {
+			cRules := rules.Clone()
+			cRules.URL = rules.Normalize.URL(u)
+			followRules = append(followRules, cRules)
+		}
+	}
+
+	for i, cRules := range followRules {
+		followRules[i] = routeRules(cRules, selector)
+	}
+	return follow(resp, followRules, selector.Concurrency)
+}
+
+// templateRules returns a copy of the rules for each value, in which the URL is the URL template of the rules
+// with the value as the ValueVar variable. Relative URLs are resolved with the URL of the response.
+func templateRules(rules *Rules, resp Response, values ...any) []*Rules {
+	followRules := make([]*Rules, 0, len(values))
+	for _, value := range values {
+		cRules := rules.Clone()
+		cRules.SetContext(WithVars(cRules.Context(), map[string]any{ValueVar: value}))
+
+		u := interpolateURL(rules.URL, VarsFrom(cRules.Context()))
+		if !u.IsAbs() {
+			u = resp.URL().ResolveReference(u)
+		}
+		cRules.URL = rules.Normalize.URL(u)
+		followRules = append(followRules, cRules)
+	}
+	return followRules
+}
+
+// follow extracts the data of the rules and returns the output of each one.
+// When crawling, the rules are added to the crawl frontier and their URLs are returned.
+// The rules whose URL is out of their Scope are not followed.
+//
+// If concurrency is greater than 1, up to concurrency rules are extracted at the same time,
+// the outputs keep the order of the rules.
+func follow(resp Response, followRules []*Rules, concurrency int) ([]any, error) {
+	var (
+		inScope = followRules[:0]
+		errs    error
+	)
+
+	for _, cRules := range followRules {
+		if err := cRules.Scope.Check(cRules.URL); err != nil {
+			errs = AddError(errs, cRules.URL.String(), selectorError(cRules, cRules.URL, err))
+			ReleaseRules(cRules)
+			continue
+		}
+
+		sameHostCredentials(cRules, resp.URL())
+		inScope = append(inScope, cRules)
+	}
+	followRules = inScope
+
+	if len(followRules) == 0 {
+		return nil, errs
+	}
+
+	var result []any
+	if cr := crawlFrom(followRules[0].Context()); cr != nil {
+		for _, cRules := range followRules {
+			cr.push(cRules)
+			result = append(result, cRules.URL.String())
+		}
+		return result, errs
+	}
+
+	outputs, outErrs := extractAll(resp, followRules, concurrency)
+	for i, cRules := range followRules {
+		out, err := outputs[i], outErrs[i]
+		if errors.Is(err, ErrAlreadyVisited) || errors.Is(err, ErrDuplicateContent) {
+			ReleaseRules(cRules)
+			continue
+		} else if err != nil {
+			errs = AddError(errs, cRules.URL.String(), selectorError(cRules, cRules.URL, err))
+
+			// With BestEffort, the outputs with partial data are kept.
+			if !cRules.BestEffort || (out == nil) || (out.Data == nil) {
+				continue
+			}
+		}
+
+		if out.Noindex {
+			ReleaseRules(cRules)
+			continue
+		}
+
+		result = append(result, out.Serializable())
+		ReleaseRules(cRules)
+	}
+
+	return result, errs
+}
+
+// extractAll extracts the rules with up to concurrency extractions at the same time,
+// returns the output and the error of each rules in order.
+func extractAll(resp Response, rules []*Rules, concurrency int) ([]*Output, []error) {
+	var (
+		outputs = make([]*Output, len(rules))
+		errs    = make([]error, len(rules))
+	)
+
+	if len(rules) == 0 {
+		return outputs, errs
+	}
+
+	var (
+		cache = followCacheFrom(rules[0].Context())
+		c     = colibriFrom(rules[0].Context())
+	)
+	if concurrency < 2 {
+		for i, r := range rules {
+			outputs[i], errs[i] = cache.extract(resp, r)
+			c.followed(r.URL, outputs[i], errs[i])
+		}
+		return outputs, errs
+	}
+
+	pool := newWorkerPool(context.Background(), concurrency, c.workerMetrics())
+	for i, r := range rules {
+		pool.Go(func(context.Context) {
+			outputs[i], errs[i] = cache.extract(resp, r)
+			c.followed(r.URL, outputs[i], errs[i])
+		}, func(err *PanicError) {
+			errs[i] = err
+		})
+	}
+
+	pool.Wait()
+	return outputs, errs
+}
+
+// assetSelector stores the assets with the Media of Colibri
+// and returns the location in which each one is stored.
+func assetSelector(rules *Rules, resp Response, rawURL ...any) ([]any, error) {
+	c := colibriFrom(rules.Context())
+	if (c == nil) || (c.Media == nil) {
+		return nil, ErrMediaIsNil
+	}
+
+	urls, errs := resolveURLs(rules, resp, rawURL...)
+	if errs != nil {
+		return nil, errs
+	}
+
+	assets := make([]*Rules, 0, len(urls))
+	for _, u := range urls {
+		aRules := rules.Clone()
+		aRules.URL = u
+		sameHostCredentials(aRules, resp.URL())
+		assets = append(assets, aRules)
+	}
+
+	locations, err := c.Media.Store(resp, assets)
+	for _, aRules := range assets {
+		ReleaseRules(aRules)
+	}
+
+	var result []any
+	for _, location := range locations {
+		if location != "" {
+			result = append(result, location)
+		}
+	}
+	return result, err
+}
+
+// exprError returns the error of the evaluation of a selector expression with the CodeExprInvalid code,
+// unless the error already has a code.
+func exprError(err error) error {
+	if ErrorCode(err) != "" {
+		return err
+	}
+	return WithCode(CodeExprInvalid, err)
+}
+
+// resolveURLs converts the values to URLs,
+// relative URLs are resolved with the URL of the response.
+func resolveURLs(rules *Rules, resp Response, rawURL ...any) ([]*url.URL, error) {
 	var (
 		urls []*url.URL
 		errs error
@@ -113,7 +517,7 @@ func followSelector(rules *Rules, resp Response, rawURL ...any) ([]any, error) {
 	for _, rawU := range rawURL {
 		u, err := ToURL(rawU)
 		if err != nil {
-			errs = AddError(errs, fmt.Sprintf("%v", rawU), err)
+			errs = AddError(errs, fmt.Sprintf("%v", rawU), selectorError(rules, resp.URL(), err))
 			continue
 		}
 
@@ -122,25 +526,37 @@ func followSelector(rules *Rules, resp Response, rawURL ...any) ([]any, error) {
 		}
 		urls = append(urls, u)
 	}
+	return urls, errs
+}
 
-	if errs != nil {
-		return nil, errs
+// cookieSelector returns the value of the cookie named by the expression of the selector
+// that is set by the response. If the selector has All, all the values are returned.
+func cookieSelector(resp Response, selector *Selector) any {
+	if resp.Header() == nil {
+		return nil
 	}
 
 	var result []any
-	for _, u := range urls {
-		cRules := rules.Clone()
-		cRules.URL = u
-
-		out, err := resp.Extract(cRules)
-		if err != nil {
-			errs = AddError(errs, u.String(), err)
+	for _, cookie := range (&http.Response{Header: resp.Header()}).Cookies() {
+		if cookie.Name != selector.Expr {
 			continue
 		}
 
-		result = append(result, out.Serializable())
-		ReleaseRules(cRules)
+		if !selector.All {
+			return cookie.Value
+		}
+		result = append(result, cookie.Value)
 	}
 
-	return result, errs
+	if result == nil {
+		return nil
+	}
+	return result
+}
+
+type colibriKey struct{}
+
+func colibriFrom(ctx context.Context) *Colibri {
+	c, _ := ctx.Value(colibriKey{}).(*Colibri)
+	return c
 }
@@ -4,11 +4,16 @@ import (
 	"encoding/json"
 	"errors"
 	"reflect"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestFindSelectors(t *testing.T) {
-	var testErr = errors.New("test err")
+	var (
+		testErr = errors.New("test err")
+		exprErr = map[string]any{"code": CodeExprInvalid, "error": "test err"}
+	)
 
 	c := New()
 	c.Client = &testClient{}
@@ -105,12 +110,12 @@ func TestFindSelectors(t *testing.T) {
 			Parent: &testNode{},
 			Output: nil,
 			ErrMap: map[string]any{
-				"title": "test err",
+				"title": exprErr,
 				"body": map[string]any{
-					"urls": "test err",
+					"urls": exprErr,
 					"imgs": map[string]any{
 						"0": map[string]any{
-							"src": "test err",
+							"src": exprErr,
 						},
 					},
 				},
@@ -220,7 +225,7 @@ func TestFindSelectors(t *testing.T) {
 				return
 
 			} else if (err == nil) && (tt.ErrMap == nil) {
-				if !reflect.DeepEqual(output, tt.Output) {
+				if !reflect.DeepEqual(deleteMeta(output), deleteMeta(tt.Output)) {
 					t.Fatal("not equal")
 				}
 				return
@@ -230,3 +235,240 @@ func TestFindSelectors(t *testing.T) {
 		})
 	}
 }
+
+func TestBestEffort(t *testing.T) {
+	c := New()
+	c.Client = &testClient{}
+	c.Parser = &testParser{}
+
+	selectors := []*Selector{
+		{
+			Name: "body",
+			Expr: "//body",
+			Selectors: []*Selector{
+				{Name: "number", Expr: "!number"},
+				{Name: "title", Expr: "!error"},
+			},
+		},
+		{
+			Name: "items",
+			Expr: "//li",
+			All:  true,
+			Selectors: []*Selector{
+				{Name: "number", Expr: "!number"},
+				{Name: "title", Expr: "!error"},
+			},
+		},
+	}
+
+	tests := []struct {
+		BestEffort bool
+		Output     map[string]any
+	}{
+		{false, map[string]any{}},
+		{true, map[string]any{
+			"body":  map[string]any{"number": 505},
+			"items": []any{map[string]any{"number": 505}},
+		}},
+	}
+
+	for _, tt := range tests {
+		rules := &Rules{Selectors: selectors, BestEffort: tt.BestEffort}
+
+		output, err := FindSelectors(rules, &testResponse{c: c}, &testNode{})
+		if err == nil {
+			t.Fatal("nil error")
+		}
+
+		errs := err.(*Errs)
+		if _, ok := errs.Get("body"); !ok {
+			t.Fatalf("%v: missing body error", tt.BestEffort)
+		} else if _, ok := errs.Get("items"); !ok {
+			t.Fatalf("%v: missing items error", tt.BestEffort)
+		}
+
+		if !reflect.DeepEqual(output, tt.Output) {
+			t.Fatalf("%v: got %v, want %v", tt.BestEffort, output, tt.Output)
+		}
+	}
+}
+
+// flakyNode is a testNode whose Find fails the first times.
+type flakyNode struct {
+	testNode
+	fails int
+	calls int
+}
+
+func (node *flakyNode) Find(selector *Selector) (Node, error) {
+	node.calls++
+	if node.calls <= node.fails {
+		return nil, errors.New("test err")
+	}
+	return node.testNode.Find(selector)
+}
+
+func TestOnError(t *testing.T) {
+	tests := []struct {
+		OnError string
+		Fails   int
+		Calls   int
+		Output  map[string]any
+		Err     bool
+	}{
+		{"", 1, 1, map[string]any{}, true},
+		{OnErrorFail, 1, 1, map[string]any{}, true},
+		{OnErrorSkip, 1, 1, map[string]any{}, false},
+		{OnErrorNull, 1, 1, map[string]any{"number": nil}, false},
+		{OnErrorRetry, 2, 3, map[string]any{"number": 505}, false},
+		{OnErrorRetry, 5, 1 + SelectorRetries, map[string]any{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.OnError, func(t *testing.T) {
+			rules := &Rules{Selectors: []*Selector{{Name: "number", Expr: "!number", OnError: tt.OnError}}}
+			parent := &flakyNode{fails: tt.Fails}
+
+			output, err := FindSelectors(rules, &testResponse{}, parent)
+			if (err != nil) != tt.Err {
+				t.Fatalf("got %v, want error %v", err, tt.Err)
+			}
+
+			if !reflect.DeepEqual(output, tt.Output) {
+				t.Fatalf("got %v, want %v", output, tt.Output)
+			}
+
+			if parent.calls != tt.Calls {
+				t.Fatalf("got %v calls, want %v", parent.calls, tt.Calls)
+			}
+		})
+	}
+}
+
+// flakyClient is a testClient whose first request to each URL fails.
+type flakyClient struct {
+	testClient
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (client *flakyClient) Do(c *Colibri, rules *Rules) (Response, error) {
+	client.mu.Lock()
+	client.calls[rules.URL.String()]++
+	calls := client.calls[rules.URL.String()]
+	client.mu.Unlock()
+
+	if calls == 1 {
+		return nil, errors.New("test err")
+	}
+	return client.testClient.Do(c, rules)
+}
+
+type countBackoff struct{ calls int }
+
+func (b *countBackoff) Backoff(_ int, _ time.Duration) time.Duration {
+	b.calls++
+	return time.Millisecond
+}
+
+func TestOnErrorRetryFollow(t *testing.T) {
+	// Only the request to the followed URL fails.
+	client := &flakyClient{calls: map[string]int{"http://example.com/root": 1}}
+	backoff := &countBackoff{}
+
+	c := New()
+	c.Client = client
+	c.Parser = &testParser{}
+	c.Visited = &testVisited{}
+
+	rules := &Rules{
+		URL:     mustNewURL("http://example.com/root"),
+		Backoff: backoff,
+		Selectors: []*Selector{{
+			Name:      "follow",
+			Expr:      "//a/@href",
+			Follow:    true,
+			OnError:   OnErrorRetry,
+			Selectors: []*Selector{{Name: "title", Expr: "//title"}},
+		}},
+	}
+
+	output, err := c.Extract(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := output.Data["follow"]; !ok {
+		t.Fatalf("got %v, want %v", output.Data, "follow")
+	}
+
+	if calls := client.calls["http://example.com/test"]; calls != 2 {
+		t.Fatalf("got %v calls, want %v", calls, 2)
+	}
+
+	if backoff.calls != 1 {
+		t.Fatalf("got %v backoff calls, want %v", backoff.calls, 1)
+	}
+}
+
+func TestFollowInvalidURL(t *testing.T) {
+	c := New()
+	c.Client = &testClient{}
+	c.Parser = &testParser{}
+
+	// The selector is not created with UnmarshalJSON, its URL template is not validated before.
+	rules := &Rules{
+		URL: mustNewURL("http://example.com"),
+		Selectors: []*Selector{{
+			Name:   "follow",
+			Expr:   "//a/@href",
+			Follow: true,
+			URL:    "http://[::1",
+		}},
+	}
+
+	if _, err := c.Extract(rules); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// pairNode is a testNode whose FindAll finds a node and a node that fails.
+type pairNode struct{ testNode }
+
+func (node *pairNode) FindAll(_ *Selector) ([]Node, error) {
+	return []Node{&testNode{}, &flakyNode{fails: 1}}, nil
+}
+
+func TestPartialResults(t *testing.T) {
+	tests := []struct {
+		Name       string
+		BestEffort bool
+		Want       map[string]any
+	}{
+		{"discarded", false, map[string]any{}},
+		{"BestEffort", true, map[string]any{"all": []any{map[string]any{"title": "test"}, map[string]any{}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			rules := &Rules{
+				BestEffort: tt.BestEffort,
+				Selectors: []*Selector{{
+					Name:      "all",
+					Expr:      "//li",
+					All:       true,
+					Selectors: []*Selector{{Name: "title", Expr: "//title"}},
+				}},
+			}
+
+			output, err := FindSelectors(rules, &testResponse{}, &pairNode{})
+			if err == nil {
+				t.Fatalf("got %v, want error", err)
+			}
+
+			if !reflect.DeepEqual(output, tt.Want) {
+				t.Fatalf("got %v, want %v", output, tt.Want)
+			}
+		})
+	}
+}
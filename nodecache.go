@@ -0,0 +1,51 @@
+package colibri
+
+import "context"
+
+// ExtractResponse finds the selectors of the rules in the root node stored in the response,
+// see NodeCacher, so other selectors can be found in a response already extracted without
+// requesting and parsing it again. The URL of the rules is replaced by the URL of the response.
+//
+// If the response does not implement NodeCacher or its root node was invalidated,
+// the URL of the response is extracted with the rules.
+func (c *Colibri) ExtractResponse(resp Response, rules *Rules) (*Output, error) {
+	if rules == nil {
+		return nil, ErrRulesIsNil
+	} else if resp == nil {
+		return c.Extract(rules)
+	}
+
+	newRules := rules.Clone()
+	defer ReleaseRules(newRules)
+
+	newRules.URL = resp.URL()
+	if nc, ok := resp.(NodeCacher); ok {
+		if node := nc.CachedNode(); node != nil {
+			newRules.SetContext(context.WithValue(newRules.Context(), cachedNodeKey{}, &cachedNode{resp, node}))
+		}
+	}
+	return c.Extract(newRules)
+}
+
+type cachedNodeKey struct{}
+
+type cachedNode struct {
+	resp Response
+	node Node
+}
+
+// cachedNodeFrom returns the response and root node passed by ExtractResponse in the context of the rules,
+// they are removed from the context so the rules of the selectors do not receive them.
+func cachedNodeFrom(rules *Rules) *cachedNode {
+	if rules == nil {
+		return nil
+	}
+
+	cached, _ := rules.Context().Value(cachedNodeKey{}).(*cachedNode)
+	if cached == nil {
+		return nil
+	}
+
+	rules.SetContext(context.WithValue(rules.Context(), cachedNodeKey{}, (*cachedNode)(nil)))
+	return cached
+}
@@ -0,0 +1,87 @@
+package colibri
+
+import "testing"
+
+type nodeResponse struct {
+	testResponse
+	node Node
+}
+
+func (resp *nodeResponse) CachedNode() Node { return resp.node }
+
+func (resp *nodeResponse) CacheNode(node Node) { resp.node = node }
+
+func (resp *nodeResponse) Invalidate() { resp.node = nil }
+
+type nodeClient struct {
+	calls int
+}
+
+func (client *nodeClient) Do(c *Colibri, _ *Rules) (Response, error) {
+	client.calls++
+	return &nodeResponse{testResponse: testResponse{c: c}}, nil
+}
+
+func (client *nodeClient) Clear() {}
+
+func TestExtractResponse(t *testing.T) {
+	var (
+		client = &nodeClient{}
+		parser = &testParser{}
+	)
+
+	c := New()
+	c.Client = client
+	c.Parser = parser
+
+	// The root node is only stored with CacheNode.
+	out, err := c.Extract(&Rules{
+		URL:       mustNewURL("http://example.com"),
+		Selectors: []*Selector{{Name: "title", Expr: "title"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out.Response.(*nodeResponse).CachedNode() != nil {
+		t.Fatal("root node stored without CacheNode")
+	}
+
+	out, err = c.Extract(&Rules{
+		URL:       mustNewURL("http://example.com"),
+		Selectors: []*Selector{{Name: "title", Expr: "title"}},
+		CacheNode: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := out.Response.(*nodeResponse)
+	if resp.CachedNode() == nil {
+		t.Fatal("root node not stored")
+	}
+
+	parser.ParseUsed = false
+	out, err = c.ExtractResponse(resp, &Rules{Selectors: []*Selector{{Name: "number", Expr: "!number"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (client.calls != 2) || parser.ParseUsed {
+		t.Fatalf("got %v requests and parsed %v, want %v and %v", client.calls, parser.ParseUsed, 2, false)
+	}
+
+	if v := out.Data["number"]; v != 505 {
+		t.Fatalf("got %v, want %v", v, 505)
+	}
+
+	// The invalidated responses are requested and parsed again.
+	resp.Invalidate()
+	if _, err = c.ExtractResponse(resp, &Rules{Selectors: []*Selector{{Name: "title", Expr: "title"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if (client.calls != 3) || !parser.ParseUsed {
+		t.Fatalf("got %v requests and parsed %v, want %v and %v", client.calls, parser.ParseUsed, 3, true)
+	}
+}
@@ -0,0 +1,130 @@
+package colibri
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RobotsNode is a Node of a document that contains indexing and crawling directives,
+// e.g. the robots meta tags and the rel="nofollow" links of an HTML document.
+// See the RespectNofollow field of the Rules structure.
+type RobotsNode interface {
+	Node
+
+	// Robots returns the directives of the robots meta tags of the document, e.g. "noindex" or "nofollow".
+	Robots() []string
+
+	// NofollowLinks returns the URLs of the links of the document marked with rel="nofollow".
+	NofollowLinks() []string
+}
+
+// robots contains the directives of a page that are honored by the selectors with Follow.
+type robots struct {
+	nofollow bool
+	links    map[string]bool
+}
+
+type robotsKey struct{}
+
+func robotsFrom(ctx context.Context) *robots {
+	r, _ := ctx.Value(robotsKey{}).(*robots)
+	return r
+}
+
+// XRobotsTag returns the directives of the X-Robots-Tag header,
+// the directives addressed to a specific user agent are ignored.
+func XRobotsTag(header http.Header) []string {
+	var directives []string
+	for _, value := range header.Values("X-Robots-Tag") {
+		// The values addressed to a user agent start with its name followed by a colon, e.g. "googlebot: noindex".
+		if i := strings.Index(value, ":"); i >= 0 {
+			if agent := strings.TrimSpace(value[:i]); !strings.Contains(agent, ",") && !isDirective(agent) {
+				continue
+			}
+		}
+		directives = append(directives, splitDirectives(value)...)
+	}
+	return directives
+}
+
+// isDirective returns true if the name is a robots directive that has a value.
+func isDirective(name string) bool {
+	switch strings.ToLower(name) {
+	case "unavailable_after", "max-snippet", "max-image-preview", "max-video-preview":
+		return true
+	}
+	return false
+}
+
+// splitDirectives splits a comma-separated list of directives.
+func splitDirectives(value string) []string {
+	var directives []string
+	for _, directive := range strings.Split(value, ",") {
+		if directive = strings.ToLower(strings.TrimSpace(directive)); directive != "" {
+			directives = append(directives, directive)
+		}
+	}
+	return directives
+}
+
+// applyRobots finds the directives of the response and the parsed document,
+// the nofollow directives and links are added to the context of the rules.
+// Returns true if the page must not be indexed.
+func applyRobots(rules *Rules, resp Response, parent Node) (noindex bool) {
+	r := &robots{}
+
+	directives := XRobotsTag(resp.Header())
+	if node, ok := parent.(RobotsNode); ok {
+		for _, directive := range node.Robots() {
+			directives = append(directives, splitDirectives(directive)...)
+		}
+
+		for _, link := range node.NofollowLinks() {
+			u, err := url.Parse(link)
+			if err != nil {
+				continue
+			}
+
+			if r.links == nil {
+				r.links = make(map[string]bool)
+			}
+			r.links[resp.URL().ResolveReference(u).String()] = true
+		}
+	}
+
+	for _, directive := range directives {
+		switch directive {
+		case "noindex":
+			noindex = true
+		case "nofollow":
+			r.nofollow = true
+		case "none":
+			noindex, r.nofollow = true, true
+		}
+	}
+
+	rules.SetContext(context.WithValue(rules.Context(), robotsKey{}, r))
+	return noindex
+}
+
+// nofollowURLs removes the URLs that must not be followed according to the robots directives of the rules.
+func nofollowURLs(rules *Rules, urls []*url.URL) []*url.URL {
+	r := robotsFrom(rules.Context())
+	if r == nil {
+		return urls
+	}
+
+	if r.nofollow {
+		return nil
+	}
+
+	result := urls[:0]
+	for _, u := range urls {
+		if !r.links[u.String()] {
+			result = append(result, u)
+		}
+	}
+	return result
+}
@@ -0,0 +1,23 @@
+package colibri
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestXRobotsTag(t *testing.T) {
+	header := http.Header{"X-Robots-Tag": {
+		"noindex, NoFollow",
+		"googlebot: noarchive",
+		"unavailable_after: 25 Jun 2010 15:00:00 PST",
+		"nosnippet, max-snippet: 20",
+	}}
+
+	got := XRobotsTag(header)
+	want := []string{"noindex", "nofollow", "unavailable_after: 25 jun 2010 15:00:00 pst", "nosnippet", "max-snippet: 20"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatal("not equal")
+	}
+}
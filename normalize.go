@@ -0,0 +1,137 @@
+package colibri
+
+import (
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidNormalize is returned when the value is not a valid Normalize configuration.
+var ErrInvalidNormalize = errors.New("invalid normalize")
+
+// TrackingParams contains the query parameters used to track visits,
+// they are removed by the Normalize configurations with StripTracking.
+// The parameters ending in * match any parameter with that prefix.
+var TrackingParams = []string{
+	"utm_*", "gclid", "gclsrc", "dclid", "fbclid", "msclkid", "yclid", "twclid",
+	"mc_cid", "mc_eid", "_ga", "_gl", "igshid", "ref_src",
+}
+
+// Normalize specifies how the URLs found by the selectors with Follow are normalized
+// before being followed, so the same page is not requested with different URLs.
+type Normalize struct {
+	// StripFragment specifies whether the fragment is removed.
+	StripFragment bool `json:"stripFragment,omitempty"`
+
+	// StripTracking specifies whether the query parameters of TrackingParams are removed.
+	StripTracking bool `json:"stripTracking,omitempty"`
+
+	// StripParams contains the names of other query parameters that are removed,
+	// the names ending in * match any parameter with that prefix.
+	StripParams []string `json:"stripParams,omitempty"`
+
+	// SortQuery specifies whether the query parameters are sorted by name.
+	SortQuery bool `json:"sortQuery,omitempty"`
+
+	// Lowercase specifies whether the scheme and the host are lowercase.
+	Lowercase bool `json:"lowercase,omitempty"`
+}
+
+// URL returns a normalized copy of the URL.
+func (n *Normalize) URL(u *url.URL) *url.URL {
+	newURL := u.ResolveReference(&url.URL{})
+	if n == nil {
+		return newURL
+	}
+
+	if n.StripFragment {
+		newURL.Fragment = ""
+		newURL.RawFragment = ""
+	}
+
+	if n.Lowercase {
+		newURL.Scheme = strings.ToLower(newURL.Scheme)
+		newURL.Host = strings.ToLower(newURL.Host)
+	}
+
+	if newURL.RawQuery == "" {
+		return newURL
+	}
+
+	// The parameters are processed without decoding their values, so their encoding is preserved.
+	params := strings.Split(newURL.RawQuery, "&")
+	if n.StripTracking || (len(n.StripParams) > 0) {
+		result := params[:0]
+		for _, param := range params {
+			if param == "" {
+				continue
+			}
+
+			name := paramName(param)
+			if (n.StripTracking && matchParam(TrackingParams, name)) || matchParam(n.StripParams, name) {
+				continue
+			}
+			result = append(result, param)
+		}
+		params = result
+	}
+
+	if n.SortQuery {
+		sort.SliceStable(params, func(i, j int) bool {
+			return paramName(params[i]) < paramName(params[j])
+		})
+	}
+
+	newURL.RawQuery = strings.Join(params, "&")
+	newURL.ForceQuery = false
+	return newURL
+}
+
+// Clone returns a copy of the Normalize configuration.
+func (n *Normalize) Clone() *Normalize {
+	if n == nil {
+		return nil
+	}
+
+	newNormalize := *n
+	newNormalize.StripParams = append([]string(nil), n.StripParams...)
+	return &newNormalize
+}
+
+func toNormalize(value any) (*Normalize, error) {
+	normalizeMap, ok := value.(map[string]any)
+	if !ok {
+		return nil, ErrInvalidNormalize
+	}
+
+	n := &Normalize{}
+	if err := processRaw(normalizeMap, n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// paramName returns the decoded name of a query parameter.
+func paramName(param string) string {
+	name, _, _ := strings.Cut(param, "=")
+	if unescaped, err := url.QueryUnescape(name); err == nil {
+		return unescaped
+	}
+	return name
+}
+
+// matchParam returns true if the name matches a pattern,
+// the patterns ending in * match the names with that prefix.
+func matchParam(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if pattern == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package colibri
+
+import (
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Normalize *Normalize
+		URL       string
+		Want      string
+	}{
+		{"nil", nil, "http://Example.com/a?b=1#c", "http://Example.com/a?b=1#c"},
+		{"stripFragment", &Normalize{StripFragment: true}, "https://example.com/a#c", "https://example.com/a"},
+		{"lowercase", &Normalize{Lowercase: true}, "https://Example.COM/Path", "https://example.com/Path"},
+		{
+			"stripTracking",
+			&Normalize{StripTracking: true},
+			"https://example.com/?utm_source=x&id=1&gclid=abc&utm_medium=y",
+			"https://example.com/?id=1",
+		},
+		{
+			"stripParams",
+			&Normalize{StripParams: []string{"session*", "ref"}},
+			"https://example.com/?sessionid=1&ref=2&reference=3",
+			"https://example.com/?reference=3",
+		},
+		{
+			"sortQuery",
+			&Normalize{SortQuery: true},
+			"https://example.com/?b=2&a=1&c=%20+&a=0",
+			"https://example.com/?a=1&a=0&b=2&c=%20+",
+		},
+		{
+			"emptyQuery",
+			&Normalize{StripTracking: true},
+			"https://example.com/?utm_source=x",
+			"https://example.com/",
+		},
+		{
+			"all",
+			&Normalize{StripFragment: true, StripTracking: true, SortQuery: true, Lowercase: true},
+			"https://EXAMPLE.com/p?z=1&fbclid=x&a=2#top",
+			"https://example.com/p?a=2&z=1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			u := mustNewURL(tt.URL)
+
+			got := tt.Normalize.URL(u).String()
+			if got != tt.Want {
+				t.Fatalf("got %v, want %v", got, tt.Want)
+			}
+
+			if u.String() != tt.URL {
+				t.Fatal("original URL modified")
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package colibri
+
+// Option configures a copy of a Colibri, see Colibri.With.
+type Option func(c *Colibri)
+
+// With returns a shallow copy of c configured with the options, c is not modified.
+// So a base instance can serve concurrent requests with different components, e.g. another Parser or Delay,
+// without changing its fields while they are in use.
+//
+// The copy shares the components that are not replaced, the Stats, the Progress, the WorkerStats
+// and the lifecycle of c: Shutdown of either of them stops both.
+func (c *Colibri) With(opts ...Option) *Colibri {
+	newC := *c
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&newC)
+		}
+	}
+	return &newC
+}
+
+// WithClient sets the Client.
+func WithClient(client Client) Option {
+	return func(c *Colibri) { c.Client = client }
+}
+
+// WithDelay sets the Delay.
+func WithDelay(delay Delay) Option {
+	return func(c *Colibri) { c.Delay = delay }
+}
+
+// WithRobotsTxt sets the RobotsTxt.
+func WithRobotsTxt(robotsTxt RobotsTxt) Option {
+	return func(c *Colibri) { c.RobotsTxt = robotsTxt }
+}
+
+// WithParser sets the Parser.
+func WithParser(parser Parser) Option {
+	return func(c *Colibri) { c.Parser = parser }
+}
+
+// WithVisited sets the Visited.
+func WithVisited(visited Visited) Option {
+	return func(c *Colibri) { c.Visited = visited }
+}
+
+// WithDuplicates sets the Duplicates.
+func WithDuplicates(duplicates Duplicates) Option {
+	return func(c *Colibri) { c.Duplicates = duplicates }
+}
+
+// WithNearDuplicates sets the NearDuplicates.
+func WithNearDuplicates(nearDuplicates *NearDuplicates) Option {
+	return func(c *Colibri) { c.NearDuplicates = nearDuplicates }
+}
+
+// WithMedia sets the Media.
+func WithMedia(media Media) Option {
+	return func(c *Colibri) { c.Media = media }
+}
@@ -0,0 +1,52 @@
+package colibri
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestWith(t *testing.T) {
+	var (
+		parser = &testParser{}
+		delay  = &testDelay{}
+	)
+
+	c := New()
+	c.Client = &testClient{}
+	c.Parser = &testParser{}
+
+	newC := c.With(WithParser(parser), WithDelay(delay), nil)
+	if (newC == c) || (newC.Parser != parser) || (newC.Delay != delay) || (newC.Client != c.Client) {
+		t.Fatalf("got %+v, want a copy with the options", newC)
+	}
+
+	if (c.Parser == parser) || (c.Delay != nil) {
+		t.Fatal("base instance modified")
+	}
+
+	var wg sync.WaitGroup
+	for _, cc := range []*Colibri{c, newC} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := cc.Extract(&Rules{
+				URL:       mustNewURL("http://example.com"),
+				Selectors: []*Selector{{Name: "title", Expr: "title"}},
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !parser.ParseUsed || !delay.WaitUsed {
+		t.Fatal("options not used")
+	}
+
+	// The copies share the Stats.
+	if stats := c.Stats(); stats.Requests != 2 {
+		t.Fatalf("got %v, want %v", stats.Requests, 2)
+	}
+}
@@ -0,0 +1,242 @@
+package colibri
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// ErrInvalidPagination is returned when the value is not a valid Pagination.
+var ErrInvalidPagination = errors.New("invalid pagination")
+
+// Names of the selectors of the next page URL, the cursor and the stop condition in the data of the pages.
+const (
+	paginationNext   = "#next"
+	paginationCursor = "#cursor"
+	paginationStop   = "#stop"
+)
+
+// Variables of the requests of the pages, see the URL field of the Pagination structure.
+const (
+	// PageVar is the number of the page, the first page is 1.
+	PageVar = "page"
+
+	// OffsetVar is the offset of the page, see the Step field of the Pagination structure.
+	OffsetVar = "offset"
+
+	// CursorVar is the cursor of the page, see the Cursor field of the Pagination structure.
+	CursorVar = "cursor"
+)
+
+// Pagination specifies how the following pages of a selector are found, see the Paginate field of the Selector.
+// The selector is found in each page and the values of all the pages are returned in order.
+//
+// In the first page the expressions are evaluated from the parent node of the selector,
+// in the following pages from the root node.
+type Pagination struct {
+	// Next is the expression of the URL of the next page, relative URLs are resolved with the URL of the page.
+	// The pagination ends when the URL is not found or has already been visited.
+	Next string `json:"next,omitempty"`
+
+	// MaxPages specifies the maximum number of pages, including the first one.
+	// If it is zero, there is no limit.
+	MaxPages int `json:"maxPages,omitempty"`
+
+	// StopWhen is an expression that ends the pagination on the page in which it is found,
+	// the values of that page are kept.
+	StopWhen string `json:"stopWhen,omitempty"`
+
+	// URL is the template of the URL of the next page, used when Next is empty, e.g. for JSON APIs.
+	// The placeholders ${page}, ${offset} and ${cursor} are replaced by the values of the next page,
+	// also in the Header and Form of the selector, so they can be sent in the request body.
+	// The pagination ends when a page has no values.
+	URL string `json:"url,omitempty"`
+
+	// Cursor is the expression of the cursor of the next page.
+	// The pagination ends when the cursor is not found.
+	Cursor string `json:"cursor,omitempty"`
+
+	// Step is the increment of the offset of each page, the offset of the first page is zero.
+	Step int `json:"step,omitempty"`
+}
+
+// Clone returns a copy of the pagination.
+func (p *Pagination) Clone() *Pagination {
+	if p == nil {
+		return nil
+	}
+
+	newPagination := *p
+	return &newPagination
+}
+
+// selectors returns the selectors that find the next page URL and the stop condition.
+func (p *Pagination) selectors(exprType string) []*Selector {
+	var selectors []*Selector
+	if p.Next != "" {
+		selectors = append(selectors, &Selector{Name: paginationNext, Expr: p.Next, Type: exprType, Extra: make(map[string]any)})
+	}
+
+	if p.Cursor != "" {
+		selectors = append(selectors, &Selector{Name: paginationCursor, Expr: p.Cursor, Type: exprType, Extra: make(map[string]any)})
+	}
+
+	if p.StopWhen != "" {
+		selectors = append(selectors, &Selector{Name: paginationStop, Expr: p.StopWhen, Type: exprType, Extra: make(map[string]any)})
+	}
+	return selectors
+}
+
+// paginateSelector finds the selector in the page of the response and in the following pages.
+// The values of the selectors with All are merged, otherwise the value of each page is added.
+func paginateSelector(src *Rules, resp Response, selector *Selector, parent Node) ([]any, error) {
+	pagination := selector.Paginate
+
+	pageSelector := selector.Clone()
+	pageSelector.Paginate = nil
+	defer ReleaseSelector(pageSelector)
+
+	pageRules := *src
+	pageRules.Selectors = pagination.selectors(selector.Type)
+
+	found, err := findSelector(src, resp, pageSelector, parent)
+	errs := selectorError(src, resp.URL(), err, selector.Name)
+	result := appendPage(nil, found)
+
+	data, pageErr := FindSelectors(&pageRules, resp, parent)
+	if pageErr != nil {
+		errs = AddError(errs, resp.URL().String(), pageErr)
+	}
+
+	var (
+		pageURL = resp.URL()
+		visited = map[string]bool{pageURL.String(): true}
+	)
+	for pages := 1; (pagination.MaxPages <= 0) || (pages < pagination.MaxPages); pages++ {
+		if data[paginationStop] != nil {
+			break
+		}
+
+		next, vars := pagination.next(data, pageURL, pages)
+		if next == nil {
+			break
+		}
+
+		// The pages whose values are sent in the body have the same URL.
+		key := next.String()
+		if vars != nil {
+			key += fmt.Sprint(vars)
+		}
+
+		if visited[key] {
+			break
+		}
+		visited[key] = true
+
+		rules := selector.Rules(src)
+		rules.URL = rules.Normalize.URL(next)
+		if vars != nil {
+			rules.SetContext(WithVars(rules.Context(), vars))
+		}
+		rules.Selectors = ReleaseSelectors(rules.Selectors)
+		rules.Selectors = append(CloneSelectors([]*Selector{pageSelector}), pagination.selectors(selector.Type)...)
+
+		if err := rules.Scope.Check(rules.URL); err != nil {
+			errs = AddError(errs, rules.URL.String(), selectorError(rules, rules.URL, err, selector.Name))
+			ReleaseRules(rules)
+			break
+		}
+		sameHostCredentials(rules, pageURL)
+
+		out, err := resp.Extract(rules)
+		if err != nil {
+			errs = AddError(errs, rules.URL.String(), selectorError(rules, rules.URL, err, selector.Name))
+		}
+		ReleaseRules(rules)
+
+		if (out == nil) || (out.Data == nil) {
+			break
+		}
+
+		data = out.Data
+		pageURL = out.Response.URL()
+
+		n := len(result)
+		if result = appendPage(result, data[selector.Name]); (pagination.Next == "") && (len(result) == n) {
+			break
+		}
+	}
+	return result, errs
+}
+
+// next returns the URL of the page that follows the number of pages found, and the variables
+// of its request. Returns a nil URL if there is no next page.
+func (p *Pagination) next(data map[string]any, pageURL *url.URL, pages int) (*url.URL, map[string]any) {
+	if p.Next != "" {
+		return nextPageURL(data[paginationNext], pageURL), nil
+	} else if p.URL == "" {
+		return nil, nil
+	}
+
+	vars := map[string]any{
+		PageVar:   pages + 1,
+		OffsetVar: pages * p.Step,
+	}
+
+	if p.Cursor != "" {
+		cursor := data[paginationCursor]
+		if (cursor == nil) || (cursor == "") {
+			return nil, nil
+		}
+		vars[CursorVar] = cursor
+	}
+
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return nil, nil
+	}
+
+	u = interpolateURL(u, vars)
+	if !u.IsAbs() {
+		u = pageURL.ResolveReference(u)
+	}
+	return u, vars
+}
+
+// appendPage adds the value of a page to the values of the previous pages.
+func appendPage(result []any, value any) []any {
+	switch v := value.(type) {
+	case nil:
+		return result
+	case []any:
+		return append(result, v...)
+	}
+	return append(result, value)
+}
+
+// nextPageURL returns the URL of the next page resolved with the URL of the page,
+// returns nil if the value is not a valid URL.
+func nextPageURL(value any, pageURL *url.URL) *url.URL {
+	u, err := ToURL(value)
+	if (err != nil) || (u.String() == "") {
+		return nil
+	}
+
+	if !u.IsAbs() {
+		u = pageURL.ResolveReference(u)
+	}
+	return u
+}
+
+func toPagination(value any) (*Pagination, error) {
+	paginationMap, ok := value.(map[string]any)
+	if !ok {
+		return nil, ErrInvalidPagination
+	}
+
+	pagination := &Pagination{}
+	if err := processRaw(paginationMap, pagination); err != nil {
+		return nil, err
+	}
+	return pagination, nil
+}
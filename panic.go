@@ -0,0 +1,39 @@
+package colibri
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is returned by Do and Extract when a panic occurs.
+// It contains the value passed to panic and the stack trace of the goroutine,
+// if the value is an error it can be obtained with errors.Is and errors.As.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+
+	// Stack is the stack trace of the goroutine when the panic was recovered.
+	Stack []byte
+}
+
+// newPanicError returns a PanicError with the recovered value,
+// the value is returned if it is already a PanicError.
+func newPanicError(value any) *PanicError {
+	if err, ok := value.(*PanicError); ok {
+		return err
+	}
+	return &PanicError{Value: value, Stack: debug.Stack()}
+}
+
+// Error returns the value passed to panic formatted with %v.
+func (err *PanicError) Error() string {
+	return fmt.Sprintf("%v", err.Value)
+}
+
+// Unwrap returns the value passed to panic if it is an error.
+func (err *PanicError) Unwrap() error {
+	if e, ok := err.Value.(error); ok {
+		return e
+	}
+	return nil
+}
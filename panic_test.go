@@ -0,0 +1,42 @@
+package colibri
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPanicError(t *testing.T) {
+	testErr := errors.New("test err")
+
+	c := New()
+	c.Client = &testClient{}
+	c.Parser = &testParser{}
+
+	_, err := c.Do(&Rules{Extra: map[string]any{"doPanic": testErr}})
+
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("got %T, want *PanicError", err)
+	}
+
+	if !errors.Is(err, testErr) {
+		t.Fatalf("got %v, want %v", err, testErr)
+	}
+
+	if !strings.Contains(string(panicErr.Stack), "testClient") {
+		t.Fatalf("stack trace without the panic: %s", panicErr.Stack)
+	}
+
+	_, err = c.Extract(&Rules{
+		Selectors: []*Selector{{Name: "title", Expr: "//title"}},
+		Extra:     map[string]any{"parserPanic": "test panic"},
+	})
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("got %T, want *PanicError", err)
+	}
+
+	if (panicErr.Value != "test panic") || (err.Error() != "test panic") || (panicErr.Unwrap() != nil) {
+		t.Fatalf("got %v, want %v", panicErr.Value, "test panic")
+	}
+}
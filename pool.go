@@ -0,0 +1,165 @@
+package colibri
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// WorkerStats contains the metrics of the workers that run the extractions of Crawl,
+// ExtractAll and the selectors with Follow and Concurrency, see Colibri.WorkerStats.
+type WorkerStats struct {
+	// Workers is the maximum number of tasks that can run at the same time in the active pools.
+	Workers int `json:"workers"`
+
+	// Running is the number of tasks running.
+	Running int `json:"running"`
+
+	// Queued is the number of tasks waiting for a free worker.
+	Queued int `json:"queued"`
+
+	// Completed is the number of tasks completed, including the ones that panicked.
+	Completed int64 `json:"completed"`
+
+	// Panics is the number of tasks that panicked.
+	Panics int64 `json:"panics"`
+}
+
+// Utilization returns the fraction of the workers that are running a task, between 0 and 1.
+func (stats WorkerStats) Utilization() float64 {
+	if stats.Workers <= 0 {
+		return 0
+	}
+	return float64(stats.Running) / float64(stats.Workers)
+}
+
+// workerMetrics stores the metrics of the worker pools of a Colibri.
+type workerMetrics struct {
+	workers   atomic.Int64
+	running   atomic.Int64
+	queued    atomic.Int64
+	completed atomic.Int64
+	panics    atomic.Int64
+}
+
+func (m *workerMetrics) stats() WorkerStats {
+	if m == nil {
+		return WorkerStats{}
+	}
+
+	return WorkerStats{
+		Workers:   int(m.workers.Load()),
+		Running:   int(m.running.Load()),
+		Queued:    int(m.queued.Load()),
+		Completed: m.completed.Load(),
+		Panics:    m.panics.Load(),
+	}
+}
+
+// workerPool runs tasks in a bounded number of goroutines.
+// Each task receives its own context, derived from the context of the pool,
+// that is canceled when the task ends. The panics of the tasks are recovered.
+type workerPool struct {
+	ctx     context.Context
+	sem     chan struct{}
+	wg      sync.WaitGroup
+	metrics *workerMetrics
+}
+
+// newWorkerPool returns a pool of size workers, if size is less than 1 the pool has one worker.
+// The metrics can be nil.
+func newWorkerPool(ctx context.Context, size int, metrics *workerMetrics) *workerPool {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	size = max(size, 1)
+	if metrics != nil {
+		metrics.workers.Add(int64(size))
+	}
+
+	return &workerPool{
+		ctx:     ctx,
+		sem:     make(chan struct{}, size),
+		metrics: metrics,
+	}
+}
+
+// acquire blocks until a worker is free and reserves it for the next call to run.
+// Returns false if the context of the pool is done first.
+func (p *workerPool) acquire() bool {
+	if p.ctx.Err() != nil {
+		return false
+	}
+
+	if p.metrics != nil {
+		p.metrics.queued.Add(1)
+		defer p.metrics.queued.Add(-1)
+	}
+
+	select {
+	case <-p.ctx.Done():
+		return false
+	case p.sem <- struct{}{}:
+		return true
+	}
+}
+
+// release frees the worker reserved with acquire without running a task.
+func (p *workerPool) release() {
+	<-p.sem
+}
+
+// run runs the task in the worker reserved with acquire.
+// If the task panics, onPanic is called with the recovered value.
+func (p *workerPool) run(task func(ctx context.Context), onPanic func(err *PanicError)) {
+	p.wg.Add(1)
+	if p.metrics != nil {
+		p.metrics.running.Add(1)
+	}
+
+	go func() {
+		ctx, cancel := context.WithCancel(p.ctx)
+		defer func() {
+			if v := recover(); v != nil {
+				if p.metrics != nil {
+					p.metrics.panics.Add(1)
+				}
+
+				if onPanic != nil {
+					onPanic(newPanicError(v))
+				}
+			}
+
+			cancel()
+			if p.metrics != nil {
+				p.metrics.running.Add(-1)
+				p.metrics.completed.Add(1)
+			}
+
+			<-p.sem
+			p.wg.Done()
+		}()
+
+		task(ctx)
+	}()
+}
+
+// Go runs the task in a free worker, see run. It blocks until a worker is free
+// and returns false, without running the task, if the context of the pool is done first.
+func (p *workerPool) Go(task func(ctx context.Context), onPanic func(err *PanicError)) bool {
+	if !p.acquire() {
+		return false
+	}
+
+	p.run(task, onPanic)
+	return true
+}
+
+// Wait waits for the running tasks and closes the pool.
+func (p *workerPool) Wait() {
+	p.wg.Wait()
+	if p.metrics != nil {
+		p.metrics.workers.Add(-int64(cap(p.sem)))
+	}
+}
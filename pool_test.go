@@ -0,0 +1,118 @@
+package colibri
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWorkerPool(t *testing.T) {
+	var (
+		metrics = &workerMetrics{}
+		pool    = newWorkerPool(context.Background(), 3, metrics)
+		release = make(chan struct{})
+
+		running, maxRunning atomic.Int64
+		mu                  sync.Mutex
+		panics              []*PanicError
+	)
+
+	if stats := metrics.stats(); stats.Workers != 3 {
+		t.Fatalf("got %v, want %v", stats.Workers, 3)
+	}
+
+	for i := 0; i < 10; i++ {
+		ok := pool.Go(func(ctx context.Context) {
+			n := running.Add(1)
+			defer running.Add(-1)
+
+			for {
+				m := maxRunning.Load()
+				if (n <= m) || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+
+			if i == 0 {
+				<-release
+			}
+
+			if ctx.Err() != nil {
+				t.Error("task context is done")
+			}
+
+			if i%5 == 4 {
+				panic(errors.New("test panic"))
+			}
+		}, func(err *PanicError) {
+			mu.Lock()
+			panics = append(panics, err)
+			mu.Unlock()
+		})
+		if !ok {
+			t.Fatal("task not run")
+		}
+
+		if i == 2 {
+			if stats := metrics.stats(); (stats.Running == 0) || (stats.Utilization() <= 0) {
+				t.Fatalf("got %+v, want running tasks", stats)
+			}
+			close(release)
+		}
+	}
+	pool.Wait()
+
+	if m := maxRunning.Load(); m > 3 {
+		t.Fatalf("got %v, want <= %v", m, 3)
+	}
+
+	if len(panics) != 2 {
+		t.Fatalf("got %v, want %v", len(panics), 2)
+	}
+
+	want := WorkerStats{Completed: 10, Panics: 2}
+	if stats := metrics.stats(); stats != want {
+		t.Fatalf("got %+v, want %+v", stats, want)
+	}
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		pool := newWorkerPool(ctx, 1, nil)
+		if pool.Go(func(context.Context) { t.Error("task run") }, nil) {
+			t.Fatal("task run")
+		}
+		pool.Wait()
+	})
+}
+
+func TestCrawlConcurrency(t *testing.T) {
+	c := New()
+	c.Client = &testClient{}
+	c.Parser = &testParser{}
+
+	var outputs int
+	err := c.Crawl(testCrawlRules, &CrawlOptions{
+		Concurrency: 4,
+		OnOutput: func(output *Output, err error) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			outputs++
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if outputs != 2 {
+		t.Fatalf("got %v, want %v", outputs, 2)
+	}
+
+	if stats := c.WorkerStats(); (stats.Workers != 0) || (stats.Running != 0) || (stats.Completed != 2) {
+		t.Fatalf("got %+v, want %+v", stats, WorkerStats{Completed: 2})
+	}
+}
@@ -0,0 +1,66 @@
+package colibri
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// ErrContentType is returned when no parser matches the Content-Type of the response.
+var ErrContentType = errors.New("no parser matches the content type")
+
+// preflight makes a HEAD request to the URL of the rules and returns an error if the GET request
+// must be skipped: ErrResponseBodySize if the Content-Length exceeds ResponseBodySize and
+// the rules do not have ParseTruncated, or
+// ErrContentType if the Parser does not match the Content-Type and the response is parsed.
+// If the HEAD request fails, the GET request is not skipped.
+func (c *Colibri) preflight(rules *Rules) (Response, error) {
+	headRules := rules.Clone()
+	defer ReleaseRules(headRules)
+
+	headRules.Method = http.MethodHead
+	headRules.Selectors = ReleaseSelectors(headRules.Selectors)
+
+	resp, err := c.Do(headRules)
+	if errors.Is(err, ErrResponseBodySize) {
+		return resp, err
+	} else if err != nil {
+		return nil, nil
+	}
+
+	if body := resp.Body(); body != nil {
+		body.Close()
+	}
+
+	if (resp.StatusCode() < 200) || (resp.StatusCode() > 299) {
+		return nil, nil
+	}
+
+	size, err := strconv.ParseInt(resp.Header().Get("Content-Length"), 10, 64)
+	if (err == nil) && (headRules.ResponseBodySize > 0) && !headRules.ParseTruncated && (size > int64(headRules.ResponseBodySize)) {
+		return resp, &BodySizeError{Limit: int64(headRules.ResponseBodySize), Size: size}
+	}
+
+	contentType := resp.Header().Get("Content-Type")
+	if isDownload(rules) || (len(rules.Selectors) == 0) || (c.Parser == nil) || detectable(contentType) {
+		return nil, nil
+	}
+
+	if !c.Parser.Match(contentType) {
+		return resp, fmt.Errorf("%w: %s", ErrContentType, contentType)
+	}
+	return nil, nil
+}
+
+// detectable returns true if the parsers can detect the content type from the body,
+// i.e. the Content-Type is missing or it is application/octet-stream.
+func detectable(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return (err != nil) || (mediaType == "application/octet-stream")
+}
@@ -0,0 +1,71 @@
+package colibri
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrUnknownProfile is returned when the Profile of the rules is not in Profiles.
+var ErrUnknownProfile = errors.New("unknown profile")
+
+// Profiles contains the header profiles that can be selected with the Profile field of the rules,
+// the keys are lowercase. Each profile contains the headers sent by a browser,
+// so the requests blend in with its traffic.
+//
+// Profiles must not be modified while requests are being made.
+var Profiles = map[string]http.Header{
+	"chrome": {
+		"User-Agent":                {"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36"},
+		"Accept":                    {"text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7"},
+		"Accept-Language":           {"en-US,en;q=0.9"},
+		"Sec-Ch-Ua":                 {`"Google Chrome";v="131", "Chromium";v="131", "Not_A Brand";v="24"`},
+		"Sec-Ch-Ua-Mobile":          {"?0"},
+		"Sec-Ch-Ua-Platform":        {`"Windows"`},
+		"Sec-Fetch-Dest":            {"document"},
+		"Sec-Fetch-Mode":            {"navigate"},
+		"Sec-Fetch-Site":            {"none"},
+		"Sec-Fetch-User":            {"?1"},
+		"Upgrade-Insecure-Requests": {"1"},
+	},
+
+	"firefox": {
+		"User-Agent":                {"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:133.0) Gecko/20100101 Firefox/133.0"},
+		"Accept":                    {"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"},
+		"Accept-Language":           {"en-US,en;q=0.5"},
+		"Sec-Fetch-Dest":            {"document"},
+		"Sec-Fetch-Mode":            {"navigate"},
+		"Sec-Fetch-Site":            {"none"},
+		"Sec-Fetch-User":            {"?1"},
+		"Upgrade-Insecure-Requests": {"1"},
+	},
+
+	"safari": {
+		"User-Agent":      {"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.1 Safari/605.1.15"},
+		"Accept":          {"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"},
+		"Accept-Language": {"en-US,en;q=0.9"},
+		"Sec-Fetch-Dest":  {"document"},
+		"Sec-Fetch-Mode":  {"navigate"},
+		"Sec-Fetch-Site":  {"none"},
+	},
+}
+
+// applyProfile adds to the header of the rules the headers of their Profile
+// that the header does not specify.
+func applyProfile(rules *Rules) error {
+	if rules.Profile == "" {
+		return nil
+	}
+
+	profile, ok := Profiles[strings.ToLower(rules.Profile)]
+	if !ok {
+		return AddError(nil, KeyProfile, ErrUnknownProfile)
+	}
+
+	for key, values := range profile {
+		if len(rules.Header.Values(key)) == 0 {
+			rules.Header[key] = append([]string(nil), values...)
+		}
+	}
+	return nil
+}
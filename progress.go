@@ -0,0 +1,104 @@
+package colibri
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Progress contains the progress of a crawl, see Colibri.Progress.
+type Progress struct {
+	// Pending is the number of URLs queued in the crawl frontier.
+	Pending int `json:"pending"`
+
+	// InFlight is the number of URLs being crawled.
+	InFlight int `json:"inFlight"`
+
+	// Completed is the number of URLs crawled without errors,
+	// including the ones skipped because they were already visited.
+	Completed int `json:"completed"`
+
+	// Failed is the number of URLs crawled with errors.
+	Failed int `json:"failed"`
+
+	// Bytes is the number of bytes read from the response bodies.
+	Bytes int64 `json:"bytes"`
+
+	// Elapsed is the time elapsed since the crawl started.
+	Elapsed time.Duration `json:"elapsed"`
+
+	// Remaining is the estimated time to crawl the pending and in-flight URLs
+	// at the average rate of the URLs crawled so far. It is zero if no URL has been crawled.
+	Remaining time.Duration `json:"remaining"`
+}
+
+// crawlProgress stores the counters of the progress of a crawl.
+type crawlProgress struct {
+	start     time.Time
+	completed atomic.Int64
+	failed    atomic.Int64
+	bytes     atomic.Int64
+}
+
+// add counts the output or the error of a crawled URL.
+func (p *crawlProgress) add(output *Output, err error) {
+	if err != nil {
+		p.failed.Add(1)
+	} else {
+		p.completed.Add(1)
+	}
+
+	if output != nil {
+		p.bytes.Add(output.BodySize)
+	}
+}
+
+// progress returns the progress of the crawl.
+func (cr *crawl) progress() Progress {
+	cr.mu.Lock()
+	pending, inFlight := len(cr.pending), len(cr.active)
+	cr.mu.Unlock()
+
+	progress := Progress{
+		Pending:   pending,
+		InFlight:  inFlight,
+		Completed: int(cr.stats.completed.Load()),
+		Failed:    int(cr.stats.failed.Load()),
+		Bytes:     cr.stats.bytes.Load(),
+	}
+
+	if !cr.stats.start.IsZero() {
+		progress.Elapsed = time.Since(cr.stats.start)
+	}
+
+	if done := progress.Completed + progress.Failed; done > 0 {
+		progress.Remaining = progress.Elapsed / time.Duration(done) * time.Duration(pending+inFlight)
+	}
+	return progress
+}
+
+// progressState stores the last crawl started by a Colibri.
+type progressState struct {
+	mu    sync.Mutex
+	crawl *crawl
+}
+
+func (s *progressState) set(cr *crawl) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.crawl = cr
+	s.mu.Unlock()
+}
+
+func (s *progressState) get() *crawl {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.crawl
+}
@@ -0,0 +1,83 @@
+package colibri
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidRedirectPolicy is returned when the value is not a valid RedirectPolicy.
+var ErrInvalidRedirectPolicy = errors.New("invalid redirect policy")
+
+// RedirectPolicy specifies which redirects are followed. When a redirect is not followed,
+// the redirect response is returned instead of an error.
+// The maximum number of redirects is specified by the Redirects field of the rules.
+type RedirectPolicy struct {
+	// Disabled specifies whether no redirect is followed.
+	Disabled bool `json:"disabled,omitempty"`
+
+	// SameHost specifies whether the redirects to other hosts are not followed.
+	SameHost bool `json:"sameHost,omitempty"`
+
+	// Status contains the status codes of the redirects that are followed, e.g. 307 and 308.
+	// If it is empty, all redirects are followed.
+	Status []int `json:"status,omitempty"`
+
+	// KeepAuth specifies whether the Authorization header is kept on the redirects to other hosts,
+	// by default it is only sent to the host of the original request.
+	KeepAuth bool `json:"keepAuth,omitempty"`
+
+	// StripAuth specifies whether the Authorization header is removed on all redirects,
+	// including those to the same host.
+	StripAuth bool `json:"stripAuth,omitempty"`
+}
+
+// Follow returns true if the redirect from the URL to the URL with the status code is followed.
+func (policy *RedirectPolicy) Follow(status int, from, to *url.URL) bool {
+	if policy == nil {
+		return true
+	}
+
+	if policy.Disabled {
+		return false
+	}
+
+	if policy.SameHost && !strings.EqualFold(from.Host, to.Host) {
+		return false
+	}
+
+	if len(policy.Status) == 0 {
+		return true
+	}
+
+	for _, code := range policy.Status {
+		if code == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Clone returns a copy of the redirect policy.
+func (policy *RedirectPolicy) Clone() *RedirectPolicy {
+	if policy == nil {
+		return nil
+	}
+
+	newPolicy := *policy
+	newPolicy.Status = append([]int(nil), policy.Status...)
+	return &newPolicy
+}
+
+func toRedirectPolicy(value any) (*RedirectPolicy, error) {
+	policyMap, ok := value.(map[string]any)
+	if !ok {
+		return nil, ErrInvalidRedirectPolicy
+	}
+
+	policy := &RedirectPolicy{}
+	if err := processRaw(policyMap, policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
@@ -0,0 +1,37 @@
+package colibri
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestRedirectPolicy_Follow(t *testing.T) {
+	var (
+		from  = mustNewURL("https://example.com/a")
+		same  = mustNewURL("https://example.com/b")
+		other = mustNewURL("https://example.org/b")
+	)
+
+	tests := []struct {
+		Name   string
+		Policy *RedirectPolicy
+		Status int
+		To     *url.URL
+		Want   bool
+	}{
+		{"nil", nil, 301, other, true},
+		{"disabled", &RedirectPolicy{Disabled: true}, 301, same, false},
+		{"sameHost", &RedirectPolicy{SameHost: true}, 302, same, true},
+		{"otherHost", &RedirectPolicy{SameHost: true}, 302, other, false},
+		{"status", &RedirectPolicy{Status: []int{307, 308}}, 308, other, true},
+		{"otherStatus", &RedirectPolicy{Status: []int{307, 308}}, 301, same, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			if got := tt.Policy.Follow(tt.Status, from, tt.To); got != tt.Want {
+				t.Fatalf("got %v, want %v", got, tt.Want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package colibri
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrUseAfterRelease is the value of the panics of the PoolingDebug mode,
+// when the rules or selectors are used or released after being released.
+var ErrUseAfterRelease = errors.New("rules or selector used after release")
+
+// PoolingMode specifies how the Rules and Selectors released with ReleaseRules and ReleaseSelector are reused,
+// see SetPooling.
+type PoolingMode int32
+
+const (
+	// PoolingOn reuses the released rules and selectors, it is the default mode.
+	PoolingOn PoolingMode = iota
+
+	// PoolingOff does not reuse the released rules and selectors, they are left to the garbage collector.
+	PoolingOff
+
+	// PoolingDebug does not reuse the released rules and selectors and panics with ErrUseAfterRelease
+	// when they are released again, cloned, or passed to Do or Extract, which return the panic as a *PanicError.
+	PoolingDebug
+)
+
+var poolingMode atomic.Int32
+
+// SetPooling sets the mode in which the released rules and selectors are reused.
+//
+// The rules and selectors obtained by unmarshaling rules, Clone and Selector.Rules come from a pool,
+// ReleaseRules and ReleaseSelector return them to it. The caller that releases them gives up their ownership:
+// they, their selectors and the rules of their Session must not be used after, since they can be
+// reused by other extractions. The maps of the Extra field are not modified when they are released,
+// so the values that reference them remain valid.
+func SetPooling(mode PoolingMode) {
+	poolingMode.Store(int32(mode))
+}
+
+// Pooling returns the mode in which the released rules and selectors are reused, see SetPooling.
+func Pooling() PoolingMode {
+	return PoolingMode(poolingMode.Load())
+}
+
+// getRules returns empty rules from the pool, or new rules if the pool is not used.
+func getRules() *Rules {
+	if Pooling() != PoolingOn {
+		return &Rules{Extra: make(map[string]any)}
+	}
+	return rulesPool.Get().(*Rules)
+}
+
+// getSelector returns an empty selector from the pool, or a new selector if the pool is not used.
+func getSelector() *Selector {
+	if Pooling() != PoolingOn {
+		return &Selector{Extra: make(map[string]any)}
+	}
+	return selectorPool.Get().(*Selector)
+}
+
+// checkReleased panics with ErrUseAfterRelease in the PoolingDebug mode if released is true.
+func checkReleased(released bool) {
+	if released && (Pooling() == PoolingDebug) {
+		panic(ErrUseAfterRelease)
+	}
+}
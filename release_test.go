@@ -0,0 +1,77 @@
+package colibri
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPoolingDebug(t *testing.T) {
+	SetPooling(PoolingDebug)
+	defer SetPooling(PoolingOn)
+
+	extra := map[string]any{"key": "value"}
+	rules := &Rules{
+		URL:       mustNewURL("http://example.com"),
+		Selectors: []*Selector{{Name: "title", Expr: "//title", Extra: map[string]any{}}},
+		Extra:     extra,
+	}
+	selector := rules.Selectors[0]
+	ReleaseRules(rules)
+
+	// The Extra maps are not modified.
+	if extra["key"] != "value" {
+		t.Fatalf("got %v, want %v", extra["key"], "value")
+	}
+
+	tests := []struct {
+		Name string
+		Func func()
+	}{
+		{"ReleaseRules", func() { ReleaseRules(rules) }},
+		{"ReleaseSelector", func() { ReleaseSelector(selector) }},
+		{"Clone", func() { rules.Clone() }},
+		{"Selector.Clone", func() { selector.Clone() }},
+		{"Selector.Rules", func() { selector.Rules(&Rules{}) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			defer func() {
+				if v := recover(); v != ErrUseAfterRelease {
+					t.Fatalf("got %v, want %v", v, ErrUseAfterRelease)
+				}
+			}()
+			tt.Func()
+		})
+	}
+
+	c := New()
+	c.Client = &testClient{}
+	c.Parser = &testParser{}
+
+	if _, err := c.Extract(rules); !errors.Is(err, ErrUseAfterRelease) {
+		t.Fatalf("got %v, want %v", err, ErrUseAfterRelease)
+	}
+
+	if _, err := c.Do(rules); !errors.Is(err, ErrUseAfterRelease) {
+		t.Fatalf("got %v, want %v", err, ErrUseAfterRelease)
+	}
+}
+
+func TestPoolingOff(t *testing.T) {
+	SetPooling(PoolingOff)
+	defer SetPooling(PoolingOn)
+
+	if mode := Pooling(); mode != PoolingOff {
+		t.Fatalf("got %v, want %v", mode, PoolingOff)
+	}
+
+	rules := &Rules{URL: mustNewURL("http://example.com"), Extra: map[string]any{}}
+	ReleaseRules(rules)
+
+	// The released rules are not reused nor marked.
+	newRules := rules.Clone()
+	if (newRules == rules) || newRules.released {
+		t.Fatal("released rules reused")
+	}
+}
@@ -0,0 +1,149 @@
+package colibri
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// responseCache is an LRU cache of the parsed responses of an Extract,
+// so the selectors that follow the same URL do not request and parse it again.
+// See Colibri.ResponseCache.
+type responseCache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+
+	// varies contains the names of the Vary headers of each URL.
+	varies map[string][]string
+}
+
+type cachedResponse struct {
+	key    string
+	url    string
+	resp   Response
+	parent Node
+}
+
+func newResponseCache(size int) *responseCache {
+	return &responseCache{
+		size:   size,
+		ll:     list.New(),
+		items:  make(map[string]*list.Element),
+		varies: make(map[string][]string),
+	}
+}
+
+// get returns the response and the root node cached for the rules,
+// returns nil if they are not cached.
+func (rc *responseCache) get(rules *Rules) (Response, Node) {
+	if (rc == nil) || !cacheableRules(rules) {
+		return nil, nil
+	}
+
+	if iRules := interpolateRules(rules); iRules != nil {
+		defer ReleaseRules(iRules)
+		rules = iRules
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	u := rules.URL.String()
+	vary, ok := rc.varies[u]
+	if !ok {
+		return nil, nil
+	}
+
+	elem, ok := rc.items[cacheKey(u, rules.Header, vary)]
+	if !ok {
+		return nil, nil
+	}
+
+	rc.ll.MoveToFront(elem)
+	cached := elem.Value.(*cachedResponse)
+	return cached.resp, cached.parent
+}
+
+// add caches the response and its root node for the rules,
+// the least recently used response is removed when the cache is full.
+func (rc *responseCache) add(rules *Rules, resp Response, parent Node) {
+	if (rc == nil) || !cacheableRules(rules) {
+		return
+	}
+
+	vary, ok := varyHeaders(resp.Header())
+	if !ok {
+		return
+	}
+
+	if iRules := interpolateRules(rules); iRules != nil {
+		defer ReleaseRules(iRules)
+		rules = iRules
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	u := rules.URL.String()
+	key := cacheKey(u, rules.Header, vary)
+	rc.varies[u] = vary
+
+	if elem, ok := rc.items[key]; ok {
+		rc.ll.MoveToFront(elem)
+		elem.Value = &cachedResponse{key: key, url: u, resp: resp, parent: parent}
+		return
+	}
+	rc.items[key] = rc.ll.PushFront(&cachedResponse{key: key, url: u, resp: resp, parent: parent})
+
+	for rc.ll.Len() > rc.size {
+		oldest := rc.ll.Remove(rc.ll.Back()).(*cachedResponse)
+		delete(rc.items, oldest.key)
+		delete(rc.varies, oldest.url)
+	}
+}
+
+// cacheableRules returns true if the rules make a GET request without a form.
+func cacheableRules(rules *Rules) bool {
+	return (rules.URL != nil) && (rules.Form == nil) && (rules.JSON == nil) && (rules.Body == "") &&
+		((rules.Method == "") || strings.EqualFold(rules.Method, http.MethodGet))
+}
+
+// varyHeaders returns the canonical names of the Vary headers,
+// returns false if the response varies on any header.
+func varyHeaders(header http.Header) ([]string, bool) {
+	var vary []string
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "*" {
+				return nil, false
+			} else if name != "" {
+				vary = append(vary, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return vary, true
+}
+
+func cacheKey(u string, header http.Header, vary []string) string {
+	var b strings.Builder
+	b.WriteString(u)
+	for _, name := range vary {
+		b.WriteByte('\n')
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(strings.Join(header.Values(name), ","))
+	}
+	return b.String()
+}
+
+type responseCacheKey struct{}
+
+func responseCacheFrom(ctx context.Context) *responseCache {
+	rc, _ := ctx.Value(responseCacheKey{}).(*responseCache)
+	return rc
+}
@@ -0,0 +1,69 @@
+package colibri
+
+import (
+	"net/url"
+	"time"
+)
+
+// RobotsInspector is implemented by the RobotsTxt that expose the content of the robots.txt files,
+// e.g. to discover the sitemaps of the hosts before a crawl.
+type RobotsInspector interface {
+	// Sitemaps returns the URLs of the sitemaps listed in the robots.txt of the URL host of the rules.
+	Sitemaps(c *Colibri, rules *Rules) ([]string, error)
+
+	// Group returns the group of the robots.txt of the URL host of the rules
+	// that applies to the User-Agent of the rules.
+	Group(c *Colibri, rules *Rules) (*RobotsGroup, error)
+}
+
+// RobotsGroup is a group of directives of a robots.txt.
+type RobotsGroup struct {
+	// Agent is the User-agent of the group, "*" is the group of the rest of the User-Agents.
+	// It is empty if no group applies.
+	Agent string
+
+	// Allow contains the paths of the Allow directives.
+	Allow []string
+
+	// Disallow contains the paths of the Disallow directives.
+	Disallow []string
+
+	// CrawlDelay is the value of the Crawl-delay directive.
+	CrawlDelay time.Duration
+}
+
+// RobotsError is returned when the URL is not allowed by robots.txt, errors.Is reports it as
+// ErrRobotstxtRestriction.
+type RobotsError struct {
+	// URL is the URL that is not allowed.
+	URL *url.URL
+
+	// Agent is the User-agent of the group of the robots.txt that applies.
+	Agent string
+
+	// Directive is the directive that disallows the URL, e.g. "Disallow: /private".
+	// It is empty if the whole host is disallowed, e.g. when the robots.txt returns 401 or 403.
+	Directive string
+}
+
+func (err *RobotsError) Error() string {
+	return ErrRobotstxtRestriction.Error()
+}
+
+func (err *RobotsError) Unwrap() error {
+	return ErrRobotstxtRestriction
+}
+
+// Sitemaps returns the URLs of the sitemaps listed in the robots.txt of the URL host of the rules,
+// see RobotsInspector. Returns nil if RobotsTxt does not implement RobotsInspector.
+func (c *Colibri) Sitemaps(rules *Rules) ([]string, error) {
+	if rules == nil {
+		return nil, ErrRulesIsNil
+	}
+
+	inspector, ok := c.RobotsTxt.(RobotsInspector)
+	if !ok {
+		return nil, nil
+	}
+	return inspector.Sitemaps(c, rules)
+}
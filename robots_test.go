@@ -0,0 +1,32 @@
+package colibri
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRobotsError(t *testing.T) {
+	var err error = &RobotsError{URL: mustNewURL("http://example.com/private"), Agent: "*", Directive: "Disallow: /private"}
+	if !errors.Is(err, ErrRobotstxtRestriction) {
+		t.Fatalf("got %v, want %v", err, ErrRobotstxtRestriction)
+	}
+
+	if code := ErrorCode(err); code != CodeRobotsDenied {
+		t.Fatalf("got %v, want %v", code, CodeRobotsDenied)
+	}
+}
+
+func TestSitemaps(t *testing.T) {
+	c := New()
+	c.RobotsTxt = testRobotsDenied{}
+
+	// testRobotsDenied does not implement RobotsInspector.
+	sitemaps, err := c.Sitemaps(&Rules{URL: mustNewURL("http://example.com")})
+	if (err != nil) || (sitemaps != nil) {
+		t.Fatalf("got %v %v, want nil", sitemaps, err)
+	}
+
+	if _, err := c.Sitemaps(nil); !errors.Is(err, ErrRulesIsNil) {
+		t.Fatalf("got %v, want %v", err, ErrRulesIsNil)
+	}
+}
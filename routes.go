@@ -0,0 +1,111 @@
+package colibri
+
+import (
+	"regexp"
+	"sort"
+)
+
+// compiledRoutes contains the compiled patterns of the routes of a selector, sorted from the longest,
+// the most specific, to the shortest.
+type compiledRoutes struct {
+	patterns []string
+	regexps  []*regexp.Regexp
+}
+
+// compileRoutes compiles and sorts the patterns of the routes.
+// Returns ErrInvalidRoute for each pattern that is not a valid regular expression.
+func compileRoutes(routes map[string]*Rules) (*compiledRoutes, error) {
+	var (
+		compiled = &compiledRoutes{patterns: make([]string, 0, len(routes))}
+		errs     error
+	)
+	for pattern := range routes {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errs = AddError(errs, pattern, ErrInvalidRoute)
+			continue
+		}
+		compiled.patterns = append(compiled.patterns, pattern)
+	}
+
+	// The longest patterns are the most specific.
+	sort.Slice(compiled.patterns, func(i, j int) bool {
+		if len(compiled.patterns[i]) != len(compiled.patterns[j]) {
+			return len(compiled.patterns[i]) > len(compiled.patterns[j])
+		}
+		return compiled.patterns[i] < compiled.patterns[j]
+	})
+
+	compiled.regexps = make([]*regexp.Regexp, len(compiled.patterns))
+	for i, pattern := range compiled.patterns {
+		compiled.regexps[i] = regexp.MustCompile(pattern)
+	}
+	return compiled, errs
+}
+
+// compileRoutes compiles the routes of the selector when it is unmarshaled,
+// the compiled routes are shared with the clones of the selector.
+func (sel *Selector) compileRoutes() error {
+	if len(sel.Routes) == 0 {
+		return nil
+	}
+
+	var err error
+	sel.sortedRoutes, err = compileRoutes(sel.Routes)
+	return err
+}
+
+// routes returns the compiled routes of the selector. The routes of the selectors that
+// were not unmarshaled, or whose routes changed after they were unmarshaled, are compiled again.
+func (sel *Selector) routes() *compiledRoutes {
+	if (sel.sortedRoutes != nil) && sameRoutes(sel.sortedRoutes, sel.Routes) {
+		return sel.sortedRoutes
+	}
+
+	compiled, _ := compileRoutes(sel.Routes)
+	return compiled
+}
+
+// sameRoutes returns true if the compiled routes contain the patterns of the routes.
+func sameRoutes(compiled *compiledRoutes, routes map[string]*Rules) bool {
+	if len(compiled.patterns) != len(routes) {
+		return false
+	}
+
+	for _, pattern := range compiled.patterns {
+		if _, ok := routes[pattern]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// routeRules returns the rules of the route of the selector whose pattern matches the URL of the rules,
+// see the Routes field of the Selector structure. The route overrides the fields of the rules
+// and its Selectors, if any, replace the selectors of the rules.
+// If no pattern matches, the rules are returned. Otherwise the rules are released.
+func routeRules(rules *Rules, selector *Selector) *Rules {
+	if (len(selector.Routes) == 0) || (rules.URL == nil) {
+		return rules
+	}
+
+	compiled := selector.routes()
+
+	u := rules.URL.String()
+	for i, re := range compiled.regexps {
+		if !re.MatchString(u) {
+			continue
+		}
+
+		route := selector.Routes[compiled.patterns[i]]
+		newRules := route.Inherit(rules)
+		if len(route.Selectors) > 0 {
+			newRules.Selectors = ReleaseSelectors(newRules.Selectors)
+			newRules.Selectors = CloneSelectors(route.Selectors)
+		}
+
+		newRules.URL = rules.URL
+		ReleaseRules(rules)
+		return newRules
+	}
+	return rules
+}
@@ -0,0 +1,126 @@
+package colibri
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRouteRules(t *testing.T) {
+	routes := map[string]*Rules{
+		"/product/":       {Method: "POST", Selectors: []*Selector{{Name: "price", Expr: "//price"}}},
+		"/product/[0-9]+": {Selectors: []*Selector{{Name: "sku", Expr: "//sku"}}},
+		"/category/":      {Selectors: []*Selector{{Name: "title", Expr: "//h2"}}},
+	}
+
+	tests := []struct {
+		URL       string
+		Method    string
+		Selectors []string
+	}{
+		// The selectors of the route replace the selectors of the rules.
+		{"https://example.com/product/abc", "POST", []string{"price"}},
+		{"https://example.com/product/123", "GET", []string{"sku"}},
+		{"https://example.com/category/1", "GET", []string{"title"}},
+		{"https://example.com/about", "GET", []string{"title"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.URL, func(t *testing.T) {
+			rules := &Rules{
+				Method:    "GET",
+				URL:       mustNewURL(tt.URL),
+				Selectors: []*Selector{{Name: "title", Expr: "//h1"}},
+			}
+
+			got := routeRules(rules, &Selector{Routes: routes})
+			if got.URL.String() != tt.URL {
+				t.Fatalf("got %v, want %v", got.URL, tt.URL)
+			}
+
+			if got.Method != tt.Method {
+				t.Fatalf("got %v, want %v", got.Method, tt.Method)
+			}
+
+			var names []string
+			for _, selector := range got.Selectors {
+				names = append(names, selector.Name)
+			}
+
+			if len(names) != len(tt.Selectors) {
+				t.Fatalf("got %v, want %v", names, tt.Selectors)
+			}
+			for i := range names {
+				if names[i] != tt.Selectors[i] {
+					t.Fatalf("got %v, want %v", names, tt.Selectors)
+				}
+			}
+		})
+	}
+}
+
+func TestCompiledRoutes(t *testing.T) {
+	var rawSelector map[string]any
+	if err := json.Unmarshal([]byte(`{
+	"expr": "//a/@href",
+	"follow": true,
+	"routes": {
+		"/category/": {"selectors": {"title": "//h2"}},
+		"/product/[0-9]+": {"selectors": {"sku": "//sku"}},
+		"/product/": {"selectors": {"price": "//price"}}
+	}
+}`), &rawSelector); err != nil {
+		t.Fatal(err)
+	}
+
+	selector, err := newSelector("links", rawSelector)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The routes are compiled and sorted when the selector is unmarshaled and shared with its clones.
+	compiled := selector.sortedRoutes
+	want := []string{"/product/[0-9]+", "/category/", "/product/"}
+	if !reflect.DeepEqual(compiled.patterns, want) {
+		t.Fatalf("got %v, want %v", compiled.patterns, want)
+	}
+
+	clone := selector.Clone()
+	if clone.routes() != compiled {
+		t.Fatal("routes compiled again")
+	}
+
+	// The routes changed after the selector was unmarshaled are compiled again.
+	delete(clone.Routes, "/category/")
+	if got := clone.routes().patterns; !reflect.DeepEqual(got, []string{"/product/[0-9]+", "/product/"}) {
+		t.Fatalf("got %v, want %v", got, []string{"/product/[0-9]+", "/product/"})
+	}
+}
+
+func TestInvalidRoute(t *testing.T) {
+	var rawSelector map[string]any
+	if err := json.Unmarshal([]byte(`{
+	"expr": "//a/@href",
+	"follow": true,
+	"routes": {"/product/(": {"selectors": {"price": "//price"}}}
+}`), &rawSelector); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := newSelector("links", rawSelector)
+
+	var errs *Errs
+	if !errors.As(err, &errs) {
+		t.Fatalf("got %v, want *Errs", err)
+	}
+
+	routesErr, _ := errs.Get(KeyRoutes)
+	if !errors.As(routesErr, &errs) {
+		t.Fatalf("got %v, want *Errs", routesErr)
+	}
+
+	if e, _ := errs.Get("/product/("); !errors.Is(e, ErrInvalidRoute) {
+		t.Fatalf("got %v, want %v", e, ErrInvalidRoute)
+	}
+}
@@ -1,6 +1,7 @@
 package colibri
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/url"
@@ -9,24 +10,78 @@ import (
 )
 
 const (
+	KeyAllowedStatusCodes = "allowedStatusCodes"
+
+	KeyBackoff = "backoff"
+
+	KeyBasicAuth = "basicAuth"
+
+	KeyBearerToken = "bearerToken"
+
+	KeyBestEffort = "bestEffort"
+
+	KeyBody = "body"
+
+	KeyBufferBody = "bufferBody"
+
+	KeyCacheNode = "cacheNode"
+
 	KeyCookies = "cookies"
 
+	KeyDebug = "debug"
+
 	KeyDelay = "delay"
 
+	KeyFailOnStatus = "failOnStatus"
+
+	KeyForm = "form"
+
 	KeyHeader = "header"
 
 	KeyIgnoreRobotsTxt = "ignoreRobotsTxt"
 
+	KeyIncludeBody = "includeBody"
+
+	KeyIncludeTLS = "includeTLS"
+
+	KeyJSON = "json"
+
+	KeyMaxDuration = "maxDuration"
+
 	KeyMethod = "method"
 
+	KeyNormalize = "normalize"
+
+	KeyParseTruncated = "parseTruncated"
+
+	KeyPreflight = "preflight"
+
+	KeyPriority = "priority"
+
+	KeyProfile = "profile"
+
 	KeyProxy = "proxy"
 
+	KeyRedirectPolicy = "redirectPolicy"
+
 	KeyRedirects = "redirects"
 
+	KeyRespectNofollow = "respectNofollow"
+
 	KeyResponseBodySize = "responseBodySize"
 
+	KeyRetryAfter = "retryAfter"
+
+	KeySaveTo = "saveTo"
+
+	KeyScope = "scope"
+
 	KeySelectors = "selectors"
 
+	KeySession = "session"
+
+	KeyTLS = "TLS"
+
 	KeyTimeout = "timeout"
 
 	KeyURL = "URL"
@@ -51,36 +106,176 @@ type Rules struct {
 	// Header contains the HTTP header.
 	Header http.Header
 
+	// Form contains the fields of a form sent in the request, URL-encoded in the body
+	// or, if the method is GET or HEAD, as the query of the URL.
+	Form url.Values
+
+	// JSON specifies a value that is sent in the request body encoded as JSON,
+	// with the Content-Type application/json. If it is not nil, the Form is sent as the query of the URL.
+	// The placeholders of its strings are replaced by the variables, see WithVars.
+	JSON any
+
+	// Body specifies the raw request body, e.g. an XML document, sent with the Content-Type of the Header
+	// or application/x-www-form-urlencoded, as curl does. It is not sent if the JSON is not nil.
+	// If it is not empty, the Form is sent as the query of the URL.
+	Body string
+
+	// BasicAuth specifies the credentials of the HTTP Basic authentication.
+	// The credentials are inherited by the requests of the selectors to the same host.
+	BasicAuth *BasicAuth
+
+	// BearerToken specifies the token sent in the Authorization header with the Bearer scheme.
+	// The token is inherited by the requests of the selectors to the same host.
+	BearerToken string
+
+	// Profile specifies the name of a header profile of Profiles, e.g. "chrome".
+	// The headers of the profile are added to the Header if it does not specify them.
+	Profile string
+
 	// Timeout specifies the time limit for the HTTP request.
 	Timeout time.Duration
 
+	// MaxDuration specifies the time limit for the entire Extract, including the requests
+	// of the selectors with Follow, unlike Timeout that limits each HTTP request.
+	// When it is exceeded, the data found is returned with ErrMaxDuration.
+	MaxDuration time.Duration
+
 	// Cookies specifies whether the client should send and store Cookies.
 	Cookies bool
 
 	// IgnoreRobotsTxt specifies whether robots.txt should be ignored.
 	IgnoreRobotsTxt bool
 
+	// RespectNofollow specifies whether the selectors with Follow skip the links marked with rel="nofollow"
+	// and honor the nofollow and noindex directives of the robots meta tags and the X-Robots-Tag header.
+	// See the RobotsNode interface and the Noindex field of the Output structure.
+	RespectNofollow bool
+
 	// Delay specifies the delay time between requests.
 	Delay time.Duration
 
 	// Redirects specifies the maximum number of redirects.
 	Redirects int
 
+	// RedirectPolicy specifies which redirects are followed.
+	RedirectPolicy *RedirectPolicy
+
 	// ResponseBodySize maximum response body size.
 	ResponseBodySize int
 
+	// ParseTruncated specifies whether the responses whose body exceeds ResponseBodySize are parsed
+	// with the content read up to the limit instead of returning ErrResponseBodySize,
+	// the Truncated field of the Output reports it.
+	ParseTruncated bool
+
+	// BufferBody specifies the maximum size of the response body kept in memory,
+	// so the body can be read again with the Body method of the response.
+	// The bodies that exceed it are not buffered. If it is zero, the body is not buffered.
+	BufferBody int
+
+	// IncludeBody specifies whether the response body read by the parser, its hash and its
+	// Content-Type are included in the Output, e.g. to parse it again offline or archive it.
+	// The body is truncated if it exceeds ResponseBodySize with ParseTruncated.
+	IncludeBody bool
+
+	// IncludeTLS specifies whether the details of the TLS connection, including the certificate
+	// chain of the server, are included in the serializable value of the responses received over TLS.
+	IncludeTLS bool
+
+	// CacheNode specifies whether the parsed root node is stored in the response, if it implements
+	// colibri.NodeCacher, so other selectors can be found in it with ExtractResponse without requesting
+	// and parsing the response again. The node is kept in memory until the response is released
+	// or invalidated. It is not inherited by the rules of the selectors.
+	CacheNode bool
+
+	// Preflight specifies whether Extract makes a HEAD request before the GET request,
+	// the GET request is skipped if the Content-Length exceeds ResponseBodySize or
+	// the Parser does not match the Content-Type.
+	Preflight bool
+
+	// FailOnStatus specifies whether the responses with a status code other than 2xx
+	// return ErrStatusCode instead of being parsed.
+	FailOnStatus bool
+
+	// AllowedStatusCodes contains the status codes of the responses that do not return ErrStatusCode,
+	// if it is not empty the other status codes return ErrStatusCode even if FailOnStatus is false.
+	AllowedStatusCodes []int
+
+	// BestEffort specifies whether the partial results of the selectors with errors are kept,
+	// e.g. the values found by the nested selectors whose siblings fail.
+	// The errors are returned with the data in both cases.
+	BestEffort bool
+
+	// Debug specifies whether the requests and responses are dumped in their wire representation,
+	// see the webextractor.Client DebugWriter.
+	Debug bool
+
+	// RetryAfter specifies the maximum number of retries of the HTTP requests
+	// answered with 429 Too Many Requests or 503 Service Unavailable and a Retry-After header,
+	// and, with Backoff, of the ones that fail with a network error.
+	RetryAfter int
+
+	// Backoff specifies the wait time before the retries of the HTTP requests answered with
+	// 429 Too Many Requests or 503 Service Unavailable without a Retry-After header,
+	// and of the ones that fail with a network error, see RetryAfter.
+	// If it is nil, only the responses with a Retry-After header are retried.
+	Backoff Backoff
+
+	// Priority specifies the crawl priority, rules with the highest priority are crawled first.
+	Priority int
+
+	// TLS specifies the TLS configuration.
+	TLS *TLS
+
+	// Normalize specifies how the URLs found by the selectors with Follow are normalized.
+	Normalize *Normalize
+
+	// Scope specifies the URLs that the selectors with Follow can follow,
+	// the URLs out of scope are not followed and return ErrOutOfScope.
+	Scope *Scope
+
+	// SaveTo specifies the path of the file in which Extract stores the response body
+	// instead of parsing it. If it is an existing directory, the name of the file
+	// is obtained from the URL.
+	SaveTo string
+
+	// Session contains the rules of the requests executed once before the extraction, e.g. a login.
+	// The data extracted by their selectors is available as variables, see WithVars.
+	// The rules must enable Cookies to share the cookies with the extraction.
+	Session []*Rules
+
 	// Selectors
 	Selectors []*Selector
 
 	// Extra stores additional data.
 	Extra map[string]any
+
+	ctx      context.Context
+	released bool
+}
+
+// Context returns the context of the rules.
+// If no context has been set, context.Background is returned.
+func (rules *Rules) Context() context.Context {
+	if rules.ctx != nil {
+		return rules.ctx
+	}
+	return context.Background()
+}
+
+// SetContext sets the context of the rules.
+// The context is inherited by the rules of the selectors and by the cloned rules.
+func (rules *Rules) SetContext(ctx context.Context) {
+	rules.ctx = ctx
 }
 
 // Clone returns a copy of the original rules.
 //
 // Cloning the Extra field can cause errors, so you should avoid storing pointers.
 func (rules *Rules) Clone() *Rules {
-	newRules := rulesPool.Get().(*Rules)
+	checkReleased(rules.released)
+
+	newRules := getRules()
 
 	if rules.URL != nil {
 		newRules.URL = rules.URL.ResolveReference(&url.URL{})
@@ -92,12 +287,45 @@ func (rules *Rules) Clone() *Rules {
 
 	newRules.Method = rules.Method
 	newRules.Header = rules.Header.Clone()
+	newRules.Form = cloneValues(rules.Form)
+	newRules.JSON = copyRaw(rules.JSON)
+	newRules.Body = rules.Body
+	newRules.BasicAuth = rules.BasicAuth.Clone()
+	newRules.BearerToken = rules.BearerToken
+	newRules.Profile = rules.Profile
 	newRules.Timeout = rules.Timeout
 	newRules.Cookies = rules.Cookies
 	newRules.IgnoreRobotsTxt = rules.IgnoreRobotsTxt
+	newRules.RespectNofollow = rules.RespectNofollow
 	newRules.Delay = rules.Delay
 	newRules.Redirects = rules.Redirects
+	newRules.RedirectPolicy = rules.RedirectPolicy.Clone()
 	newRules.ResponseBodySize = rules.ResponseBodySize
+	newRules.ParseTruncated = rules.ParseTruncated
+	newRules.BufferBody = rules.BufferBody
+	newRules.IncludeBody = rules.IncludeBody
+	newRules.IncludeTLS = rules.IncludeTLS
+	newRules.CacheNode = rules.CacheNode
+	newRules.Preflight = rules.Preflight
+	newRules.FailOnStatus = rules.FailOnStatus
+	newRules.AllowedStatusCodes = append([]int(nil), rules.AllowedStatusCodes...)
+	newRules.BestEffort = rules.BestEffort
+	newRules.Debug = rules.Debug
+	newRules.RetryAfter = rules.RetryAfter
+	newRules.Backoff = rules.Backoff
+	newRules.Priority = rules.Priority
+	newRules.MaxDuration = rules.MaxDuration
+	newRules.SaveTo = rules.SaveTo
+	newRules.TLS = rules.TLS.Clone()
+	newRules.Normalize = rules.Normalize.Clone()
+	newRules.Scope = rules.Scope.Clone()
+
+	if len(rules.Session) > 0 {
+		newRules.Session = make([]*Rules, 0, len(rules.Session))
+		for _, sRules := range rules.Session {
+			newRules.Session = append(newRules.Session, sRules.Clone())
+		}
+	}
 
 	if len(rules.Selectors) > 0 {
 		newRules.Selectors = CloneSelectors(rules.Selectors)
@@ -107,6 +335,8 @@ func (rules *Rules) Clone() *Rules {
 	for key, value := range rules.Extra {
 		newRules.Extra[key] = value
 	}
+
+	newRules.ctx = rules.ctx
 	return newRules
 }
 
@@ -118,24 +348,56 @@ func (rules *Rules) Clear() {
 	rules.URL = nil
 	rules.Proxy = nil
 	rules.Header = nil
+	rules.Form = nil
+	rules.JSON = nil
+	rules.Body = ""
+	rules.BasicAuth = nil
+	rules.BearerToken = ""
+	rules.Profile = ""
 	rules.Timeout = 0
 	rules.Cookies = false
 	rules.IgnoreRobotsTxt = false
+	rules.RespectNofollow = false
 	rules.Delay = 0
 	rules.Redirects = 0
+	rules.RedirectPolicy = nil
 	rules.ResponseBodySize = 0
-
+	rules.ParseTruncated = false
+	rules.BufferBody = 0
+	rules.IncludeBody = false
+	rules.IncludeTLS = false
+	rules.CacheNode = false
+	rules.Preflight = false
+	rules.FailOnStatus = false
+	rules.AllowedStatusCodes = nil
+	rules.BestEffort = false
+	rules.Debug = false
+	rules.RetryAfter = 0
+	rules.Backoff = nil
+	rules.Priority = 0
+	rules.MaxDuration = 0
+	rules.SaveTo = ""
+	rules.TLS = nil
+	rules.Normalize = nil
+	rules.Scope = nil
+
+	rules.Session = ReleaseSessions(rules.Session)
 	rules.Selectors = ReleaseSelectors(rules.Selectors)
-	clear(rules.Extra)
+	rules.Extra = make(map[string]any)
+	rules.ctx = nil
 }
 
 func (rules *Rules) UnmarshalJSON(b []byte) (err error) {
-	newRules := rulesPool.Get().(*Rules)
+	newRules := getRules()
 
 	if err := json.Unmarshal(b, &newRules.Extra); err != nil {
 		return err
 	}
 
+	if err := resolveDefinitions(newRules.Extra); err != nil {
+		return err
+	}
+
 	if err := processRaw(newRules.Extra, newRules); err != nil {
 		return err
 	}
@@ -144,8 +406,184 @@ func (rules *Rules) UnmarshalJSON(b []byte) (err error) {
 	return nil
 }
 
-// ReleaseRules clears and sends the rules to the rules pool.
+func (rules *Rules) MarshalJSON() ([]byte, error) {
+	raw := make(map[string]any, len(rules.Extra))
+	for key, value := range rules.Extra {
+		raw[key] = value
+	}
+
+	if rules.Method != "" {
+		raw[KeyMethod] = rules.Method
+	}
+
+	if rules.URL != nil {
+		raw[KeyURL] = rules.URL.String()
+	}
+
+	if rules.Proxy != nil {
+		raw[KeyProxy] = rules.Proxy.String()
+	}
+
+	if rules.Header != nil {
+		raw[KeyHeader] = rules.Header
+	}
+
+	if rules.Form != nil {
+		raw[KeyForm] = rules.Form
+	}
+
+	if rules.JSON != nil {
+		raw[KeyJSON] = rules.JSON
+	}
+
+	if rules.Body != "" {
+		raw[KeyBody] = rules.Body
+	}
+
+	if rules.BasicAuth != nil {
+		raw[KeyBasicAuth] = rules.BasicAuth
+	}
+
+	if rules.BearerToken != "" {
+		raw[KeyBearerToken] = rules.BearerToken
+	}
+
+	if rules.Profile != "" {
+		raw[KeyProfile] = rules.Profile
+	}
+
+	if rules.Timeout != 0 {
+		raw[KeyTimeout] = fromDuration(rules.Timeout)
+	}
+
+	if rules.Cookies {
+		raw[KeyCookies] = rules.Cookies
+	}
+
+	if rules.IgnoreRobotsTxt {
+		raw[KeyIgnoreRobotsTxt] = rules.IgnoreRobotsTxt
+	}
+
+	if rules.RespectNofollow {
+		raw[KeyRespectNofollow] = rules.RespectNofollow
+	}
+
+	if rules.Delay != 0 {
+		raw[KeyDelay] = fromDuration(rules.Delay)
+	}
+
+	if rules.Redirects != 0 {
+		raw[KeyRedirects] = rules.Redirects
+	}
+
+	if rules.RedirectPolicy != nil {
+		raw[KeyRedirectPolicy] = rules.RedirectPolicy
+	}
+
+	if rules.ResponseBodySize != 0 {
+		raw[KeyResponseBodySize] = rules.ResponseBodySize
+	}
+
+	if rules.ParseTruncated {
+		raw[KeyParseTruncated] = rules.ParseTruncated
+	}
+
+	if rules.BufferBody != 0 {
+		raw[KeyBufferBody] = rules.BufferBody
+	}
+
+	if rules.IncludeBody {
+		raw[KeyIncludeBody] = rules.IncludeBody
+	}
+
+	if rules.IncludeTLS {
+		raw[KeyIncludeTLS] = rules.IncludeTLS
+	}
+
+	if rules.CacheNode {
+		raw[KeyCacheNode] = rules.CacheNode
+	}
+
+	if rules.Preflight {
+		raw[KeyPreflight] = rules.Preflight
+	}
+
+	if rules.FailOnStatus {
+		raw[KeyFailOnStatus] = rules.FailOnStatus
+	}
+
+	if len(rules.AllowedStatusCodes) > 0 {
+		raw[KeyAllowedStatusCodes] = rules.AllowedStatusCodes
+	}
+
+	if rules.BestEffort {
+		raw[KeyBestEffort] = rules.BestEffort
+	}
+
+	if rules.Debug {
+		raw[KeyDebug] = rules.Debug
+	}
+
+	if rules.RetryAfter != 0 {
+		raw[KeyRetryAfter] = rules.RetryAfter
+	}
+
+	if rules.Priority != 0 {
+		raw[KeyPriority] = rules.Priority
+	}
+
+	if rules.TLS != nil {
+		raw[KeyTLS] = rules.TLS
+	}
+
+	if rules.Normalize != nil {
+		raw[KeyNormalize] = rules.Normalize
+	}
+
+	if rules.Scope != nil {
+		raw[KeyScope] = rules.Scope
+	}
+
+	if rules.SaveTo != "" {
+		raw[KeySaveTo] = rules.SaveTo
+	}
+
+	if len(rules.Session) > 0 {
+		raw[KeySession] = rules.Session
+	}
+
+	if rules.MaxDuration != 0 {
+		raw[KeyMaxDuration] = fromDuration(rules.MaxDuration)
+	}
+
+	if m, ok := rules.Backoff.(json.Marshaler); ok {
+		raw[KeyBackoff] = m
+	}
+
+	if len(rules.Selectors) > 0 {
+		raw[KeySelectors] = selectorsMap(rules.Selectors)
+	}
+	return json.Marshal(raw)
+}
+
+// ReleaseRules clears and sends the rules to the rules pool, the rules, their selectors
+// and the rules of their Session must not be used after, see SetPooling.
 func ReleaseRules(rules *Rules) {
+	checkReleased(rules.released)
+
 	rules.Clear()
-	rulesPool.Put(rules)
+	switch Pooling() {
+	case PoolingOn:
+		rulesPool.Put(rules)
+	case PoolingDebug:
+		rules.released = true
+	}
+}
+
+// ReleaseSessions releases the rules of a session, see the Session field of the Rules structure.
+func ReleaseSessions(session []*Rules) []*Rules {
+	for _, rules := range session {
+		ReleaseRules(rules)
+	}
+	return nil
 }
@@ -14,12 +14,40 @@ var (
 	"url":             "http://example.com",
 	"proxy":           "http://proxy.example.com:8080",
 	"header":          {"User-Agent": "test/0.2.0"},
+	"form":            {"page": "1"},
+	"json":            {"query": "colibri", "limit": 10},
+	"Body":            "<query>colibri</query>",
+	"basicAuth":       {"username": "colibri", "password": "secret"},
+	"bearerToken":     "token",
+	"profile":         "chrome",
 	"timeout":         2.5,
+	"maxDuration":     60000,
 	"cookies":         true,
 	"ignoreRobotsTXT": true,
+	"respectNofollow": true,
 	"delay":           1.5,
 	"redirects": 3,
+	"redirectPolicy": {"sameHost": true, "status": [301, 308]},
 	"responseBodySize": 5000,
+	"parseTruncated": true,
+	"bufferBody": 4096,
+	"includeBody": true,
+	"includeTLS": true,
+	"cacheNode": true,
+	"preflight": true,
+	"failOnStatus": true,
+	"allowedStatusCodes": [200, 404],
+	"bestEffort": true,
+	"debug": true,
+	"retryAfter": 2,
+	"backoff": {"type": "exponential", "base": 1000, "max": 30000, "jitter": true},
+	"TLS": {"insecureSkipVerify": true, "minVersion": "1.2", "fingerprint": "chrome"},
+	"normalize": {"stripFragment": true, "stripParams": ["utm_*"]},
+	"scope": {"schemes": "http", "domains": [".example.com"]},
+	"saveTo": "page.html",
+	"session": [
+		{"method": "POST", "URL": "http://example.com/login", "form": {"user": "colibri"}}
+	],
 	"Selectors": {
 		"body": {
 			"name": "body",
@@ -31,12 +59,35 @@ var (
 					"expr":   "//a/@href",
 					"all":    true,
 					"follow": true,
+					"concurrency": 4,
+					"submit": {"q": ["colibri"]},
+					"var":    true,
 					"method": "get",
+					"url":    "/api/items/${value}",
 					"proxy":  "http://proxy.example.com:8080",
 					"header": {
 						"User-Agent": ["test/0.2.0"]
 					},
+					"form": {"page": "2"},
 					"timeout": 5000,
+					"priority": 1,
+					"saveTo": "downloads",
+					"onError": "retry",
+					"paginate": {
+						"next": "//a[@rel='next']/@href",
+						"maxPages": 3,
+						"stopWhen": "//p[@class='end']",
+						"url": "/items?cursor=${cursor}",
+						"cursor": "//next",
+						"step": 20
+					},
+					"routes": {
+						"/product/": {
+							"selectors": {
+								"price": "//span[@class='price']"
+							}
+						}
+					},
 					"selectors": {
 						"title": "//title"
 					},
@@ -53,6 +104,7 @@ var (
 	"url":              "http://example.com",
 	"proxy":            "$$$$<:>8080",
 	"header":           {"User-Agent": 0.2},
+	"form":             {"page": 1},
 	"timeout":          "2.5",
 	"cookies":          "true",
 	"ignoreRobotsTXT":  1,
@@ -148,6 +200,8 @@ func TestRules_UnmarshalJSON(t *testing.T) {
 
 		{"nil", []byte(`{}`), &Rules{Extra: make(map[string]any)}, false},
 
+		{"nullJSON", []byte(`{"json": null}`), &Rules{Extra: make(map[string]any)}, false},
+
 		{"null", []byte(`null`), &Rules{}, false},
 
 		{"fail", []byte(`"string"`), nil, true},
@@ -157,6 +211,10 @@ func TestRules_UnmarshalJSON(t *testing.T) {
 		{"errInvalidSelectors", testBadRawRulesJSON_ErrInvalidSelectors, nil, true},
 
 		{"errInvalidSelector", testBadRawRulesJSON_ErrInvalidSelector, nil, true},
+
+		{"errInvalidOnError", []byte(`{"Selectors": {"title": {"expr": "//title", "onError": "ignore"}}}`), nil, true},
+
+		{"errInvalidURL", []byte(`{"Selectors": {"links": {"expr": "//a/@href", "follow": true, "url": "http://[::1"}}}`), nil, true},
 	}
 
 	for _, tt := range tests {
@@ -188,17 +246,36 @@ func TestSelector_Rules(t *testing.T) {
 		Rules    *Rules
 	}{
 		{testRules, testSelector, &Rules{
-			Method:           testSelector.Method,
-			Proxy:            testRules.Proxy,
-			Header:           http.Header{"User-Agent": {"test/0.2.0"}},
-			Timeout:          testRules.Timeout,
-			Cookies:          testRules.Cookies,
-			IgnoreRobotsTxt:  testRules.IgnoreRobotsTxt,
-			Delay:            testRules.Delay,
-			Redirects:        testRules.Redirects,
-			ResponseBodySize: testRules.ResponseBodySize,
-			Selectors:        testSelector.Selectors,
-			Extra:            testSelector.Extra,
+			Method:             testSelector.Method,
+			Proxy:              testRules.Proxy,
+			Header:             http.Header{"User-Agent": {"test/0.2.0"}},
+			BasicAuth:          testRules.BasicAuth,
+			BearerToken:        testRules.BearerToken,
+			Profile:            testRules.Profile,
+			Timeout:            testRules.Timeout,
+			Cookies:            testRules.Cookies,
+			IgnoreRobotsTxt:    testRules.IgnoreRobotsTxt,
+			RespectNofollow:    testRules.RespectNofollow,
+			Delay:              testRules.Delay,
+			Redirects:          testRules.Redirects,
+			RedirectPolicy:     testRules.RedirectPolicy,
+			ResponseBodySize:   testRules.ResponseBodySize,
+			ParseTruncated:     testRules.ParseTruncated,
+			BufferBody:         testRules.BufferBody,
+			IncludeBody:        testRules.IncludeBody,
+			IncludeTLS:         testRules.IncludeTLS,
+			Preflight:          testRules.Preflight,
+			FailOnStatus:       testRules.FailOnStatus,
+			AllowedStatusCodes: testRules.AllowedStatusCodes,
+			BestEffort:         testRules.BestEffort,
+			Debug:              testRules.Debug,
+			RetryAfter:         testRules.RetryAfter,
+			Backoff:            testRules.Backoff,
+			TLS:                testRules.TLS,
+			Normalize:          testRules.Normalize,
+			Scope:              testRules.Scope,
+			Selectors:          testSelector.Selectors,
+			Extra:              testSelector.Extra,
 		}},
 
 		{
@@ -209,6 +286,7 @@ func TestSelector_Rules(t *testing.T) {
 			},
 			&Selector{
 				Method:  "GET",
+				URL:     "/api/items/${value}",
 				Proxy:   mustNewURL("http://proxy.example.com:8080"),
 				Header:  http.Header{"Accept": []string{"text/html"}},
 				Timeout: 10 * time.Millisecond,
@@ -218,6 +296,7 @@ func TestSelector_Rules(t *testing.T) {
 			},
 			&Rules{
 				Method:  "GET",
+				URL:     mustNewURL("/api/items/${value}"),
 				Proxy:   mustNewURL("http://proxy.example.com:8080"),
 				Header:  http.Header{"Accept": []string{"text/html"}},
 				Timeout: 10 * time.Millisecond,
@@ -252,3 +331,21 @@ func BenchmarkRulesJSON(b *testing.B) {
 		ReleaseRules(&rules)
 	}
 }
+
+func TestRules_MarshalJSON(t *testing.T) {
+	b, err := json.Marshal(testRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules := &Rules{}
+	defer ReleaseRules(rules)
+
+	if err := json.Unmarshal(b, rules); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(rules, testRules) {
+		t.Fatal("not equal")
+	}
+}
@@ -0,0 +1,99 @@
+package colibri
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+var (
+	// ErrOutOfScope is returned when the URL of a selector with Follow is not in the Scope of the rules.
+	ErrOutOfScope = errors.New("URL out of scope")
+
+	// ErrInvalidScope is returned when the value is not a valid Scope.
+	ErrInvalidScope = errors.New("invalid scope")
+)
+
+// Scope specifies the URLs that the selectors with Follow can follow.
+type Scope struct {
+	// Schemes contains the allowed schemes, e.g. "https".
+	// If it is empty, any scheme is allowed.
+	Schemes []string `json:"schemes,omitempty"`
+
+	// Domains contains the allowed domains. If it is empty, any domain is allowed.
+	// "example.com" only allows the host example.com, ".example.com" also allows its subdomains
+	// and "*.example.com" only allows its subdomains.
+	Domains []string `json:"domains,omitempty"`
+}
+
+// Check returns ErrOutOfScope if the URL is not in the scope.
+func (scope *Scope) Check(u *url.URL) error {
+	if scope == nil {
+		return nil
+	}
+
+	if (len(scope.Schemes) > 0) && !scope.matchScheme(u.Scheme) {
+		return ErrOutOfScope
+	}
+
+	if (len(scope.Domains) > 0) && !scope.matchDomain(u.Hostname()) {
+		return ErrOutOfScope
+	}
+	return nil
+}
+
+func (scope *Scope) matchScheme(scheme string) bool {
+	for _, s := range scope.Schemes {
+		if strings.EqualFold(s, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+func (scope *Scope) matchDomain(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	for _, domain := range scope.Domains {
+		domain = strings.ToLower(domain)
+
+		switch {
+		case strings.HasPrefix(domain, "*."):
+			if strings.HasSuffix(host, domain[1:]) {
+				return true
+			}
+		case strings.HasPrefix(domain, "."):
+			if (host == domain[1:]) || strings.HasSuffix(host, domain) {
+				return true
+			}
+		case host == domain:
+			return true
+		}
+	}
+	return false
+}
+
+// Clone returns a copy of the scope.
+func (scope *Scope) Clone() *Scope {
+	if scope == nil {
+		return nil
+	}
+
+	return &Scope{
+		Schemes: append([]string(nil), scope.Schemes...),
+		Domains: append([]string(nil), scope.Domains...),
+	}
+}
+
+func toScope(value any) (*Scope, error) {
+	scopeMap, ok := value.(map[string]any)
+	if !ok {
+		return nil, ErrInvalidScope
+	}
+
+	scope := &Scope{}
+	if err := processRaw(scopeMap, scope); err != nil {
+		return nil, err
+	}
+	return scope, nil
+}
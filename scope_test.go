@@ -0,0 +1,42 @@
+package colibri
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScope(t *testing.T) {
+	scope := &Scope{
+		Schemes: []string{"HTTPS"},
+		Domains: []string{"example.com", ".example.org", "*.example.net"},
+	}
+
+	tests := []struct {
+		URL string
+		Err error
+	}{
+		{"https://example.com/a", nil},
+		{"https://EXAMPLE.com:8080/a", nil},
+		{"http://example.com/a", ErrOutOfScope},
+		{"https://www.example.com", ErrOutOfScope},
+		{"https://example.org", nil},
+		{"https://a.b.example.org", nil},
+		{"https://badexample.org", ErrOutOfScope},
+		{"https://example.net", ErrOutOfScope},
+		{"https://www.example.net", nil},
+		{"https://other.com", ErrOutOfScope},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.URL, func(t *testing.T) {
+			if err := scope.Check(mustNewURL(tt.URL)); !errors.Is(err, tt.Err) {
+				t.Fatalf("got %v, want %v", err, tt.Err)
+			}
+		})
+	}
+
+	var nilScope *Scope
+	if err := nilScope.Check(mustNewURL("ftp://example.com")); err != nil {
+		t.Fatal(err)
+	}
+}
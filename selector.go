@@ -1,9 +1,11 @@
 package colibri
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
 	"time"
 )
@@ -11,13 +13,27 @@ import (
 const (
 	KeyAll = "all"
 
+	KeyAsset = "asset"
+
+	KeyConcurrency = "concurrency"
+
 	KeyExpr = "expr"
 
 	KeyFollow = "follow"
 
 	KeyName = "name"
 
+	KeyOnError = "onError"
+
+	KeyPaginate = "paginate"
+
+	KeyRoutes = "routes"
+
+	KeySubmit = "submit"
+
 	KeyType = "type"
+
+	KeyVar = "var"
 )
 
 var (
@@ -26,8 +42,34 @@ var (
 
 	// ErrInvalidSelectors is returned when the value is not a valid selector value.
 	ErrInvalidSelectors = errors.New("invalid selectors")
+
+	// ErrInvalidOnError is returned when the OnError of a selector is not a valid policy.
+	ErrInvalidOnError = errors.New("invalid OnError policy")
+
+	// ErrInvalidRoute is returned when the pattern of a route is not a valid regular expression.
+	ErrInvalidRoute = errors.New("invalid route pattern")
+)
+
+// Error policies of the selectors, see the OnError field of the Selector structure.
+const (
+	// OnErrorFail adds the error of the selector to the errors of the parent, it is the default policy.
+	OnErrorFail = "fail"
+
+	// OnErrorSkip discards the error and the value of the selector.
+	OnErrorSkip = "skip"
+
+	// OnErrorNull discards the error and the value of the selector is nil.
+	OnErrorNull = "null"
+
+	// OnErrorRetry finds the selector again, up to SelectorRetries times, waiting the Backoff of the rules
+	// before each retry. The URLs requested by the selector are requested again even if they were visited.
+	// If the error persists it is added to the errors of the parent.
+	OnErrorRetry = "retry"
 )
 
+// SelectorRetries is the maximum number of retries of the selectors with the OnErrorRetry policy.
+const SelectorRetries = 2
+
 var selectorPool = sync.Pool{
 	New: func() any {
 		return &Selector{Extra: make(map[string]any)}
@@ -50,28 +92,83 @@ type Selector struct {
 	// Follow specifies whether the URLs found by the selector should be followed.
 	Follow bool
 
+	// Concurrency specifies the maximum number of URLs followed at the same time,
+	// the outputs keep the order of the URLs. If it is less than 2, the URLs are followed one by one.
+	Concurrency int
+
+	// Asset specifies whether the URLs found by the selector are assets
+	// that are downloaded and stored by the Media component of Colibri.
+	// The value of the selector is the location in which each asset is stored.
+	Asset bool
+
+	// Submit specifies whether the forms found by the selector are submitted,
+	// the values override the fields of the forms. As with Follow, the value of
+	// the selector is the output of each submitted form, see the FormNode interface.
+	Submit url.Values
+
+	// Var specifies whether the value of the selector is available as a variable
+	// to the other selectors of the rules and to the requests of their Follow, see WithVars.
+	// The selectors with Var are found before the rest.
+	Var bool
+
 	// Method specifies the HTTP method (GET, POST, PUT, ...).
 	Method string
 
+	// URL specifies the template of the URL of the requests of Follow, e.g. "/api/items/${value}".
+	// If it is not empty, the values found by the selector are not the URLs to follow: the requests are
+	// made to the URL with the placeholder ${value} replaced by each value. The placeholder
+	// can also be used in the Header and Form, see ValueVar.
+	URL string
+
 	// Proxy specifies the URL of the proxy.
 	Proxy *url.URL
 
 	// Header contains the HTTP header.
 	Header http.Header
 
+	// Form contains the fields of the form sent in the requests of the followed URLs,
+	// see the Form field of the Rules structure.
+	Form url.Values
+
 	// Timeout specifies the time limit for the HTTP request.
 	Timeout time.Duration
 
+	// Priority specifies the crawl priority of the followed URLs.
+	Priority int
+
+	// SaveTo specifies the path of the file or directory in which the response bodies
+	// of the followed URLs are stored, see the SaveTo field of the Rules structure.
+	SaveTo string
+
+	// OnError specifies what happens when the selector fails: OnErrorFail, OnErrorSkip,
+	// OnErrorNull or OnErrorRetry. If it is empty, OnErrorFail is used.
+	OnError string
+
+	// Paginate specifies how the following pages are found, the selector is found in each page
+	// and the values of all the pages are returned, see the Pagination structure.
+	Paginate *Pagination
+
+	// Routes contains the rules of the followed URLs that match a pattern, e.g. "/product/" or "/category/",
+	// so different kinds of pages get different selectors. The patterns are regular expressions matched
+	// against the URL, the longest pattern that matches is used. The rules of the route override
+	// the rules of the selector, see Rules.Inherit, and the Selectors of the route, if any,
+	// replace the selectors of the selector instead of being merged with them.
+	Routes map[string]*Rules
+
 	// Selectors nested selectors.
 	Selectors []*Selector
 
 	// Extra stores additional data.
 	Extra map[string]any
+
+	compiled     *exprCache
+	sortedRoutes *compiledRoutes
+	released     bool
 }
 
 func newSelector(name string, rawSelector any) (*Selector, error) {
 	var (
-		selector = selectorPool.Get().(*Selector)
+		selector = getSelector()
 		err      error
 	)
 
@@ -87,6 +184,22 @@ func newSelector(name string, rawSelector any) (*Selector, error) {
 		return nil, ErrInvalidSelector
 	}
 
+	if compileErr := selector.compile(); compileErr != nil {
+		err = AddError(err, KeyExpr, compileErr)
+	}
+
+	if !validOnError(selector.OnError) {
+		err = AddError(err, KeyOnError, ErrInvalidOnError)
+	}
+
+	if _, urlErr := url.Parse(selector.URL); urlErr != nil {
+		err = AddError(err, KeyURL, urlErr)
+	}
+
+	if routesErr := selector.compileRoutes(); routesErr != nil {
+		err = AddError(err, KeyRoutes, routesErr)
+	}
+
 	selector.Name = name
 	return selector, err
 }
@@ -125,12 +238,20 @@ func newSelectors(rawSelectors any) ([]*Selector, error) {
 // If the selector does not have a specified value for the Proxy, User-Agent, or Timeout fields,
 // the values from the source rules are used.
 //
-// The values for the Cookies, IgnoreRobotsTxt, Delay, Redirects, ResponseBodySize fields are obtained from the source rules.
+// The other request options, such as Cookies, Delay or Redirects, are obtained from the source rules,
+// while URL, Form, Priority and SaveTo are obtained from the selector.
 func (sel *Selector) Rules(src *Rules) *Rules {
-	newRules := rulesPool.Get().(*Rules)
+	checkReleased(sel.released || src.released)
+
+	newRules := getRules()
 
 	newRules.Method = sel.Method
 
+	// The URL template is used by the selectors with Follow.
+	if sel.URL != "" {
+		newRules.URL, _ = url.Parse(sel.URL)
+	}
+
 	if sel.Proxy != nil {
 		newRules.Proxy = sel.Proxy.ResolveReference(&url.URL{})
 	} else if src.Proxy != nil {
@@ -148,6 +269,11 @@ func (sel *Selector) Rules(src *Rules) *Rules {
 		}
 	}
 
+	newRules.Form = cloneValues(sel.Form)
+	newRules.BasicAuth = src.BasicAuth.Clone()
+	newRules.BearerToken = src.BearerToken
+	newRules.Profile = src.Profile
+
 	if sel.Timeout == 0 {
 		newRules.Timeout = src.Timeout
 	} else if sel.Timeout > 0 {
@@ -156,9 +282,27 @@ func (sel *Selector) Rules(src *Rules) *Rules {
 
 	newRules.Cookies = src.Cookies
 	newRules.IgnoreRobotsTxt = src.IgnoreRobotsTxt
+	newRules.RespectNofollow = src.RespectNofollow
 	newRules.Delay = src.Delay
 	newRules.Redirects = src.Redirects
+	newRules.RedirectPolicy = src.RedirectPolicy.Clone()
 	newRules.ResponseBodySize = src.ResponseBodySize
+	newRules.ParseTruncated = src.ParseTruncated
+	newRules.BufferBody = src.BufferBody
+	newRules.IncludeBody = src.IncludeBody
+	newRules.IncludeTLS = src.IncludeTLS
+	newRules.Preflight = src.Preflight
+	newRules.FailOnStatus = src.FailOnStatus
+	newRules.AllowedStatusCodes = append([]int(nil), src.AllowedStatusCodes...)
+	newRules.BestEffort = src.BestEffort
+	newRules.Debug = src.Debug
+	newRules.RetryAfter = src.RetryAfter
+	newRules.Backoff = src.Backoff
+	newRules.Priority = sel.Priority
+	newRules.SaveTo = sel.SaveTo
+	newRules.TLS = src.TLS.Clone()
+	newRules.Normalize = src.Normalize.Clone()
+	newRules.Scope = src.Scope.Clone()
 
 	if len(sel.Selectors) > 0 {
 		newRules.Selectors = CloneSelectors(sel.Selectors)
@@ -169,6 +313,7 @@ func (sel *Selector) Rules(src *Rules) *Rules {
 		newRules.Extra[key] = value
 	}
 
+	newRules.ctx = src.ctx
 	return newRules
 }
 
@@ -176,22 +321,43 @@ func (sel *Selector) Rules(src *Rules) *Rules {
 //
 // Cloning the Extra field can cause errors, so you should avoid storing pointers.
 func (sel *Selector) Clone() *Selector {
-	newSelector := selectorPool.Get().(*Selector)
+	checkReleased(sel.released)
+
+	newSelector := getSelector()
 
 	newSelector.Name = sel.Name
 	newSelector.Expr = sel.Expr
 	newSelector.Type = sel.Type
+	newSelector.compiled = sel.compiled
+	newSelector.sortedRoutes = sel.sortedRoutes
 	newSelector.All = sel.All
 	newSelector.Follow = sel.Follow
+	newSelector.Concurrency = sel.Concurrency
+	newSelector.Asset = sel.Asset
+	newSelector.Submit = cloneValues(sel.Submit)
+	newSelector.Var = sel.Var
 
 	newSelector.Method = sel.Method
+	newSelector.URL = sel.URL
 
 	if sel.Proxy != nil {
 		newSelector.Proxy = sel.Proxy.ResolveReference(&url.URL{})
 	}
 
 	newSelector.Header = sel.Header.Clone()
+	newSelector.Form = cloneValues(sel.Form)
 	newSelector.Timeout = sel.Timeout
+	newSelector.Priority = sel.Priority
+	newSelector.SaveTo = sel.SaveTo
+	newSelector.OnError = sel.OnError
+	newSelector.Paginate = sel.Paginate.Clone()
+
+	if sel.Routes != nil {
+		newSelector.Routes = make(map[string]*Rules, len(sel.Routes))
+		for pattern, rules := range sel.Routes {
+			newSelector.Routes[pattern] = rules.Clone()
+		}
+	}
 
 	if len(sel.Selectors) > 0 {
 		newSelector.Selectors = CloneSelectors(sel.Selectors)
@@ -211,22 +377,133 @@ func (sel *Selector) Clear() {
 	sel.Name = ""
 	sel.Expr = ""
 	sel.Type = ""
+	sel.compiled = nil
+	sel.sortedRoutes = nil
 	sel.All = false
 	sel.Follow = false
+	sel.Concurrency = 0
+	sel.Asset = false
+	sel.Submit = nil
+	sel.Var = false
 
 	sel.Method = ""
+	sel.URL = ""
 	sel.Proxy = nil
 	sel.Header = nil
+	sel.Form = nil
 	sel.Timeout = 0
+	sel.Priority = 0
+	sel.SaveTo = ""
+	sel.OnError = ""
+	sel.Paginate = nil
+
+	for _, rules := range sel.Routes {
+		ReleaseRules(rules)
+	}
+	sel.Routes = nil
 
 	sel.Selectors = ReleaseSelectors(sel.Selectors)
-	clear(sel.Extra)
+	sel.Extra = make(map[string]any)
 }
 
-// ReleaseRules clears and sends the selector to the selector pool.
+func (sel *Selector) MarshalJSON() ([]byte, error) {
+	raw := make(map[string]any, len(sel.Extra))
+	for key, value := range sel.Extra {
+		raw[key] = value
+	}
+
+	raw[KeyExpr] = sel.Expr
+
+	if sel.Type != "" {
+		raw[KeyType] = sel.Type
+	}
+
+	if sel.All {
+		raw[KeyAll] = sel.All
+	}
+
+	if sel.Follow {
+		raw[KeyFollow] = sel.Follow
+	}
+
+	if sel.Concurrency != 0 {
+		raw[KeyConcurrency] = sel.Concurrency
+	}
+
+	if sel.Asset {
+		raw[KeyAsset] = sel.Asset
+	}
+
+	if sel.Submit != nil {
+		raw[KeySubmit] = sel.Submit
+	}
+
+	if sel.Var {
+		raw[KeyVar] = sel.Var
+	}
+
+	if sel.Method != "" {
+		raw[KeyMethod] = sel.Method
+	}
+
+	if sel.URL != "" {
+		raw[KeyURL] = sel.URL
+	}
+
+	if sel.Proxy != nil {
+		raw[KeyProxy] = sel.Proxy.String()
+	}
+
+	if sel.Header != nil {
+		raw[KeyHeader] = sel.Header
+	}
+
+	if sel.Form != nil {
+		raw[KeyForm] = sel.Form
+	}
+
+	if sel.Timeout != 0 {
+		raw[KeyTimeout] = fromDuration(sel.Timeout)
+	}
+
+	if sel.Priority != 0 {
+		raw[KeyPriority] = sel.Priority
+	}
+
+	if sel.SaveTo != "" {
+		raw[KeySaveTo] = sel.SaveTo
+	}
+
+	if sel.OnError != "" {
+		raw[KeyOnError] = sel.OnError
+	}
+
+	if sel.Paginate != nil {
+		raw[KeyPaginate] = sel.Paginate
+	}
+
+	if len(sel.Routes) > 0 {
+		raw[KeyRoutes] = sel.Routes
+	}
+
+	if len(sel.Selectors) > 0 {
+		raw[KeySelectors] = selectorsMap(sel.Selectors)
+	}
+	return json.Marshal(raw)
+}
+
+// ReleaseSelector clears and sends the selector to the selector pool,
+// the selector and its nested selectors must not be used after, see SetPooling.
 func ReleaseSelector(selector *Selector) {
+	checkReleased(selector.released)
+
 	selector.Clear()
-	selectorPool.Put(selector)
+	switch Pooling() {
+	case PoolingOn:
+		selectorPool.Put(selector)
+	case PoolingDebug:
+		selector.released = true
+	}
 }
 
 func ReleaseSelectors(selectors []*Selector) []*Selector {
@@ -236,6 +513,24 @@ func ReleaseSelectors(selectors []*Selector) []*Selector {
 	return nil
 }
 
+// validOnError returns true if the policy is empty or one of the OnError policies.
+func validOnError(policy string) bool {
+	switch strings.ToLower(policy) {
+	case "", OnErrorFail, OnErrorSkip, OnErrorNull, OnErrorRetry:
+		return true
+	}
+	return false
+}
+
+// selectorsMap returns the selectors indexed by name.
+func selectorsMap(selectors []*Selector) map[string]*Selector {
+	result := make(map[string]*Selector, len(selectors))
+	for _, selector := range selectors {
+		result[selector.Name] = selector
+	}
+	return result
+}
+
 // CloneSelectors clones the selectors.
 func CloneSelectors(selectors []*Selector) []*Selector {
 	result := make([]*Selector, 0, len(selectors))
@@ -0,0 +1,74 @@
+package colibri
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// SelectorError is an error of a selector, it contains the path of the selector
+// and the URL of the response or of the followed URL in which the error occurred.
+// The errors stored in Errs by the selectors are of this type, see errors.As.
+type SelectorError struct {
+	// Path contains the names of the selectors from the root rules to the selector,
+	// the selectors with All add the index of the node, e.g. ["items", "0", "title"].
+	Path []string
+
+	// URL is the URL of the response or of the followed URL.
+	URL *url.URL
+
+	// Err is the original error.
+	Err error
+}
+
+// Error returns the message of the original error.
+func (err *SelectorError) Error() string {
+	return err.Err.Error()
+}
+
+func (err *SelectorError) Unwrap() error {
+	return err.Err
+}
+
+// PathString returns the path of the selector with its names separated by dots.
+func (err *SelectorError) PathString() string {
+	return strings.Join(err.Path, ".")
+}
+
+// selectorError returns the error with the path of the selectors of the rules followed by the names,
+// and the URL. The *Errs and the *SelectorError are returned as they are,
+// since their errors already contain the path.
+func selectorError(rules *Rules, u *url.URL, err error, names ...string) error {
+	switch err.(type) {
+	case nil, *Errs, *SelectorError:
+		return err
+	}
+
+	path := selectorPath(rules.Context())
+	return &SelectorError{
+		Path: append(path[:len(path):len(path)], names...),
+		URL:  u,
+		Err:  err,
+	}
+}
+
+type selectorPathKey struct{}
+
+// selectorPath returns the path of the selectors stored in the context.
+func selectorPath(ctx context.Context) []string {
+	path, _ := ctx.Value(selectorPathKey{}).([]string)
+	return path
+}
+
+// withSelectorPath returns a copy of the context whose selector path ends with the names.
+func withSelectorPath(ctx context.Context, names ...string) context.Context {
+	path := selectorPath(ctx)
+	return context.WithValue(ctx, selectorPathKey{}, append(path[:len(path):len(path)], names...))
+}
+
+// selectorRules returns the rules of the selector, their context contains the path of the selector.
+func selectorRules(src *Rules, selector *Selector) *Rules {
+	rules := selector.Rules(src)
+	rules.SetContext(withSelectorPath(rules.Context(), selector.Name))
+	return rules
+}
@@ -0,0 +1,82 @@
+package colibri
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSelectorError(t *testing.T) {
+	testErr := errors.New("test err")
+
+	c := New()
+	c.Client = &testClient{}
+	c.Parser = &testParser{}
+
+	rules := &Rules{Selectors: []*Selector{
+		{
+			Name: "body",
+			Expr: "//body",
+			Selectors: []*Selector{
+				{
+					Name:      "imgs",
+					Expr:      "//img",
+					All:       true,
+					Selectors: []*Selector{{Name: "src", Expr: "!error"}},
+				},
+			},
+		},
+		{
+			Name:   "links",
+			Expr:   "//a/@href",
+			All:    true,
+			Follow: true,
+			Extra:  map[string]any{"doErr": testErr},
+		},
+	}}
+
+	_, err := FindSelectors(rules, &testResponse{c: c}, &testNode{})
+	if err == nil {
+		t.Fatal("nil error")
+	}
+
+	tests := []struct {
+		Keys []string
+		Path []string
+		URL  string
+	}{
+		{[]string{"body", "imgs", "0", "src"}, []string{"body", "imgs", "0", "src"}, "http://example.com"},
+		{[]string{"links", "http://example.com/test"}, []string{"links"}, "http://example.com/test"},
+	}
+
+	for _, tt := range tests {
+		e := err
+		for _, key := range tt.Keys {
+			errs, ok := e.(*Errs)
+			if !ok {
+				t.Fatalf("%v: got %T, want *Errs", key, e)
+			}
+
+			if e, ok = errs.Get(key); !ok {
+				t.Fatalf("%v: missing error", key)
+			}
+		}
+
+		var selectorErr *SelectorError
+		if !errors.As(e, &selectorErr) {
+			t.Fatalf("got %T, want *SelectorError", e)
+		}
+
+		if !reflect.DeepEqual(selectorErr.Path, tt.Path) {
+			t.Fatalf("got %v, want %v", selectorErr.Path, tt.Path)
+		}
+
+		if selectorErr.URL.String() != tt.URL {
+			t.Fatalf("got %v, want %v", selectorErr.URL, tt.URL)
+		}
+
+		if e.Error() != testErr.Error() {
+			t.Fatalf("got %v, want %v", e, testErr)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package colibri
+
+import (
+	"context"
+	"strconv"
+)
+
+type sessionKey struct{}
+
+// startSession extracts the data of the session rules in order and sets the context
+// of the rules with the extracted data as variables, so the session is executed only once
+// for the rules and the rules of their selectors.
+// The session rules are not marked as visited and their content is not checked for duplicates.
+func (c *Colibri) startSession(rules *Rules) error {
+	var (
+		ctx  = context.WithValue(rules.Context(), sessionKey{}, true)
+		vars = make(map[string]any)
+		s    = *c
+	)
+	s.Visited = nil
+	s.Duplicates = nil
+
+	for i, sRules := range rules.Session {
+		if sRules == nil {
+			continue
+		}
+
+		stepRules := sRules.Clone()
+		stepRules.SetContext(WithVars(ctx, vars))
+
+		output, err := s.Extract(stepRules)
+		ReleaseRules(stepRules)
+		if err != nil {
+			return AddError(nil, KeySession, AddError(nil, strconv.Itoa(i), err))
+		}
+
+		for key, value := range output.Data {
+			vars[key] = value
+		}
+	}
+
+	rules.SetContext(WithVars(ctx, vars))
+	return nil
+}
+
+// inSession returns true if the session of the context has already been started.
+func inSession(ctx context.Context) bool {
+	started, _ := ctx.Value(sessionKey{}).(bool)
+	return started
+}
@@ -0,0 +1,124 @@
+package colibri
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrShutdown is returned when a request or an extraction is started after Shutdown.
+var ErrShutdown = errors.New("colibri is shut down")
+
+// Flusher is implemented by the sinks that buffer the outputs, see Shutdown.
+type Flusher interface {
+	// Flush writes the buffered outputs without closing the sink.
+	Flush() error
+}
+
+// lifecycle tracks the requests and the extractions in progress, so Shutdown can wait for them.
+type lifecycle struct {
+	mu      sync.Mutex
+	closing bool
+	active  int
+
+	// shutdown is closed when Shutdown is called and idle when the operations in progress end.
+	shutdown chan struct{}
+	idle     chan struct{}
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{
+		shutdown: make(chan struct{}),
+		idle:     make(chan struct{}),
+	}
+}
+
+// begin registers an operation in progress, returns false if Shutdown has been called.
+func (l *lifecycle) begin() bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closing {
+		return false
+	}
+	l.active++
+	return true
+}
+
+// end unregisters an operation registered with begin.
+func (l *lifecycle) end() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.active--
+	if l.closing && (l.active == 0) {
+		close(l.idle)
+	}
+}
+
+// closed reports whether Shutdown has been called.
+func (l *lifecycle) closed() bool {
+	if l == nil {
+		return false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closing
+}
+
+// done returns a channel that is closed when Shutdown is called.
+func (l *lifecycle) done() <-chan struct{} {
+	if l == nil {
+		return nil
+	}
+	return l.shutdown
+}
+
+// Shutdown stops the scheduling of new work: the requests, including the ones of the selectors
+// with Follow, return ErrShutdown and Crawl, ExtractAll and Monitor stop choosing new rules.
+// Then it waits for the requests in progress and for Crawl and ExtractAll to flush their sinks
+// that implement Flusher and to store their checkpoints. The pages interrupted by Shutdown
+// remain pending in the checkpoint of the crawl.
+//
+// If the context is done before, its error is returned. After Shutdown, c can not be used again.
+// Shutdown is only available if c was created with New, otherwise it returns nil immediately.
+func (c *Colibri) Shutdown(ctx context.Context) error {
+	l := c.life
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	if !l.closing {
+		l.closing = true
+		close(l.shutdown)
+		if l.active == 0 {
+			close(l.idle)
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-l.idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// flushSink flushes the sink if it implements Flusher.
+func flushSink(sink Sink) error {
+	if f, ok := sink.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package colibri
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type blockingClient struct {
+	testClient
+	once    sync.Once
+	started chan struct{}
+	release chan struct{}
+}
+
+func (client *blockingClient) Do(c *Colibri, rules *Rules) (Response, error) {
+	client.once.Do(func() { close(client.started) })
+	<-client.release
+	return client.testClient.Do(c, rules)
+}
+
+type testFlushSink struct {
+	testSink
+	flushed int
+}
+
+func (sink *testFlushSink) Flush() error {
+	sink.flushed++
+	return nil
+}
+
+func TestShutdown(t *testing.T) {
+	client := &blockingClient{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+
+	c := New()
+	c.Client = client
+	c.Parser = &testParser{}
+
+	var (
+		path = filepath.Join(t.TempDir(), "checkpoint.json")
+		sink = &testFlushSink{}
+		done = make(chan error, 1)
+	)
+	go func() {
+		done <- c.Crawl(testCrawlRules, &CrawlOptions{Sink: sink, Checkpoint: path})
+	}()
+
+	<-client.started
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- c.Shutdown(context.Background())
+	}()
+
+	// Shutdown waits for the request in progress.
+	select {
+	case err := <-shutdown:
+		t.Fatalf("got %v, want waiting", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(client.release)
+
+	if err := <-shutdown; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := <-done; !errors.Is(err, ErrShutdown) {
+		t.Fatalf("got %v, want %v", err, ErrShutdown)
+	}
+
+	if (len(sink.outputs) != 1) || (sink.flushed != 1) {
+		t.Fatalf("got %v outputs and %v flushes, want 1 and 1", len(sink.outputs), sink.flushed)
+	}
+
+	// The followed URL remains pending.
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var state checkpoint
+	if err := json.Unmarshal(b, &state); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(state.Pending) != 1 {
+		t.Fatalf("got %v, want %v", len(state.Pending), 1)
+	}
+
+	if _, err := c.Do(&Rules{URL: mustNewURL("http://example.com")}); !errors.Is(err, ErrShutdown) {
+		t.Fatalf("got %v, want %v", err, ErrShutdown)
+	}
+
+	if _, err := c.ExtractAll([]*Rules{testCrawlRules}, nil); !errors.Is(err, ErrShutdown) {
+		t.Fatalf("got %v, want %v", err, ErrShutdown)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	client := &blockingClient{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	defer close(client.release)
+
+	c := New()
+	c.Client = client
+
+	go c.Do(&Rules{URL: mustNewURL("http://example.com")})
+	<-client.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := c.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
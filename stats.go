@@ -0,0 +1,189 @@
+package colibri
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Stats contains the counters of the requests made by a Colibri since it was created
+// or since the last Clear, see Colibri.Stats.
+type Stats struct {
+	// Requests is the number of requests made, without the retries.
+	Requests int64 `json:"requests"`
+
+	// Errors is the number of requests that failed, including the unexpected status codes.
+	Errors int64 `json:"errors"`
+
+	// Retries is the number of requests repeated by the Client, see the Attempter interface.
+	Retries int64 `json:"retries"`
+
+	// RobotsDenied is the number of requests not allowed by robots.txt.
+	RobotsDenied int64 `json:"robotsDenied"`
+
+	// CacheHits is the number of responses and outputs reused from the caches of the Extract,
+	// see the ResponseCache field of Colibri.
+	CacheHits int64 `json:"cacheHits"`
+
+	// Bytes is the number of bytes read from the extracted response bodies.
+	Bytes int64 `json:"bytes"`
+
+	// Hosts contains the counters of each host.
+	Hosts map[string]HostStats `json:"hosts,omitempty"`
+}
+
+// HostStats contains the counters of the requests made to a host, see Stats.
+type HostStats struct {
+	Requests     int64 `json:"requests"`
+	Errors       int64 `json:"errors"`
+	Retries      int64 `json:"retries"`
+	RobotsDenied int64 `json:"robotsDenied"`
+	Bytes        int64 `json:"bytes"`
+}
+
+// Reporter is implemented by the components of Colibri that report their internal statistics,
+// e.g. the hosts tracked by a Delay or the transports of a Client.
+type Reporter interface {
+	// Report returns the statistics of the component, the value must be encodable as JSON.
+	Report() any
+}
+
+// statsCounters stores the Stats of a Colibri.
+type statsCounters struct {
+	mu    sync.Mutex
+	stats Stats
+}
+
+// host updates the counters of the host of the URL with f, the mutex must be locked.
+func (sc *statsCounters) host(u *url.URL, f func(host *HostStats)) {
+	if u == nil {
+		return
+	}
+
+	if sc.stats.Hosts == nil {
+		sc.stats.Hosts = make(map[string]HostStats)
+	}
+
+	name := strings.ToLower(u.Hostname())
+	host := sc.stats.Hosts[name]
+	f(&host)
+	sc.stats.Hosts[name] = host
+}
+
+// Stats returns a snapshot of the counters of the requests, e.g. for post-run reporting.
+// The counters are only available if c was created with New.
+func (c *Colibri) Stats() Stats {
+	if (c == nil) || (c.counters == nil) {
+		return Stats{}
+	}
+
+	c.counters.mu.Lock()
+	defer c.counters.mu.Unlock()
+
+	stats := c.counters.stats
+	if stats.Hosts != nil {
+		stats.Hosts = make(map[string]HostStats, len(c.counters.stats.Hosts))
+		for name, host := range c.counters.stats.Hosts {
+			stats.Hosts[name] = host
+		}
+	}
+	return stats
+}
+
+// countRequest counts the request to the URL, its retries and its error.
+func (c *Colibri) countRequest(u *url.URL, resp Response, err error) {
+	if (c == nil) || (c.counters == nil) {
+		return
+	}
+
+	var retries int64
+	if a, ok := resp.(Attempter); ok && (a.Attempts() > 1) {
+		retries = int64(a.Attempts() - 1)
+	}
+
+	var failed int64
+	if err != nil {
+		failed = 1
+	}
+
+	c.counters.mu.Lock()
+	defer c.counters.mu.Unlock()
+
+	c.counters.stats.Requests++
+	c.counters.stats.Errors += failed
+	c.counters.stats.Retries += retries
+	c.counters.host(u, func(host *HostStats) {
+		host.Requests++
+		host.Errors += failed
+		host.Retries += retries
+	})
+}
+
+// countRobots counts the request to the URL if robots.txt does not allow it.
+func (c *Colibri) countRobots(u *url.URL, err error) {
+	if (c == nil) || (c.counters == nil) || !errors.Is(err, ErrRobotstxtRestriction) {
+		return
+	}
+
+	c.counters.mu.Lock()
+	defer c.counters.mu.Unlock()
+
+	c.counters.stats.RobotsDenied++
+	c.counters.host(u, func(host *HostStats) {
+		host.RobotsDenied++
+	})
+}
+
+// countCacheHit counts a response or an output reused from a cache.
+func (c *Colibri) countCacheHit() {
+	if (c == nil) || (c.counters == nil) {
+		return
+	}
+
+	c.counters.mu.Lock()
+	c.counters.stats.CacheHits++
+	c.counters.mu.Unlock()
+}
+
+// CountBytes counts the bytes read from the response body of the URL in the Stats and in the Budget.
+// It is used by the clients whose responses implement ByteCounter, the bytes of the rest
+// of the responses are counted by Extract.
+func (c *Colibri) CountBytes(u *url.URL, n int64) {
+	if c == nil {
+		return
+	}
+	c.Budget.addBytes(u, n)
+
+	if (c.counters == nil) || (n <= 0) {
+		return
+	}
+
+	c.counters.mu.Lock()
+	defer c.counters.mu.Unlock()
+
+	c.counters.stats.Bytes += n
+	c.counters.host(u, func(host *HostStats) {
+		host.Bytes += n
+	})
+}
+
+// clear resets the counters.
+func (sc *statsCounters) clear() {
+	if sc == nil {
+		return
+	}
+
+	sc.mu.Lock()
+	sc.stats = Stats{}
+	sc.mu.Unlock()
+}
+
+// countResponseBytes counts the bytes read from the body of the response,
+// unless the response counts them itself, see ByteCounter.
+func (c *Colibri) countResponseBytes(u *url.URL, resp Response, n int64) {
+	if counter, ok := resp.(ByteCounter); ok && counter.CountsBytes() {
+		return
+	}
+	c.CountBytes(u, n)
+}
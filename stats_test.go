@@ -0,0 +1,69 @@
+package colibri
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testRobotsDenied struct{}
+
+func (testRobotsDenied) IsAllowed(_ *Colibri, rules *Rules) error {
+	if rules.Extra["denied"] != nil {
+		return ErrRobotstxtRestriction
+	}
+	return nil
+}
+
+func (testRobotsDenied) Clear() {}
+
+func TestStats(t *testing.T) {
+	c := New()
+	c.Client = &testClient{}
+	c.Parser = &testParser{}
+	c.RobotsTxt = testRobotsDenied{}
+	c.ResponseCache = 8
+
+	rules := &Rules{
+		URL: mustNewURL("http://example.com"),
+		Selectors: []*Selector{
+			{Name: "title", Expr: "//title"},
+			{Name: "a", Expr: "//a/@href", Follow: true},
+			{Name: "b", Expr: "//a/@href", Follow: true},
+		},
+		Extra: map[string]any{},
+	}
+	if _, err := c.Extract(rules); err != nil {
+		t.Fatal(err)
+	}
+
+	errRules := &Rules{URL: mustNewURL("http://EXAMPLE.org/a"), Extra: map[string]any{"doErr": errors.New("do error")}}
+	if _, err := c.Do(errRules); err == nil {
+		t.Fatal("error expected")
+	}
+
+	deniedRules := &Rules{URL: mustNewURL("http://example.org/b"), Extra: map[string]any{"denied": true}}
+	if _, err := c.Do(deniedRules); !errors.Is(err, ErrRobotstxtRestriction) {
+		t.Fatalf("got %v, want %v", err, ErrRobotstxtRestriction)
+	}
+
+	// The followed URL is requested once, the output is shared by both selectors.
+	want := Stats{
+		Requests:     3,
+		Errors:       1,
+		RobotsDenied: 1,
+		CacheHits:    1,
+		Hosts: map[string]HostStats{
+			"example.com": {Requests: 2},
+			"example.org": {Requests: 1, Errors: 1, RobotsDenied: 1},
+		},
+	}
+	if got := c.Stats(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	c.Clear()
+	if got := c.Stats(); !reflect.DeepEqual(got, Stats{}) {
+		t.Fatalf("got %+v, want %+v", got, Stats{})
+	}
+}
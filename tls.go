@@ -0,0 +1,120 @@
+package colibri
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+)
+
+var (
+	// ErrInvalidTLS is returned when the value is not a valid TLS configuration.
+	ErrInvalidTLS = errors.New("invalid TLS")
+
+	// ErrTLSVersion is returned when the TLS version is not supported.
+	ErrTLSVersion = errors.New("unsupported TLS version")
+
+	// ErrRootCAs is returned when no certificate could be parsed from RootCAs.
+	ErrRootCAs = errors.New("no valid root CA certificates")
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLS specifies the TLS configuration of the HTTP requests.
+type TLS struct {
+	// InsecureSkipVerify specifies whether the server certificate should not be verified.
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+
+	// RootCAs contains the PEM encoded certificates of the root certificate authorities.
+	// If it is empty, the system root certificates are used.
+	RootCAs string `json:"rootCAs,omitempty"`
+
+	// Certificate contains the PEM encoded client certificate.
+	Certificate string `json:"certificate,omitempty"`
+
+	// Key contains the PEM encoded private key of the client certificate.
+	Key string `json:"key,omitempty"`
+
+	// MinVersion specifies the minimum TLS version ("1.0", "1.1", "1.2" or "1.3").
+	MinVersion string `json:"minVersion,omitempty"`
+
+	// ServerName overrides the server name used for SNI and to verify the server certificate.
+	ServerName string `json:"serverName,omitempty"`
+
+	// Fingerprint specifies the browser whose TLS ClientHello is imitated, e.g. "chrome".
+	// The supported fingerprints depend on the client.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Config returns a *tls.Config with the TLS configuration applied to a copy of base.
+// If base is nil, an empty configuration is used.
+func (t *TLS) Config(base *tls.Config) (*tls.Config, error) {
+	config := &tls.Config{}
+	if base != nil {
+		config = base.Clone()
+	}
+
+	if t == nil {
+		return config, nil
+	}
+
+	if t.InsecureSkipVerify {
+		config.InsecureSkipVerify = true
+	}
+
+	if t.RootCAs != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(t.RootCAs)) {
+			return nil, ErrRootCAs
+		}
+		config.RootCAs = pool
+	}
+
+	if (t.Certificate != "") || (t.Key != "") {
+		cert, err := tls.X509KeyPair([]byte(t.Certificate), []byte(t.Key))
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if t.MinVersion != "" {
+		version, ok := tlsVersions[t.MinVersion]
+		if !ok {
+			return nil, ErrTLSVersion
+		}
+		config.MinVersion = version
+	}
+
+	if t.ServerName != "" {
+		config.ServerName = t.ServerName
+	}
+	return config, nil
+}
+
+// Clone returns a copy of the TLS configuration.
+func (t *TLS) Clone() *TLS {
+	if t == nil {
+		return nil
+	}
+
+	newTLS := *t
+	return &newTLS
+}
+
+func toTLS(value any) (*TLS, error) {
+	tlsMap, ok := value.(map[string]any)
+	if !ok {
+		return nil, ErrInvalidTLS
+	}
+
+	t := &TLS{}
+	if err := processRaw(tlsMap, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
@@ -0,0 +1,64 @@
+package colibri
+
+import (
+	"crypto/tls"
+	"errors"
+	"testing"
+)
+
+func TestTLS_Config(t *testing.T) {
+	tests := []struct {
+		Name string
+		TLS  *TLS
+		Base *tls.Config
+		Want func(*tls.Config) bool
+		Err  error
+	}{
+		{
+			Name: "nil",
+			Want: func(c *tls.Config) bool { return !c.InsecureSkipVerify },
+		},
+		{
+			Name: "base",
+			TLS:  &TLS{ServerName: "example.com"},
+			Base: &tls.Config{InsecureSkipVerify: true},
+			Want: func(c *tls.Config) bool { return c.InsecureSkipVerify && (c.ServerName == "example.com") },
+		},
+		{
+			Name: "minVersion",
+			TLS:  &TLS{MinVersion: "1.3"},
+			Want: func(c *tls.Config) bool { return c.MinVersion == tls.VersionTLS13 },
+		},
+		{
+			Name: "badMinVersion",
+			TLS:  &TLS{MinVersion: "2.0"},
+			Err:  ErrTLSVersion,
+		},
+		{
+			Name: "badRootCAs",
+			TLS:  &TLS{RootCAs: "cert"},
+			Err:  ErrRootCAs,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := tt.TLS.Config(tt.Base)
+			if !errors.Is(err, tt.Err) {
+				t.Fatal(err)
+			} else if (err == nil) && !tt.Want(config) {
+				t.Fatal("unexpected config")
+			}
+		})
+	}
+
+	t.Run("badCertificate", func(t *testing.T) {
+		if _, err := (&TLS{Certificate: "cert", Key: "key"}).Config(nil); err == nil {
+			t.Fatal("error expected")
+		}
+	})
+}
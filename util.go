@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/url"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -13,6 +14,12 @@ var (
 	// ErrInvalidHeader is returned when the value is an invalid header.
 	ErrInvalidHeader = errors.New("invalid header")
 
+	// ErrInvalidValues is returned when the value is not a valid set of form values.
+	ErrInvalidValues = errors.New("invalid form values")
+
+	// ErrInvalidRules is returned when the value is not a valid rules value.
+	ErrInvalidRules = errors.New("invalid rules")
+
 	// ErrMustBeString is returned when the value is not a string.
 	ErrMustBeString = errors.New("must be a string")
 
@@ -30,12 +37,38 @@ var (
 
 	headerType = reflect.TypeOf(http.Header{})
 
+	valuesType = reflect.TypeOf(url.Values{})
+
 	durationType = reflect.TypeOf(time.Duration(0))
 
 	selectorsType = reflect.TypeOf([]*Selector{})
+
+	rulesSliceType = reflect.TypeOf([]*Rules{})
+
+	rulesMapType = reflect.TypeOf(map[string]*Rules{})
+
+	tlsType = reflect.TypeOf((*TLS)(nil))
+
+	basicAuthType = reflect.TypeOf((*BasicAuth)(nil))
+
+	normalizeType = reflect.TypeOf((*Normalize)(nil))
+
+	scopeType = reflect.TypeOf((*Scope)(nil))
+
+	stringsType = reflect.TypeOf([]string{})
+
+	intsType = reflect.TypeOf([]int{})
+
+	redirectPolicyType = reflect.TypeOf((*RedirectPolicy)(nil))
+
+	paginationType = reflect.TypeOf((*Pagination)(nil))
+
+	backoffType = reflect.TypeOf((*Backoff)(nil)).Elem()
+
+	anyType = reflect.TypeOf((*any)(nil)).Elem()
 )
 
-func processRaw[T Rules | Selector](raw map[string]any, output *T) error {
+func processRaw[T Rules | Selector | TLS | BasicAuth | Normalize | Scope | RedirectPolicy | Pagination](raw map[string]any, output *T) error {
 	if raw == nil {
 		return nil
 	}
@@ -61,10 +94,40 @@ func processRaw[T Rules | Selector](raw map[string]any, output *T) error {
 				value, err = ToURL(value)
 			case headerType:
 				value, err = toHeader(value)
+			case valuesType:
+				value, err = toValues(value)
 			case durationType:
 				value, err = toDuration(value)
 			case selectorsType:
 				value, err = newSelectors(value)
+			case rulesSliceType:
+				value, err = toRulesSlice(value)
+			case rulesMapType:
+				value, err = toRulesMap(value)
+			case tlsType:
+				value, err = toTLS(value)
+			case basicAuthType:
+				value, err = toBasicAuth(value)
+			case normalizeType:
+				value, err = toNormalize(value)
+			case scopeType:
+				value, err = toScope(value)
+			case stringsType:
+				value, err = toStrings(value)
+			case intsType:
+				value, err = toInts(value)
+			case redirectPolicyType:
+				value, err = toRedirectPolicy(value)
+			case paginationType:
+				value, err = toPagination(value)
+			case backoffType:
+				value, err = toBackoff(value)
+			case anyType:
+				// The null values leave the field unset, e.g. the JSON of the rules.
+				if value == nil {
+					delete(raw, key)
+					continue
+				}
 			}
 
 			if err != nil {
@@ -86,6 +149,47 @@ func processRaw[T Rules | Selector](raw map[string]any, output *T) error {
 	return errs
 }
 
+// toStrings converts a string or a slice of strings to a []string.
+func toStrings(value any) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case []any:
+		result := make([]string, 0, len(v))
+		for _, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return nil, ErrMustBeString
+			}
+			result = append(result, s)
+		}
+		return result, nil
+	}
+	return nil, ErrMustBeString
+}
+
+// toInts converts a number or a slice of numbers to a []int.
+func toInts(value any) ([]int, error) {
+	rawSlice, ok := value.([]any)
+	if !ok {
+		n, err := toInt(value)
+		if err != nil {
+			return nil, err
+		}
+		return []int{n}, nil
+	}
+
+	result := make([]int, 0, len(rawSlice))
+	for _, e := range rawSlice {
+		n, err := toInt(e)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, n)
+	}
+	return result, nil
+}
+
 // ToURL converts a value to a *url.URL.
 func ToURL(value any) (*url.URL, error) {
 	rawURL, ok := value.(string)
@@ -137,6 +241,110 @@ func toHeader(value any) (http.Header, error) {
 	return header, nil
 }
 
+func toValues(value any) (url.Values, error) {
+	values := url.Values{}
+
+	if value == nil {
+		return values, nil
+	}
+
+	valuesMap, ok := value.(map[string]any)
+	if !ok {
+		return values, ErrInvalidValues
+	}
+
+	for k, v := range valuesMap {
+		switch val := v.(type) {
+		case string:
+			values.Add(k, val)
+		case []any:
+			for _, e := range val {
+				s, ok := e.(string)
+				if !ok {
+					return values, ErrInvalidValues
+				}
+				values.Add(k, s)
+			}
+
+		default:
+			return values, ErrInvalidValues
+		}
+	}
+	return values, nil
+}
+
+func toRulesSlice(value any) ([]*Rules, error) {
+	rawSlice, ok := value.([]any)
+	if !ok {
+		return nil, ErrInvalidRules
+	}
+
+	var (
+		result []*Rules
+		errs   error
+	)
+	for i, rawRules := range rawSlice {
+		rawMap, ok := rawRules.(map[string]any)
+		if !ok {
+			errs = AddError(errs, strconv.Itoa(i), ErrInvalidRules)
+			continue
+		}
+
+		newRules := getRules()
+		newRules.Extra = rawMap
+		if err := processRaw(newRules.Extra, newRules); err != nil {
+			errs = AddError(errs, strconv.Itoa(i), err)
+		}
+		result = append(result, newRules)
+	}
+	return result, errs
+}
+
+// toRulesMap converts a map of raw rules to a map[string]*Rules, the null rules are omitted.
+func toRulesMap(value any) (map[string]*Rules, error) {
+	rawMap, ok := value.(map[string]any)
+	if !ok {
+		return nil, ErrInvalidRules
+	}
+
+	var (
+		result = make(map[string]*Rules, len(rawMap))
+		errs   error
+	)
+	for key, rawRules := range rawMap {
+		if rawRules == nil {
+			continue
+		}
+
+		rulesMap, ok := rawRules.(map[string]any)
+		if !ok {
+			errs = AddError(errs, key, ErrInvalidRules)
+			continue
+		}
+
+		newRules := getRules()
+		newRules.Extra = rulesMap
+		if err := processRaw(newRules.Extra, newRules); err != nil {
+			errs = AddError(errs, key, err)
+		}
+		result[key] = newRules
+	}
+	return result, errs
+}
+
+// cloneValues returns a copy of the values, returns nil if values is nil.
+func cloneValues(values url.Values) url.Values {
+	if values == nil {
+		return nil
+	}
+
+	result := make(url.Values, len(values))
+	for key, value := range values {
+		result[key] = append([]string(nil), value...)
+	}
+	return result
+}
+
 func toDuration(value any) (time.Duration, error) {
 	switch d := value.(type) {
 	case int:
@@ -146,3 +354,7 @@ func toDuration(value any) (time.Duration, error) {
 	}
 	return 0, ErrMustBeNumber
 }
+
+func fromDuration(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
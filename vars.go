@@ -0,0 +1,223 @@
+package colibri
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// placeholderRegexp matches the placeholders of the variables, e.g. ${token} or ${login.token}.
+var placeholderRegexp = regexp.MustCompile(`\$\{([^{}]+)\}`)
+
+type varsKey struct{}
+
+// WithVars returns a copy of ctx with the variables added to those of ctx.
+//
+// The placeholders ${name} in the URL, Header and Form of the rules and in the expressions
+// of the selectors are replaced by the value of the variable, see the Interpolate function.
+func WithVars(ctx context.Context, vars map[string]any) context.Context {
+	merged := make(map[string]any)
+	for key, value := range VarsFrom(ctx) {
+		merged[key] = value
+	}
+
+	for key, value := range vars {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, varsKey{}, merged)
+}
+
+// VarsFrom returns the variables of ctx, see WithVars.
+// The returned map must not be modified.
+func VarsFrom(ctx context.Context) map[string]any {
+	vars, _ := ctx.Value(varsKey{}).(map[string]any)
+	return vars
+}
+
+// Interpolate replaces the placeholders ${name} in s by the value of the variable.
+// The name can be a path in which the keys of the maps and the indices of the slices
+// are separated by dots (e.g. ${login.token}). The placeholders of the variables
+// that do not exist are not replaced.
+func Interpolate(s string, vars map[string]any) string {
+	return interpolate(s, vars, func(s string) string { return s })
+}
+
+func interpolate(s string, vars map[string]any, escape func(string) string) string {
+	if (len(vars) == 0) || !strings.Contains(s, "${") {
+		return s
+	}
+
+	return placeholderRegexp.ReplaceAllStringFunc(s, func(placeholder string) string {
+		value, ok := lookupVar(vars, placeholder[2:len(placeholder)-1])
+		if !ok {
+			return placeholder
+		}
+
+		if str, ok := value.(string); ok {
+			return escape(str)
+		}
+		return escape(fmt.Sprint(value))
+	})
+}
+
+// lookupVar returns the value of the path in the variables.
+func lookupVar(vars map[string]any, path string) (any, bool) {
+	var value any = vars
+	for _, key := range strings.Split(strings.TrimSpace(path), ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			var ok bool
+			if value, ok = v[key]; !ok {
+				return nil, false
+			}
+
+		case []any:
+			i, err := strconv.Atoi(key)
+			if (err != nil) || (i < 0) || (i >= len(v)) {
+				return nil, false
+			}
+			value = v[i]
+
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// interpolateRules returns a copy of the rules in which the placeholders of the URL, Header
+// and Form are replaced by the variables of the context. Returns nil if there is nothing to replace.
+func interpolateRules(rules *Rules) *Rules {
+	vars := VarsFrom(rules.Context())
+	if (len(vars) == 0) || !hasPlaceholders(rules) {
+		return nil
+	}
+
+	newRules := rules.Clone()
+	if newRules.URL != nil {
+		newRules.URL = interpolateURL(newRules.URL, vars)
+	}
+
+	for _, values := range newRules.Header {
+		for i, value := range values {
+			values[i] = Interpolate(value, vars)
+		}
+	}
+
+	for _, values := range newRules.Form {
+		for i, value := range values {
+			values[i] = Interpolate(value, vars)
+		}
+	}
+	newRules.JSON = interpolateJSON(newRules.JSON, vars)
+
+	if newRules.BasicAuth != nil {
+		newRules.BasicAuth.Username = Interpolate(newRules.BasicAuth.Username, vars)
+		newRules.BasicAuth.Password = Interpolate(newRules.BasicAuth.Password, vars)
+	}
+	newRules.BearerToken = Interpolate(newRules.BearerToken, vars)
+	return newRules
+}
+
+func hasPlaceholders(rules *Rules) bool {
+	if (rules.URL != nil) && (strings.Contains(rules.URL.Path, "${") || strings.Contains(rules.URL.RawQuery, "${") ||
+		strings.Contains(rules.URL.Host, "${") || strings.Contains(rules.URL.Fragment, "${")) {
+		return true
+	}
+
+	for _, values := range rules.Header {
+		for _, value := range values {
+			if strings.Contains(value, "${") {
+				return true
+			}
+		}
+	}
+
+	for _, values := range rules.Form {
+		for _, value := range values {
+			if strings.Contains(value, "${") {
+				return true
+			}
+		}
+	}
+
+	if hasJSONPlaceholders(rules.JSON) {
+		return true
+	}
+
+	if (rules.BasicAuth != nil) && (strings.Contains(rules.BasicAuth.Username, "${") || strings.Contains(rules.BasicAuth.Password, "${")) {
+		return true
+	}
+	return strings.Contains(rules.BearerToken, "${")
+}
+
+// interpolateJSON replaces the placeholders of the strings of the raw JSON value,
+// the maps and slices are modified in place.
+func interpolateJSON(value any, vars map[string]any) any {
+	switch v := value.(type) {
+	case string:
+		return Interpolate(v, vars)
+	case map[string]any:
+		for key, e := range v {
+			v[key] = interpolateJSON(e, vars)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = interpolateJSON(e, vars)
+		}
+	}
+	return value
+}
+
+func hasJSONPlaceholders(value any) bool {
+	switch v := value.(type) {
+	case string:
+		return strings.Contains(v, "${")
+	case map[string]any:
+		for _, e := range v {
+			if hasJSONPlaceholders(e) {
+				return true
+			}
+		}
+	case []any:
+		for _, e := range v {
+			if hasJSONPlaceholders(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// interpolateURL replaces the placeholders of the host, path, query and fragment of the URL,
+// the values are escaped according to the component in which they are replaced.
+func interpolateURL(u *url.URL, vars map[string]any) *url.URL {
+	newURL := *u
+	newURL.Host = Interpolate(u.Host, vars)
+	newURL.Path = Interpolate(u.Path, vars)
+	newURL.RawPath = ""
+	newURL.RawQuery = interpolate(u.RawQuery, vars, url.QueryEscape)
+	newURL.Fragment = Interpolate(u.Fragment, vars)
+	newURL.RawFragment = ""
+	return &newURL
+}
+
+// interpolateSelector returns the selector or, if its expression has placeholders,
+// a copy of the selector with the placeholders replaced by the variables of the rules.
+func interpolateSelector(rules *Rules, selector *Selector) *Selector {
+	if !strings.Contains(selector.Expr, "${") {
+		return selector
+	}
+
+	vars := VarsFrom(rules.Context())
+	if len(vars) == 0 {
+		return selector
+	}
+
+	newSelector := *selector
+	newSelector.Expr = Interpolate(selector.Expr, vars)
+	return &newSelector
+}
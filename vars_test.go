@@ -0,0 +1,101 @@
+package colibri
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	vars := map[string]any{
+		"token": "abc",
+		"id":    float64(10),
+		"login": map[string]any{"user": "colibri", "roles": []any{"admin", "dev"}},
+	}
+
+	tests := []struct {
+		S, Want string
+	}{
+		{"", ""},
+		{"no placeholders", "no placeholders"},
+		{"Bearer ${token}", "Bearer abc"},
+		{"/items/${id}", "/items/10"},
+		{"${login.user}:${login.roles.1}", "colibri:dev"},
+		{"${missing} ${login.roles.5} ${token.x}", "${missing} ${login.roles.5} ${token.x}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.S, func(t *testing.T) {
+			if got := Interpolate(tt.S, vars); got != tt.Want {
+				t.Fatal("not equal")
+			}
+		})
+	}
+}
+
+func TestWithVars(t *testing.T) {
+	ctx := WithVars(context.Background(), map[string]any{"a": 1, "b": 2})
+	ctx = WithVars(ctx, map[string]any{"b": 3})
+
+	if got, want := VarsFrom(ctx), map[string]any{"a": 1, "b": 3}; !reflect.DeepEqual(got, want) {
+		t.Fatal("not equal")
+	}
+
+	rules := &Rules{
+		URL:    mustNewURL("https://example.com/items/${id}?q=${q}&page=1"),
+		Header: http.Header{"Authorization": {"Bearer ${token}"}},
+		Form:   url.Values{"csrf": {"${token}"}},
+	}
+	rules.SetContext(WithVars(context.Background(), map[string]any{"id": "a b", "q": "x&y", "token": "abc"}))
+
+	iRules := interpolateRules(rules)
+	if iRules == nil {
+		t.Fatal("rules not interpolated")
+	}
+
+	if got, want := iRules.URL.String(), "https://example.com/items/a%20b?q=x%26y&page=1"; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	if got := iRules.Header.Get("Authorization"); got != "Bearer abc" {
+		t.Fatal("not equal")
+	}
+
+	if got := iRules.Form.Get("csrf"); got != "abc" {
+		t.Fatal("not equal")
+	}
+
+	if rules.Header.Get("Authorization") != "Bearer ${token}" {
+		t.Fatal("original rules modified")
+	}
+
+	bRules := &Rules{BearerToken: "${token}", BasicAuth: &BasicAuth{Username: "user", Password: "${token}"}}
+	bRules.SetContext(rules.Context())
+
+	iRules = interpolateRules(bRules)
+	if (iRules == nil) || (iRules.BearerToken != "abc") || (iRules.BasicAuth.Password != "abc") {
+		t.Fatal("credentials not interpolated")
+	}
+
+	if bRules.BasicAuth.Password != "${token}" {
+		t.Fatal("original rules modified")
+	}
+
+	jRules := &Rules{JSON: map[string]any{"query": "${q}", "ids": []any{"${id}", 1.0}}}
+	jRules.SetContext(rules.Context())
+
+	iRules = interpolateRules(jRules)
+	if want := map[string]any{"query": "x&y", "ids": []any{"a b", 1.0}}; (iRules == nil) || !reflect.DeepEqual(iRules.JSON, want) {
+		t.Fatal("JSON not interpolated")
+	}
+
+	if jRules.JSON.(map[string]any)["query"] != "${q}" {
+		t.Fatal("original rules modified")
+	}
+
+	if interpolateRules(&Rules{URL: mustNewURL("https://example.com")}) != nil {
+		t.Fatal("rules without placeholders interpolated")
+	}
+}
@@ -0,0 +1,212 @@
+package colibri
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"time"
+)
+
+// DefaultWatchInterval default interval between the checks of the rules file.
+const DefaultWatchInterval = 500 * time.Millisecond
+
+// WatchOptions configures a watch.
+type WatchOptions struct {
+	// Context specifies the context of the watch, when it is canceled the watch is stopped.
+	// If it is nil, context.Background is used.
+	Context context.Context
+
+	// Interval specifies the interval between the checks of the rules file.
+	Interval time.Duration
+
+	// CacheResponse specifies whether the response of the first run is reused while the URL
+	// and the method of the rules do not change, so the selectors are found in the stored root node
+	// without requesting and parsing the response again, see ExtractResponse.
+	CacheResponse bool
+
+	// Writer is where the changes of the output data and the errors are printed.
+	// If it is nil, os.Stdout is used.
+	Writer io.Writer
+
+	// OnChange is called with the fields of the output data that changed since the previous run.
+	OnChange func(rules *Rules, output *Output, changes []Change)
+
+	// OnError is called with the errors of loading and extracting the rules.
+	OnError func(err error)
+}
+
+// Watch loads the rules file from fsys, see LoadRules, and extracts the rules every time
+// the file or the files it includes change. The fields of the output data that changed
+// since the previous run are printed, the first run prints all the fields as added.
+//
+// Visited, Duplicates and NearDuplicates are not used, since the same URL is requested on each run.
+// Watch blocks until the context is canceled and returns its error.
+func (c *Colibri) Watch(opts *WatchOptions, fsys fs.FS, name string) error {
+	if opts == nil {
+		opts = &WatchOptions{}
+	}
+
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	w := &watcher{
+		c:      c.With(WithVisited(nil), WithDuplicates(nil), WithNearDuplicates(nil)),
+		opts:   opts,
+		writer: opts.Writer,
+	}
+	if w.writer == nil {
+		w.writer = os.Stdout
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	name = path.Clean(name)
+	for {
+		raw, err := loadRaw(fsys, name, nil)
+		switch {
+		case err != nil:
+			// The error is reported once, e.g. while the file is being edited.
+			if err.Error() != w.lastErr {
+				w.report(err)
+			}
+		case !reflect.DeepEqual(raw, w.raw):
+			w.raw = raw
+			w.run(raw)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.life.done():
+			return ErrShutdown
+		case <-ticker.C:
+		}
+	}
+}
+
+// WatchFile watches the rules file of the local path, see Watch.
+func (c *Colibri) WatchFile(opts *WatchOptions, name string) error {
+	return c.Watch(opts, osFS{}, filepath.ToSlash(name))
+}
+
+type watcher struct {
+	c      *Colibri
+	opts   *WatchOptions
+	writer io.Writer
+
+	raw      map[string]any
+	previous map[string]any
+	lastErr  string
+
+	resp    Response
+	respKey string
+}
+
+// run extracts the raw rules and prints the changes of the output data.
+func (w *watcher) run(raw map[string]any) {
+	w.lastErr = ""
+
+	rules, err := rulesFromRaw(raw)
+	if err != nil {
+		w.report(err)
+		return
+	}
+
+	output, err := w.extract(rules)
+	if err != nil {
+		w.report(err)
+	}
+
+	if output == nil {
+		return
+	}
+
+	previous := w.previous
+	if previous == nil {
+		previous = map[string]any{}
+	}
+
+	data := output.Data
+	if data == nil {
+		data = map[string]any{}
+	}
+
+	changes := Diff(previous, data)
+	w.previous = data
+
+	printChanges(w.writer, changes)
+	if (len(changes) > 0) && (w.opts.OnChange != nil) {
+		w.opts.OnChange(rules, output, changes)
+	}
+}
+
+// extract extracts the rules, the response of the previous run is reused
+// if CacheResponse is set and the URL and the method of the rules did not change.
+func (w *watcher) extract(rules *Rules) (*Output, error) {
+	if !w.opts.CacheResponse {
+		return w.c.Extract(rules)
+	}
+
+	var key string
+	if rules.URL != nil {
+		key = rules.Method + " " + rules.URL.String()
+	}
+
+	if (w.resp != nil) && (key == w.respKey) {
+		return w.c.ExtractResponse(w.resp, rules)
+	}
+
+	rules.CacheNode = true
+	output, err := w.c.Extract(rules)
+	if output != nil {
+		w.resp, w.respKey = output.Response, key
+	}
+	return output, err
+}
+
+func (w *watcher) report(err error) {
+	w.lastErr = err.Error()
+	fmt.Fprintln(w.writer, "error:", err)
+
+	if w.opts.OnError != nil {
+		w.opts.OnError(err)
+	}
+}
+
+// printChanges prints a line for each change: "+ path: new" for the added fields,
+// "- path: old" for the removed fields and "~ path: old -> new" for the modified fields.
+func printChanges(w io.Writer, changes []Change) {
+	for _, change := range changes {
+		switch {
+		case change.Old == nil:
+			fmt.Fprintf(w, "+ %s: %s\n", change.Path, formatValue(change.New))
+		case change.New == nil:
+			fmt.Fprintf(w, "- %s: %s\n", change.Path, formatValue(change.Old))
+		default:
+			fmt.Fprintf(w, "~ %s: %s -> %s\n", change.Path, formatValue(change.Old), formatValue(change.New))
+		}
+	}
+}
+
+// formatValue returns the value as JSON, so the strings are quoted.
+func formatValue(value any) string {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(b)
+}
@@ -0,0 +1,77 @@
+package colibri
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "rules.json")
+	writeRules := func(selectors string) {
+		rules := `{"url": "http://example.com", "selectors": ` + selectors + `}`
+		if err := os.WriteFile(name, []byte(rules), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeRules(`{"number": "!number"}`)
+
+	client := &nodeClient{}
+	c := New()
+	c.Client = client
+	c.Parser = &testParser{}
+
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		changes     = make(chan []Change)
+		done        = make(chan error)
+		out         bytes.Buffer
+	)
+	defer cancel()
+
+	go func() {
+		done <- c.WatchFile(&WatchOptions{
+			Context:       ctx,
+			Interval:      10 * time.Millisecond,
+			CacheResponse: true,
+			Writer:        &out,
+			OnChange: func(_ *Rules, _ *Output, c []Change) {
+				changes <- c
+			},
+		}, name)
+	}()
+
+	want := []Change{{Path: "number", New: 505}}
+	if got := <-changes; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	writeRules(`{"title": "title"}`)
+
+	want = []Change{{Path: "number", Old: 505}, {Path: "title", New: "test"}}
+	if got := <-changes; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want %v", err, context.Canceled)
+	}
+
+	// The response of the first run is reused.
+	if client.calls != 1 {
+		t.Fatalf("got %v requests, want %v", client.calls, 1)
+	}
+
+	for _, line := range []string{"+ number: 505", "- number: 505", `+ title: "test"`} {
+		if !strings.Contains(out.String(), line) {
+			t.Fatalf("got %q, want %q", out.String(), line)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package webextractor
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheExt is the extension of the files of the DiskCache entries.
+const cacheExt = ".cache"
+
+// Cache stores the responses of the GET requests together with their validators,
+// ETag and Last-Modified. The Client revalidates the stored responses with conditional requests
+// and uses the stored body when the server responds with 304 Not Modified.
+type Cache interface {
+	// Get returns the entry stored with the key, returns nil if it does not exist.
+	Get(key string) (*CacheEntry, error)
+
+	// Set stores the entry with the key.
+	Set(key string, entry *CacheEntry) error
+}
+
+// CacheEntry represents a response stored in a Cache.
+type CacheEntry struct {
+	// Key is the key with which the entry is stored, usually the URL of the request.
+	Key string `json:"key"`
+
+	// StatusCode is the status code of the response.
+	StatusCode int `json:"code"`
+
+	// Header contains the headers of the response.
+	Header http.Header `json:"header"`
+
+	// Stored is the time in which the entry was stored or revalidated.
+	Stored time.Time `json:"stored"`
+
+	// Size is the size in bytes of the entry.
+	Size int64 `json:"-"`
+
+	// Body is the decompressed body of the response.
+	Body []byte `json:"-"`
+}
+
+// ETag returns the ETag header of the stored response.
+func (entry *CacheEntry) ETag() string {
+	return entry.Header.Get("ETag")
+}
+
+// LastModified returns the Last-Modified header of the stored response.
+func (entry *CacheEntry) LastModified() string {
+	return entry.Header.Get("Last-Modified")
+}
+
+// cacheable returns true if the response has a validator and can be stored.
+func cacheable(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+
+	if strings.Contains(strings.ToLower(resp.Header.Get("Cache-Control")), "no-store") {
+		return false
+	}
+	return (resp.Header.Get("ETag") != "") || (resp.Header.Get("Last-Modified") != "")
+}
+
+// DiskCache stores the entries in files of a directory,
+// so they are kept between runs. See the Cache interface.
+//
+// When the size of the entries exceeds MaxSize,
+// the least recently used entries are removed.
+type DiskCache struct {
+	// Dir is the directory in which the entries are stored.
+	Dir string
+
+	// MaxSize specifies the maximum size in bytes of the entries.
+	// If it is zero, there is no limit.
+	MaxSize int64
+
+	mu sync.Mutex
+}
+
+// NewDiskCache returns a new DiskCache structure that stores the entries in dir,
+// the directory is created if it does not exist.
+func NewDiskCache(dir string, maxSize int64) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DiskCache{Dir: dir, MaxSize: maxSize}, nil
+}
+
+func (dc *DiskCache) Get(key string) (*CacheEntry, error) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	filename := dc.filename(key)
+	entry, err := readCacheEntry(filename, true)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// The modification time records the last use of the entry, see evict.
+	now := time.Now()
+	os.Chtimes(filename, now, now)
+	return entry, nil
+}
+
+func (dc *DiskCache) Set(key string, entry *CacheEntry) error {
+	meta := *entry
+	meta.Key = key
+
+	b, err := json.Marshal(&meta)
+	if err != nil {
+		return err
+	}
+
+	// The file contains the JSON metadata on the first line followed by the body.
+	var buf bytes.Buffer
+	buf.Grow(len(b) + 1 + len(entry.Body))
+	buf.Write(b)
+	buf.WriteByte('\n')
+	buf.Write(entry.Body)
+
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	filename := dc.filename(key)
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, filename); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return dc.evict()
+}
+
+// Delete removes the entry stored with the key.
+func (dc *DiskCache) Delete(key string) error {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	err := os.Remove(dc.filename(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Entries returns the stored entries without their bodies,
+// sorted from the most to the least recently used.
+func (dc *DiskCache) Entries() ([]*CacheEntry, error) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	files, err := dc.files()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*CacheEntry, 0, len(files))
+	for i := len(files) - 1; i >= 0; i-- {
+		entry, err := readCacheEntry(filepath.Join(dc.Dir, files[i].Name()), false)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Size returns the size in bytes of the stored entries.
+func (dc *DiskCache) Size() (int64, error) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	files, err := dc.files()
+	if err != nil {
+		return 0, err
+	}
+
+	var size int64
+	for _, file := range files {
+		size += file.Size()
+	}
+	return size, nil
+}
+
+// Clear removes all the stored entries.
+func (dc *DiskCache) Clear() error {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	files, err := dc.files()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, file := range files {
+		errs = append(errs, os.Remove(filepath.Join(dc.Dir, file.Name())))
+	}
+	return errors.Join(errs...)
+}
+
+// evict removes the least recently used entries until the size of the entries does not exceed MaxSize.
+func (dc *DiskCache) evict() error {
+	if dc.MaxSize <= 0 {
+		return nil
+	}
+
+	files, err := dc.files()
+	if err != nil {
+		return err
+	}
+
+	var size int64
+	for _, file := range files {
+		size += file.Size()
+	}
+
+	for _, file := range files {
+		if size <= dc.MaxSize {
+			break
+		}
+
+		if err := os.Remove(filepath.Join(dc.Dir, file.Name())); err != nil {
+			return err
+		}
+		size -= file.Size()
+	}
+	return nil
+}
+
+// files returns the files of the entries sorted from the least to the most recently used.
+func (dc *DiskCache) files() ([]fs.FileInfo, error) {
+	dirEntries, err := os.ReadDir(dc.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []fs.FileInfo
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || (filepath.Ext(dirEntry.Name()) != cacheExt) {
+			continue
+		}
+
+		info, err := dirEntry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		files = append(files, info)
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+	return files, nil
+}
+
+func (dc *DiskCache) filename(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(dc.Dir, hex.EncodeToString(sum[:])+cacheExt)
+}
+
+// readCacheEntry reads the entry stored in the file, the body is only read if body is true.
+func readCacheEntry(filename string, body bool) (*CacheEntry, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, rest, _ := bytes.Cut(b, []byte{'\n'})
+
+	var entry CacheEntry
+	if err := json.Unmarshal(meta, &entry); err != nil {
+		return nil, err
+	}
+
+	entry.Size = int64(len(b))
+	if body {
+		entry.Body = rest
+	}
+	return &entry, nil
+}
@@ -0,0 +1,173 @@
+package webextractor
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gonzxlez/colibri"
+)
+
+func TestDiskCache(t *testing.T) {
+	dc, err := NewDiskCache(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := dc.Get("https://example.com")
+	if err != nil {
+		t.Fatal(err)
+	} else if entry != nil {
+		t.Fatalf(gotWantFormat, entry, nil)
+	}
+
+	want := &CacheEntry{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Etag": {`"v1"`}},
+		Stored:     time.Now().UTC().Truncate(time.Second),
+		Body:       []byte("colibri\nbody"),
+	}
+	if err := dc.Set("https://example.com", want); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err = dc.Get("https://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want.Key = "https://example.com"
+	want.Size = entry.Size
+	if !reflect.DeepEqual(entry, want) {
+		t.Fatalf(gotWantFormat, entry, want)
+	}
+
+	if entry.ETag() != `"v1"` {
+		t.Fatalf(prefixGotWantFormat, "ETag", entry.ETag(), `"v1"`)
+	}
+
+	t.Run("Eviction", func(t *testing.T) {
+		dc, err := NewDiskCache(t.TempDir(), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := []byte(strings.Repeat("a", 100))
+		for _, key := range []string{"a", "b", "c"} {
+			if err := dc.Set(key, &CacheEntry{StatusCode: http.StatusOK, Body: body}); err != nil {
+				t.Fatal(err)
+			}
+
+			// The modification times of the files must be different.
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		// "a" becomes the most recently used entry.
+		if _, err := dc.Get("a"); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		size, err := dc.Size()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dc.MaxSize = size / 2
+		if err := dc.Set("d", &CacheEntry{StatusCode: http.StatusOK}); err != nil {
+			t.Fatal(err)
+		}
+
+		entries, err := dc.Entries()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var keys []string
+		for _, entry := range entries {
+			keys = append(keys, entry.Key)
+			if entry.Body != nil {
+				t.Fatalf(prefixGotWantFormat, entry.Key, entry.Body, nil)
+			}
+		}
+
+		if want := []string{"d", "a"}; !reflect.DeepEqual(keys, want) {
+			t.Fatalf(gotWantFormat, keys, want)
+		}
+
+		if err := dc.Delete("d"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := dc.Clear(); err != nil {
+			t.Fatal(err)
+		}
+
+		if size, _ := dc.Size(); size != 0 {
+			t.Fatalf(prefixGotWantFormat, "Size", size, 0)
+		}
+	})
+}
+
+func TestClientCache(t *testing.T) {
+	var notModified int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "text/html")
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fmt.Fprint(w, "<p>colibri</p>")
+	}))
+	defer ts.Close()
+
+	dc, err := NewDiskCache(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	we, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	we.Delay = nil     // Deactivate Delay
+	we.RobotsTxt = nil // Deactivate RobotsTxt
+	we.Client.(*Client).Cache = dc
+
+	for i := 0; i < 3; i++ {
+		resp, err := we.Do(&colibri.Rules{Method: "GET", URL: mustNewURL(ts.URL)})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode() != http.StatusOK {
+			t.Fatalf(prefixGotWantFormat, "Status Code", resp.StatusCode(), http.StatusOK)
+		}
+
+		if cached := resp.(*Response).Cached(); cached != (i > 0) {
+			t.Fatalf(prefixGotWantFormat, "Cached", cached, i > 0)
+		}
+
+		body, err := io.ReadAll(resp.Body())
+		resp.Body().Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(body) != "<p>colibri</p>" {
+			t.Fatalf(prefixGotWantFormat, "Body", string(body), "<p>colibri</p>")
+		}
+	}
+
+	if notModified != 2 {
+		t.Fatalf(prefixGotWantFormat, "Not Modified", notModified, 2)
+	}
+}
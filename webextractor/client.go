@@ -2,12 +2,21 @@
 package webextractor
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"io"
 	"net"
 	"net/http"
 	"net/http/cookiejar"
+	"net/http/httptrace"
 	"net/url"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gonzxlez/colibri"
@@ -16,10 +25,10 @@ import (
 	"golang.org/x/net/publicsuffix"
 )
 
-// New returns a new Colibri structure with default values.
-// Returns an error if an error occurs when initializing the values.
-func New(cookieJar ...http.CookieJar) (*colibri.Colibri, error) {
-	client, err := NewClient(cookieJar...)
+// New returns a new Colibri structure with default values configured with the options,
+// e.g. WithJar or WithoutDelay. Returns an error if an error occurs when initializing the values.
+func New(opts ...Option) (*colibri.Colibri, error) {
+	client, err := NewClient()
 	if err != nil {
 		return nil, err
 	}
@@ -34,6 +43,12 @@ func New(cookieJar ...http.CookieJar) (*colibri.Colibri, error) {
 	c.Delay = NewReqDelay()
 	c.RobotsTxt = NewRobotsData()
 	c.Parser = parser
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(c, client)
+		}
+	}
 	return c, nil
 }
 
@@ -43,7 +58,69 @@ type Client struct {
 	// Jar specifies the cookie jar.
 	Jar http.CookieJar
 
-	pool sync.Pool
+	// Transport specifies the transport of the requests. If it is not nil, it is used instead of
+	// the transports created for each proxy and TLS configuration, so the Proxy and TLS of the rules,
+	// the Proxies, TLSConfig, Resolver and Fingerprinter of the client and the Blocklist are not applied.
+	Transport http.RoundTripper
+
+	// Timeout specifies the time limit of each request made by the client, including the redirects
+	// and reading the response body. If it is zero, there is no limit.
+	Timeout time.Duration
+
+	// TLSConfig specifies the default TLS configuration,
+	// the TLS configuration of the rules is applied to a copy of it.
+	TLSConfig *tls.Config
+
+	// Resolver specifies the resolver used to obtain the addresses of the hosts.
+	// If it is nil, the system resolver is used.
+	Resolver Resolver
+
+	// Auth specifies the authenticators that add credentials to the requests, see OAuth2.
+	Auth []Authenticator
+
+	// Signers specifies the signers of the requests, see SigV4 and HMAC.
+	Signers []Signer
+
+	// Proxies distributes the requests of the rules without a Proxy among the proxies of the pool,
+	// the failures of the requests are reported to the pool, see ProxyPool.
+	Proxies *ProxyPool
+
+	// Fingerprinter performs the TLS handshakes of the rules whose TLS specifies a Fingerprint.
+	// If it is nil, the StdFingerprinter is used.
+	Fingerprinter Fingerprinter
+
+	// Cache stores the responses of the GET requests that have validators,
+	// the stored responses are revalidated with conditional requests, see DiskCache.
+	Cache Cache
+
+	// DebugWriter returns the writer in which the requests and responses to the URL are dumped
+	// when the rules have Debug. If it is nil or returns nil, os.Stderr is used.
+	DebugWriter func(u *url.URL) io.Writer
+
+	// debugMu serializes the dumps, so the dumps of concurrent requests are not mixed.
+	debugMu sync.Mutex
+
+	rw sync.RWMutex
+
+	// transports caches a transport for each proxy and TLS configuration,
+	// so the connections are reused between requests.
+	transports map[transportKey]roundTripper
+
+	// active is the number of requests in progress.
+	active atomic.Int64
+}
+
+// roundTripper is an http.RoundTripper whose idle connections can be closed.
+type roundTripper interface {
+	http.RoundTripper
+	CloseIdleConnections()
+}
+
+type transportKey struct {
+	proxy     string
+	tls       colibri.TLS
+	hasTLS    bool
+	tlsConfig *tls.Config
 }
 
 // NewClient returns a new Client structure.
@@ -66,14 +143,41 @@ func NewClient(cookieJar ...http.CookieJar) (*Client, error) {
 
 // Do makes an HTTP request based on the rules.
 func (client *Client) Do(c *colibri.Colibri, rules *colibri.Rules) (colibri.Response, error) {
-	httpClient := client.getClient(rules.Proxy)
-	defer client.pool.Put(httpClient)
+	client.active.Add(1)
+	defer client.active.Add(-1)
+
+	proxyURL, pooled := rules.Proxy, false
+	if (proxyURL == nil) && (client.Proxies != nil) && (client.Transport == nil) {
+		var err error
+		if proxyURL, err = client.Proxies.Next(); err != nil {
+			return nil, err
+		}
+		pooled = true
+	}
+
+	var transport http.RoundTripper = client.Transport
+	if transport == nil {
+		t, err := client.transport(proxyURL, rules.TLS)
+		if err != nil {
+			return nil, err
+		}
+		transport = t
+	}
+
+	httpClient := &http.Client{Transport: transport, Timeout: client.Timeout}
+
+	// Debug
+	if rules.Debug {
+		httpClient.Transport = &dumpTransport{
+			rt: transport,
+			w:  client.debugWriter(rules.URL),
+			mu: &client.debugMu,
+		}
+	}
 
 	// CookieJar
 	if rules.Cookies {
 		httpClient.Jar = client.Jar
-	} else {
-		httpClient.Jar = nil
 	}
 
 	// Request
@@ -82,19 +186,158 @@ func (client *Client) Do(c *colibri.Colibri, rules *colibri.Rules) (colibri.Resp
 		return nil, err
 	}
 
+	// Blocklist
+	// The addresses are not checked when a proxy is used, the proxy resolves the host of the request,
+	// but the URLs of the redirects are always checked by CheckRedirect.
+	var blocklist *colibri.Blocklist
+	if c != nil {
+		blocklist = c.Blocklist
+	}
+
+	if (blocklist != nil) && (proxyURL == nil) {
+		req = req.WithContext(withBlocklist(req.Context(), blocklist))
+	}
+
+	// Cache
+	entry, err := client.cacheEntry(req)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, auth := range client.Auth {
+		if err := auth.Authenticate(req); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := client.sign(req); err != nil {
+		return nil, err
+	}
+
 	// Redirects
-	var redirects []*url.URL
-	httpClient.CheckRedirect = func(_ *http.Request, via []*http.Request) error {
+	var (
+		redirects []*url.URL
+		chain     []*colibri.Redirect
+	)
+	httpClient.CheckRedirect = func(redirectReq *http.Request, via []*http.Request) error {
+		prev := via[len(via)-1]
+		if (redirectReq.Response != nil) && !rules.RedirectPolicy.Follow(redirectReq.Response.StatusCode, prev.URL, redirectReq.URL) {
+			return http.ErrUseLastResponse
+		}
+
 		if len(via) > rules.Redirects {
 			return colibri.ErrMaxRedirects
 		}
 
-		redirects = append(redirects, via[len(via)-1].URL)
+		if err := blocklist.Check(redirectReq.URL); err != nil {
+			return err
+		}
+
+		// The Authorization header is only sent to the host of the original request,
+		// unless the redirect policy specifies otherwise.
+		policy := rules.RedirectPolicy
+		switch auth := via[0].Header.Get("Authorization"); {
+		case (policy != nil) && policy.StripAuth:
+			redirectReq.Header.Del("Authorization")
+		case (policy != nil) && policy.KeepAuth && (auth != ""):
+			redirectReq.Header.Set("Authorization", auth)
+		case redirectReq.URL.Host != via[0].URL.Host:
+			redirectReq.Header.Del("Authorization")
+		}
+
+		redirects = append(redirects, prev.URL)
+		if redirectReq.Response != nil {
+			chain = append(chain, &colibri.Redirect{
+				URL:        prev.URL,
+				StatusCode: redirectReq.Response.StatusCode,
+				Location:   redirectReq.Response.Header.Get("Location"),
+				SetCookie:  redirectReq.Response.Header.Values("Set-Cookie"),
+			})
+		}
 		return nil
 	}
 
 	// Response
+	t := newTimer()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), t.trace()))
+
+	start := time.Now()
 	resp, err := httpClient.Do(req)
+
+	var (
+		attempts = 1
+		lastWait time.Duration
+	)
+	for i := 0; i < rules.RetryAfter; i++ {
+		var (
+			wait time.Duration
+			ok   bool
+		)
+		if err != nil {
+			// The network errors are retried with the Backoff of the rules.
+			if (rules.Backoff != nil) && retryError(req.Context(), err) {
+				wait, ok = rules.Backoff.Backoff(i+1, lastWait), true
+			}
+		} else {
+			// The Retry-After header has priority over the Backoff of the rules.
+			wait, ok = retryAfter(resp)
+			if !ok && (rules.Backoff != nil) && retryStatus(resp.StatusCode) {
+				wait, ok = rules.Backoff.Backoff(i+1, lastWait), true
+			}
+		}
+
+		if !ok {
+			break
+		}
+		lastWait = wait
+
+		if err == nil {
+			resp.Body.Close()
+
+			if c != nil {
+				if t, ok := c.Delay.(Throttler); ok {
+					t.Throttle(rules.URL, wait)
+				}
+			}
+		}
+
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+
+		redirects, chain = nil, nil
+		start = time.Now()
+		t.reset()
+		retryReq := req.Clone(req.Context())
+		if req.GetBody != nil {
+			if retryReq.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+
+		// The signatures are renewed, since they usually include the time of the request.
+		if err := client.sign(retryReq); err != nil {
+			return nil, err
+		}
+		resp, err = httpClient.Do(retryReq)
+		attempts++
+	}
+
+	// The errors of the request that are not caused by the proxy are not reported.
+	if pooled && (req.Context().Err() == nil) && !errors.Is(err, colibri.ErrBlocked) && !errors.Is(err, colibri.ErrMaxRedirects) {
+		client.Proxies.Observe(proxyURL, proxyError(resp, err))
+	}
+
+	if c != nil {
+		if o, ok := c.Delay.(Observer); ok {
+			var statusCode int
+			if err == nil {
+				statusCode = resp.StatusCode
+			}
+			o.Observe(rules.URL, time.Since(start), statusCode)
+		}
+	}
+
 	if err != nil {
 		return nil, err
 	}
@@ -102,67 +345,454 @@ func (client *Client) Do(c *colibri.Colibri, rules *colibri.Rules) (colibri.Resp
 	r := &Response{
 		HTTP:      resp,
 		redirects: redirects,
+		chain:     chain,
+		timer:     t,
+		attempts:  attempts,
+		tls:       rules.IncludeTLS,
 		c:         c,
 	}
 
+	if (entry != nil) && (resp.StatusCode == http.StatusNotModified) {
+		if resp, err = client.revalidate(req, resp, entry); err != nil {
+			return nil, err
+		}
+		r.HTTP, r.cached = resp, true
+	}
+
 	// ResponseBodySize
-	if rules.ResponseBodySize != 0 {
-		n := int64(rules.ResponseBodySize)
-		r.HTTP.Body = io.NopCloser(io.LimitReader(resp.Body, n))
+	n := int64(rules.ResponseBodySize)
+	if (n != 0) && (resp.ContentLength > n) && !rules.ParseTruncated {
+		r.HTTP.Body = limitReadCloser(resp.Body, n, false)
+		return r, &colibri.BodySizeError{Limit: n, Size: resp.ContentLength}
+	}
 
-		if resp.ContentLength > n {
-			return r, colibri.ErrResponseBodySize
+	// Content-Encoding
+	if r.encoding, err = decompress(resp); err != nil {
+		return nil, err
+	}
+
+	if n != 0 {
+		// The limit applies to the decompressed body, it is enforced while the body is read
+		// because the Content-Length is unknown for chunked and compressed responses.
+		r.limited = limitReadCloser(resp.Body, n, rules.ParseTruncated)
+		r.HTTP.Body = r.limited
+	}
+
+	if (client.Cache != nil) && (req.Method == http.MethodGet) && !r.cached && cacheable(resp) {
+		if err := client.store(req, resp); err != nil {
+			return nil, err
 		}
 	}
+
+	r.body = newHashReadCloser(t.body(r.HTTP.Body))
+	if c != nil {
+		// The bytes are counted while they are read, also the ones of the requests made with Do.
+		u := rules.URL
+		r.body.count = func(n int64) { c.CountBytes(u, n) }
+	}
+	r.HTTP.Body = r.body
+	r.buffer = int64(rules.BufferBody)
+
+	// IncludeBody
+	if rules.IncludeBody {
+		r.recorded = new(bytes.Buffer)
+		r.HTTP.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.TeeReader(r.HTTP.Body, r.recorded), r.HTTP.Body}
+	}
 	return r, nil
 }
 
-// Clear assigns nil to Jar.
-func (client *Client) Clear() { client.Jar = nil }
+// cacheEntry returns the entry of the Cache for the GET request
+// and adds the conditional headers of its validators to the request.
+func (client *Client) cacheEntry(req *http.Request) (*CacheEntry, error) {
+	if (client.Cache == nil) || (req.Method != http.MethodGet) {
+		return nil, nil
+	}
 
-func (client *Client) getClient(proxyURL *url.URL) *http.Client {
-	var httpClient *http.Client
-	if v := client.pool.Get(); v != nil {
-		httpClient = v.(*http.Client)
-	} else {
-		httpClient = &http.Client{}
+	entry, err := client.Cache.Get(req.URL.String())
+	if (err != nil) || (entry == nil) {
+		return nil, err
+	}
+
+	if etag := entry.ETag(); (etag != "") && (req.Header.Get("If-None-Match") == "") {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	if lastModified := entry.LastModified(); (lastModified != "") && (req.Header.Get("If-Modified-Since") == "") {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+	return entry, nil
+}
+
+// revalidate returns the response stored in the entry updated with the headers
+// of the 304 Not Modified response.
+func (client *Client) revalidate(req *http.Request, resp *http.Response, entry *CacheEntry) (*http.Response, error) {
+	resp.Body.Close()
+
+	if entry.Header == nil {
+		entry.Header = make(http.Header)
+	}
+
+	for key, values := range resp.Header {
+		if key != "Content-Length" {
+			entry.Header[key] = values
+		}
+	}
+	entry.Stored = time.Now()
+
+	if err := client.Cache.Set(req.URL.String(), entry); err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:        strconv.Itoa(entry.StatusCode) + " " + http.StatusText(entry.StatusCode),
+		StatusCode:    entry.StatusCode,
+		Proto:         resp.Proto,
+		ProtoMajor:    resp.ProtoMajor,
+		ProtoMinor:    resp.ProtoMinor,
+		Header:        entry.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       resp.Request,
+		TLS:           resp.TLS,
+	}, nil
+}
+
+// store reads the body of the response and stores it in the Cache,
+// the body of the response is replaced so it can still be read.
+// Bodies that exceed the ResponseBodySize are not stored.
+func (client *Client) store(req *http.Request, resp *http.Response) error {
+	limited, _ := resp.Body.(*limitedBody)
+
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if errors.Is(err, colibri.ErrResponseBodySize) {
+		// The error is returned when the body is read.
+		resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(b), errReader{err}))
+		return nil
+	} else if err != nil {
+		return err
 	}
+	resp.Body = io.NopCloser(bytes.NewReader(b))
 
-	t, ok := httpClient.Transport.(*http.Transport)
-	if (httpClient.Transport == nil) || !ok {
-		t = defaultTransport()
+	if (limited != nil) && limited.exceeded {
+		return nil
 	}
 
+	return client.Cache.Set(req.URL.String(), &CacheEntry{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		Stored:     time.Now(),
+		Body:       b,
+	})
+}
+
+// sign signs the request with the signers.
+func (client *Client) sign(req *http.Request) error {
+	for _, signer := range client.Signers {
+		if err := signer.Sign(req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Clear assigns nil to Jar, closes the idle connections and clears the Resolver cache.
+func (client *Client) Clear() {
+	client.Jar = nil
+
+	client.rw.Lock()
+	for key, t := range client.transports {
+		t.CloseIdleConnections()
+		delete(client.transports, key)
+	}
+	client.rw.Unlock()
+
+	if r, ok := client.Resolver.(interface{ Clear() }); ok {
+		r.Clear()
+	}
+}
+
+// Report returns the number of cached transports and of requests in progress.
+// See the colibri.Reporter interface.
+func (client *Client) Report() any {
+	client.rw.RLock()
+	transports := len(client.transports)
+	client.rw.RUnlock()
+
+	report := map[string]any{
+		"transports": transports,
+		"active":     client.active.Load(),
+	}
+
+	if client.Proxies != nil {
+		report["proxies"] = client.Proxies.Report()
+	}
+	return report
+}
+
+// transport returns the cached transport for the proxy and the TLS configuration,
+// if it does not exist a new one is created.
+func (client *Client) transport(proxyURL *url.URL, rulesTLS *colibri.TLS) (roundTripper, error) {
+	key := transportKey{tlsConfig: client.TLSConfig}
 	if proxyURL != nil {
-		t.Proxy = http.ProxyURL(proxyURL)
+		key.proxy = proxyURL.String()
+	}
+
+	if rulesTLS != nil {
+		key.tls = *rulesTLS
+		key.hasTLS = true
+	}
+
+	client.rw.RLock()
+	t, ok := client.transports[key]
+	client.rw.RUnlock()
+
+	if ok {
+		return t, nil
+	}
+
+	tlsConfig := client.TLSConfig
+	if rulesTLS != nil {
+		var err error
+		if tlsConfig, err = rulesTLS.Config(client.TLSConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if (rulesTLS != nil) && (rulesTLS.Fingerprint != "") {
+		var err error
+		if t, err = client.fingerprintTransport(proxyURL, tlsConfig, rulesTLS.Fingerprint); err != nil {
+			return nil, err
+		}
+
+	} else {
+		httpTransport := client.defaultTransport()
+		if proxyURL != nil {
+			httpTransport.Proxy = http.ProxyURL(proxyURL)
+		}
+		httpTransport.TLSClientConfig = tlsConfig
+		t = httpTransport
+	}
+
+	client.rw.Lock()
+	defer client.rw.Unlock()
+
+	// Another request may have created the transport in the meantime.
+	if cached, ok := client.transports[key]; ok {
+		return cached, nil
 	}
 
-	httpClient.Transport = t
-	return httpClient
+	if client.transports == nil {
+		client.transports = make(map[transportKey]roundTripper)
+	}
+	client.transports[key] = t
+	return t, nil
+}
+
+// dialer returns a dialer that uses the Resolver of the client.
+func (client *Client) dialer() *dialer {
+	return &dialer{
+		Dialer: net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		},
+		client: client,
+	}
+}
+
+// proxyError returns the error of the request made through a proxy,
+// the responses with the 407 Proxy Authentication Required status code are errors of the proxy.
+func proxyError(resp *http.Response, err error) error {
+	if (err == nil) && (resp.StatusCode == http.StatusProxyAuthRequired) {
+		return errors.New(resp.Status)
+	}
+	return err
+}
+
+// limitReadCloser returns a ReadCloser that reads at most n bytes from rc and closes rc when it is closed.
+// If rc has more than n bytes, rc is closed, which aborts the download of the body,
+// and a *colibri.BodySizeError is returned after the first n bytes, or io.EOF if truncate is true.
+func limitReadCloser(rc io.ReadCloser, n int64, truncate bool) *limitedBody {
+	return &limitedBody{rc: rc, limit: max(n, 0), truncate: truncate}
+}
+
+type limitedBody struct {
+	rc       io.ReadCloser
+	limit    int64
+	read     int64
+	truncate bool
+	exceeded bool
+	err      error
+}
+
+func (body *limitedBody) Read(p []byte) (int, error) {
+	if body.err != nil {
+		return 0, body.err
+	}
+
+	// One more byte than the limit is read to know if it is exceeded.
+	if remaining := body.limit - body.read + 1; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+
+	n, err := body.rc.Read(p)
+	if body.read+int64(n) > body.limit {
+		n = int(body.limit - body.read)
+		body.read = body.limit
+		body.rc.Close()
+
+		body.exceeded = true
+		body.err = &colibri.BodySizeError{Limit: body.limit, Read: body.read, Size: -1}
+		if body.truncate {
+			body.err = io.EOF
+		}
+		return n, body.err
+	}
+
+	body.read += int64(n)
+	return n, err
+}
+
+func (body *limitedBody) Close() error {
+	return body.rc.Close()
+}
+
+// errReader is a Reader that returns the error.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// retryAfter returns the duration of the Retry-After header
+// of the 429 Too Many Requests and 503 Service Unavailable responses.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if !retryStatus(resp.StatusCode) {
+		return 0, false
+	}
+
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+// retryStatus returns true if the requests answered with the status code are retried,
+// 429 Too Many Requests and 503 Service Unavailable.
+func retryStatus(code int) bool {
+	return (code == http.StatusTooManyRequests) || (code == http.StatusServiceUnavailable)
+}
+
+// retryError returns true if the error of the request is a network error that can be retried,
+// e.g. a refused or reset connection or a timeout. The blocked hosts and the errors
+// of the redirects are not retried.
+func retryError(ctx context.Context, err error) bool {
+	if (ctx.Err() != nil) || errors.Is(err, colibri.ErrBlocked) || errors.Is(err, colibri.ErrMaxRedirects) {
+		return false
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && urlErr.Timeout()
+}
+
+// sleep pauses for the duration or until the context is canceled.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func httpRequest(rules *colibri.Rules) (*http.Request, error) {
-	req, err := http.NewRequest(rules.Method, rules.URL.String(), nil /* Body */)
+	var (
+		u    = rules.URL
+		body io.Reader
+	)
+
+	if rules.Form != nil {
+		if (rules.JSON != nil) || (rules.Body != "") || (rules.Method == "") || (rules.Method == http.MethodGet) || (rules.Method == http.MethodHead) {
+			u = rules.URL.ResolveReference(&url.URL{})
+			u.RawQuery = rules.Form.Encode()
+		} else {
+			body = strings.NewReader(rules.Form.Encode())
+		}
+	}
+
+	contentType := "application/x-www-form-urlencoded"
+	if rules.JSON != nil {
+		b, err := json.Marshal(rules.JSON)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(b)
+		contentType = "application/json"
+	} else if rules.Body != "" {
+		body = strings.NewReader(rules.Body)
+	}
+
+	req, err := http.NewRequestWithContext(rules.Context(), rules.Method, u.String(), body)
 	if err != nil {
 		return nil, err
 	}
-	req.Header = rules.Header
+	req.Header = rules.Header.Clone()
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+
+	// The BearerToken has priority over the BasicAuth.
+	if rules.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+rules.BearerToken)
+	} else if rules.BasicAuth != nil {
+		req.SetBasicAuth(rules.BasicAuth.Username, rules.BasicAuth.Password)
+	}
+
+	if (body != nil) && (req.Header.Get("Content-Type") == "") {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	// The body is decompressed by the client, see decompress.
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", AcceptEncoding)
+	}
 	return req, nil
 }
 
-func defaultTransport() *http.Transport {
+func (client *Client) defaultTransport() *http.Transport {
 	return &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-		DialContext: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-			DualStack: true,
-		}).DialContext,
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           client.dialer().DialContext,
 		TLSHandshakeTimeout:   10 * time.Second,
-		DisableKeepAlives:     true,
-		MaxIdleConns:          1,
-		MaxIdleConnsPerHost:   -1,
-		IdleConnTimeout:       30 * time.Second,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
 		ForceAttemptHTTP2:     true,
 	}
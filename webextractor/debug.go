@@ -0,0 +1,59 @@
+package webextractor
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"sync"
+)
+
+// dumpTransport writes the wire representation of the requests and responses,
+// including the ones of the redirects, see the Debug field of the rules.
+type dumpTransport struct {
+	rt http.RoundTripper
+	w  io.Writer
+	mu *sync.Mutex
+}
+
+func (t *dumpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqDump, err := httputil.DumpRequestOut(req, true)
+	if err != nil {
+		return nil, err
+	}
+	t.write(">>> Request", reqDump)
+
+	resp, err := t.rt.RoundTrip(req)
+	if err != nil {
+		t.write("<<< Error", []byte(err.Error()+"\n"))
+		return nil, err
+	}
+
+	// The body is dumped as it is received, before being decompressed.
+	respDump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	t.write("<<< Response", respDump)
+	return resp, nil
+}
+
+func (t *dumpTransport) write(title string, dump []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fmt.Fprintf(t.w, "%s\n%s\n\n", title, dump)
+}
+
+// debugWriter returns the writer of the dumps of the URL.
+func (client *Client) debugWriter(u *url.URL) io.Writer {
+	if client.DebugWriter != nil {
+		if w := client.DebugWriter(u); w != nil {
+			return w
+		}
+	}
+	return os.Stderr
+}
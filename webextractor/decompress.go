@@ -0,0 +1,89 @@
+package webextractor
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// AcceptEncoding is the value of the Accept-Encoding header sent
+// when the rules do not specify one.
+const AcceptEncoding = "gzip, deflate, br, zstd"
+
+// decoders returns a reader that decompresses r for each supported Content-Encoding.
+var decoders = map[string]func(r io.Reader) (io.ReadCloser, error){
+	"gzip": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	"x-gzip": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	"deflate": func(r io.Reader) (io.ReadCloser, error) {
+		return zlib.NewReader(r)
+	},
+	"br": func(r io.Reader) (io.ReadCloser, error) {
+		return io.NopCloser(brotli.NewReader(r)), nil
+	},
+	"zstd": func(r io.Reader) (io.ReadCloser, error) {
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	},
+}
+
+// decompress replaces the body of the response with its decompressed content
+// and returns the original Content-Encoding.
+// Responses with an unsupported encoding are not modified.
+func decompress(resp *http.Response) (string, error) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if (encoding == "") || (encoding == "identity") || (resp.Request.Method == http.MethodHead) {
+		return encoding, nil
+	}
+
+	newDecoder, ok := decoders[encoding]
+	if !ok {
+		return encoding, nil
+	}
+
+	decoder, err := newDecoder(resp.Body)
+	if errors.Is(err, io.EOF) {
+		// Empty body.
+		decoder, err = io.NopCloser(strings.NewReader("")), nil
+	}
+
+	if err != nil {
+		resp.Body.Close()
+		return encoding, err
+	}
+
+	resp.Body = &decompressReader{decoder: decoder, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return encoding, nil
+}
+
+// decompressReader reads the decompressed body and closes both
+// the decoder and the original body.
+type decompressReader struct {
+	decoder io.ReadCloser
+	body    io.ReadCloser
+}
+
+func (dr *decompressReader) Read(p []byte) (int, error) {
+	return dr.decoder.Read(p)
+}
+
+func (dr *decompressReader) Close() error {
+	dr.decoder.Close()
+	return dr.body.Close()
+}
@@ -1,17 +1,181 @@
 package webextractor
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"net"
+	"net/http"
 	"net/url"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/gonzxlez/colibri"
+
+	"golang.org/x/net/publicsuffix"
 )
 
+// Throttler is implemented by the delays that can slow down the HTTP requests to a host,
+// for example when the server responds with a Retry-After header.
+type Throttler interface {
+	// Throttle delays the next HTTP requests to the URL host by at least the duration.
+	Throttle(u *url.URL, d time.Duration)
+}
+
+// Observer is implemented by the delays that adapt to the responses of the server.
+type Observer interface {
+	// Observe records the latency and the status code of an HTTP request to the URL,
+	// the status code is 0 if the request failed.
+	Observe(u *url.URL, latency time.Duration, statusCode int)
+}
+
+// DelayKey returns the key of the URL by which the delays are applied,
+// the HTTP requests to URLs with the same key share the delay. See HostKey, DomainKey and IPKey.
+type DelayKey func(u *url.URL) string
+
+// HostKey returns the host of the URL, including the port. It is the default DelayKey.
+func HostKey(u *url.URL) string {
+	return u.Host
+}
+
+// DomainKey returns the registrable domain (eTLD+1) of the URL host, e.g. example.com
+// for a.example.com and b.example.com, so the subdomains of a domain share the delay.
+// If the host is an IP address or has no registrable domain, the host name is returned.
+func DomainKey(u *url.URL) string {
+	host := strings.ToLower(u.Hostname())
+	if net.ParseIP(host) != nil {
+		return host
+	}
+
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
+
+// IPKeyTTL is how long IPKey caches the addresses of each host,
+// unless its resolver is a CachedResolver.
+const IPKeyTTL = 5 * time.Minute
+
+// IPKey returns a DelayKey that returns the lowest address to which the resolver resolves the URL host,
+// so the hosts of the same server share the delay. The key does not depend on the order of the addresses,
+// e.g. with round-robin DNS. If resolver is not a CachedResolver, the addresses are cached for IPKeyTTL.
+// If resolver is nil, net.DefaultResolver is used. If the host cannot be resolved, the host name is returned.
+func IPKey(resolver Resolver) DelayKey {
+	if _, ok := resolver.(*CachedResolver); !ok {
+		cached := NewCachedResolver(resolver)
+		cached.TTL = IPKeyTTL
+		resolver = cached
+	}
+
+	return func(u *url.URL) string {
+		host := u.Hostname()
+		if ip := net.ParseIP(host); ip != nil {
+			return ip.String()
+		}
+
+		addrs, err := resolver.LookupIPAddr(context.Background(), host)
+		if (err != nil) || (len(addrs) == 0) {
+			return strings.ToLower(host)
+		}
+
+		lowest := addrs[0].IP
+		for _, addr := range addrs[1:] {
+			if bytes.Compare(addr.IP.To16(), lowest.To16()) < 0 {
+				lowest = addr.IP
+			}
+		}
+		return lowest.String()
+	}
+}
+
+// key returns the key of the URL with the DelayKey, the host if it is nil.
+func (key DelayKey) key(u *url.URL) string {
+	if key == nil {
+		return u.Host
+	}
+	return key(u)
+}
+
+// requestKeys stores the keys of the hosts with HTTP requests in progress, so the Wait, Stamp
+// and Done of a request use the same key even if the DelayKey changes, e.g. IPKey when the
+// addresses of the host change.
+type requestKeys struct {
+	mu    sync.Mutex
+	hosts map[string]*requestKey
+}
+
+type requestKey struct {
+	key      string
+	requests int
+}
+
+// acquire returns the key of the URL host and counts a request in progress.
+func (rk *requestKeys) acquire(k DelayKey, u *url.URL) string {
+	rk.mu.Lock()
+	defer rk.mu.Unlock()
+
+	if rk.hosts == nil {
+		rk.hosts = make(map[string]*requestKey)
+	}
+
+	r, ok := rk.hosts[u.Host]
+	if !ok {
+		r = &requestKey{key: k.key(u)}
+		rk.hosts[u.Host] = r
+	}
+	r.requests++
+	return r.key
+}
+
+// release returns the key of the URL host and ends a request in progress.
+func (rk *requestKeys) release(k DelayKey, u *url.URL) string {
+	rk.mu.Lock()
+	defer rk.mu.Unlock()
+
+	r, ok := rk.hosts[u.Host]
+	if !ok {
+		return k.key(u)
+	}
+
+	if r.requests--; r.requests <= 0 {
+		delete(rk.hosts, u.Host)
+	}
+	return r.key
+}
+
+// get returns the key of the URL host, the one of the requests in progress if there are any.
+func (rk *requestKeys) get(k DelayKey, u *url.URL) string {
+	rk.mu.Lock()
+	r, ok := rk.hosts[u.Host]
+	rk.mu.Unlock()
+
+	if ok {
+		return r.key
+	}
+	return k.key(u)
+}
+
+func (rk *requestKeys) clear() {
+	rk.mu.Lock()
+	clear(rk.hosts)
+	rk.mu.Unlock()
+}
+
 // ReqDelay manages the delay between each HTTP request.
 // See the colibri.Delay interface.
 type ReqDelay struct {
+	// Key specifies the key by which the delays are applied.
+	// If it is nil, the delays are applied by host, see HostKey.
+	Key DelayKey
+
 	rw        sync.RWMutex
 	timestamp map[string]int64
 	done      map[string]chan struct{}
+	keys      requestKeys
 }
 
 // NewReqDelay returns a new ReqDelay structure.
@@ -23,8 +187,12 @@ func NewReqDelay() *ReqDelay {
 }
 
 func (rd *ReqDelay) Wait(u *url.URL, duration time.Duration) {
+	rd.wait(rd.keys.acquire(rd.Key, u), duration)
+}
+
+func (rd *ReqDelay) wait(key string, duration time.Duration) {
 	rd.rw.RLock()
-	ch, ok := rd.done[u.Host]
+	ch, ok := rd.done[key]
 	rd.rw.RUnlock()
 
 	if ok {
@@ -32,12 +200,12 @@ func (rd *ReqDelay) Wait(u *url.URL, duration time.Duration) {
 
 	} else {
 		rd.rw.Lock()
-		rd.done[u.Host] = make(chan struct{}, 1)
+		rd.done[key] = make(chan struct{}, 1)
 		rd.rw.Unlock()
 	}
 
 	rd.rw.RLock()
-	timestamp, ok := rd.timestamp[u.Host]
+	timestamp, ok := rd.timestamp[key]
 	rd.rw.RUnlock()
 
 	if ok {
@@ -49,17 +217,40 @@ func (rd *ReqDelay) Wait(u *url.URL, duration time.Duration) {
 }
 
 func (rd *ReqDelay) Done(u *url.URL) {
+	rd.release(rd.keys.release(rd.Key, u))
+}
+
+func (rd *ReqDelay) release(key string) {
 	rd.rw.Lock()
 	select {
-	case rd.done[u.Host] <- struct{}{}:
+	case rd.done[key] <- struct{}{}:
 	default:
 	}
 	rd.rw.Unlock()
 }
 
 func (rd *ReqDelay) Stamp(u *url.URL) {
+	rd.stamp(rd.keys.get(rd.Key, u))
+}
+
+func (rd *ReqDelay) stamp(key string) {
 	rd.rw.Lock()
-	rd.timestamp[u.Host] = time.Now().UnixMilli()
+	rd.timestamp[key] = time.Now().UnixMilli()
+	rd.rw.Unlock()
+}
+
+// Throttle moves the timestamp of the URL host forward,
+// so the next HTTP request waits for the duration in addition to the delay.
+func (rd *ReqDelay) Throttle(u *url.URL, d time.Duration) {
+	rd.throttle(rd.keys.get(rd.Key, u), d)
+}
+
+func (rd *ReqDelay) throttle(key string, d time.Duration) {
+	rd.rw.Lock()
+	timestamp := time.Now().Add(d).UnixMilli()
+	if timestamp > rd.timestamp[key] {
+		rd.timestamp[key] = timestamp
+	}
 	rd.rw.Unlock()
 }
 
@@ -72,11 +263,318 @@ func (rd *ReqDelay) Clear() {
 		delete(rd.done, host)
 	}
 	rd.rw.Unlock()
+
+	rd.keys.clear()
+}
+
+// MarshalJSON returns the JSON representation of the timestamps
+// of the last HTTP request made to each host or key.
+func (rd *ReqDelay) MarshalJSON() ([]byte, error) {
+	rd.rw.RLock()
+	defer rd.rw.RUnlock()
+	return json.Marshal(rd.timestamp)
+}
+
+// UnmarshalJSON restores the timestamps stored with MarshalJSON.
+func (rd *ReqDelay) UnmarshalJSON(b []byte) error {
+	var timestamp map[string]int64
+	if err := json.Unmarshal(b, &timestamp); err != nil {
+		return err
+	}
+
+	rd.rw.Lock()
+	for host, t := range timestamp {
+		rd.timestamp[host] = t
+	}
+	rd.rw.Unlock()
+	return nil
+}
+
+// Report returns the number of hosts with a timestamp.
+// See the colibri.Reporter interface.
+func (rd *ReqDelay) Report() any {
+	rd.rw.RLock()
+	defer rd.rw.RUnlock()
+	return map[string]any{"hosts": len(rd.timestamp)}
 }
 
 func (rd *ReqDelay) visit(u *url.URL) bool {
 	rd.rw.RLock()
-	_, ok := rd.timestamp[u.Host]
+	_, ok := rd.timestamp[rd.keys.get(rd.Key, u)]
 	rd.rw.RUnlock()
 	return ok
 }
+
+// TokenBucket limits the rate of HTTP requests to each host with a token bucket.
+// Each host has its own bucket that is filled at Rate tokens per second up to Burst tokens,
+// each HTTP request consumes a token. The duration of the delay is ignored,
+// use Delays to combine it with ReqDelay.
+// See the colibri.Delay interface.
+type TokenBucket struct {
+	// Rate specifies the number of requests per second to each host.
+	Rate float64
+
+	// Burst specifies the maximum number of requests that can be made at once.
+	Burst int
+
+	// Key specifies the key by which the buckets are shared.
+	// If it is nil, each host has its own bucket, see HostKey.
+	Key DelayKey
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket returns a new TokenBucket structure.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &TokenBucket{
+		Rate:    rate,
+		Burst:   burst,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (tb *TokenBucket) Wait(u *url.URL, _ time.Duration) {
+	if tb.Rate <= 0 {
+		return
+	}
+
+	tb.mu.Lock()
+	b := tb.fill(tb.Key.key(u))
+
+	// The token is reserved, if the bucket is empty
+	// the wait is the time needed to refill it.
+	b.tokens--
+	wait := time.Duration(-b.tokens / tb.Rate * float64(time.Second))
+	tb.mu.Unlock()
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+func (tb *TokenBucket) Done(_ *url.URL) {}
+
+func (tb *TokenBucket) Stamp(_ *url.URL) {}
+
+// Throttle empties the bucket of the URL host for the duration.
+func (tb *TokenBucket) Throttle(u *url.URL, d time.Duration) {
+	if tb.Rate <= 0 {
+		return
+	}
+
+	tb.mu.Lock()
+	b := tb.fill(tb.Key.key(u))
+	b.tokens = math.Min(b.tokens, -d.Seconds()*tb.Rate)
+	tb.mu.Unlock()
+}
+
+func (tb *TokenBucket) Clear() {
+	tb.mu.Lock()
+	clear(tb.buckets)
+	tb.mu.Unlock()
+}
+
+// Report returns the number of hosts with a bucket.
+// See the colibri.Reporter interface.
+func (tb *TokenBucket) Report() any {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	return map[string]any{"hosts": len(tb.buckets)}
+}
+
+// fill returns the bucket of the key with the tokens generated since the last use.
+func (tb *TokenBucket) fill(key string) *bucket {
+	now := time.Now()
+
+	b, ok := tb.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(tb.Burst), last: now}
+		tb.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(tb.Burst), b.tokens+now.Sub(b.last).Seconds()*tb.Rate)
+	b.last = now
+	return b
+}
+
+// AutoDelay adapts the delay between the HTTP requests to each host to the load of the server.
+// The delay of a host starts with the duration of the rules and is doubled when the latency exceeds
+// TargetLatency or the request fails, while the server is healthy it is reduced by Step (AIMD).
+// See the colibri.Delay interface.
+type AutoDelay struct {
+	// MinDelay specifies the minimum delay.
+	MinDelay time.Duration
+
+	// MaxDelay specifies the maximum delay.
+	MaxDelay time.Duration
+
+	// TargetLatency specifies the latency above which the server is considered overloaded.
+	TargetLatency time.Duration
+
+	// Step specifies the amount by which the delay is reduced.
+	Step time.Duration
+
+	// Key specifies the key by which the delays are shared.
+	// If it is nil, each host has its own delay, see HostKey.
+	Key DelayKey
+
+	rd     *ReqDelay
+	rw     sync.RWMutex
+	delays map[string]time.Duration
+}
+
+// NewAutoDelay returns a new AutoDelay structure with delays between minDelay and maxDelay.
+func NewAutoDelay(minDelay, maxDelay time.Duration) *AutoDelay {
+	return &AutoDelay{
+		MinDelay:      minDelay,
+		MaxDelay:      maxDelay,
+		TargetLatency: time.Second,
+		Step:          100 * time.Millisecond,
+		rd:            NewReqDelay(),
+		delays:        make(map[string]time.Duration),
+	}
+}
+
+// Delay returns the current delay of the URL host.
+func (ad *AutoDelay) Delay(u *url.URL) time.Duration {
+	ad.rw.RLock()
+	defer ad.rw.RUnlock()
+	return ad.delays[ad.rd.keys.get(ad.Key, u)]
+}
+
+func (ad *AutoDelay) Wait(u *url.URL, duration time.Duration) {
+	key := ad.rd.keys.acquire(ad.Key, u)
+
+	ad.rw.Lock()
+	d, ok := ad.delays[key]
+	if !ok {
+		d = ad.clamp(duration)
+		ad.delays[key] = d
+	}
+	ad.rw.Unlock()
+
+	ad.rd.wait(key, d)
+}
+
+func (ad *AutoDelay) Done(u *url.URL) { ad.rd.release(ad.rd.keys.release(ad.Key, u)) }
+
+func (ad *AutoDelay) Stamp(u *url.URL) { ad.rd.stamp(ad.rd.keys.get(ad.Key, u)) }
+
+// Observe doubles the delay of the URL host if the request failed, the server responded
+// with 429 or 5xx or the latency exceeds TargetLatency, otherwise the delay is reduced by Step.
+func (ad *AutoDelay) Observe(u *url.URL, latency time.Duration, statusCode int) {
+	overloaded := (statusCode == 0) || (statusCode == http.StatusTooManyRequests) ||
+		(statusCode >= http.StatusInternalServerError) || (latency > ad.TargetLatency)
+
+	key := ad.rd.keys.get(ad.Key, u)
+
+	ad.rw.Lock()
+	d := ad.delays[key]
+	if overloaded {
+		d = ad.clamp(max(2*d, ad.Step))
+	} else {
+		d = ad.clamp(d - ad.Step)
+	}
+	ad.delays[key] = d
+	ad.rw.Unlock()
+}
+
+// Throttle doubles the delay of the URL host and delays the next request by the duration.
+func (ad *AutoDelay) Throttle(u *url.URL, d time.Duration) {
+	ad.Observe(u, 0, http.StatusTooManyRequests)
+	ad.rd.throttle(ad.rd.keys.get(ad.Key, u), d)
+}
+
+func (ad *AutoDelay) Clear() {
+	ad.rw.Lock()
+	clear(ad.delays)
+	ad.rw.Unlock()
+
+	ad.rd.Clear()
+}
+
+// Report returns the current delay of each host in milliseconds.
+// See the colibri.Reporter interface.
+func (ad *AutoDelay) Report() any {
+	ad.rw.RLock()
+	defer ad.rw.RUnlock()
+
+	delays := make(map[string]int64, len(ad.delays))
+	for host, d := range ad.delays {
+		delays[host] = d.Milliseconds()
+	}
+	return map[string]any{"hosts": len(ad.delays), "delays": delays}
+}
+
+func (ad *AutoDelay) clamp(d time.Duration) time.Duration {
+	if (ad.MaxDelay > 0) && (d > ad.MaxDelay) {
+		d = ad.MaxDelay
+	}
+	return max(d, ad.MinDelay)
+}
+
+// Delays combines several delays into one, each HTTP request waits for all of them.
+// See the colibri.Delay interface.
+type Delays []colibri.Delay
+
+func (delays Delays) Wait(u *url.URL, duration time.Duration) {
+	for _, d := range delays {
+		d.Wait(u, duration)
+	}
+}
+
+func (delays Delays) Done(u *url.URL) {
+	for _, d := range delays {
+		d.Done(u)
+	}
+}
+
+func (delays Delays) Stamp(u *url.URL) {
+	for _, d := range delays {
+		d.Stamp(u)
+	}
+}
+
+func (delays Delays) Throttle(u *url.URL, d time.Duration) {
+	for _, delay := range delays {
+		if t, ok := delay.(Throttler); ok {
+			t.Throttle(u, d)
+		}
+	}
+}
+
+func (delays Delays) Observe(u *url.URL, latency time.Duration, statusCode int) {
+	for _, delay := range delays {
+		if o, ok := delay.(Observer); ok {
+			o.Observe(u, latency, statusCode)
+		}
+	}
+}
+
+// Report returns the reports of the delays that implement colibri.Reporter,
+// the rest of the delays are reported as nil.
+func (delays Delays) Report() any {
+	reports := make([]any, len(delays))
+	for i, d := range delays {
+		if r, ok := d.(colibri.Reporter); ok {
+			reports[i] = r.Report()
+		}
+	}
+	return reports
+}
+
+func (delays Delays) Clear() {
+	for _, d := range delays {
+		d.Clear()
+	}
+}
@@ -1,9 +1,16 @@
 package webextractor
 
 import (
+	"context"
+	"net"
 	"net/url"
+	"reflect"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/gonzxlez/colibri"
 )
 
 func TestReqDelay(t *testing.T) {
@@ -69,3 +76,273 @@ func TestReqClear(t *testing.T) {
 		t.Fatal("Uncleaned")
 	}
 }
+
+func TestTokenBucket(t *testing.T) {
+	var (
+		tb = NewTokenBucket(100, 2)
+		u  = mustNewURL("https://pkg.go.dev")
+	)
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		tb.Wait(u, 0)
+	}
+
+	// 2 requests of the burst, 4 requests at 100 requests per second.
+	if end := time.Since(start); end < 40*time.Millisecond {
+		t.Fatal("Delay is not expected", end)
+	}
+
+	start = time.Now()
+	tb.Wait(mustNewURL("https://go.dev"), 0)
+
+	if end := time.Since(start); end > 5*time.Millisecond {
+		t.Fatal("Hosts must not share the bucket", end)
+	}
+
+	tb.Clear()
+
+	if len(tb.buckets) > 0 {
+		t.Fatal("Uncleaned")
+	}
+}
+
+func TestDelays(t *testing.T) {
+	var (
+		rd     = NewReqDelay()
+		tb     = NewTokenBucket(50, 1)
+		delays = Delays{rd, tb}
+		u      = mustNewURL("https://pkg.go.dev")
+	)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		delays.Wait(u, 5*time.Millisecond)
+		delays.Done(u)
+		delays.Stamp(u)
+	}
+
+	if end := time.Since(start); end < 40*time.Millisecond {
+		t.Fatal("Delay is not expected", end)
+	}
+
+	if !rd.visit(u) {
+		t.Fatal("URL not visit")
+	}
+
+	delays.Clear()
+
+	if rd.visit(u) || (len(tb.buckets) > 0) {
+		t.Fatal("Uncleaned")
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	var (
+		u = mustNewURL("https://pkg.go.dev")
+		d = 30 * time.Millisecond
+	)
+
+	for _, delay := range []colibri.Delay{NewReqDelay(), NewTokenBucket(1000, 1), Delays{NewReqDelay()}} {
+		start := time.Now()
+
+		delay.(Throttler).Throttle(u, d)
+		delay.Wait(u, time.Millisecond)
+
+		if end := time.Since(start); end < d {
+			t.Fatalf("%T: Delay is not expected %v", delay, end)
+		}
+	}
+}
+
+func TestAutoDelay(t *testing.T) {
+	var (
+		ad = NewAutoDelay(10*time.Millisecond, time.Second)
+		u  = mustNewURL("https://pkg.go.dev")
+	)
+	ad.Step = 50 * time.Millisecond
+
+	ad.Wait(u, 100*time.Millisecond)
+	ad.Done(u)
+	ad.Stamp(u)
+
+	tests := []struct {
+		Latency    time.Duration
+		StatusCode int
+		WantDelay  time.Duration
+	}{
+		{10 * time.Millisecond, 200, 50 * time.Millisecond},
+		{10 * time.Millisecond, 200, 10 * time.Millisecond},
+		{10 * time.Millisecond, 500, 50 * time.Millisecond},
+		{0, 0, 100 * time.Millisecond},
+		{2 * time.Second, 200, 200 * time.Millisecond},
+		{0, 429, 400 * time.Millisecond},
+		{0, 503, 800 * time.Millisecond},
+		{0, 503, time.Second},
+	}
+
+	for _, tt := range tests {
+		ad.Observe(u, tt.Latency, tt.StatusCode)
+
+		if d := ad.Delay(u); d != tt.WantDelay {
+			t.Fatalf(gotWantFormat, d, tt.WantDelay)
+		}
+	}
+
+	ad.Clear()
+
+	if ad.Delay(u) != 0 {
+		t.Fatal("Uncleaned")
+	}
+}
+
+func TestDelayReport(t *testing.T) {
+	var (
+		rd = NewReqDelay()
+		ad = NewAutoDelay(10*time.Millisecond, time.Second)
+		u  = mustNewURL("https://pkg.go.dev")
+	)
+
+	rd.Stamp(u)
+	ad.Wait(u, 100*time.Millisecond)
+	ad.Done(u)
+
+	got := Delays{rd, ad, NewTokenBucket(1, 1)}.Report()
+	want := []any{
+		map[string]any{"hosts": 1},
+		map[string]any{"hosts": 1, "delays": map[string]int64{"pkg.go.dev": 100}},
+		map[string]any{"hosts": 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf(gotWantFormat, got, want)
+	}
+}
+
+func TestDelayKey(t *testing.T) {
+	resolver := &testResolver{}
+	ipKey := IPKey(resolver)
+
+	tests := []struct {
+		Key  DelayKey
+		URL  string
+		Want string
+	}{
+		{HostKey, "https://a.example.com:8080/a", "a.example.com:8080"},
+		{DomainKey, "https://a.example.com/a", "example.com"},
+		{DomainKey, "https://B.example.co.uk", "example.co.uk"},
+		{DomainKey, "http://127.0.0.1:8080", "127.0.0.1"},
+		{DomainKey, "http://localhost", "localhost"},
+		{ipKey, "http://colibri.test:8080", "127.0.0.1"},
+		{ipKey, "http://[::1]", "::1"},
+		{ipKey, "http://unknown.test", "unknown.test"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.URL, func(t *testing.T) {
+			if got := tt.Key(mustNewURL(tt.URL)); got != tt.Want {
+				t.Fatalf(gotWantFormat, got, tt.Want)
+			}
+		})
+	}
+}
+
+// roundRobinResolver rotates the addresses of the host on each lookup.
+type roundRobinResolver struct {
+	mu      sync.Mutex
+	addrs   []net.IPAddr
+	lookups int
+}
+
+func (r *roundRobinResolver) LookupIPAddr(_ context.Context, _ string) ([]net.IPAddr, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lookups++
+	r.addrs = append(r.addrs[1:], r.addrs[0])
+	return append([]net.IPAddr(nil), r.addrs...), nil
+}
+
+func TestIPKeyRoundRobin(t *testing.T) {
+	resolver := &roundRobinResolver{addrs: []net.IPAddr{
+		{IP: net.ParseIP("10.0.0.3")},
+		{IP: net.ParseIP("10.0.0.1")},
+		{IP: net.ParseIP("10.0.0.2")},
+	}}
+
+	ipKey := IPKey(resolver)
+	u := mustNewURL("https://colibri.test")
+	for i := 0; i < 3; i++ {
+		if got := ipKey(u); got != "10.0.0.1" {
+			t.Fatalf(gotWantFormat, got, "10.0.0.1")
+		}
+	}
+
+	if resolver.lookups != 1 {
+		t.Fatalf(prefixGotWantFormat, "lookups", resolver.lookups, 1)
+	}
+}
+
+func TestReqDelayChangingKey(t *testing.T) {
+	var (
+		delay = NewReqDelay()
+		u     = mustNewURL("https://colibri.test")
+		n     int
+	)
+
+	// The key alternates on each call, the Wait and Done of a request use the same key.
+	delay.Key = func(_ *url.URL) string {
+		n++
+		return strconv.Itoa(n % 2)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 3; i++ {
+			delay.Wait(u, time.Millisecond)
+			delay.Done(u)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the requests are blocked")
+	}
+}
+
+func TestReqDelayKey(t *testing.T) {
+	var (
+		delay    = NewReqDelay()
+		a        = mustNewURL("https://a.example.com")
+		b        = mustNewURL("https://b.example.com")
+		duration = 50 * time.Millisecond
+	)
+	delay.Key = DomainKey
+
+	delay.Wait(a, duration)
+	delay.Done(a)
+	delay.Stamp(a)
+
+	// The subdomains share the delay.
+	if !delay.visit(b) {
+		t.Fatal("URL not visit")
+	}
+
+	start := time.Now()
+	delay.Wait(b, duration)
+	delay.Done(b)
+
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Fatalf(gotWantFormat, elapsed, duration)
+	}
+
+	ad := NewAutoDelay(0, time.Second)
+	ad.Key = DomainKey
+	ad.Wait(a, duration)
+	ad.Done(a)
+
+	if d := ad.Delay(b); d != duration {
+		t.Fatalf(gotWantFormat, d, duration)
+	}
+}
@@ -0,0 +1,427 @@
+package webextractor
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+var (
+	// ErrUnknownFingerprint is returned when the StdFingerprinter does not know the fingerprint.
+	ErrUnknownFingerprint = errors.New("unknown TLS fingerprint")
+
+	// ErrFingerprintProxy is returned when the proxy of a request with a Fingerprint is not an HTTP proxy.
+	ErrFingerprintProxy = errors.New("unsupported proxy scheme for TLS fingerprints")
+
+	// ErrProxyConnect is returned when the proxy does not accept the CONNECT request.
+	ErrProxyConnect = errors.New("proxy CONNECT failed")
+)
+
+// Fingerprinter performs TLS handshakes whose ClientHello imitates the fingerprint of a browser,
+// since many anti-bot systems block the fingerprint of the Go TLS client regardless of the headers.
+// It is usually an adapter of the uTLS library (github.com/refraction-networking/utls)
+// that maps the fingerprints, e.g. "chrome" or "firefox", to its ClientHelloIDs.
+type Fingerprinter interface {
+	// Handshake performs the TLS handshake over conn with the configuration and the fingerprint.
+	// Returns the TLS connection and the protocol negotiated with ALPN, "h2" or "http/1.1".
+	Handshake(ctx context.Context, conn net.Conn, config *tls.Config, fingerprint string) (net.Conn, string, error)
+}
+
+// StdFingerprinter is the Fingerprinter used when the Fingerprinter of the Client is nil.
+// It performs the handshakes with crypto/tls, which does not allow to control the order
+// of the extensions of the ClientHello, so the fingerprints are only approximated with the
+// cipher suites, the curves and the ALPN protocols of the browsers: "chrome", "edge", "firefox" and "safari".
+// An adapter of uTLS is required to imitate the fingerprints exactly.
+type StdFingerprinter struct{}
+
+// stdFingerprints contains the TLS 1.2 cipher suites and the curves of the fingerprints of the StdFingerprinter.
+var stdFingerprints = map[string]struct {
+	cipherSuites []uint16
+	curves       []tls.CurveID
+}{
+	"chrome": {
+		cipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+			tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+			tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+		},
+		curves: []tls.CurveID{tls.X25519, tls.CurveP256, tls.CurveP384},
+	},
+	"firefox": {
+		cipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+			tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+			tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+		},
+		curves: []tls.CurveID{tls.X25519, tls.CurveP256, tls.CurveP384, tls.CurveP521},
+	},
+	"safari": {
+		cipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+			tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+			tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+		},
+		curves: []tls.CurveID{tls.X25519, tls.CurveP256, tls.CurveP384, tls.CurveP521},
+	},
+}
+
+// Handshake performs the TLS handshake over conn with crypto/tls.
+// The cipher suites and the curves of the configuration are only replaced if they are empty.
+// Returns ErrUnknownFingerprint if the fingerprint is not one of the StdFingerprinter.
+func (StdFingerprinter) Handshake(ctx context.Context, conn net.Conn, config *tls.Config, fingerprint string) (net.Conn, string, error) {
+	name := strings.ToLower(fingerprint)
+	if name == "edge" {
+		name = "chrome"
+	}
+
+	fp, ok := stdFingerprints[name]
+	if !ok {
+		return nil, "", fmt.Errorf("%w: %q", ErrUnknownFingerprint, fingerprint)
+	}
+
+	config = config.Clone()
+	if len(config.CipherSuites) == 0 {
+		config.CipherSuites = fp.cipherSuites
+	}
+
+	if len(config.CurvePreferences) == 0 {
+		config.CurvePreferences = fp.curves
+	}
+
+	if config.MinVersion == 0 {
+		config.MinVersion = tls.VersionTLS12
+	}
+
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return nil, "", err
+	}
+	return tlsConn, tlsConn.ConnectionState().NegotiatedProtocol, nil
+}
+
+// fingerprintTransport is an http.RoundTripper that establishes the TLS connections with a Fingerprinter.
+// The connections that negotiate HTTP/2 are used by an http2.ClientConn,
+// the rest by an http.Transport.
+type fingerprintTransport struct {
+	fingerprinter Fingerprinter
+	fingerprint   string
+	config        *tls.Config
+	proxy         *url.URL
+	dialer        *dialer
+
+	h1 *http.Transport
+	h2 *http2.Transport
+
+	mu      sync.Mutex
+	conns   map[string]*http2.ClientConn
+	http1   map[string]bool
+	dialing map[string]*h2Dial
+}
+
+// h2Dial is a dial of an HTTP/2 connection in progress,
+// the concurrent requests to the same address wait for it instead of dialing their own connections.
+type h2Dial struct {
+	done chan struct{}
+	err  error
+}
+
+func (client *Client) fingerprintTransport(proxyURL *url.URL, config *tls.Config, fingerprint string) (*fingerprintTransport, error) {
+	fingerprinter := client.Fingerprinter
+	if fingerprinter == nil {
+		fingerprinter = StdFingerprinter{}
+	}
+
+	if (proxyURL != nil) && (proxyURL.Scheme != "http") {
+		return nil, ErrFingerprintProxy
+	}
+
+	t := &fingerprintTransport{
+		fingerprinter: fingerprinter,
+		fingerprint:   fingerprint,
+		config:        config,
+		proxy:         proxyURL,
+		dialer:        client.dialer(),
+		conns:         make(map[string]*http2.ClientConn),
+		http1:         make(map[string]bool),
+		dialing:       make(map[string]*h2Dial),
+	}
+
+	t.h1 = client.defaultTransport()
+	t.h1.ForceAttemptHTTP2 = false
+	t.h1.DialTLSContext = t.dialHTTP1
+	if proxyURL != nil {
+		// The HTTPS requests are tunneled by dialTLS.
+		t.h1.Proxy = func(req *http.Request) (*url.URL, error) {
+			if req.URL.Scheme == "https" {
+				return nil, nil
+			}
+			return proxyURL, nil
+		}
+	}
+
+	t.h2 = &http2.Transport{}
+	return t, nil
+}
+
+func (t *fingerprintTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "https" {
+		return t.h1.RoundTrip(req)
+	}
+
+	addr := canonicalAddr(req.URL)
+	for {
+		t.mu.Lock()
+		if t.http1[addr] {
+			t.mu.Unlock()
+			return t.h1.RoundTrip(req)
+		}
+
+		if cc, ok := t.conns[addr]; ok && cc.CanTakeNewRequest() {
+			t.mu.Unlock()
+			return cc.RoundTrip(req)
+		}
+
+		d, ok := t.dialing[addr]
+		if !ok {
+			d = &h2Dial{done: make(chan struct{})}
+			t.dialing[addr] = d
+			t.mu.Unlock()
+
+			d.err = t.dialH2(req.Context(), addr)
+
+			t.mu.Lock()
+			delete(t.dialing, addr)
+			t.mu.Unlock()
+			close(d.done)
+
+			if d.err != nil {
+				return nil, d.err
+			}
+			continue
+		}
+		t.mu.Unlock()
+
+		// Another request is dialing the address, its connection is used once it is established.
+		select {
+		case <-d.done:
+			if d.err != nil {
+				return nil, d.err
+			}
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// dialH2 dials the address and stores the HTTP/2 connection,
+// the connection that it replaces is closed once its requests are finished.
+// If the address negotiates HTTP/1.1, it is marked to be used with the http.Transport.
+func (t *fingerprintTransport) dialH2(ctx context.Context, addr string) error {
+	conn, proto, err := t.dialTLS(ctx, addr)
+	if err != nil {
+		return err
+	}
+
+	if proto != http2.NextProtoTLS {
+		// The connection is discarded, the http.Transport dials its own connections.
+		conn.Close()
+
+		t.mu.Lock()
+		t.http1[addr] = true
+		t.mu.Unlock()
+		return nil
+	}
+
+	cc, err := t.h2.NewClientConn(conn)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	t.mu.Lock()
+	replaced := t.conns[addr]
+	t.conns[addr] = cc
+	t.mu.Unlock()
+
+	if replaced != nil {
+		go replaced.Shutdown(context.Background())
+	}
+	return nil
+}
+
+// CloseIdleConnections closes the connections that are not in use.
+func (t *fingerprintTransport) CloseIdleConnections() {
+	t.h1.CloseIdleConnections()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for addr, cc := range t.conns {
+		if cc.State().StreamsActive == 0 {
+			cc.Close()
+			delete(t.conns, addr)
+		}
+	}
+}
+
+// dialHTTP1 dials the connections of the http.Transport.
+func (t *fingerprintTransport) dialHTTP1(ctx context.Context, _, addr string) (net.Conn, error) {
+	conn, proto, err := t.dialTLS(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if proto == http2.NextProtoTLS {
+		conn.Close()
+
+		// The host is dialed again with HTTP/2 on the next request.
+		t.mu.Lock()
+		delete(t.http1, addr)
+		t.mu.Unlock()
+		return nil, fmt.Errorf("%s negotiated %s after HTTP/1.1", addr, proto)
+	}
+	return conn, nil
+}
+
+// dialTLS dials the address, through the proxy if any, and performs the TLS handshake with the Fingerprinter.
+func (t *fingerprintTransport) dialTLS(ctx context.Context, addr string) (net.Conn, string, error) {
+	var (
+		conn net.Conn
+		err  error
+	)
+
+	if t.proxy != nil {
+		conn, err = t.dialProxy(ctx, addr)
+	} else {
+		conn, err = t.dialer.DialContext(ctx, "tcp", addr)
+	}
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	config := t.config.Clone()
+	if config.ServerName == "" {
+		config.ServerName, _, _ = net.SplitHostPort(addr)
+	}
+
+	if len(config.NextProtos) == 0 {
+		config.NextProtos = []string{http2.NextProtoTLS, "http/1.1"}
+	}
+
+	trace := httptrace.ContextClientTrace(ctx)
+	if (trace != nil) && (trace.TLSHandshakeStart != nil) {
+		trace.TLSHandshakeStart()
+	}
+
+	tlsConn, proto, err := t.fingerprinter.Handshake(ctx, conn, config, t.fingerprint)
+	if (trace != nil) && (trace.TLSHandshakeDone != nil) {
+		trace.TLSHandshakeDone(tls.ConnectionState{NegotiatedProtocol: proto}, err)
+	}
+
+	if err != nil {
+		conn.Close()
+		return nil, "", err
+	}
+	return tlsConn, proto, nil
+}
+
+// dialProxy establishes a tunnel to the address with a CONNECT request to the proxy.
+func (t *fingerprintTransport) dialProxy(ctx context.Context, addr string) (net.Conn, error) {
+	conn, err := t.dialer.DialContext(ctx, "tcp", canonicalAddr(t.proxy))
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+
+	if u := t.proxy.User; u != nil {
+		password, _ := u.Password()
+		req.SetBasicAuth(u.Username(), password)
+		req.Header["Proxy-Authorization"] = req.Header["Authorization"]
+		req.Header.Del("Authorization")
+	}
+
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("%w: %s", ErrProxyConnect, resp.Status)
+	}
+	return conn, nil
+}
+
+// canonicalAddr returns the host and port of the URL,
+// the port is obtained from the scheme if the URL does not specify one.
+func canonicalAddr(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
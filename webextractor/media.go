@@ -0,0 +1,196 @@
+package webextractor
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/gonzxlez/colibri"
+)
+
+// DefaultMediaWorkers default number of assets downloaded concurrently.
+const DefaultMediaWorkers = 4
+
+// ErrAssetStatus is returned when the response of an asset does not have a 2xx status code.
+var ErrAssetStatus = errors.New("unexpected asset status code")
+
+// Storage stores the content of the assets.
+type Storage interface {
+	// Store stores the content with the name and returns its location.
+	Store(name string, r io.Reader) (string, error)
+}
+
+// MediaPipeline downloads the assets concurrently and stores them in the Storage.
+// The assets are named after the SHA-256 hash of their content, so an asset found
+// in several URLs is stored only once.
+// See the colibri.Media interface.
+type MediaPipeline struct {
+	// Storage specifies where the assets are stored.
+	Storage Storage
+
+	// Workers specifies the number of assets downloaded concurrently.
+	// If it is less than 1, DefaultMediaWorkers is used.
+	Workers int
+
+	rw     sync.RWMutex
+	stored map[string]string // content hash -> location
+}
+
+// NewMediaPipeline returns a new MediaPipeline structure that stores the assets in storage.
+func NewMediaPipeline(storage Storage) *MediaPipeline {
+	return &MediaPipeline{
+		Storage: storage,
+		Workers: DefaultMediaWorkers,
+		stored:  make(map[string]string),
+	}
+}
+
+func (media *MediaPipeline) Store(resp colibri.Response, rules []*colibri.Rules) ([]string, error) {
+	workers := media.Workers
+	if workers < 1 {
+		workers = DefaultMediaWorkers
+	}
+
+	var (
+		locations = make([]string, len(rules))
+		errs      = make([]error, len(rules))
+		sem       = make(chan struct{}, workers)
+		wg        sync.WaitGroup
+	)
+
+	for i, r := range rules {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, r *colibri.Rules) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			locations[i], errs[i] = media.store(resp, r)
+		}(i, r)
+	}
+	wg.Wait()
+
+	var err error
+	for i, e := range errs {
+		if e != nil {
+			err = colibri.AddError(err, rules[i].URL.String(), e)
+		}
+	}
+	return locations, err
+}
+
+func (media *MediaPipeline) Clear() {
+	media.rw.Lock()
+	clear(media.stored)
+	media.rw.Unlock()
+}
+
+// store downloads the asset and stores it if its content has not been stored yet.
+func (media *MediaPipeline) store(resp colibri.Response, rules *colibri.Rules) (string, error) {
+	assetResp, err := resp.Do(rules)
+	if err != nil {
+		return "", err
+	}
+
+	body := assetResp.Body()
+	defer body.Close()
+
+	if code := assetResp.StatusCode(); (code < 200) || (code > 299) {
+		return "", fmt.Errorf("%w: %d", ErrAssetStatus, code)
+	}
+
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+	hash := hex.EncodeToString(sum[:])
+
+	media.rw.RLock()
+	location, ok := media.stored[hash]
+	media.rw.RUnlock()
+
+	if ok {
+		return location, nil
+	}
+
+	location, err = media.Storage.Store(hash+assetExt(rules, assetResp), bytes.NewReader(b))
+	if err != nil {
+		return "", err
+	}
+
+	media.rw.Lock()
+	if media.stored == nil {
+		media.stored = make(map[string]string)
+	}
+	media.stored[hash] = location
+	media.rw.Unlock()
+	return location, nil
+}
+
+// assetExt returns the extension of the asset, obtained from the URL
+// or, if the URL does not have one, from the Content-Type.
+func assetExt(rules *colibri.Rules, resp colibri.Response) string {
+	if ext := path.Ext(rules.URL.Path); ext != "" {
+		return ext
+	}
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header().Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+
+	exts, _ := mime.ExtensionsByType(mediaType)
+	if len(exts) == 0 {
+		return ""
+	}
+	return exts[0]
+}
+
+// DiskStorage stores the assets in a directory.
+type DiskStorage struct {
+	// Dir specifies the directory in which the assets are stored.
+	Dir string
+}
+
+// NewDiskStorage returns a new DiskStorage structure that stores the assets in dir.
+func NewDiskStorage(dir string) *DiskStorage {
+	return &DiskStorage{Dir: dir}
+}
+
+// Store stores the content in the file with the name and returns the path of the file.
+// The file is replaced only when the content has been completely written.
+func (disk *DiskStorage) Store(name string, r io.Reader) (string, error) {
+	if err := os.MkdirAll(disk.Dir, 0o755); err != nil {
+		return "", err
+	}
+
+	f, err := os.CreateTemp(disk.Dir, name+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = io.Copy(f, r)
+	if err = errors.Join(err, f.Close()); err != nil {
+		return "", err
+	}
+
+	location := filepath.Join(disk.Dir, name)
+	if err := os.Rename(f.Name(), location); err != nil {
+		return "", err
+	}
+	return location, nil
+}
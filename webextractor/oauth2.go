@@ -0,0 +1,163 @@
+package webextractor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTokenExpiryDelta is the time before the expiration of a token in which it is renewed.
+const DefaultTokenExpiryDelta = 10 * time.Second
+
+// ErrOAuth2Token is returned when the token endpoint does not return a valid token.
+var ErrOAuth2Token = errors.New("cannot obtain OAuth2 token")
+
+// Authenticator adds credentials to the requests of the Client.
+type Authenticator interface {
+	// Authenticate adds the credentials to the request, it is called before each request.
+	Authenticate(req *http.Request) error
+}
+
+// OAuth2 obtains OAuth2 access tokens with the client credentials flow or, if RefreshToken
+// is not empty, with the refresh token flow, and adds them to the requests in the
+// "Authorization: Bearer" header. The tokens are renewed before they expire.
+// See the Authenticator interface.
+type OAuth2 struct {
+	// TokenURL specifies the URL of the token endpoint.
+	TokenURL string
+
+	// ClientID and ClientSecret are the credentials of the client,
+	// they are sent to the token endpoint with HTTP Basic authentication.
+	ClientID     string
+	ClientSecret string
+
+	// Scopes specifies the requested scopes.
+	Scopes []string
+
+	// RefreshToken specifies the refresh token. If the token endpoint returns
+	// a new refresh token, it replaces this one.
+	RefreshToken string
+
+	// Hosts specifies the hosts of the requests to which the token is added.
+	// If it is empty, the token is only added to the requests to the host of the TokenURL,
+	// so the token is not sent to the foreign hosts of the followed URLs.
+	Hosts []string
+
+	// Client specifies the HTTP client used to obtain the tokens,
+	// if it is nil http.DefaultClient is used.
+	Client *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// NewOAuth2 returns a new OAuth2 structure that obtains the tokens with the client credentials flow.
+func NewOAuth2(tokenURL, clientID, clientSecret string, scopes ...string) *OAuth2 {
+	return &OAuth2{
+		TokenURL:     tokenURL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Scopes:       scopes,
+	}
+}
+
+func (o *OAuth2) Authenticate(req *http.Request) error {
+	hosts := o.Hosts
+	if len(hosts) == 0 {
+		tokenURL, err := url.Parse(o.TokenURL)
+		if err != nil {
+			return err
+		}
+		hosts = []string{tokenURL.Host}
+	}
+
+	if !matchHost(hosts, req.URL) {
+		return nil
+	}
+
+	token, err := o.Token(req.Context())
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Token returns a valid access token, a new token is obtained if the current one has expired.
+func (o *OAuth2) Token(ctx context.Context) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if (o.token != "") && (o.expiry.IsZero() || time.Now().Add(DefaultTokenExpiryDelta).Before(o.expiry)) {
+		return o.token, nil
+	}
+
+	form := url.Values{}
+	if o.RefreshToken != "" {
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", o.RefreshToken)
+	} else {
+		form.Set("grant_type", "client_credentials")
+	}
+
+	if len(o.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(o.ClientID), url.QueryEscape(o.ClientSecret))
+
+	client := o.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+
+	var token struct {
+		AccessToken  string `json:"access_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+		RefreshToken string `json:"refresh_token"`
+		Error        string `json:"error"`
+	}
+
+	if err := json.Unmarshal(body, &token); (err != nil) || (resp.StatusCode != http.StatusOK) || (token.AccessToken == "") {
+		if token.Error != "" {
+			return "", fmt.Errorf("%w: %d %s", ErrOAuth2Token, resp.StatusCode, token.Error)
+		}
+		return "", fmt.Errorf("%w: %d", ErrOAuth2Token, resp.StatusCode)
+	}
+
+	o.token = token.AccessToken
+	o.expiry = time.Time{}
+	if token.ExpiresIn > 0 {
+		o.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+
+	if token.RefreshToken != "" {
+		o.RefreshToken = token.RefreshToken
+	}
+	return o.token, nil
+}
@@ -0,0 +1,41 @@
+package webextractor
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gonzxlez/colibri"
+)
+
+// Option configures the Colibri returned by New and its Client.
+type Option func(c *colibri.Colibri, client *Client)
+
+// WithJar sets the cookie jar of the Client, by default a new cookiejar.Jar is used.
+func WithJar(jar http.CookieJar) Option {
+	return func(_ *colibri.Colibri, client *Client) { client.Jar = jar }
+}
+
+// WithTransport sets the Transport of the Client.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(_ *colibri.Colibri, client *Client) { client.Transport = transport }
+}
+
+// WithTimeout sets the Timeout of the Client.
+func WithTimeout(timeout time.Duration) Option {
+	return func(_ *colibri.Colibri, client *Client) { client.Timeout = timeout }
+}
+
+// WithProxyPool sets the Proxies of the Client, see ProxyPool.
+func WithProxyPool(pool *ProxyPool) Option {
+	return func(_ *colibri.Colibri, client *Client) { client.Proxies = pool }
+}
+
+// WithoutRobots disables the robots.txt restrictions, the RobotsTxt of Colibri is nil.
+func WithoutRobots() Option {
+	return func(c *colibri.Colibri, _ *Client) { c.RobotsTxt = nil }
+}
+
+// WithoutDelay disables the delay between requests, the Delay of Colibri is nil.
+func WithoutDelay() Option {
+	return func(c *colibri.Colibri, _ *Client) { c.Delay = nil }
+}
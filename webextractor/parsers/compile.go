@@ -0,0 +1,67 @@
+package parsers
+
+import (
+	"regexp"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/xpath"
+	"github.com/gonzxlez/colibri"
+)
+
+// The XPath, CSS and regular expressions of the selectors are compiled when the rules are unmarshaled,
+// see colibri.RegisterCompiler.
+func init() {
+	colibri.RegisterCompiler(XPathExpr, func(expr string) (any, error) {
+		return xpath.Compile(expr)
+	})
+
+	colibri.RegisterCompiler(CSSelector, func(expr string) (any, error) {
+		return cascadia.Compile(expr)
+	})
+
+	colibri.RegisterCompiler(RegularExpr, func(expr string) (any, error) {
+		return regexp.Compile(expr)
+	})
+}
+
+// compiledXPath returns the expression of the selector compiled as XPath.
+func compiledXPath(selector *colibri.Selector) (*xpath.Expr, error) {
+	value, err := selector.Compiled(XPathExpr)
+	if err != nil {
+		return nil, err
+	}
+
+	expr, ok := value.(*xpath.Expr)
+	if !ok {
+		return nil, ErrExprType
+	}
+	return expr, nil
+}
+
+// compiledCSS returns the expression of the selector compiled as a CSS selector.
+func compiledCSS(selector *colibri.Selector) (cascadia.Selector, error) {
+	value, err := selector.Compiled(CSSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	sel, ok := value.(cascadia.Selector)
+	if !ok {
+		return nil, ErrExprType
+	}
+	return sel, nil
+}
+
+// compiledRegexp returns the expression of the selector compiled as a regular expression.
+func compiledRegexp(selector *colibri.Selector) (*regexp.Regexp, error) {
+	value, err := selector.Compiled(RegularExpr)
+	if err != nil {
+		return nil, err
+	}
+
+	re, ok := value.(*regexp.Regexp)
+	if !ok {
+		return nil, ErrExprType
+	}
+	return re, nil
+}
@@ -0,0 +1,123 @@
+package parsers
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/gonzxlez/colibri"
+
+	"github.com/antchfx/htmlquery"
+	nethtml "golang.org/x/net/html"
+)
+
+// Form returns the form of the node, if the node is not a form the closest ancestor form is used.
+// The values of the form are those that the browser would submit without user interaction:
+// the enabled inputs with a name, the checked checkboxes and radio buttons,
+// the selected options and the text of the textareas. Buttons and file inputs are not included.
+// See the colibri.FormNode interface.
+func (html *HTMLNode) Form() (*colibri.Form, error) {
+	form := html.node
+	for (form != nil) && !isElement(form, "form") {
+		form = form.Parent
+	}
+
+	if form == nil {
+		return nil, colibri.ErrNotForm
+	}
+
+	result := &colibri.Form{
+		Action: htmlquery.SelectAttr(form, "action"),
+		Method: strings.ToUpper(htmlquery.SelectAttr(form, "method")),
+		Values: url.Values{},
+	}
+
+	if result.Method == "" {
+		result.Method = "GET"
+	}
+
+	formValues(form, result.Values)
+	return result, nil
+}
+
+// formValues adds the values of the fields of the node to values.
+func formValues(node *nethtml.Node, values url.Values) {
+	for n := node.FirstChild; n != nil; n = n.NextSibling {
+		if n.Type != nethtml.ElementNode {
+			continue
+		}
+
+		name := htmlquery.SelectAttr(n, "name")
+		if (name == "") || hasAttr(n, "disabled") {
+			formValues(n, values)
+			continue
+		}
+
+		switch n.Data {
+		case "input":
+			typ := strings.ToLower(htmlquery.SelectAttr(n, "type"))
+			switch typ {
+			case "submit", "button", "image", "reset", "file":
+				continue
+			case "checkbox", "radio":
+				if !hasAttr(n, "checked") {
+					continue
+				}
+
+				value := "on"
+				if hasAttr(n, "value") {
+					value = htmlquery.SelectAttr(n, "value")
+				}
+				values.Add(name, value)
+				continue
+			}
+			values.Add(name, htmlquery.SelectAttr(n, "value"))
+
+		case "textarea":
+			values.Add(name, htmlquery.InnerText(n))
+
+		case "select":
+			selectValues(n, name, values)
+
+		default:
+			formValues(n, values)
+		}
+	}
+}
+
+// selectValues adds the selected options of the select to values,
+// if no option is selected and the select is not multiple, the first option is added.
+func selectValues(node *nethtml.Node, name string, values url.Values) {
+	options := htmlquery.Find(node, ".//option")
+
+	var selected bool
+	for _, option := range options {
+		if hasAttr(option, "selected") {
+			values.Add(name, optionValue(option))
+			selected = true
+		}
+	}
+
+	if !selected && !hasAttr(node, "multiple") && (len(options) > 0) {
+		values.Add(name, optionValue(options[0]))
+	}
+}
+
+func optionValue(option *nethtml.Node) string {
+	if hasAttr(option, "value") {
+		return htmlquery.SelectAttr(option, "value")
+	}
+	return strings.TrimSpace(htmlquery.InnerText(option))
+}
+
+func isElement(node *nethtml.Node, name string) bool {
+	return (node.Type == nethtml.ElementNode) && (node.Data == name)
+}
+
+func hasAttr(node *nethtml.Node, name string) bool {
+	for _, attr := range node.Attr {
+		if attr.Key == name {
+			return true
+		}
+	}
+	return false
+}
@@ -7,7 +7,8 @@ import (
 
 	"github.com/andybalholm/cascadia"
 	"github.com/antchfx/htmlquery"
-	"golang.org/x/net/html"
+	"github.com/antchfx/xpath"
+	nethtml "golang.org/x/net/html"
 	"golang.org/x/net/html/charset"
 )
 
@@ -15,7 +16,7 @@ import (
 const HTMLRegexp = `^text\/html`
 
 type HTMLNode struct {
-	node *html.Node
+	node *nethtml.Node
 }
 
 func ParseHTML(resp colibri.Response) (*HTMLNode, error) {
@@ -39,9 +40,19 @@ func (html *HTMLNode) Find(selector *colibri.Selector) (colibri.Node, error) {
 
 	switch {
 	case strings.EqualFold(selector.Type, XPathExpr):
-		return html.XPathFind(selector.Expr)
+		expr, err := compiledXPath(selector)
+		if err != nil {
+			return nil, err
+		}
+		return html.xpathFind(expr), nil
 	case strings.EqualFold(selector.Type, CSSelector):
-		return html.CSSFind(selector.Expr)
+		sel, err := compiledCSS(selector)
+		if err != nil {
+			return nil, err
+		}
+		return html.cssFind(sel), nil
+	case strings.EqualFold(selector.Type, NavigateExpr):
+		return navigateFind(html, selector.Expr)
 	}
 	return nil, ErrExprType
 }
@@ -53,9 +64,19 @@ func (html *HTMLNode) FindAll(selector *colibri.Selector) ([]colibri.Node, error
 
 	switch {
 	case strings.EqualFold(selector.Type, XPathExpr):
-		return html.XPathFindAll(selector.Expr)
+		expr, err := compiledXPath(selector)
+		if err != nil {
+			return nil, err
+		}
+		return html.xpathFindAll(expr), nil
 	case strings.EqualFold(selector.Type, CSSelector):
-		return html.CSSFindAll(selector.Expr)
+		sel, err := compiledCSS(selector)
+		if err != nil {
+			return nil, err
+		}
+		return html.cssFindAll(sel), nil
+	case strings.EqualFold(selector.Type, NavigateExpr):
+		return Navigate(html, selector.Expr)
 	}
 	return nil, ErrExprType
 }
@@ -64,6 +85,68 @@ func (html *HTMLNode) Value() any {
 	return htmlquery.InnerText(html.node)
 }
 
+// VisibleText returns the text of the node without the content of the script, style,
+// noscript and template elements, the text of each element is separated by a space.
+// See the colibri.VisibleTextNode interface.
+func (html *HTMLNode) VisibleText() string {
+	var (
+		sb   strings.Builder
+		walk func(n *nethtml.Node)
+	)
+	walk = func(n *nethtml.Node) {
+		switch n.Type {
+		case nethtml.TextNode:
+			sb.WriteString(n.Data)
+			return
+		case nethtml.CommentNode:
+			return
+		case nethtml.ElementNode:
+			switch n.Data {
+			case "script", "style", "noscript", "template":
+				return
+			}
+			sb.WriteByte(' ')
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(html.node)
+	return strings.TrimSpace(sb.String())
+}
+
+// Parent returns the parent element.
+func (html *HTMLNode) Parent() colibri.Node {
+	for n := html.node.Parent; n != nil; n = n.Parent {
+		if (n.Type == nethtml.ElementNode) || (n.Type == nethtml.DocumentNode) {
+			return &HTMLNode{n}
+		}
+	}
+	return nil
+}
+
+// NextSibling returns the next sibling element.
+func (html *HTMLNode) NextSibling() colibri.Node {
+	for n := html.node.NextSibling; n != nil; n = n.NextSibling {
+		if n.Type == nethtml.ElementNode {
+			return &HTMLNode{n}
+		}
+	}
+	return nil
+}
+
+// Children returns the child elements.
+func (html *HTMLNode) Children() []colibri.Node {
+	var children []colibri.Node
+	for n := html.node.FirstChild; n != nil; n = n.NextSibling {
+		if n.Type == nethtml.ElementNode {
+			children = append(children, &HTMLNode{n})
+		}
+	}
+	return children
+}
+
 func (html *HTMLNode) XPathFind(expr string) (colibri.Node, error) {
 	htmlNode, err := htmlquery.Query(html.node, expr)
 	if err != nil {
@@ -93,12 +176,7 @@ func (html *HTMLNode) CSSFind(expr string) (colibri.Node, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	htmlNode := cascadia.Query(html.node, sel)
-	if htmlNode == nil {
-		return nil, nil
-	}
-	return &HTMLNode{htmlNode}, nil
+	return html.cssFind(sel), nil
 }
 
 func (html *HTMLNode) CSSFindAll(expr string) ([]colibri.Node, error) {
@@ -106,10 +184,37 @@ func (html *HTMLNode) CSSFindAll(expr string) ([]colibri.Node, error) {
 	if err != nil {
 		return nil, err
 	}
+	return html.cssFindAll(sel), nil
+}
 
+func (html *HTMLNode) xpathFind(expr *xpath.Expr) colibri.Node {
+	htmlNode := htmlquery.QuerySelector(html.node, expr)
+	if htmlNode == nil {
+		return nil
+	}
+	return &HTMLNode{htmlNode}
+}
+
+func (html *HTMLNode) xpathFindAll(expr *xpath.Expr) []colibri.Node {
+	var elements []colibri.Node
+	for _, node := range htmlquery.QuerySelectorAll(html.node, expr) {
+		elements = append(elements, &HTMLNode{node})
+	}
+	return elements
+}
+
+func (html *HTMLNode) cssFind(sel cascadia.Selector) colibri.Node {
+	htmlNode := cascadia.Query(html.node, sel)
+	if htmlNode == nil {
+		return nil
+	}
+	return &HTMLNode{htmlNode}
+}
+
+func (html *HTMLNode) cssFindAll(sel cascadia.Selector) []colibri.Node {
 	var elements []colibri.Node
 	for _, node := range cascadia.QueryAll(html.node, sel) {
 		elements = append(elements, &HTMLNode{node})
 	}
-	return elements, nil
+	return elements
 }
@@ -0,0 +1,89 @@
+package parsers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gonzxlez/colibri"
+
+	"github.com/antchfx/htmlquery"
+	nethtml "golang.org/x/net/html"
+)
+
+// Images returns the img elements of the node and of its descendants. The srcset of the img
+// elements of a picture contains the candidates of the source elements of the picture first,
+// in the order in which the browser considers them.
+// See the colibri.ImageNode interface.
+func (html *HTMLNode) Images() []*colibri.Image {
+	var images []*colibri.Image
+	add := func(n *nethtml.Node) {
+		if !isElement(n, "img") {
+			return
+		}
+
+		image := &colibri.Image{
+			Src:    strings.TrimSpace(htmlquery.SelectAttr(n, "src")),
+			Alt:    htmlquery.SelectAttr(n, "alt"),
+			Width:  dimension(htmlquery.SelectAttr(n, "width")),
+			Height: dimension(htmlquery.SelectAttr(n, "height")),
+		}
+
+		if (n.Parent != nil) && isElement(n.Parent, "picture") {
+			for s := n.Parent.FirstChild; s != nil; s = s.NextSibling {
+				if !isElement(s, "source") {
+					continue
+				}
+
+				for _, candidate := range parseSrcset(htmlquery.SelectAttr(s, "srcset")) {
+					candidate.Media = htmlquery.SelectAttr(s, "media")
+					candidate.Type = htmlquery.SelectAttr(s, "type")
+					image.Srcset = append(image.Srcset, candidate)
+				}
+			}
+		}
+
+		image.Srcset = append(image.Srcset, parseSrcset(htmlquery.SelectAttr(n, "srcset"))...)
+		images = append(images, image)
+	}
+
+	add(html.node)
+	walkElements(html.node, add)
+	return images
+}
+
+// parseSrcset returns the candidates of the srcset attribute, e.g. "small.jpg 480w, large.jpg 1080w".
+// The URLs cannot contain whitespace and the URLs that end with a comma do not have descriptor.
+func parseSrcset(srcset string) []*colibri.ImageCandidate {
+	var candidates []*colibri.ImageCandidate
+	for rest := strings.TrimSpace(srcset); rest != ""; rest = strings.TrimLeft(rest, " \t\n\r\f,") {
+		end := strings.IndexAny(rest, " \t\n\r\f")
+		if end < 0 {
+			end = len(rest)
+		}
+
+		candidate := &colibri.ImageCandidate{URL: rest[:end]}
+		rest = rest[end:]
+
+		if u, ok := strings.CutSuffix(candidate.URL, ","); ok {
+			candidate.URL = strings.TrimRight(u, ",")
+		} else {
+			descriptor, next, _ := strings.Cut(rest, ",")
+			candidate.Descriptor = strings.TrimSpace(descriptor)
+			rest = next
+		}
+
+		if candidate.URL != "" {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
+
+// dimension returns the value of a width or height attribute, 0 if it is not a valid number.
+func dimension(value string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "px")))
+	if (err != nil) || (n < 0) {
+		return 0
+	}
+	return n
+}
@@ -1,6 +1,11 @@
 package parsers
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
 	"strings"
 
 	"github.com/gonzxlez/colibri"
@@ -13,6 +18,9 @@ const JSONRegexp = `^application\/(json|x-json|([a-z]+\+json))`
 
 type JSONode struct {
 	node *jsonquery.Node
+
+	// numbers stores the values of the nodes decoded with ParseJSONNumber.
+	numbers map[*jsonquery.Node]any
 }
 
 func ParseJSON(resp colibri.Response) (*JSONode, error) {
@@ -20,41 +28,168 @@ func ParseJSON(resp colibri.Response) (*JSONode, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &JSONode{root}, nil
+	return &JSONode{node: root}, nil
+}
+
+// ParseJSONNumber parses the JSON content like ParseJSON but preserves the precision of numbers:
+// integers that fit in an int64 are returned as int64 and the rest of the numbers as json.Number,
+// instead of float64.
+//
+//	parsers.Set(p, parsers.JSONRegexp, parsers.ParseJSONNumber)
+func ParseJSONNumber(resp colibri.Response) (*JSONode, error) {
+	b, err := io.ReadAll(resp.Body())
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := jsonquery.Parse(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	numbers := make(map[*jsonquery.Node]any)
+	jsonNumbers(toInt64(v), root, numbers)
+	return &JSONode{node: root, numbers: numbers}, nil
 }
 
 func (json *JSONode) Find(selector *colibri.Selector) (colibri.Node, error) {
+	if strings.EqualFold(selector.Type, NavigateExpr) {
+		return navigateFind(json, selector.Expr)
+	}
+
 	if (selector.Type != "") && !strings.EqualFold(selector.Type, XPathExpr) {
 		return nil, ErrExprType
 	}
 
-	jsonNode, err := jsonquery.Query(json.node, selector.Expr)
+	expr, err := compiledXPath(selector)
 	if err != nil {
 		return nil, err
-	} else if jsonNode == nil {
+	}
+
+	jsonNode := jsonquery.QuerySelector(json.node, expr)
+	if jsonNode == nil {
 		return nil, nil
 	}
 
-	return &JSONode{jsonNode}, nil
+	return &JSONode{jsonNode, json.numbers}, nil
 }
 
 func (json *JSONode) FindAll(selector *colibri.Selector) ([]colibri.Node, error) {
+	if strings.EqualFold(selector.Type, NavigateExpr) {
+		return Navigate(json, selector.Expr)
+	}
+
 	if (selector.Type != "") && !strings.EqualFold(selector.Type, XPathExpr) {
 		return nil, ErrExprType
 	}
 
-	jsonNodes, err := jsonquery.QueryAll(json.node, selector.Expr)
+	expr, err := compiledXPath(selector)
 	if err != nil {
 		return nil, err
 	}
 
 	var nodes []colibri.Node
-	for _, node := range jsonNodes {
-		nodes = append(nodes, &JSONode{node})
+	for _, node := range jsonquery.QuerySelectorAll(json.node, expr) {
+		nodes = append(nodes, &JSONode{node, json.numbers})
 	}
 	return nodes, nil
 }
 
 func (json *JSONode) Value() any {
+	if v, ok := json.numbers[json.node]; ok {
+		return v
+	}
 	return json.node.Value()
 }
+
+func (json *JSONode) Parent() colibri.Node {
+	if json.node.Parent == nil {
+		return nil
+	}
+	return &JSONode{json.node.Parent, json.numbers}
+}
+
+func (json *JSONode) NextSibling() colibri.Node {
+	if json.node.NextSibling == nil {
+		return nil
+	}
+	return &JSONode{json.node.NextSibling, json.numbers}
+}
+
+func (json *JSONode) Children() []colibri.Node {
+	var children []colibri.Node
+	for n := json.node.FirstChild; n != nil; n = n.NextSibling {
+		children = append(children, &JSONode{n, json.numbers})
+	}
+	return children
+}
+
+// jsonNumbers stores the value of each node of the jsonquery tree.
+// The tree is traversed in the same order in which jsonquery builds it.
+func jsonNumbers(v any, node *jsonquery.Node, numbers map[*jsonquery.Node]any) {
+	child := node.FirstChild
+
+	switch v := v.(type) {
+	case []any:
+		for _, value := range v {
+			if child == nil {
+				return
+			}
+
+			numbers[child] = value
+			jsonNumbers(value, child, numbers)
+			child = child.NextSibling
+		}
+
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if child == nil {
+				return
+			}
+
+			numbers[child] = v[key]
+			jsonNumbers(v[key], child, numbers)
+			child = child.NextSibling
+		}
+
+	default:
+		// The text node stores the value as a string.
+		if child != nil {
+			numbers[child] = fmt.Sprintf("%v", v)
+		}
+	}
+}
+
+// toInt64 replaces the json.Number values that are integers with int64 values.
+func toInt64(v any) any {
+	switch v := v.(type) {
+	case []any:
+		for i := range v {
+			v[i] = toInt64(v[i])
+		}
+
+	case map[string]any:
+		for key := range v {
+			v[key] = toInt64(v[key])
+		}
+
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+	}
+	return v
+}
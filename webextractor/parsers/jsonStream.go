@@ -0,0 +1,83 @@
+package parsers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/gonzxlez/colibri"
+
+	"github.com/antchfx/jsonquery"
+)
+
+// ParseJSONStream parses the JSON content incrementally, see StreamNode.
+// If the document is an array, each item is decoded and parsed separately,
+// so the selectors are evaluated against the items of the array.
+// Otherwise, the document is parsed as a single item.
+//
+//	parsers.Set(p, parsers.JSONRegexp, parsers.ParseJSONStream)
+func ParseJSONStream(resp colibri.Response) (*StreamNode, error) {
+	r := bufio.NewReader(resp.Body())
+	dec := json.NewDecoder(r)
+
+	first, err := peekNonSpace(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if first != '[' {
+		var done bool
+		return NewStreamNode(func() (colibri.Node, error) {
+			if done {
+				return nil, io.EOF
+			}
+			done = true
+
+			root, err := jsonquery.Parse(r)
+			if err != nil {
+				return nil, err
+			}
+			return &JSONode{node: root}, nil
+		}), nil
+	}
+
+	// Array delimiter.
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	return NewStreamNode(func() (colibri.Node, error) {
+		if !dec.More() {
+			return nil, io.EOF
+		}
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+
+		root, err := jsonquery.Parse(bytes.NewReader(raw))
+		if err != nil {
+			return nil, err
+		}
+		return &JSONode{node: root}, nil
+	}), nil
+}
+
+// peekNonSpace returns the first byte that is not a space without consuming it.
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			r.Discard(1)
+		default:
+			return b[0], nil
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package parsers
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/gonzxlez/colibri"
+
+	"github.com/antchfx/htmlquery"
+	nethtml "golang.org/x/net/html"
+)
+
+// Links returns the a elements with href of the node and of its descendants.
+// The href are resolved against the base element of the document, if it exists,
+// and the text of the links is returned with the whitespace collapsed.
+// See the colibri.LinkNode interface.
+func (html *HTMLNode) Links() []*colibri.Link {
+	base := baseURL(root(html.node))
+
+	var links []*colibri.Link
+	add := func(n *nethtml.Node) {
+		if !isElement(n, "a") || !hasAttr(n, "href") {
+			return
+		}
+
+		href := strings.TrimSpace(htmlquery.SelectAttr(n, "href"))
+		if u, err := url.Parse(href); (err == nil) && (base != nil) {
+			href = base.ResolveReference(u).String()
+		}
+
+		links = append(links, &colibri.Link{
+			URL:  href,
+			Text: strings.Join(strings.Fields(htmlquery.InnerText(n)), " "),
+			Rel:  htmlquery.SelectAttr(n, "rel"),
+		})
+	}
+
+	add(html.node)
+	walkElements(html.node, add)
+	return links
+}
+
+// baseURL returns the href of the first base element of the document, nil if it does not exist.
+func baseURL(doc *nethtml.Node) *url.URL {
+	var base *url.URL
+	walkElements(doc, func(n *nethtml.Node) {
+		if (base != nil) || !isElement(n, "base") || !hasAttr(n, "href") {
+			return
+		}
+		base, _ = url.Parse(strings.TrimSpace(htmlquery.SelectAttr(n, "href")))
+	})
+	return base
+}
@@ -0,0 +1,69 @@
+package parsers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gonzxlez/colibri"
+)
+
+// Navigation steps of the NavigateExpr expressions.
+const (
+	ParentStep = "parent"
+
+	NextStep = "next"
+
+	ChildrenStep = "children"
+)
+
+// ErrNavigateStep is returned when a navigation expression contains an unknown step.
+var ErrNavigateStep = errors.New("invalid navigation step")
+
+// Navigate returns the nodes reached by following the steps of the expression from the node.
+// The steps are separated by "/", for example "parent/next" selects the next sibling
+// of the parent node and "children" selects all the child nodes.
+func Navigate(node colibri.NavigableNode, expr string) ([]colibri.Node, error) {
+	nodes := []colibri.Node{node}
+
+	for _, step := range strings.Split(strings.Trim(expr, "/"), "/") {
+		var next []colibri.Node
+		for _, n := range nodes {
+			nav, ok := n.(colibri.NavigableNode)
+			if !ok {
+				continue
+			}
+
+			switch strings.ToLower(strings.TrimSpace(step)) {
+			case "", ".":
+				next = append(next, nav)
+
+			case ParentStep:
+				if parent := nav.Parent(); parent != nil {
+					next = append(next, parent)
+				}
+
+			case NextStep:
+				if sibling := nav.NextSibling(); sibling != nil {
+					next = append(next, sibling)
+				}
+
+			case ChildrenStep:
+				next = append(next, nav.Children()...)
+
+			default:
+				return nil, ErrNavigateStep
+			}
+		}
+		nodes = next
+	}
+	return nodes, nil
+}
+
+// navigateFind returns the first node reached by the navigation expression.
+func navigateFind(node colibri.NavigableNode, expr string) (colibri.Node, error) {
+	nodes, err := Navigate(node, expr)
+	if (err != nil) || (len(nodes) == 0) {
+		return nil, err
+	}
+	return nodes[0], nil
+}
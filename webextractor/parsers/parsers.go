@@ -4,6 +4,7 @@ package parsers
 import (
 	"errors"
 	"regexp"
+	"sort"
 	"sync"
 
 	"github.com/gonzxlez/colibri"
@@ -15,6 +16,9 @@ const (
 	CSSelector = "css"
 
 	RegularExpr = "regular"
+
+	// NavigateExpr navigates the document tree relative to the node, see Navigate.
+	NavigateExpr = "navigate"
 )
 
 var (
@@ -28,13 +32,32 @@ var (
 // Parsers is used to parse the content of the answers.
 // When a regular expression matches the content type of the response, the content
 // of the response is parsed with the parser corresponding to the regular expression.
+// If several regular expressions match, the parser with the highest priority is used,
+// parsers with the same priority are tried in the order they were added.
 type Parsers struct {
 	rw    sync.RWMutex
 	funcs map[string]*parser
+	order []*parser // sorted by priority and registration order
+	seq   uint64
 }
 
 type parser struct {
-	RE   *regexp.Regexp
+	Expr     string
+	RE       *regexp.Regexp
+	Priority int
+	Func     func(colibri.Response) (colibri.Node, error)
+	seq      uint64
+}
+
+// Entry represents a parser added to Parsers.
+type Entry struct {
+	// Expr is the regular expression that matches the content type.
+	Expr string
+
+	// Priority is the priority of the parser.
+	Priority int
+
+	// Func is the parser function.
 	Func func(colibri.Response) (colibri.Node, error)
 }
 
@@ -55,7 +78,15 @@ func New() (*Parsers, error) {
 }
 
 // Set adds a parser with its regular expression corresponding to the parsers.
+// The parser is added with priority 0, see SetPriority.
 func Set[T colibri.Node](parsers *Parsers, expr string, parserFunc func(colibri.Response) (T, error)) error {
+	return SetPriority(parsers, expr, 0, parserFunc)
+}
+
+// SetPriority adds a parser with its regular expression and priority corresponding to the parsers.
+// If a parser with the same regular expression already exists, it is replaced
+// keeping its registration order.
+func SetPriority[T colibri.Node](parsers *Parsers, expr string, priority int, parserFunc func(colibri.Response) (T, error)) error {
 	if (parsers == nil) || (expr == "") || (parserFunc == nil) {
 		return nil
 	}
@@ -65,59 +96,149 @@ func Set[T colibri.Node](parsers *Parsers, expr string, parserFunc func(colibri.
 		return err
 	}
 
-	parsers.rw.Lock()
-	parsers.funcs[expr] = &parser{
-		RE: regular,
+	p := &parser{
+		Expr:     expr,
+		RE:       regular,
+		Priority: priority,
 		Func: func(resp colibri.Response) (colibri.Node, error) {
 			return parserFunc(resp)
 		},
 	}
-	parsers.rw.Unlock()
+
+	parsers.rw.Lock()
+	defer parsers.rw.Unlock()
+
+	if parsers.funcs == nil {
+		parsers.funcs = make(map[string]*parser)
+	}
+
+	if old, ok := parsers.funcs[expr]; ok {
+		p.seq = old.seq
+	} else {
+		parsers.seq++
+		p.seq = parsers.seq
+	}
+
+	parsers.funcs[expr] = p
+	parsers.sort()
 	return nil
 }
 
-// Match returns true if the content-type is supported.
-func (parsers *Parsers) Match(contentType string) bool {
+// Get returns the parser added with the regular expression.
+func (parsers *Parsers) Get(expr string) (Entry, bool) {
 	parsers.rw.RLock()
 	defer parsers.rw.RUnlock()
 
-	for _, p := range parsers.funcs {
-		if p.RE.MatchString(contentType) {
-			return true
-		}
+	p, ok := parsers.funcs[expr]
+	if !ok {
+		return Entry{}, false
+	}
+	return p.entry(), true
+}
+
+// Delete removes the parser added with the regular expression.
+// Returns false if the parser does not exist.
+func (parsers *Parsers) Delete(expr string) bool {
+	parsers.rw.Lock()
+	defer parsers.rw.Unlock()
+
+	if _, ok := parsers.funcs[expr]; !ok {
+		return false
 	}
-	return false
+
+	delete(parsers.funcs, expr)
+	parsers.sort()
+	return true
+}
+
+// List returns the parsers in the order in which they are matched.
+func (parsers *Parsers) List() []Entry {
+	parsers.rw.RLock()
+	defer parsers.rw.RUnlock()
+
+	entries := make([]Entry, 0, len(parsers.order))
+	for _, p := range parsers.order {
+		entries = append(entries, p.entry())
+	}
+	return entries
+}
+
+// Match returns true if the content-type is supported.
+func (parsers *Parsers) Match(contentType string) bool {
+	return parsers.find(contentType) != nil
 }
 
 // Parse parses the response based on the rules.
+// If the response does not have a Content-Type or it is application/octet-stream,
+// the content type is detected from the first bytes of the body, see DetectContentType.
 func (parsers *Parsers) Parse(rules *colibri.Rules, resp colibri.Response) (colibri.Node, error) {
 	if (rules == nil) || (resp == nil) {
 		return nil, nil
 	}
 
-	var (
-		contentType = resp.Header().Get("Content-Type")
-		parserFunc  func(colibri.Response) (colibri.Node, error)
-	)
+	contentType := resp.Header().Get("Content-Type")
+	parserFunc := parsers.find(contentType)
 
-	parsers.rw.Lock()
-	for _, p := range parsers.funcs {
-		if p.RE.MatchString(contentType) {
-			parserFunc = p.Func
-			break
+	if (parserFunc == nil) && mustSniff(contentType) {
+		var err error
+		if resp, err = sniff(resp); err != nil {
+			return nil, err
 		}
+		parserFunc = parsers.find(resp.Header().Get("Content-Type"))
 	}
-	parsers.rw.Unlock()
 
 	if parserFunc == nil {
 		return nil, ErrNotMatch
 	}
 
-	return parserFunc(resp)
+	node, err := parserFunc(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if p, ok := node.(Preparer); ok {
+		if err := p.Prepare(rules); err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+// find returns the parser function that matches the content type.
+func (parsers *Parsers) find(contentType string) func(colibri.Response) (colibri.Node, error) {
+	parsers.rw.RLock()
+	defer parsers.rw.RUnlock()
+
+	for _, p := range parsers.order {
+		if p.RE.MatchString(contentType) {
+			return p.Func
+		}
+	}
+	return nil
+}
+
+// sort sorts the parsers by priority and registration order.
+func (parsers *Parsers) sort() {
+	parsers.order = make([]*parser, 0, len(parsers.funcs))
+	for _, p := range parsers.funcs {
+		parsers.order = append(parsers.order, p)
+	}
+
+	sort.Slice(parsers.order, func(i, j int) bool {
+		if parsers.order[i].Priority != parsers.order[j].Priority {
+			return parsers.order[i].Priority > parsers.order[j].Priority
+		}
+		return parsers.order[i].seq < parsers.order[j].seq
+	})
+}
+
+func (p *parser) entry() Entry {
+	return Entry{Expr: p.Expr, Priority: p.Priority, Func: p.Func}
 }
 
 func (parsers *Parsers) Clear() {
 	parsers.rw.Lock()
 	clear(parsers.funcs)
+	parsers.order = nil
 	parsers.rw.Unlock()
 }
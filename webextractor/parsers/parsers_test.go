@@ -1,8 +1,10 @@
 package parsers
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
@@ -203,13 +205,13 @@ func TestColibriExtrac(t *testing.T) {
 			},
 			nil, /* Data */
 			map[string]any{
-				"Title": "expected identifier, found ] instead",
-				"First": "expression must evaluate to a node-set",
-				"Img":   ErrExprType.Error(),
+				"Title": exprErr("expected identifier, found ] instead"),
+				"First": exprErr("expression must evaluate to a node-set"),
+				"Img":   exprErr(ErrExprType.Error()),
 
-				"a":    "expression must evaluate to a node-set",
-				"Span": "expected identifier, found ] instead",
-				"Divs": ErrExprType.Error(),
+				"a":    exprErr("expression must evaluate to a node-set"),
+				"Span": exprErr("expected identifier, found ] instead"),
+				"Divs": exprErr(ErrExprType.Error()),
 			},
 		},
 		{
@@ -226,10 +228,10 @@ func TestColibriExtrac(t *testing.T) {
 			},
 			nil, /* Output */
 			map[string]any{
-				"Female":  "expression must evaluate to a node-set",
-				"City":    ErrExprType.Error(),
-				"Hobbies": "//hobbies[/* has an invalid token",
-				"Jobs":    ErrExprType.Error(),
+				"Female":  exprErr("expression must evaluate to a node-set"),
+				"City":    exprErr(ErrExprType.Error()),
+				"Hobbies": exprErr("//hobbies[/* has an invalid token"),
+				"Jobs":    exprErr(ErrExprType.Error()),
 			},
 		},
 		{
@@ -246,10 +248,10 @@ func TestColibriExtrac(t *testing.T) {
 			},
 			nil, /* Output */
 			map[string]any{
-				"Go":     "error parsing regexp: unexpected ): `)\\bGo\\]`",
-				"Source": ErrExprType.Error(),
-				"URLs":   "error parsing regexp: invalid escape sequence: `\\K`",
-				"Emails": ErrExprType.Error(),
+				"Go":     exprErr("error parsing regexp: unexpected ): `)\\bGo\\]`"),
+				"Source": exprErr(ErrExprType.Error()),
+				"URLs":   exprErr("error parsing regexp: invalid escape sequence: `\\K`"),
+				"Emails": exprErr(ErrExprType.Error()),
 			},
 		},
 		{
@@ -266,11 +268,11 @@ func TestColibriExtrac(t *testing.T) {
 			},
 			nil, /* Output */
 			map[string]any{
-				"title": "expression must evaluate to a node-set",
-				"link":  ErrExprType.Error(),
+				"title": exprErr("expression must evaluate to a node-set"),
+				"link":  exprErr(ErrExprType.Error()),
 
-				"items": "expression must evaluate to a node-set",
-				"a":     ErrExprType.Error(),
+				"items": exprErr("expression must evaluate to a node-set"),
+				"a":     exprErr(ErrExprType.Error()),
 			},
 		},
 	}
@@ -333,6 +335,76 @@ func TestParsers(t *testing.T) {
 			t.Fatal(err)
 		}
 	})
+
+	t.Run("Sniff", func(t *testing.T) {
+		tests := []struct {
+			ContentType string
+			Body        string
+			Expr        string
+			Want        any
+		}{
+			{"", htmlBody, "//title", "My test page"},
+			{"application/octet-stream", jsonBody, "//name", "Go Gopher"},
+			{"", xmlBody, "//channel/title", "Test RSS"},
+			{"", textBody, `Go\s+is\s+the\s+work`, "Go is the work"},
+		}
+
+		for _, tt := range tests {
+			resp := &testResp{
+				header: http.Header{},
+				body:   io.NopCloser(strings.NewReader(tt.Body)),
+			}
+			resp.header.Set("Content-Type", tt.ContentType)
+
+			node, err := parsers.Parse(&colibri.Rules{}, resp)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			exprType := XPathExpr
+			if _, ok := node.(*TextNode); ok {
+				exprType = RegularExpr
+			}
+
+			child, err := node.Find(&colibri.Selector{Expr: tt.Expr, Type: exprType})
+			if err != nil {
+				t.Fatal(err)
+			} else if got := child.Value(); got != tt.Want {
+				t.Fatalf("got %v, want %v", got, tt.Want)
+			}
+		}
+	})
+
+	t.Run("SniffErrNotMatch", func(t *testing.T) {
+		resp := &testResp{
+			header: http.Header{},
+			body:   io.NopCloser(bytes.NewReader([]byte{0x1f, 0x8b, 0x08})),
+		}
+
+		_, err := parsers.Parse(&colibri.Rules{}, resp)
+		if !errors.Is(err, ErrNotMatch) {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestDetectContentType(t *testing.T) {
+	tests := []struct {
+		Data string
+		Want string
+	}{
+		{htmlBody, "text/html; charset=utf-8"},
+		{jsonBody, "application/json"},
+		{"\xEF\xBB\xBF [1, 2]", "application/json"},
+		{xmlBody, "application/xml"},
+		{textBody, "text/plain; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		if got := DetectContentType([]byte(tt.Data)); got != tt.Want {
+			t.Fatalf("got %v, want %v", got, tt.Want)
+		}
+	}
 }
 
 func TestSet(t *testing.T) {
@@ -360,6 +432,570 @@ func TestSet(t *testing.T) {
 	})
 }
 
+func TestSetPriority(t *testing.T) {
+	parsers, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// application/xml matches both XMLRegexp and the new expression.
+	if err := SetPriority(parsers, `^application\/xml`, 1, ParseText); err != nil {
+		t.Fatal(err)
+	}
+
+	node, err := parsers.Parse(&colibri.Rules{}, &testResp{
+		header: http.Header{"Content-Type": {"application/xml"}},
+		body:   io.NopCloser(strings.NewReader(xmlBody)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if _, ok := node.(*TextNode); !ok {
+		t.Fatalf("got %T, want %T", node, &TextNode{})
+	}
+
+	var (
+		want = []string{`^application\/xml`, HTMLRegexp, JSONRegexp, TextRegexp, XMLRegexp}
+		got  []string
+	)
+	for _, entry := range parsers.List() {
+		got = append(got, entry.Expr)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// Replacing a parser keeps its registration order.
+	if err := Set(parsers, HTMLRegexp, ParseHTML); err != nil {
+		t.Fatal(err)
+	} else if entries := parsers.List(); entries[1].Expr != HTMLRegexp {
+		t.Fatalf("got %v, want %v", entries[1].Expr, HTMLRegexp)
+	}
+}
+
+func TestGetDelete(t *testing.T) {
+	parsers, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry, ok := parsers.Get(JSONRegexp)
+	if !ok {
+		t.Fatal("parser not found")
+	} else if (entry.Expr != JSONRegexp) || (entry.Priority != 0) || (entry.Func == nil) {
+		t.Fatalf("got %v, want %v", entry.Expr, JSONRegexp)
+	}
+
+	if !parsers.Delete(JSONRegexp) {
+		t.Fatal("parser not deleted")
+	}
+
+	if parsers.Delete(JSONRegexp) {
+		t.Fatal("deleted twice")
+	}
+
+	if _, ok := parsers.Get(JSONRegexp); ok {
+		t.Fatal("parser found")
+	}
+
+	if parsers.Match("application/json") {
+		t.Fatal("must not match")
+	}
+
+	if len(parsers.List()) != 3 {
+		t.Fatalf("got %v, want %v", len(parsers.List()), 3)
+	}
+}
+
+func TestNavigate(t *testing.T) {
+	parsers, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ContentType string
+		Body        string
+		Anchor      string
+		Expr        string
+		Want        []any
+	}{
+		{"text/html", htmlBody, "//title", "parent/next/children", []any{"Link 1", "Link 2", "Link 3"}},
+		{"text/html", htmlBody, "//a", "next", []any{"Link 2"}},
+		{"application/xml", xmlBody, "//channel/title", "next", []any{"https://www.test.rss"}},
+		{"application/xml", xmlBody, "//item", "children", []any{"Item 2", "https://www.test.rss/item2"}},
+		{"application/json", jsonBody, "//contact", "next/children", []any{"coding", "backend"}},
+		{"application/json", jsonBody, "//web", "parent/parent/children/.", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Expr, func(t *testing.T) {
+			node, err := parsers.Parse(&colibri.Rules{}, &testResp{
+				header: http.Header{"Content-Type": {tt.ContentType}},
+				body:   io.NopCloser(strings.NewReader(tt.Body)),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			anchor, err := node.Find(&colibri.Selector{Expr: tt.Anchor})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			nodes, err := anchor.FindAll(&colibri.Selector{Expr: tt.Expr, Type: NavigateExpr})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.Want == nil {
+				if len(nodes) != 4 {
+					t.Fatalf("got %v, want %v", len(nodes), 4)
+				}
+				return
+			}
+
+			var got []any
+			for _, n := range nodes {
+				got = append(got, strings.TrimSpace(fmt.Sprint(n.Value())))
+			}
+
+			if !reflect.DeepEqual(got, tt.Want) {
+				t.Fatalf("got %v, want %v", got, tt.Want)
+			}
+		})
+	}
+
+	t.Run("ErrNavigateStep", func(t *testing.T) {
+		node, err := ParseHTML(&testResp{
+			header: http.Header{},
+			body:   io.NopCloser(strings.NewReader(htmlBody)),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := node.Find(&colibri.Selector{Expr: "up", Type: NavigateExpr}); !errors.Is(err, ErrNavigateStep) {
+			t.Fatalf("got %v, want %v", err, ErrNavigateStep)
+		}
+	})
+}
+
+func TestForm(t *testing.T) {
+	const body = `<html><body>
+<form action="/search?x=1" method="post">
+	<input type="hidden" name="csrf" value="token">
+	<input type="text" name="q" value="colibri">
+	<input type="text" name="disabled" value="x" disabled>
+	<input type="checkbox" name="exact" checked>
+	<input type="checkbox" name="unchecked" value="1">
+	<input type="radio" name="lang" value="en">
+	<input type="radio" name="lang" value="es" checked>
+	<select name="sort"><option value="date">Date</option><option selected>Relevance</option></select>
+	<select name="page"><option>1</option><option>2</option></select>
+	<fieldset><textarea name="notes">hello</textarea></fieldset>
+	<input type="submit" name="go" value="Search">
+</form>
+<p>outside</p>
+</body></html>`
+
+	node, err := ParseHTML(&testResp{
+		header: http.Header{},
+		body:   io.NopCloser(strings.NewReader(body)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input, err := node.Find(&colibri.Selector{Expr: "//input[@name='q']"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	form, err := input.(colibri.FormNode).Form()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &colibri.Form{
+		Action: "/search?x=1",
+		Method: "POST",
+		Values: url.Values{
+			"csrf":  {"token"},
+			"q":     {"colibri"},
+			"exact": {"on"},
+			"lang":  {"es"},
+			"sort":  {"Relevance"},
+			"page":  {"1"},
+			"notes": {"hello"},
+		},
+	}
+
+	if !reflect.DeepEqual(form, want) {
+		t.Fatalf("got %v, want %v", form, want)
+	}
+
+	p, err := node.Find(&colibri.Selector{Expr: "//p"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.(colibri.FormNode).Form(); !errors.Is(err, colibri.ErrNotForm) {
+		t.Fatalf("got %v, want %v", err, colibri.ErrNotForm)
+	}
+}
+
+func TestRobots(t *testing.T) {
+	const body = `<html><head>
+<meta name="Robots" content="noindex, nofollow">
+<meta name="description" content="colibri">
+<link rel="nofollow stylesheet" href="/style.css">
+</head><body>
+<a href="/a">a</a>
+<a href=" /b " rel="NoFollow">b</a>
+<a href="/c" rel="external">c</a>
+<map><area href="/d" rel="nofollow"></map>
+</body></html>`
+
+	node, err := ParseHTML(&testResp{
+		header: http.Header{},
+		body:   io.NopCloser(strings.NewReader(body)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The directives are those of the document, regardless of the node.
+	a, err := node.Find(&colibri.Selector{Expr: "//a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	robots := a.(colibri.RobotsNode)
+	if got, want := robots.Robots(), []string{"noindex, nofollow"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Robots: got %v, want %v", got, want)
+	}
+
+	if got, want := robots.NofollowLinks(), []string{"/style.css", "/b", "/d"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("NofollowLinks: got %v, want %v", got, want)
+	}
+}
+
+func TestLinks(t *testing.T) {
+	const body = `<html><head><base href="/docs/"></head><body>
+<nav><a href="intro">Getting
+	started</a> <a href="https://example.org" rel="nofollow external">Example</a></nav>
+<a name="top">no href</a>
+<a href=" /contact "><b>Contact</b> us</a>
+</body></html>`
+
+	node, err := ParseHTML(&testResp{
+		header: http.Header{},
+		body:   io.NopCloser(strings.NewReader(body)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*colibri.Link{
+		{URL: "/docs/intro", Text: "Getting started"},
+		{URL: "https://example.org", Text: "Example", Rel: "nofollow external"},
+		{URL: "/contact", Text: "Contact us"},
+	}
+	if got := node.Links(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// The links are those of the node and its descendants.
+	nav, err := node.Find(&colibri.Selector{Expr: "//nav"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := nav.(colibri.LinkNode).Links(); !reflect.DeepEqual(got, want[:2]) {
+		t.Fatalf("got %v, want %v", got, want[:2])
+	}
+}
+
+func TestImages(t *testing.T) {
+	const body = `<html><body>
+<img src=" /logo.png " alt="Logo" width="120" height="40px">
+<picture>
+	<source srcset="hero.avif" type="image/avif">
+	<source media="(min-width: 800px)" srcset="hero-large.jpg 1600w,
+		hero-medium.jpg 800w">
+	<img src="hero.jpg" srcset="hero@2x.jpg 2x" alt="Hero" width="auto">
+</picture>
+<img alt="no src">
+</body></html>`
+
+	node, err := ParseHTML(&testResp{
+		header: http.Header{},
+		body:   io.NopCloser(strings.NewReader(body)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hero := &colibri.Image{
+		Src: "hero.jpg",
+		Srcset: []*colibri.ImageCandidate{
+			{URL: "hero.avif", Type: "image/avif"},
+			{URL: "hero-large.jpg", Descriptor: "1600w", Media: "(min-width: 800px)"},
+			{URL: "hero-medium.jpg", Descriptor: "800w", Media: "(min-width: 800px)"},
+			{URL: "hero@2x.jpg", Descriptor: "2x"},
+		},
+		Alt: "Hero",
+	}
+
+	want := []*colibri.Image{
+		{Src: "/logo.png", Alt: "Logo", Width: 120, Height: 40},
+		hero,
+		{Alt: "no src"},
+	}
+	if got := node.Images(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// The images are those of the node and its descendants.
+	picture, err := node.Find(&colibri.Selector{Expr: "//picture"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := picture.(colibri.ImageNode).Images(); !reflect.DeepEqual(got, []*colibri.Image{hero}) {
+		t.Fatalf("got %v, want %v", got, []*colibri.Image{hero})
+	}
+
+	tests := []struct {
+		Srcset string
+		Want   []*colibri.ImageCandidate
+	}{
+		{"", nil},
+		{"a.jpg", []*colibri.ImageCandidate{{URL: "a.jpg"}}},
+		{" a.jpg 1x ,b.jpg 2x", []*colibri.ImageCandidate{{URL: "a.jpg", Descriptor: "1x"}, {URL: "b.jpg", Descriptor: "2x"}}},
+		{"a.jpg, b.jpg 480w", []*colibri.ImageCandidate{{URL: "a.jpg"}, {URL: "b.jpg", Descriptor: "480w"}}},
+	}
+
+	for _, tt := range tests {
+		if got := parseSrcset(tt.Srcset); !reflect.DeepEqual(got, tt.Want) {
+			t.Fatalf("%q: got %v, want %v", tt.Srcset, got, tt.Want)
+		}
+	}
+}
+
+func TestVisibleText(t *testing.T) {
+	const body = `<html><head><title>Title</title><style>body { color: red; }</style>
+<script>var tracking = "script text";</script></head>
+<body><h1>Colibri</h1><p>A small<b>bird</b>.</p><noscript>Enable JavaScript</noscript><!-- comment --></body></html>`
+
+	node, err := ParseHTML(&testResp{
+		header: http.Header{},
+		body:   io.NopCloser(strings.NewReader(body)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Title Colibri A small bird."
+	if got := strings.Join(strings.Fields(node.VisibleText()), " "); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseJSONNumber(t *testing.T) {
+	const body = `{"id": 1234567890123456789, "price": 1.25, "big": 123456789012345678901234567890, "ids": [9007199254740993]}`
+
+	node, err := ParseJSONNumber(&testResp{
+		header: http.Header{"Content-Type": {"application/json"}},
+		body:   io.NopCloser(strings.NewReader(body)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Expr string
+		Want any
+	}{
+		{"//id", int64(1234567890123456789)},
+		{"//id/text()", "1234567890123456789"},
+		{"//price", json.Number("1.25")},
+		{"//big", json.Number("123456789012345678901234567890")},
+		{"//ids", []any{int64(9007199254740993)}},
+		{"//ids/*", int64(9007199254740993)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Expr, func(t *testing.T) {
+			child, err := node.Find(&colibri.Selector{Expr: tt.Expr})
+			if err != nil {
+				t.Fatal(err)
+			} else if child == nil {
+				t.Fatal("node not found")
+			}
+
+			if got := child.Value(); !reflect.DeepEqual(got, tt.Want) {
+				t.Fatalf("got %#v, want %#v", got, tt.Want)
+			}
+		})
+	}
+}
+
+func TestParseJSONStream(t *testing.T) {
+	parsers, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Set(parsers, JSONRegexp, ParseJSONStream); err != nil {
+		t.Fatal(err)
+	}
+
+	const body = ` [
+		{"id": 1, "name": "Go Gopher", "tags": ["go"]},
+		{"id": 2, "name": "Ferris", "tags": ["rust", "crab"]},
+		{"id": 3, "name": "Duke"}
+	]`
+
+	var (
+		names = &colibri.Selector{Name: "names", Expr: "/name", All: true}
+		tags  = &colibri.Selector{Name: "tags", Expr: "//tags/*", All: true}
+		first = &colibri.Selector{Name: "first", Expr: "/id"}
+		rules = &colibri.Rules{Selectors: []*colibri.Selector{names, tags, first}}
+	)
+
+	node, err := parsers.Parse(rules, &testResp{
+		header: http.Header{"Content-Type": {"application/json"}},
+		body:   io.NopCloser(strings.NewReader(body)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := colibri.FindSelectors(rules, &testResp{}, node)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]any{
+		"names": []any{"Go Gopher", "Ferris", "Duke"},
+		"tags":  []any{"go", "rust", "crab"},
+		"first": float64(1),
+	}
+
+	if !reflect.DeepEqual(data, want) {
+		t.Fatalf("got %v, want %v", data, want)
+	}
+
+	if _, err := node.Find(&colibri.Selector{Expr: "/id"}); !errors.Is(err, ErrStreamConsumed) {
+		t.Fatalf("got %v, want %v", err, ErrStreamConsumed)
+	}
+
+	t.Run("Object", func(t *testing.T) {
+		node, err := ParseJSONStream(&testResp{body: io.NopCloser(strings.NewReader(jsonBody))})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		child, err := node.Find(&colibri.Selector{Expr: "//name"})
+		if err != nil {
+			t.Fatal(err)
+		} else if child.Value() != "Go Gopher" {
+			t.Fatalf("got %v, want %v", child.Value(), "Go Gopher")
+		}
+	})
+}
+
+func TestParseXMLStream(t *testing.T) {
+	parsers, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Set(parsers, XMLRegexp, ParseXMLStream("//item")); err != nil {
+		t.Fatal(err)
+	}
+
+	rules := &colibri.Rules{
+		Selectors: []*colibri.Selector{
+			{Name: "titles", Expr: "title", All: true},
+			{Name: "link", Expr: "link"},
+		},
+	}
+
+	node, err := parsers.Parse(rules, &testResp{
+		header: http.Header{"Content-Type": {"application/rss+xml"}},
+		body:   io.NopCloser(strings.NewReader(xmlBody)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := colibri.FindSelectors(rules, &testResp{}, node)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]any{
+		"titles": []any{"Item 2", "Item 1"},
+		"link":   "https://www.test.rss/item2",
+	}
+
+	if !reflect.DeepEqual(data, want) {
+		t.Fatalf("got %v, want %v", data, want)
+	}
+
+	if _, err := ParseXMLStream("[")(&testResp{body: io.NopCloser(strings.NewReader(xmlBody))}); err == nil {
+		t.Fatal("invalid expression accepted")
+	}
+}
+
+func TestCompiledSelectors(t *testing.T) {
+	var rules colibri.Rules
+	err := json.Unmarshal([]byte(`{"selectors": {"xpath": {"expr": "//a/@href", "type": "xpath", "all": true}, "css": {"expr": "title", "type": "css"}}}`), &rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer colibri.ReleaseSelectors(rules.Selectors)
+
+	node, err := ParseHTML(&testResp{
+		header: http.Header{},
+		body:   io.NopCloser(strings.NewReader(htmlBody)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The compiled expressions are reused.
+	for i := 0; i < 2; i++ {
+		for _, selector := range rules.Selectors {
+			value, err := selector.Compiled(selector.Type)
+			if (err != nil) || (value == nil) {
+				t.Fatalf("%s: got %v, want the compiled expression", selector.Name, err)
+			}
+
+			nodes, err := node.FindAll(selector)
+			if err != nil {
+				t.Fatal(err)
+			} else if len(nodes) == 0 {
+				t.Fatalf("%s: got %v, want nodes", selector.Name, nodes)
+			}
+		}
+	}
+
+	for _, raw := range []string{
+		`{"selectors": {"xpath": {"expr": "//a[", "type": "xpath"}}}`,
+		`{"selectors": {"css": {"expr": "a[", "type": "css"}}}`,
+		`{"selectors": {"regular": {"expr": "a(", "type": "regular"}}}`,
+	} {
+		var invalid colibri.Rules
+		if err := json.Unmarshal([]byte(raw), &invalid); err == nil {
+			t.Fatalf("%s: got %v, want an error", raw, err)
+		}
+	}
+}
+
 func TestParsersClear(t *testing.T) {
 	parsers, err := New()
 	if err != nil {
@@ -376,7 +1012,7 @@ func TestParsersClear(t *testing.T) {
 		t.Fatal("must not match")
 	}
 
-	if len(parsers.funcs) > 0 {
+	if (len(parsers.funcs) > 0) || (len(parsers.List()) > 0) {
 		t.Fatal("uncleaned map")
 	}
 }
@@ -440,6 +1076,11 @@ const (
 	</rss>`
 )
 
+// exprErr returns the JSON representation of an error of a selector expression.
+func exprErr(msg string) map[string]any {
+	return map[string]any{"code": colibri.CodeExprInvalid, "error": msg}
+}
+
 type testResp struct {
 	u      *url.URL
 	header http.Header
@@ -0,0 +1,59 @@
+package parsers
+
+import (
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	nethtml "golang.org/x/net/html"
+)
+
+// Robots returns the content of the robots meta tags of the document,
+// e.g. <meta name="robots" content="noindex, nofollow">.
+// See the colibri.RobotsNode interface.
+func (html *HTMLNode) Robots() []string {
+	var directives []string
+	walkElements(root(html.node), func(n *nethtml.Node) {
+		if isElement(n, "meta") && strings.EqualFold(htmlquery.SelectAttr(n, "name"), "robots") {
+			directives = append(directives, htmlquery.SelectAttr(n, "content"))
+		}
+	})
+	return directives
+}
+
+// NofollowLinks returns the href of the a, area and link elements of the document
+// whose rel attribute contains nofollow.
+// See the colibri.RobotsNode interface.
+func (html *HTMLNode) NofollowLinks() []string {
+	var links []string
+	walkElements(root(html.node), func(n *nethtml.Node) {
+		if !isElement(n, "a") && !isElement(n, "area") && !isElement(n, "link") {
+			return
+		}
+
+		for _, rel := range strings.Fields(htmlquery.SelectAttr(n, "rel")) {
+			if strings.EqualFold(rel, "nofollow") {
+				links = append(links, strings.TrimSpace(htmlquery.SelectAttr(n, "href")))
+				return
+			}
+		}
+	})
+	return links
+}
+
+// root returns the document node of the node.
+func root(node *nethtml.Node) *nethtml.Node {
+	for node.Parent != nil {
+		node = node.Parent
+	}
+	return node
+}
+
+// walkElements calls fn with each element of the tree of the node.
+func walkElements(node *nethtml.Node, fn func(n *nethtml.Node)) {
+	for n := node.FirstChild; n != nil; n = n.NextSibling {
+		if n.Type == nethtml.ElementNode {
+			fn(n)
+		}
+		walkElements(n, fn)
+	}
+}
@@ -0,0 +1,87 @@
+package parsers
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gonzxlez/colibri"
+)
+
+// sniffLen is the maximum number of bytes used to detect the content type,
+// see http.DetectContentType.
+const sniffLen = 512
+
+// mustSniff returns true if the content type does not identify the content of the response.
+func mustSniff(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return (err != nil) || (mediaType == "application/octet-stream")
+}
+
+// DetectContentType detects the content type of the data.
+// JSON and XML documents are detected by their first character,
+// the rest of the content types are detected with http.DetectContentType.
+func DetectContentType(data []byte) string {
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF")), " \t\r\n")
+
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")), bytes.HasPrefix(trimmed, []byte("[")):
+		return "application/json"
+
+	case bytes.HasPrefix(trimmed, []byte("<?xml")):
+		return "application/xml"
+	}
+	return http.DetectContentType(data)
+}
+
+// sniff reads the first bytes of the response body and returns a response
+// whose Content-Type is the detected content type.
+// The body of the returned response contains the bytes read.
+func sniff(resp colibri.Response) (colibri.Response, error) {
+	body := resp.Body()
+	if body == nil {
+		return resp, nil
+	}
+
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(body, buf)
+	if (err != nil) && (err != io.EOF) && (err != io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	buf = buf[:n]
+
+	header := resp.Header().Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Type", DetectContentType(buf))
+
+	return &sniffedResponse{
+		Response: resp,
+		header:   header,
+		body: struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(buf), body), body},
+	}, nil
+}
+
+// sniffedResponse is a response with the detected Content-Type.
+type sniffedResponse struct {
+	colibri.Response
+	header http.Header
+	body   io.ReadCloser
+}
+
+func (resp *sniffedResponse) Header() http.Header {
+	return resp.header
+}
+
+func (resp *sniffedResponse) Body() io.ReadCloser {
+	return resp.body
+}
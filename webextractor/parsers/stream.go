@@ -0,0 +1,131 @@
+package parsers
+
+import (
+	"errors"
+	"io"
+
+	"github.com/gonzxlez/colibri"
+)
+
+// ErrStreamConsumed is returned when a streaming node is queried with a selector
+// that was not evaluated while the stream was read.
+var ErrStreamConsumed = errors.New("stream already consumed")
+
+// Preparer is implemented by the nodes that need the rules before the selectors are found,
+// such as the streaming nodes, which evaluate all the selectors in a single pass over the body.
+// Parsers.Parse calls Prepare after parsing the response.
+type Preparer interface {
+	Prepare(rules *colibri.Rules) error
+}
+
+// StreamNode evaluates the selectors against each item of a stream,
+// only the nodes that match the selectors are kept in memory.
+//
+// A selector with All finds the nodes of all the items,
+// a selector without All finds the first node that matches.
+type StreamNode struct {
+	next     func() (colibri.Node, error)
+	consumed bool
+	results  map[*colibri.Selector]*streamResult
+}
+
+type streamResult struct {
+	nodes []colibri.Node
+	err   error
+}
+
+// NewStreamNode returns a new StreamNode that reads the items with next.
+// next must return io.EOF when there are no more items.
+func NewStreamNode(next func() (colibri.Node, error)) *StreamNode {
+	return &StreamNode{next: next}
+}
+
+// Prepare evaluates the selectors of the rules reading the stream.
+func (stream *StreamNode) Prepare(rules *colibri.Rules) error {
+	return stream.evaluate(rules.Selectors...)
+}
+
+func (stream *StreamNode) Find(selector *colibri.Selector) (colibri.Node, error) {
+	result, err := stream.result(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.nodes) == 0 {
+		return nil, result.err
+	}
+	return result.nodes[0], result.err
+}
+
+func (stream *StreamNode) FindAll(selector *colibri.Selector) ([]colibri.Node, error) {
+	result, err := stream.result(selector)
+	if err != nil {
+		return nil, err
+	}
+	return result.nodes, result.err
+}
+
+// Value returns nil, the items of the stream are not stored.
+func (stream *StreamNode) Value() any {
+	return nil
+}
+
+// result returns the result of the selector,
+// if the stream has not been read, the selector is evaluated.
+func (stream *StreamNode) result(selector *colibri.Selector) (*streamResult, error) {
+	if result, ok := stream.results[selector]; ok {
+		return result, nil
+	}
+
+	if err := stream.evaluate(selector); err != nil {
+		return nil, err
+	}
+	return stream.results[selector], nil
+}
+
+func (stream *StreamNode) evaluate(selectors ...*colibri.Selector) error {
+	if stream.consumed {
+		return ErrStreamConsumed
+	}
+	stream.consumed = true
+
+	stream.results = make(map[*colibri.Selector]*streamResult, len(selectors))
+	for _, selector := range selectors {
+		stream.results[selector] = &streamResult{}
+	}
+
+	for pending := len(selectors); pending > 0; {
+		item, err := stream.next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		pending = 0
+		for _, selector := range selectors {
+			result := stream.results[selector]
+			if (result.err != nil) || (!selector.All && (len(result.nodes) > 0)) {
+				continue
+			}
+
+			if selector.All {
+				nodes, err := item.FindAll(selector)
+				result.nodes = append(result.nodes, nodes...)
+				result.err = err
+
+			} else {
+				node, err := item.Find(selector)
+				if node != nil {
+					result.nodes = append(result.nodes, node)
+				}
+				result.err = err
+			}
+
+			if (result.err == nil) && (selector.All || (len(result.nodes) == 0)) {
+				pending++
+			}
+		}
+	}
+	return nil
+}
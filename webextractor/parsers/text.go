@@ -2,7 +2,6 @@ package parsers
 
 import (
 	"io"
-	"regexp"
 	"strings"
 
 	"github.com/gonzxlez/colibri"
@@ -28,7 +27,7 @@ func (text *TextNode) Find(selector *colibri.Selector) (colibri.Node, error) {
 		return nil, ErrExprType
 	}
 
-	re, err := regexp.Compile(selector.Expr)
+	re, err := compiledRegexp(selector)
 	if err != nil {
 		return nil, err
 	}
@@ -42,7 +41,7 @@ func (text *TextNode) FindAll(selector *colibri.Selector) ([]colibri.Node, error
 		return nil, ErrExprType
 	}
 
-	re, err := regexp.Compile(selector.Expr)
+	re, err := compiledRegexp(selector)
 	if err != nil {
 		return nil, err
 	}
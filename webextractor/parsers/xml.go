@@ -24,14 +24,21 @@ func ParseXML(resp colibri.Response) (*XMLNode, error) {
 }
 
 func (xml *XMLNode) Find(selector *colibri.Selector) (colibri.Node, error) {
+	if strings.EqualFold(selector.Type, NavigateExpr) {
+		return navigateFind(xml, selector.Expr)
+	}
+
 	if (selector.Type != "") && !strings.EqualFold(selector.Type, XPathExpr) {
 		return nil, ErrExprType
 	}
 
-	xmlNode, err := xmlquery.Query(xml.node, selector.Expr)
+	expr, err := compiledXPath(selector)
 	if err != nil {
 		return nil, err
-	} else if xmlNode == nil {
+	}
+
+	xmlNode := xmlquery.QuerySelector(xml.node, expr)
+	if xmlNode == nil {
 		return nil, nil
 	}
 
@@ -39,17 +46,21 @@ func (xml *XMLNode) Find(selector *colibri.Selector) (colibri.Node, error) {
 }
 
 func (xml *XMLNode) FindAll(selector *colibri.Selector) ([]colibri.Node, error) {
+	if strings.EqualFold(selector.Type, NavigateExpr) {
+		return Navigate(xml, selector.Expr)
+	}
+
 	if (selector.Type != "") && !strings.EqualFold(selector.Type, XPathExpr) {
 		return nil, ErrExprType
 	}
 
-	xmlNodes, err := xmlquery.QueryAll(xml.node, selector.Expr)
+	expr, err := compiledXPath(selector)
 	if err != nil {
 		return nil, err
 	}
 
 	var nodes []colibri.Node
-	for _, node := range xmlNodes {
+	for _, node := range xmlquery.QuerySelectorAll(xml.node, expr) {
 		nodes = append(nodes, &XMLNode{node})
 	}
 	return nodes, nil
@@ -58,3 +69,34 @@ func (xml *XMLNode) FindAll(selector *colibri.Selector) ([]colibri.Node, error)
 func (xml *XMLNode) Value() any {
 	return xml.node.InnerText()
 }
+
+// Parent returns the parent element.
+func (xml *XMLNode) Parent() colibri.Node {
+	for n := xml.node.Parent; n != nil; n = n.Parent {
+		if (n.Type == xmlquery.ElementNode) || (n.Type == xmlquery.DocumentNode) {
+			return &XMLNode{n}
+		}
+	}
+	return nil
+}
+
+// NextSibling returns the next sibling element.
+func (xml *XMLNode) NextSibling() colibri.Node {
+	for n := xml.node.NextSibling; n != nil; n = n.NextSibling {
+		if n.Type == xmlquery.ElementNode {
+			return &XMLNode{n}
+		}
+	}
+	return nil
+}
+
+// Children returns the child elements.
+func (xml *XMLNode) Children() []colibri.Node {
+	var children []colibri.Node
+	for n := xml.node.FirstChild; n != nil; n = n.NextSibling {
+		if n.Type == xmlquery.ElementNode {
+			children = append(children, &XMLNode{n})
+		}
+	}
+	return children
+}
@@ -0,0 +1,30 @@
+package parsers
+
+import (
+	"github.com/gonzxlez/colibri"
+
+	"github.com/antchfx/xmlquery"
+)
+
+// ParseXMLStream returns a parser function that parses the XML content incrementally,
+// see StreamNode. Only the elements that match the XPath expression (e.g. "//item")
+// are decoded, the selectors are evaluated against each element as it is read,
+// so their expressions must be relative to the element (e.g. "title" or ".//link").
+//
+//	parsers.Set(p, parsers.XMLRegexp, parsers.ParseXMLStream("//item"))
+func ParseXMLStream(expr string) func(colibri.Response) (*StreamNode, error) {
+	return func(resp colibri.Response) (*StreamNode, error) {
+		sp, err := xmlquery.CreateStreamParser(resp.Body(), expr)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewStreamNode(func() (colibri.Node, error) {
+			node, err := sp.Read()
+			if err != nil {
+				return nil, err
+			}
+			return &XMLNode{node}, nil
+		}), nil
+	}
+}
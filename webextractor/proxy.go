@@ -0,0 +1,274 @@
+package webextractor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrNoHealthyProxy is returned when all the proxies of the ProxyPool are evicted.
+var ErrNoHealthyProxy = errors.New("no healthy proxy")
+
+// Default values of the ProxyPool.
+const (
+	DefaultProxyMaxFailures  = 3
+	DefaultProxyCooldown     = time.Minute
+	DefaultProxyProbeTimeout = 10 * time.Second
+)
+
+// ProxyPool distributes the HTTP requests among several proxies in turn, see the Proxies field of Client.
+//
+// The failures of the requests are tracked by proxy, a proxy that fails MaxFailures times in a row is evicted
+// for Cooldown and then restored. Probe checks the proxies actively, including the evicted ones.
+type ProxyPool struct {
+	// MaxFailures specifies the number of consecutive failures after which a proxy is evicted.
+	// If it is zero, DefaultProxyMaxFailures is used.
+	MaxFailures int
+
+	// Cooldown specifies how long a proxy is evicted.
+	// If it is zero, DefaultProxyCooldown is used.
+	Cooldown time.Duration
+
+	// ProbeURL specifies the URL requested through each proxy by Probe, the proxy is healthy
+	// if the response has a status code lower than 500 other than 407 Proxy Authentication Required.
+	ProbeURL *url.URL
+
+	// ProbeTimeout specifies the time limit of each probe.
+	// If it is zero, DefaultProxyProbeTimeout is used.
+	ProbeTimeout time.Duration
+
+	mu      sync.Mutex
+	proxies []*proxyState
+	next    int
+}
+
+type proxyState struct {
+	url          *url.URL
+	failures     int
+	requests     int64
+	errors       int64
+	evictedUntil time.Time
+	lastError    string
+	lastCheck    time.Time
+}
+
+// ProxyHealth is the health status of a proxy of the ProxyPool.
+type ProxyHealth struct {
+	// URL is the URL of the proxy.
+	URL string `json:"url"`
+
+	// Healthy reports whether the proxy is used.
+	Healthy bool `json:"healthy"`
+
+	// Failures is the number of consecutive failures.
+	Failures int `json:"failures"`
+
+	// Requests is the number of requests made through the proxy, including the probes.
+	Requests int64 `json:"requests"`
+
+	// Errors is the number of failed requests.
+	Errors int64 `json:"errors"`
+
+	// EvictedUntil is the time until which the proxy is evicted, it is zero if the proxy is healthy.
+	EvictedUntil time.Time `json:"evictedUntil"`
+
+	// LastError is the message of the last failure.
+	LastError string `json:"lastError,omitempty"`
+
+	// LastCheck is the time of the last request or probe.
+	LastCheck time.Time `json:"lastCheck"`
+}
+
+// NewProxyPool returns a new ProxyPool structure with the proxies.
+func NewProxyPool(proxies ...*url.URL) *ProxyPool {
+	pool := &ProxyPool{}
+	for _, proxy := range proxies {
+		pool.Add(proxy)
+	}
+	return pool
+}
+
+// Add adds the proxy to the pool.
+func (pool *ProxyPool) Add(proxy *url.URL) {
+	pool.mu.Lock()
+	pool.proxies = append(pool.proxies, &proxyState{url: proxy})
+	pool.mu.Unlock()
+}
+
+// Next returns the next proxy that is not evicted, the evicted proxies are restored when
+// their cooldown ends. Returns ErrNoHealthyProxy if all the proxies are evicted or the pool is empty.
+func (pool *ProxyPool) Next() (*url.URL, error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	now := time.Now()
+	for i := 0; i < len(pool.proxies); i++ {
+		p := pool.proxies[(pool.next+i)%len(pool.proxies)]
+		if p.evictedUntil.IsZero() || now.After(p.evictedUntil) {
+			pool.next = (pool.next + i + 1) % len(pool.proxies)
+			return p.url, nil
+		}
+	}
+	return nil, ErrNoHealthyProxy
+}
+
+// Observe records the result of a request made through the proxy, err is nil if it succeeded.
+// The proxy is evicted when it fails MaxFailures times in a row.
+func (pool *ProxyPool) Observe(proxy *url.URL, err error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	p := pool.state(proxy)
+	if p == nil {
+		return
+	}
+
+	p.requests++
+	p.lastCheck = time.Now()
+
+	if err == nil {
+		p.failures = 0
+		p.evictedUntil = time.Time{}
+		return
+	}
+
+	p.errors++
+	p.failures++
+	p.lastError = err.Error()
+
+	maxFailures := pool.MaxFailures
+	if maxFailures <= 0 {
+		maxFailures = DefaultProxyMaxFailures
+	}
+
+	if p.failures >= maxFailures {
+		cooldown := pool.Cooldown
+		if cooldown <= 0 {
+			cooldown = DefaultProxyCooldown
+		}
+		p.evictedUntil = p.lastCheck.Add(cooldown)
+	}
+}
+
+// Probe requests the ProbeURL through each proxy and records the results, so the evicted proxies
+// that work again are restored before their cooldown ends. It does nothing if ProbeURL is nil.
+func (pool *ProxyPool) Probe(ctx context.Context) {
+	if pool.ProbeURL == nil {
+		return
+	}
+
+	timeout := pool.ProbeTimeout
+	if timeout <= 0 {
+		timeout = DefaultProxyProbeTimeout
+	}
+
+	var wg sync.WaitGroup
+	for _, proxy := range pool.Proxies() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pool.Observe(proxy, probeProxy(ctx, proxy, pool.ProbeURL, timeout))
+		}()
+	}
+	wg.Wait()
+}
+
+// HealthCheck probes the proxies every interval until the context is done, see Probe.
+func (pool *ProxyPool) HealthCheck(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pool.Probe(ctx)
+		}
+	}
+}
+
+// probeProxy requests the URL through the proxy.
+func probeProxy(ctx context.Context, proxy, u *url.URL, timeout time.Duration) error {
+	transport := &http.Transport{Proxy: http.ProxyURL(proxy)}
+	defer transport.CloseIdleConnections()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return errors.New(resp.Status)
+	}
+	return proxyError(resp, nil)
+}
+
+// Proxies returns the proxies of the pool, including the evicted ones.
+func (pool *ProxyPool) Proxies() []*url.URL {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	proxies := make([]*url.URL, len(pool.proxies))
+	for i, p := range pool.proxies {
+		proxies[i] = p.url
+	}
+	return proxies
+}
+
+// Health returns the health status of each proxy.
+func (pool *ProxyPool) Health() []ProxyHealth {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	now := time.Now()
+	health := make([]ProxyHealth, len(pool.proxies))
+	for i, p := range pool.proxies {
+		health[i] = ProxyHealth{
+			URL:       p.url.String(),
+			Healthy:   p.evictedUntil.IsZero() || now.After(p.evictedUntil),
+			Failures:  p.failures,
+			Requests:  p.requests,
+			Errors:    p.errors,
+			LastError: p.lastError,
+			LastCheck: p.lastCheck,
+		}
+
+		if !health[i].Healthy {
+			health[i].EvictedUntil = p.evictedUntil
+		}
+	}
+	return health
+}
+
+// Report returns the health status of each proxy.
+// See the colibri.Reporter interface.
+func (pool *ProxyPool) Report() any {
+	return pool.Health()
+}
+
+// state returns the state of the proxy, the mutex must be locked.
+func (pool *ProxyPool) state(proxy *url.URL) *proxyState {
+	if proxy == nil {
+		return nil
+	}
+
+	for _, p := range pool.proxies {
+		if p.url.String() == proxy.String() {
+			return p
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package webextractor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gonzxlez/colibri"
+)
+
+func TestProxyPool(t *testing.T) {
+	var (
+		a    = mustNewURL("http://a.test:8080")
+		b    = mustNewURL("http://b.test:8080")
+		pool = NewProxyPool(a, b)
+	)
+	pool.MaxFailures = 2
+	pool.Cooldown = 50 * time.Millisecond
+
+	for _, want := range []string{a.Host, b.Host, a.Host} {
+		proxy, err := pool.Next()
+		if err != nil {
+			t.Fatal(err)
+		} else if proxy.Host != want {
+			t.Fatalf(gotWantFormat, proxy.Host, want)
+		}
+	}
+
+	errProxy := errors.New("proxy error")
+	pool.Observe(a, errProxy)
+	pool.Observe(a, errProxy)
+	pool.Observe(b, errProxy)
+
+	// a is evicted, b has failed once.
+	for i := 0; i < 2; i++ {
+		if proxy, _ := pool.Next(); proxy.Host != b.Host {
+			t.Fatalf(gotWantFormat, proxy.Host, b.Host)
+		}
+	}
+
+	health := pool.Health()
+	if health[0].Healthy || (health[0].Failures != 2) || (health[0].LastError != errProxy.Error()) || health[0].EvictedUntil.IsZero() {
+		t.Fatalf(prefixGotWantFormat, "Health", health[0], "evicted")
+	}
+
+	if !health[1].Healthy || (health[1].Failures != 1) || (health[1].Errors != 1) {
+		t.Fatalf(prefixGotWantFormat, "Health", health[1], "healthy")
+	}
+
+	pool.Observe(b, errProxy)
+	if _, err := pool.Next(); !errors.Is(err, ErrNoHealthyProxy) {
+		t.Fatalf(gotWantFormat, err, ErrNoHealthyProxy)
+	}
+
+	// The proxies are restored after the cooldown.
+	time.Sleep(pool.Cooldown)
+	if _, err := pool.Next(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientProxies(t *testing.T) {
+	var (
+		down    atomic.Bool
+		proxied atomic.Int64
+	)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		proxied.Add(1)
+		w.Write([]byte("ok"))
+	}))
+	defer proxy.Close()
+
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+
+	we, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	we.Delay = nil     // Deactivate Delay
+	we.RobotsTxt = nil // Deactivate RobotsTxt
+
+	pool := NewProxyPool(mustNewURL(dead.URL), mustNewURL(proxy.URL))
+	pool.MaxFailures = 1
+	pool.Cooldown = time.Hour
+	pool.ProbeURL = mustNewURL("http://example.test/probe")
+	we.Client.(*Client).Proxies = pool
+
+	do := func() error {
+		resp, err := we.Do(&colibri.Rules{Method: "GET", URL: mustNewURL("http://example.test")})
+		if err == nil {
+			resp.Body().Close()
+		}
+		return err
+	}
+
+	// The first request is made through the dead proxy, that is evicted.
+	if err := do(); err == nil {
+		t.Fatal("error expected")
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := do(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := proxied.Load(); n != 2 {
+		t.Fatalf(prefixGotWantFormat, "Proxied", n, 2)
+	}
+
+	// 407 is an error of the proxy.
+	down.Store(true)
+	if err := do(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := pool.Next(); !errors.Is(err, ErrNoHealthyProxy) {
+		t.Fatalf(gotWantFormat, err, ErrNoHealthyProxy)
+	}
+
+	if err := do(); !errors.Is(err, ErrNoHealthyProxy) {
+		t.Fatalf(gotWantFormat, err, ErrNoHealthyProxy)
+	}
+
+	// The probe restores the proxy that works again.
+	down.Store(false)
+	pool.Probe(context.Background())
+
+	health := pool.Health()
+	if health[0].Healthy || !health[1].Healthy {
+		t.Fatalf(prefixGotWantFormat, "Health", health, "[evicted healthy]")
+	}
+
+	if err := do(); err != nil {
+		t.Fatal(err)
+	}
+}
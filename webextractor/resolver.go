@@ -0,0 +1,169 @@
+package webextractor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http/httptrace"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/gonzxlez/colibri"
+)
+
+// ErrNoAddresses is returned when the resolver does not return any address for the host.
+var ErrNoAddresses = errors.New("no addresses found for host")
+
+// Resolver resolves host names to IP addresses.
+// *net.Resolver can be used to query custom DNS servers,
+// other implementations can use DNS over HTTPS, static tables, etc.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// CachedResolver caches the addresses obtained by a Resolver for each host.
+// The addresses are kept until TTL expires or the cache is cleared.
+type CachedResolver struct {
+	// Resolver specifies the resolver used on cache misses.
+	Resolver Resolver
+
+	// TTL specifies how long the addresses are cached.
+	// If it is zero, the addresses are cached until Clear is called.
+	TTL time.Duration
+
+	rw    sync.RWMutex
+	cache map[string]cachedAddrs
+}
+
+type cachedAddrs struct {
+	addrs   []net.IPAddr
+	expires time.Time
+}
+
+// NewCachedResolver returns a new CachedResolver structure.
+// If resolver is nil, net.DefaultResolver is used.
+func NewCachedResolver(resolver Resolver) *CachedResolver {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	return &CachedResolver{
+		Resolver: resolver,
+		cache:    make(map[string]cachedAddrs),
+	}
+}
+
+// LookupIPAddr returns the cached addresses of the host,
+// if they are not cached they are obtained from the Resolver.
+func (cr *CachedResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	cr.rw.RLock()
+	cached, ok := cr.cache[host]
+	cr.rw.RUnlock()
+
+	if ok && (cached.expires.IsZero() || time.Now().Before(cached.expires)) {
+		return cached.addrs, nil
+	}
+
+	addrs, err := cr.Resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	cached = cachedAddrs{addrs: addrs}
+	if cr.TTL > 0 {
+		cached.expires = time.Now().Add(cr.TTL)
+	}
+
+	cr.rw.Lock()
+	cr.cache[host] = cached
+	cr.rw.Unlock()
+	return addrs, nil
+}
+
+// Clear removes the cached addresses.
+func (cr *CachedResolver) Clear() {
+	cr.rw.Lock()
+	clear(cr.cache)
+	cr.rw.Unlock()
+}
+
+// dialer dials the addresses obtained by the client resolver,
+// if the resolver is nil the dialer resolves the addresses.
+//
+// If the context has a blocklist, see withBlocklist, the blocked addresses are not dialed,
+// so the host names that resolve to internal addresses are blocked.
+type dialer struct {
+	net.Dialer
+	client *Client
+}
+
+type blocklistKey struct{}
+
+// withBlocklist returns a copy of the context with the blocklist of the addresses dialed.
+func withBlocklist(ctx context.Context, blocklist *colibri.Blocklist) context.Context {
+	return context.WithValue(ctx, blocklistKey{}, blocklist)
+}
+
+func (d *dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	blocklist, _ := ctx.Value(blocklistKey{}).(*colibri.Blocklist)
+
+	resolver := d.client.Resolver
+	if resolver == nil {
+		if blocklist == nil {
+			return d.Dialer.DialContext(ctx, network, address)
+		}
+		resolver = net.DefaultResolver
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if err := blocklist.CheckIP(addr); err != nil {
+			return nil, err
+		}
+		return d.Dialer.DialContext(ctx, network, address)
+	}
+
+	var addrs []net.IPAddr
+	err = traceDNS(httptrace.ContextClientTrace(ctx), host, func() (err error) {
+		addrs, err = resolver.LookupIPAddr(ctx, host)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	} else if len(addrs) == 0 {
+		return nil, ErrNoAddresses
+	}
+
+	if blocklist != nil {
+		if addrs = allowedAddrs(blocklist, addrs); len(addrs) == 0 {
+			return nil, colibri.ErrBlocked
+		}
+	}
+
+	var errs error
+	for _, addr := range addrs {
+		conn, err := d.Dialer.DialContext(ctx, network, net.JoinHostPort(addr.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		errs = errors.Join(errs, err)
+	}
+	return nil, errs
+}
+
+// allowedAddrs returns the addresses that are not blocked.
+func allowedAddrs(blocklist *colibri.Blocklist, addrs []net.IPAddr) []net.IPAddr {
+	allowed := make([]net.IPAddr, 0, len(addrs))
+	for _, addr := range addrs {
+		ip, ok := netip.AddrFromSlice(addr.IP)
+		if ok && (blocklist.CheckIP(ip) == nil) {
+			allowed = append(allowed, addr)
+		}
+	}
+	return allowed
+}
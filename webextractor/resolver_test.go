@@ -0,0 +1,154 @@
+package webextractor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gonzxlez/colibri"
+)
+
+type testResolver struct {
+	Lookups int
+}
+
+func (r *testResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	r.Lookups++
+	if host == "colibri.test" {
+		return []net.IPAddr{{IP: net.ParseIP("127.0.0.1")}}, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func TestCachedResolver(t *testing.T) {
+	ts := testServer()
+	defer ts.Close()
+
+	var (
+		resolver = &testResolver{}
+		cached   = NewCachedResolver(resolver)
+		port     = mustNewURL(ts.URL).Port()
+	)
+
+	we, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	we.Delay = nil     // Deactivate Delay
+	we.RobotsTxt = nil // Deactivate RobotsTxt
+	we.Client.(*Client).Resolver = cached
+
+	for i := 0; i < 3; i++ {
+		rules := &colibri.Rules{
+			Method: "GET",
+			URL:    mustNewURL("http://colibri.test:" + port + "/html"),
+		}
+
+		resp, err := we.Do(rules)
+		if err != nil {
+			t.Fatal(err)
+		} else if resp.StatusCode() != http.StatusOK {
+			t.Fatalf(prefixGotWantFormat, "Status Code", resp.StatusCode(), http.StatusOK)
+		}
+	}
+
+	if resolver.Lookups != 1 {
+		t.Fatalf(prefixGotWantFormat, "Lookups", resolver.Lookups, 1)
+	}
+
+	_, err = we.Do(&colibri.Rules{Method: "GET", URL: mustNewURL("http://unknown.test:" + port)})
+	if err == nil {
+		t.Fatal("error expected")
+	}
+
+	we.Clear()
+
+	if len(cached.cache) > 0 {
+		t.Fatal("Uncleaned")
+	}
+}
+
+func TestBlocklist(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://blocked.test/", http.StatusFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	port := mustNewURL(ts.URL).Port()
+
+	we, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	we.Delay = nil     // Deactivate Delay
+	we.RobotsTxt = nil // Deactivate RobotsTxt
+	we.Client.(*Client).Resolver = &testResolver{}
+	we.Blocklist = &colibri.Blocklist{Hosts: []string{"blocked.test"}}
+
+	// The host resolves to an address that is not blocked.
+	resp, err := we.Do(&colibri.Rules{Method: "GET", URL: mustNewURL("http://colibri.test:" + port)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body().Close()
+
+	_, err = we.Do(&colibri.Rules{Method: "GET", URL: mustNewURL("http://colibri.test:" + port + "/redirect"), Redirects: 5})
+	if !errors.Is(err, colibri.ErrBlocked) {
+		t.Fatalf(prefixGotWantFormat, "Redirect", err, colibri.ErrBlocked)
+	}
+
+	// The idle connections were dialed before the blocklist changed.
+	we.Clear()
+	we.Blocklist.Private = true
+
+	// The host resolves to a loopback address.
+	_, err = we.Do(&colibri.Rules{Method: "GET", URL: mustNewURL("http://colibri.test:" + port)})
+	if !errors.Is(err, colibri.ErrBlocked) {
+		t.Fatalf(prefixGotWantFormat, "Resolved", err, colibri.ErrBlocked)
+	}
+
+	_, err = we.Do(&colibri.Rules{Method: "GET", URL: mustNewURL(ts.URL)})
+	if !errors.Is(err, colibri.ErrBlocked) {
+		t.Fatalf(prefixGotWantFormat, "IP", err, colibri.ErrBlocked)
+	}
+
+	t.Run("Proxy", func(t *testing.T) {
+		// The proxy resolves the hosts, so only the URLs of the redirects are checked.
+		var proxied []string
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proxied = append(proxied, r.URL.String())
+			if r.URL.Path == "/redirect" {
+				http.Redirect(w, r, "http://blocked.test/", http.StatusFound)
+				return
+			}
+			w.Write([]byte("ok"))
+		}))
+		defer proxy.Close()
+
+		we.Blocklist = &colibri.Blocklist{Hosts: []string{"blocked.test"}}
+
+		_, err := we.Do(&colibri.Rules{
+			Method:    "GET",
+			URL:       mustNewURL("http://colibri.test/redirect"),
+			Proxy:     mustNewURL(proxy.URL),
+			Redirects: 5,
+		})
+		if !errors.Is(err, colibri.ErrBlocked) {
+			t.Fatalf(prefixGotWantFormat, "Redirect", err, colibri.ErrBlocked)
+		}
+
+		if want := []string{"http://colibri.test/redirect"}; !reflect.DeepEqual(proxied, want) {
+			t.Fatalf(prefixGotWantFormat, "Proxied", proxied, want)
+		}
+	})
+}
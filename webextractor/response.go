@@ -1,9 +1,15 @@
 package webextractor
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
 	"io"
 	"net/http"
 	"net/url"
+	"sync"
+	"time"
 
 	"github.com/gonzxlez/colibri"
 )
@@ -13,7 +19,21 @@ import (
 type Response struct {
 	HTTP      *http.Response
 	redirects []*url.URL
+	chain     []*colibri.Redirect
+	encoding  string
+	cached    bool
+	tls       bool
+	timer     *timer
+	attempts  int
+	body      *hashReadCloser
+	limited   *limitedBody
+	buffer    int64
+	buffered  []byte
+	recorded  *bytes.Buffer
 	c         *colibri.Colibri
+
+	mu   sync.Mutex
+	node colibri.Node
 }
 
 func (resp *Response) URL() *url.URL {
@@ -28,32 +48,308 @@ func (resp *Response) Header() http.Header {
 	return resp.HTTP.Header
 }
 
+// Body returns the response body. If the rules have BufferBody, the body is read into memory
+// the first time and each call returns a new reader of the content, unless the body exceeds BufferBody.
 func (resp *Response) Body() io.ReadCloser {
+	resp.mu.Lock()
+	defer resp.mu.Unlock()
+
+	if resp.buffer > 0 {
+		resp.readBuffer()
+	}
+
+	if resp.buffered != nil {
+		return io.NopCloser(bytes.NewReader(resp.buffered))
+	}
 	return resp.HTTP.Body
 }
 
+// BufferedBody returns the body kept in memory, returns nil if the body was not kept.
+// If the rules have IncludeBody, the content read from the body is kept.
+// See the colibri.BodyBufferer interface.
+func (resp *Response) BufferedBody() []byte {
+	resp.mu.Lock()
+	defer resp.mu.Unlock()
+
+	if (resp.buffered == nil) && (resp.recorded != nil) {
+		return bytes.Clone(resp.recorded.Bytes())
+	}
+	return resp.buffered
+}
+
+// readBuffer reads the body into memory if it does not exceed the buffer size,
+// otherwise the content read is kept at the beginning of the body.
+func (resp *Response) readBuffer() {
+	n := resp.buffer
+	resp.buffer = 0
+
+	b, err := io.ReadAll(io.LimitReader(resp.HTTP.Body, n+1))
+	if (err == nil) && (int64(len(b)) <= n) {
+		resp.HTTP.Body.Close()
+		resp.buffered = b
+		return
+	}
+
+	var rest io.Reader = resp.HTTP.Body
+	if err != nil {
+		rest = errReader{err}
+	}
+
+	resp.HTTP.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(b), rest), resp.HTTP.Body}
+}
+
 func (resp *Response) Redirects() []*url.URL {
 	return resp.redirects
 }
 
+// RedirectChain returns the status code, Location and Set-Cookie headers of each redirect followed.
+// See the colibri.RedirectTracer interface.
+func (resp *Response) RedirectChain() []*colibri.Redirect {
+	return resp.chain
+}
+
+// Encoding returns the original Content-Encoding of the response body,
+// the body returned by Body is already decompressed.
+func (resp *Response) Encoding() string {
+	return resp.encoding
+}
+
+// Cookies returns the cookies set by the Set-Cookie headers of the response,
+// the cookies set by the redirects are in the RedirectChain.
+func (resp *Response) Cookies() []*http.Cookie {
+	return resp.HTTP.Cookies()
+}
+
+// TLS returns the details of the TLS connection of the response,
+// returns nil if the response was not received over TLS.
+func (resp *Response) TLS() *TLSInfo {
+	return newTLSInfo(resp.HTTP.TLS)
+}
+
+// Timings returns the durations of the phases of the request,
+// the Total duration is available once the body is read or closed.
+func (resp *Response) Timings() Timings {
+	if resp.timer == nil {
+		return Timings{}
+	}
+	return resp.timer.get()
+}
+
+// Attempts returns the number of requests made, including the retries.
+// See the colibri.Attempter interface.
+func (resp *Response) Attempts() int {
+	return max(resp.attempts, 1)
+}
+
+// BodySize returns the number of bytes read from the decompressed body.
+// See the colibri.BodySizer interface.
+func (resp *Response) BodySize() int64 {
+	if resp.body == nil {
+		return 0
+	}
+	return resp.body.n
+}
+
+// Truncated returns true if the body exceeded the ResponseBodySize of the rules with ParseTruncated
+// and was read up to the limit. See the colibri.Truncater interface.
+func (resp *Response) Truncated() bool {
+	return (resp.limited != nil) && resp.limited.exceeded
+}
+
+// CountsBytes returns true if the bytes read from the body are counted with the CountBytes
+// method of the Colibri that made the request. See the colibri.ByteCounter interface.
+func (resp *Response) CountsBytes() bool {
+	return (resp.body != nil) && (resp.body.count != nil)
+}
+
+// Cached returns true if the response was obtained from the Cache of the Client
+// after being revalidated by the server.
+func (resp *Response) Cached() bool {
+	return resp.cached
+}
+
 func (resp *Response) Serializable() map[string]any {
 	var redirects []string
 	for _, u := range resp.Redirects() {
 		redirects = append(redirects, u.String())
 	}
 
-	return map[string]any{
+	m := map[string]any{
 		"url":       resp.HTTP.Request.URL.String(),
 		"code":      resp.HTTP.StatusCode,
 		"header":    resp.HTTP.Header,
 		"redirects": redirects,
 	}
+
+	if len(resp.chain) > 0 {
+		chain := make([]map[string]any, 0, len(resp.chain))
+		for _, redirect := range resp.chain {
+			chain = append(chain, map[string]any{
+				"url":       redirect.URL.String(),
+				"code":      redirect.StatusCode,
+				"location":  redirect.Location,
+				"setCookie": redirect.SetCookie,
+			})
+		}
+		m["redirectChain"] = chain
+	}
+
+	if cookies := resp.Cookies(); len(cookies) > 0 {
+		serialized := make([]map[string]any, 0, len(cookies))
+		for _, cookie := range cookies {
+			serialized = append(serialized, serializeCookie(cookie))
+		}
+		m["cookies"] = serialized
+	}
+
+	// The details of the TLS connection are only included if the rules have IncludeTLS.
+	if info := resp.TLS(); resp.tls && (info != nil) {
+		m["tls"] = info.Serializable()
+	}
+
+	if resp.encoding != "" {
+		m["encoding"] = resp.encoding
+	}
+
+	if resp.cached {
+		m["cached"] = true
+	}
+
+	if resp.timer != nil {
+		m["timings"] = resp.Timings().Serializable()
+	}
+
+	if (resp.body != nil) && (resp.body.sum != nil) {
+		m["hash"] = hex.EncodeToString(resp.body.sum)
+	}
+	return m
+}
+
+// serializeCookie returns the attributes of the cookie, the empty attributes are omitted.
+func serializeCookie(cookie *http.Cookie) map[string]any {
+	m := map[string]any{
+		"name":  cookie.Name,
+		"value": cookie.Value,
+	}
+
+	if cookie.Domain != "" {
+		m["domain"] = cookie.Domain
+	}
+
+	if cookie.Path != "" {
+		m["path"] = cookie.Path
+	}
+
+	if !cookie.Expires.IsZero() {
+		m["expires"] = cookie.Expires.UTC().Format(time.RFC3339)
+	}
+
+	if cookie.MaxAge != 0 {
+		m["maxAge"] = cookie.MaxAge
+	}
+
+	if cookie.Secure {
+		m["secure"] = true
+	}
+
+	if cookie.HttpOnly {
+		m["httpOnly"] = true
+	}
+	return m
+}
+
+// Hash returns the SHA-256 hash of the response body in hexadecimal.
+// The hash is computed while the body is read, if the body has not been read
+// completely, the rest of the body is read into memory so it can still be read with Body.
+// See the colibri.Hasher interface.
+func (resp *Response) Hash() (string, error) {
+	if resp.body == nil {
+		resp.body = newHashReadCloser(resp.HTTP.Body)
+		resp.HTTP.Body = resp.body
+	}
+
+	if resp.body.sum == nil {
+		// The body is read through the readers that wrap it, e.g. the one of IncludeBody.
+		b, err := io.ReadAll(resp.HTTP.Body)
+		if err != nil {
+			return "", err
+		}
+
+		resp.HTTP.Body = struct {
+			io.Reader
+			io.Closer
+		}{bytes.NewReader(b), resp.body}
+	}
+	return hex.EncodeToString(resp.body.sum), nil
 }
 
 func (resp *Response) Do(rules *colibri.Rules) (colibri.Response, error) {
 	return resp.c.Do(rules)
 }
 
+// Extract extracts the rules, if the rules do not have a URL the selectors are found
+// in the parsed response without requesting and parsing it again, see colibri.Colibri.ExtractResponse.
 func (resp *Response) Extract(rules *colibri.Rules) (*colibri.Output, error) {
+	if (rules != nil) && (rules.URL == nil) {
+		return resp.c.ExtractResponse(resp, rules)
+	}
 	return resp.c.Extract(rules)
 }
+
+// CachedNode returns the root node of the parsed response, nil if it has not been parsed or it was invalidated.
+// See the colibri.NodeCacher interface.
+func (resp *Response) CachedNode() colibri.Node {
+	resp.mu.Lock()
+	defer resp.mu.Unlock()
+
+	return resp.node
+}
+
+// CacheNode stores the root node of the parsed response.
+func (resp *Response) CacheNode(node colibri.Node) {
+	resp.mu.Lock()
+	defer resp.mu.Unlock()
+
+	resp.node = node
+}
+
+// Invalidate removes the root node of the parsed response, so its memory can be released.
+func (resp *Response) Invalidate() {
+	resp.CacheNode(nil)
+}
+
+// hashReadCloser computes the hash of the content read,
+// the hash is available when the end of the content is reached.
+type hashReadCloser struct {
+	rc    io.ReadCloser
+	h     hash.Hash
+	sum   []byte
+	n     int64
+	count func(n int64)
+}
+
+func newHashReadCloser(rc io.ReadCloser) *hashReadCloser {
+	return &hashReadCloser{rc: rc, h: sha256.New()}
+}
+
+func (hrc *hashReadCloser) Read(p []byte) (int, error) {
+	n, err := hrc.rc.Read(p)
+	hrc.h.Write(p[:n])
+	hrc.n += int64(n)
+
+	if (hrc.count != nil) && (n > 0) {
+		hrc.count(int64(n))
+	}
+
+	if (err == io.EOF) && (hrc.sum == nil) {
+		hrc.sum = hrc.h.Sum(nil)
+	}
+	return n, err
+}
+
+func (hrc *hashReadCloser) Close() error {
+	return hrc.rc.Close()
+}
@@ -1,9 +1,16 @@
 package webextractor
 
 import (
+	"context"
+	"errors"
 	"io"
+	"net/http"
 	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/gonzxlez/colibri"
 
@@ -13,65 +20,356 @@ import (
 const robotsTxtPath = "/robots.txt"
 
 // RobotsData gets, stores and parses robots.txt restrictions.
+// The restrictions are stored by host, including the port, until they expire or Clear is called.
 type RobotsData struct {
+	// TTL specifies how long the restrictions of a host are stored.
+	// If it is zero, the restrictions do not expire.
+	TTL time.Duration
+
+	// MaxHosts specifies the maximum number of hosts whose restrictions are stored,
+	// the oldest restrictions are removed when the limit is reached.
+	// If it is zero, there is no limit.
+	MaxHosts int
+
+	// Refresh specifies whether the expired restrictions are obtained again in the background,
+	// meanwhile the expired restrictions are used. Otherwise, they are obtained before the request.
+	Refresh bool
+
+	// Fetch specifies the request of the robots.txt files, see the RobotsFetch structure.
+	// If it is nil, the request is a copy of the rules of the URL.
+	Fetch *RobotsFetch
+
 	rw   sync.RWMutex
-	data map[string]*robotstxt.RobotsData
+	data map[string]*robotsEntry
+}
+
+// RobotsFetch specifies the request of the robots.txt files, instead of the rules of the URL,
+// so a slow robots.txt does not use the settings of the pages.
+type RobotsFetch struct {
+	// Timeout specifies the time limit of the request, including the reading of the body.
+	// If it is zero, only the context of the rules of the URL limits the request.
+	Timeout time.Duration
+
+	// Header specifies the headers of the request.
+	// If it does not have a User-Agent, the User-Agent of the rules of the URL is used.
+	Header http.Header
+
+	// Proxy specifies the proxy of the request. If it is nil, no proxy is used.
+	Proxy *url.URL
+
+	// Redirects specifies the maximum number of redirects followed.
+	Redirects int
+
+	// MaxBodySize specifies the maximum size of the robots.txt, the rest of the content is ignored.
+	// If it is zero, colibri.DefaultResponseBodySize is used.
+	MaxBodySize int
+
+	// AllowOnServerError specifies whether the responses with a 5xx status code allow everything,
+	// otherwise they disallow everything.
+	AllowOnServerError bool
+}
+
+// rules returns the rules of the request of the robots.txt of the URL.
+func (fetch *RobotsFetch) rules(rules *colibri.Rules, robotsURL *url.URL) *colibri.Rules {
+	header := fetch.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	if header.Get("User-Agent") == "" {
+		if userAgent := rules.Header.Get("User-Agent"); userAgent != "" {
+			header.Set("User-Agent", userAgent)
+		}
+	}
+
+	robotsRules := &colibri.Rules{
+		Method:           "GET",
+		URL:              robotsURL,
+		Proxy:            fetch.Proxy,
+		Header:           header,
+		Timeout:          fetch.Timeout,
+		Redirects:        fetch.Redirects,
+		ResponseBodySize: fetch.MaxBodySize,
+		IgnoreRobotsTxt:  true,
+		Extra:            make(map[string]any),
+	}
+	robotsRules.SetContext(rules.Context())
+	return robotsRules
+}
+
+type robotsEntry struct {
+	data       *robotstxt.RobotsData
+	groups     map[string]*colibri.RobotsGroup
+	stored     time.Time
+	refreshing bool
 }
 
 // NewRobotsData returns a new RobotsData structure.
 func NewRobotsData() *RobotsData {
-	return &RobotsData{data: make(map[string]*robotstxt.RobotsData)}
+	return &RobotsData{data: make(map[string]*robotsEntry)}
 }
 
 // IsAllowed verifies that the User-Agent can access the URL.
 // Gets and stores the robots.txt restrictions of the URL host and for use in URLs with the same host.
+//
+// If the URL is not allowed, a *colibri.RobotsError with the directive that disallows it is returned.
 func (robots *RobotsData) IsAllowed(c *colibri.Colibri, rules *colibri.Rules) error {
 	if rules.URL.Path == robotsTxtPath {
 		return nil
 	}
 
+	entry, err := robots.entry(c, rules)
+	if err != nil {
+		return err
+	}
+
+	userAgent := rules.Header.Get("User-Agent")
+	if entry.data.TestAgent(rules.URL.Path, userAgent) {
+		return nil
+	}
+
+	robotsErr := &colibri.RobotsError{URL: rules.URL}
+	if group := entry.group(userAgent); group != nil {
+		robotsErr.Agent = group.Agent
+		robotsErr.Directive = disallowDirective(group, rules.URL.Path)
+	}
+	return robotsErr
+}
+
+// Sitemaps returns the URLs of the sitemaps listed in the robots.txt of the URL host.
+// See the colibri.RobotsInspector interface.
+func (robots *RobotsData) Sitemaps(c *colibri.Colibri, rules *colibri.Rules) ([]string, error) {
+	entry, err := robots.entry(c, rules)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string(nil), entry.data.Sitemaps...), nil
+}
+
+// Group returns the group of the robots.txt of the URL host that applies to the User-Agent.
+// See the colibri.RobotsInspector interface.
+func (robots *RobotsData) Group(c *colibri.Colibri, rules *colibri.Rules) (*colibri.RobotsGroup, error) {
+	entry, err := robots.entry(c, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	group := entry.group(rules.Header.Get("User-Agent"))
+	if group == nil {
+		return &colibri.RobotsGroup{}, nil
+	}
+
+	return &colibri.RobotsGroup{
+		Agent:      group.Agent,
+		Allow:      append([]string(nil), group.Allow...),
+		Disallow:   append([]string(nil), group.Disallow...),
+		CrawlDelay: group.CrawlDelay,
+	}, nil
+}
+
+// entry returns the stored restrictions of the URL host,
+// if they are not stored or have expired they are obtained.
+func (robots *RobotsData) entry(c *colibri.Colibri, rules *colibri.Rules) (*robotsEntry, error) {
 	robots.rw.RLock()
-	robotsData, ok := robots.data[rules.URL.Host]
+	entry, ok := robots.data[rules.URL.Host]
 	robots.rw.RUnlock()
 
-	if !ok {
-		robotsRef, err := url.Parse(robotsTxtPath)
-		if err != nil {
-			return err
-		}
+	switch {
+	case ok && !robots.expired(entry):
+		return entry, nil
+
+	case ok && robots.Refresh:
+		robots.refresh(c, rules)
+		return entry, nil
+	}
+
+	status, body, err := robots.fetch(c, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	robots.rw.Lock()
+	defer robots.rw.Unlock()
+	return robots.store(rules.URL.Host, status, body)
+}
+
+// fetch gets the status code and the content of the robots.txt of the URL host.
+func (robots *RobotsData) fetch(c *colibri.Colibri, rules *colibri.Rules) (int, []byte, error) {
+	robotsRef, err := url.Parse(robotsTxtPath)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	robotsURL := rules.URL.ResolveReference(robotsRef)
 
-		robotsRules := rules.Clone()
+	var robotsRules *colibri.Rules
+	if robots.Fetch != nil {
+		// The rules of Fetch are not obtained from the pool, so they are not released.
+		robotsRules = robots.Fetch.rules(rules, robotsURL)
+
+		if robots.Fetch.Timeout > 0 {
+			ctx, cancel := context.WithTimeout(robotsRules.Context(), robots.Fetch.Timeout)
+			defer cancel()
+			robotsRules.SetContext(ctx)
+		}
+	} else {
+		robotsRules = rules.Clone()
+		defer colibri.ReleaseRules(robotsRules)
 
 		robotsRules.Method = "GET"
-		robotsRules.URL = rules.URL.ResolveReference(robotsRef)
+		robotsRules.URL = robotsURL
 		robotsRules.IgnoreRobotsTxt = true
+	}
 
-		resp, err := c.Do(robotsRules)
-		if err != nil {
-			return err
+	// The status code is interpreted by robotstxt, e.g. 404 allows everything.
+	robotsRules.FailOnStatus = false
+	robotsRules.AllowedStatusCodes = nil
+
+	// The content that exceeds the body size is ignored.
+	resp, err := c.Do(robotsRules)
+	if (err != nil) && !(errors.Is(err, colibri.ErrResponseBodySize) && (resp != nil)) {
+		return 0, nil, err
+	}
+
+	buf, err := io.ReadAll(resp.Body())
+	resp.Body().Close()
+	if (err != nil) && !errors.Is(err, colibri.ErrResponseBodySize) {
+		return 0, nil, err
+	}
+
+	status := resp.StatusCode()
+	if (robots.Fetch != nil) && robots.Fetch.AllowOnServerError && (status >= 500) && (status <= 599) {
+		status = http.StatusNotFound
+	}
+	return status, buf, nil
+}
+
+// refresh gets the restrictions of the URL host in the background,
+// if an error occurs the expired restrictions are kept.
+func (robots *RobotsData) refresh(c *colibri.Colibri, rules *colibri.Rules) {
+	host := rules.URL.Host
+
+	robots.rw.Lock()
+	entry, ok := robots.data[host]
+	if !ok || entry.refreshing {
+		robots.rw.Unlock()
+		return
+	}
+	entry.refreshing = true
+	robots.rw.Unlock()
+
+	// The request is not canceled with the rules.
+	refreshRules := rules.Clone()
+	refreshRules.SetContext(context.WithoutCancel(rules.Context()))
+
+	go func() {
+		defer colibri.ReleaseRules(refreshRules)
+
+		status, body, err := robots.fetch(c, refreshRules)
+
+		robots.rw.Lock()
+		defer robots.rw.Unlock()
+
+		if err == nil {
+			_, err = robots.store(host, status, body)
 		}
 
-		buf, err := io.ReadAll(resp.Body())
 		if err != nil {
-			return err
+			entry.refreshing = false
 		}
+	}()
+}
 
-		robotsData, err = robotstxt.FromStatusAndBytes(resp.StatusCode(), buf)
-		if err != nil {
-			return err
+// expired returns true if the TTL of the entry has passed.
+func (robots *RobotsData) expired(entry *robotsEntry) bool {
+	return (robots.TTL > 0) && (time.Since(entry.stored) >= robots.TTL)
+}
+
+// store parses and stores the restrictions of the host and removes the oldest ones if MaxHosts is exceeded,
+// the mutex must be locked.
+func (robots *RobotsData) store(host string, status int, body []byte) (*robotsEntry, error) {
+	robotsData, err := robotstxt.FromStatusAndBytes(status, body)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &robotsEntry{data: robotsData, stored: time.Now()}
+	if (status >= 200) && (status <= 299) {
+		entry.groups = parseRobotsGroups(body)
+	}
+
+	if robots.data == nil {
+		robots.data = make(map[string]*robotsEntry)
+	}
+
+	if _, ok := robots.data[host]; !ok && (robots.MaxHosts > 0) {
+		for len(robots.data) >= robots.MaxHosts {
+			var (
+				oldest string
+				stored time.Time
+			)
+			for h, entry := range robots.data {
+				if (oldest == "") || entry.stored.Before(stored) {
+					oldest, stored = h, entry.stored
+				}
+			}
+			delete(robots.data, oldest)
 		}
+	}
 
-		robots.rw.Lock()
-		robots.data[rules.URL.Host] = robotsData
-		robots.rw.Unlock()
+	robots.data[host] = entry
+	return entry, nil
+}
 
-		colibri.ReleaseRules(robotsRules)
+// Set stores the robots.txt restrictions of the host, e.g. "example.com" or "example.com:8080",
+// parsed from the content of the robots.txt. The restrictions expire as if they had been obtained.
+func (robots *RobotsData) Set(host string, robotsTxt []byte) error {
+	robots.rw.Lock()
+	defer robots.rw.Unlock()
+
+	_, err := robots.store(host, http.StatusOK, robotsTxt)
+	return err
+}
+
+// Get returns the stored robots.txt restrictions of the host and the time they were stored,
+// including the expired ones. Returns false if the host has no stored restrictions.
+func (robots *RobotsData) Get(host string) (*robotstxt.RobotsData, time.Time, bool) {
+	robots.rw.RLock()
+	defer robots.rw.RUnlock()
+
+	entry, ok := robots.data[host]
+	if !ok {
+		return nil, time.Time{}, false
 	}
+	return entry.data, entry.stored, true
+}
 
-	if robotsData.TestAgent(rules.URL.Path, rules.Header.Get("User-Agent")) {
-		return nil
+// Hosts returns the sorted hosts whose restrictions are stored.
+func (robots *RobotsData) Hosts() []string {
+	robots.rw.RLock()
+	hosts := make([]string, 0, len(robots.data))
+	for host := range robots.data {
+		hosts = append(hosts, host)
 	}
-	return colibri.ErrRobotstxtRestriction
+	robots.rw.RUnlock()
+
+	slices.Sort(hosts)
+	return hosts
+}
+
+// Delete removes the stored restrictions of the host.
+func (robots *RobotsData) Delete(host string) {
+	robots.rw.Lock()
+	delete(robots.data, host)
+	robots.rw.Unlock()
+}
+
+// Report returns the number of hosts whose robots.txt restrictions are stored.
+// See the colibri.Reporter interface.
+func (robots *RobotsData) Report() any {
+	robots.rw.RLock()
+	defer robots.rw.RUnlock()
+	return map[string]any{"hosts": len(robots.data)}
 }
 
 // Clear removes stored robots.txt restrictions.
@@ -80,3 +378,98 @@ func (robots *RobotsData) Clear() {
 	clear(robots.data)
 	robots.rw.Unlock()
 }
+
+// group returns the group of directives that applies to the User-Agent, the group with the longest
+// User-agent that prefixes it or the "*" group as robotstxt does. Returns nil if no group applies.
+func (entry *robotsEntry) group(userAgent string) *colibri.RobotsGroup {
+	var (
+		userAgentLower = strings.ToLower(userAgent)
+		group          = entry.groups["*"]
+		prefixLen      int
+	)
+	for agent, g := range entry.groups {
+		if (agent != "*") && strings.HasPrefix(userAgentLower, agent) && (len(agent) > prefixLen) {
+			group, prefixLen = g, len(agent)
+		}
+	}
+	return group
+}
+
+// parseRobotsGroups returns the groups of directives of the robots.txt by lowercase User-agent.
+// The consecutive User-agent lines share the directives that follow them.
+func parseRobotsGroups(body []byte) map[string]*colibri.RobotsGroup {
+	var (
+		groups = make(map[string]*colibri.RobotsGroup)
+		agents []string
+		empty  = true
+	)
+	for _, line := range strings.Split(string(body), "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		if key == "user-agent" {
+			if !empty {
+				agents, empty = nil, true
+			}
+			agents = append(agents, value)
+			continue
+		}
+
+		if (key != "allow") && (key != "disallow") && (key != "crawl-delay") {
+			continue
+		}
+		empty = false
+
+		for _, agent := range agents {
+			group, ok := groups[strings.ToLower(agent)]
+			if !ok {
+				group = &colibri.RobotsGroup{Agent: agent}
+				groups[strings.ToLower(agent)] = group
+			}
+
+			switch key {
+			case "allow":
+				group.Allow = append(group.Allow, value)
+			case "disallow":
+				group.Disallow = append(group.Disallow, value)
+			case "crawl-delay":
+				if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+					group.CrawlDelay = time.Duration(seconds * float64(time.Second))
+				}
+			}
+		}
+	}
+	return groups
+}
+
+// disallowDirective returns the longest Disallow directive of the group that matches the path,
+// the matching is done by robotstxt so the wildcards are interpreted in the same way.
+func disallowDirective(group *colibri.RobotsGroup, path string) string {
+	var directive string
+	for _, disallow := range group.Disallow {
+		if len(disallow) <= len(directive) {
+			continue
+		}
+
+		robotsData, err := robotstxt.FromString("User-agent: *\nDisallow: " + disallow)
+		if (err == nil) && !robotsData.TestAgent(path, "*") {
+			directive = disallow
+		}
+	}
+
+	if directive == "" {
+		return ""
+	}
+	return "Disallow: " + directive
+}
@@ -0,0 +1,302 @@
+package webextractor
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gonzxlez/colibri"
+)
+
+func TestRobotsDataExpiration(t *testing.T) {
+	var (
+		fetches  atomic.Int64
+		disallow atomic.Bool
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == robotsTxtPath {
+			fetches.Add(1)
+			if disallow.Load() {
+				w.Write([]byte("User-agent: *\nDisallow: /"))
+			}
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	we, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	we.Delay = nil // Deactivate Delay
+
+	robots := we.RobotsTxt.(*RobotsData)
+	robots.TTL = 50 * time.Millisecond
+
+	do := func() error {
+		resp, err := we.Do(&colibri.Rules{Method: "GET", URL: mustNewURL(ts.URL + "/page")})
+		if err == nil {
+			resp.Body().Close()
+		}
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := do(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf(prefixGotWantFormat, "Fetches", n, 1)
+	}
+
+	// The expired restrictions are obtained before the request.
+	disallow.Store(true)
+	time.Sleep(robots.TTL)
+
+	if err := do(); !errors.Is(err, colibri.ErrRobotstxtRestriction) {
+		t.Fatalf(gotWantFormat, err, colibri.ErrRobotstxtRestriction)
+	}
+
+	// The expired restrictions are used while they are obtained in the background.
+	robots.Refresh = true
+	disallow.Store(false)
+	time.Sleep(robots.TTL)
+
+	if err := do(); !errors.Is(err, colibri.ErrRobotstxtRestriction) {
+		t.Fatalf(gotWantFormat, err, colibri.ErrRobotstxtRestriction)
+	}
+
+	// The restrictions obtained in the background are used once they are stored.
+	deadline := time.Now().Add(time.Second)
+	for err := do(); err != nil; err = do() {
+		if !errors.Is(err, colibri.ErrRobotstxtRestriction) || time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if n := fetches.Load(); n != 3 {
+		t.Fatalf(prefixGotWantFormat, "Fetches", n, 3)
+	}
+}
+
+func TestRobotsDataSet(t *testing.T) {
+	robots := NewRobotsData()
+	robots.MaxHosts = 2
+
+	for _, host := range []string{"a.test", "b.test", "c.test"} {
+		if err := robots.Set(host, []byte("User-agent: *\nDisallow: /private")); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The oldest host is removed.
+	if hosts, want := robots.Hosts(), []string{"b.test", "c.test"}; !reflect.DeepEqual(hosts, want) {
+		t.Fatalf(prefixGotWantFormat, "Hosts", hosts, want)
+	}
+
+	rules := &colibri.Rules{URL: mustNewURL("http://c.test/private/a"), Header: http.Header{}}
+	if err := robots.IsAllowed(nil, rules); !errors.Is(err, colibri.ErrRobotstxtRestriction) {
+		t.Fatalf(gotWantFormat, err, colibri.ErrRobotstxtRestriction)
+	}
+
+	data, stored, ok := robots.Get("c.test")
+	if !ok || (data == nil) || stored.IsZero() {
+		t.Fatal("stored restrictions expected")
+	}
+
+	robots.Delete("c.test")
+	if _, _, ok := robots.Get("c.test"); ok {
+		t.Fatal("deleted restrictions")
+	}
+}
+
+func TestRobotsDataInspect(t *testing.T) {
+	robotsTxt := `# comment
+User-agent: *
+Disallow: /private
+Disallow: /private/docs/*.pdf
+
+User-agent: Colibri
+User-agent: other
+Allow: /private/public
+Disallow: /private # comment
+Crawl-delay: 1.5
+
+Sitemap: https://example.test/sitemap.xml
+Sitemap: https://example.test/news.xml
+`
+
+	robots := NewRobotsData()
+	if err := robots.Set("example.test", []byte(robotsTxt)); err != nil {
+		t.Fatal(err)
+	}
+
+	var c *colibri.Colibri // The restrictions are stored, c is not used.
+	newRules := func(path, userAgent string) *colibri.Rules {
+		return &colibri.Rules{
+			URL:    mustNewURL("https://example.test" + path),
+			Header: http.Header{"User-Agent": []string{userAgent}},
+		}
+	}
+
+	sitemaps, err := robots.Sitemaps(c, newRules("/", "test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"https://example.test/sitemap.xml", "https://example.test/news.xml"}; !reflect.DeepEqual(sitemaps, want) {
+		t.Fatalf(prefixGotWantFormat, "Sitemaps", sitemaps, want)
+	}
+
+	group, err := robots.Group(c, newRules("/", "colibri/0.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantGroup := &colibri.RobotsGroup{
+		Agent:      "Colibri",
+		Allow:      []string{"/private/public"},
+		Disallow:   []string{"/private"},
+		CrawlDelay: 1500 * time.Millisecond,
+	}
+	if !reflect.DeepEqual(group, wantGroup) {
+		t.Fatalf(prefixGotWantFormat, "Group", group, wantGroup)
+	}
+
+	tests := []struct {
+		Path      string
+		UserAgent string
+		Agent     string
+		Directive string
+	}{
+		{"/private/a", "test", "*", "Disallow: /private"},
+		{"/private/docs/a.pdf", "test", "*", "Disallow: /private/docs/*.pdf"},
+		{"/private/a", "other", "other", "Disallow: /private"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.UserAgent+tt.Path, func(t *testing.T) {
+			err := robots.IsAllowed(c, newRules(tt.Path, tt.UserAgent))
+
+			var robotsErr *colibri.RobotsError
+			if !errors.As(err, &robotsErr) || !errors.Is(err, colibri.ErrRobotstxtRestriction) {
+				t.Fatalf(gotWantFormat, err, colibri.ErrRobotstxtRestriction)
+			}
+
+			if robotsErr.Agent != tt.Agent {
+				t.Fatalf(prefixGotWantFormat, "Agent", robotsErr.Agent, tt.Agent)
+			}
+
+			if robotsErr.Directive != tt.Directive {
+				t.Fatalf(prefixGotWantFormat, "Directive", robotsErr.Directive, tt.Directive)
+			}
+		})
+	}
+
+	if err := robots.IsAllowed(c, newRules("/private/public", "colibri")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRobotsFetch(t *testing.T) {
+	var (
+		status    atomic.Int64
+		slow      atomic.Bool
+		userAgent atomic.Value
+	)
+	status.Store(http.StatusOK)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != robotsTxtPath {
+			w.Write([]byte("ok"))
+			return
+		}
+
+		userAgent.Store(r.Header.Get("User-Agent"))
+		if slow.Load() {
+			time.Sleep(200 * time.Millisecond)
+		}
+
+		w.WriteHeader(int(status.Load()))
+		w.Write([]byte("User-agent: *\nDisallow: /a\n# " + strings.Repeat("-", 64) + "\nDisallow: /b\n"))
+	}))
+	defer ts.Close()
+
+	we, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	we.Delay = nil // Deactivate Delay
+
+	robots := we.RobotsTxt.(*RobotsData)
+	robots.Fetch = &RobotsFetch{
+		Header:      http.Header{"User-Agent": []string{"robots/0.1"}},
+		Timeout:     100 * time.Millisecond,
+		MaxBodySize: 32,
+	}
+
+	do := func(path string) error {
+		resp, err := we.Do(&colibri.Rules{
+			Method:  "GET",
+			URL:     mustNewURL(ts.URL + path),
+			Header:  http.Header{"User-Agent": []string{"test/0.1"}},
+			Timeout: time.Minute,
+		})
+		if err == nil {
+			resp.Body().Close()
+		}
+		return err
+	}
+
+	t.Run("MaxBodySize", func(t *testing.T) {
+		if err := do("/a"); !errors.Is(err, colibri.ErrRobotstxtRestriction) {
+			t.Fatalf(gotWantFormat, err, colibri.ErrRobotstxtRestriction)
+		}
+
+		// The directive exceeds the body size.
+		if err := do("/b"); err != nil {
+			t.Fatal(err)
+		}
+
+		if ua := userAgent.Load(); ua != "robots/0.1" {
+			t.Fatalf(prefixGotWantFormat, "User-Agent", ua, "robots/0.1")
+		}
+	})
+
+	t.Run("ServerError", func(t *testing.T) {
+		robots.Clear()
+		status.Store(http.StatusServiceUnavailable)
+
+		if err := do("/c"); !errors.Is(err, colibri.ErrRobotstxtRestriction) {
+			t.Fatalf(gotWantFormat, err, colibri.ErrRobotstxtRestriction)
+		}
+
+		robots.Clear()
+		robots.Fetch.AllowOnServerError = true
+
+		if err := do("/c"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		robots.Clear()
+		slow.Store(true)
+
+		if err := do("/c"); colibri.ErrorCode(err) != colibri.CodeTimeout {
+			t.Fatalf(gotWantFormat, err, colibri.CodeTimeout)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package webextractor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+)
+
+// ErrS3Status is returned when the object storage responds with a status code other than 2xx.
+var ErrS3Status = errors.New("unexpected S3 status code")
+
+// S3Storage stores the content in the bucket of an S3-compatible object storage.
+// The requests are signed with AWS Signature Version 4, see SigV4, and use path-style URLs
+// (e.g. https://s3.us-east-1.amazonaws.com/bucket/name).
+// See the Storage interface.
+type S3Storage struct {
+	// Endpoint specifies the URL of the object storage.
+	Endpoint string
+
+	// Bucket specifies the name of the bucket.
+	Bucket string
+
+	// Region specifies the region of the bucket.
+	Region string
+
+	// AccessKeyID and SecretAccessKey are the credentials used to sign the requests.
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// SessionToken specifies the token of temporary credentials, it is optional.
+	SessionToken string
+
+	// Prefix is added to the name of the objects.
+	Prefix string
+
+	// Client specifies the HTTP client, if it is nil http.DefaultClient is used.
+	Client *http.Client
+}
+
+// NewS3Storage returns a new S3Storage structure that stores the content in the bucket.
+func NewS3Storage(endpoint, region, bucket, accessKeyID, secretAccessKey string) *S3Storage {
+	return &S3Storage{
+		Endpoint:        endpoint,
+		Bucket:          bucket,
+		Region:          region,
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+	}
+}
+
+// Store uploads the content as the object with the name and returns the URL of the object.
+// The Content-Type of the object is obtained from the extension of the name.
+func (s3 *S3Storage) Store(name string, r io.Reader) (string, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(s3.Endpoint)
+	if err != nil {
+		return "", err
+	}
+	u = u.JoinPath(s3.Bucket, s3.Prefix+name)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPut, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	signer := &SigV4{
+		AccessKeyID:     s3.AccessKeyID,
+		SecretAccessKey: s3.SecretAccessKey,
+		SessionToken:    s3.SessionToken,
+		Region:          s3.Region,
+		Service:         "s3",
+	}
+
+	if err := signer.Sign(req); err != nil {
+		return "", err
+	}
+
+	client := s3.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	if (resp.StatusCode < 200) || (resp.StatusCode > 299) {
+		return "", fmt.Errorf("%w: %d", ErrS3Status, resp.StatusCode)
+	}
+	return u.String(), nil
+}
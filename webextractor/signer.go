@@ -0,0 +1,295 @@
+package webextractor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultHMACHeader default header of the signature of the HMAC signer.
+	DefaultHMACHeader = "X-Signature"
+
+	// DefaultHMACTimestampHeader default header of the timestamp of the HMAC signer.
+	DefaultHMACTimestampHeader = "X-Timestamp"
+
+	// unsignedPayload is the payload hash of SigV4 when the body cannot be read again.
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// Signer signs the requests of the Client.
+type Signer interface {
+	// Sign signs the request, it is called just before sending each request,
+	// after the authenticators, see the Authenticator interface.
+	Sign(req *http.Request) error
+}
+
+// SigV4 signs the requests with AWS Signature Version 4.
+// See the Signer interface.
+type SigV4 struct {
+	// AccessKeyID and SecretAccessKey are the credentials used to sign the requests.
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// SessionToken specifies the token of temporary credentials, it is optional.
+	SessionToken string
+
+	// Region and Service specify the scope of the signature (e.g. "us-east-1" and "s3").
+	Region  string
+	Service string
+
+	// Hosts specifies the hosts of the requests that are signed.
+	// If it is empty, all requests are signed.
+	Hosts []string
+}
+
+// NewSigV4 returns a new SigV4 structure that signs the requests for the service in the region.
+func NewSigV4(accessKeyID, secretAccessKey, region, service string) *SigV4 {
+	return &SigV4{
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+		Region:          region,
+		Service:         service,
+	}
+}
+
+func (s *SigV4) Sign(req *http.Request) error {
+	if !matchHost(s.Hosts, req.URL) {
+		return nil
+	}
+
+	payloadHash := unsignedPayload
+	if body, ok, err := requestBody(req); err != nil {
+		return err
+	} else if ok {
+		sum := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(sum[:])
+	}
+
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	signV4(req, payloadHash, s.AccessKeyID, s.SecretAccessKey, s.Region, s.Service, time.Now())
+	return nil
+}
+
+// HMAC signs the requests with a keyed-hash message authentication code.
+// The signed message is composed of the method, the request URI, the timestamp and the
+// hex-encoded SHA-256 hash of the body separated by newlines:
+//
+//	GET\n/path?query\n1700000000\ne3b0c442...
+//
+// The timestamp is the Unix time in seconds, it is sent in the TimestampHeader
+// and the hex-encoded signature in the Header. See the Signer interface.
+type HMAC struct {
+	// Key specifies the secret key.
+	Key []byte
+
+	// Hash specifies the hash function, if it is nil SHA-256 is used.
+	Hash func() hash.Hash
+
+	// Header specifies the header of the signature, if it is empty DefaultHMACHeader is used.
+	Header string
+
+	// TimestampHeader specifies the header of the timestamp,
+	// if it is empty DefaultHMACTimestampHeader is used.
+	TimestampHeader string
+
+	// Hosts specifies the hosts of the requests that are signed.
+	// If it is empty, all requests are signed.
+	Hosts []string
+}
+
+// NewHMAC returns a new HMAC structure that signs the requests with the key.
+func NewHMAC(key []byte) *HMAC {
+	return &HMAC{Key: key}
+}
+
+func (s *HMAC) Sign(req *http.Request) error {
+	if !matchHost(s.Hosts, req.URL) {
+		return nil
+	}
+
+	body, _, err := requestBody(req)
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(firstNonEmpty(s.TimestampHeader, DefaultHMACTimestampHeader), timestamp)
+	req.Header.Set(firstNonEmpty(s.Header, DefaultHMACHeader), s.Signature(req.Method, req.URL.RequestURI(), timestamp, body))
+	return nil
+}
+
+// Signature returns the hex-encoded signature of the message of a request.
+func (s *HMAC) Signature(method, requestURI, timestamp string, body []byte) string {
+	h := s.Hash
+	if h == nil {
+		h = sha256.New
+	}
+
+	sum := sha256.Sum256(body)
+	mac := hmac.New(h, s.Key)
+	mac.Write([]byte(method + "\n" + requestURI + "\n" + timestamp + "\n" + hex.EncodeToString(sum[:])))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// requestBody returns the body of the request without consuming it.
+// Returns false if the body cannot be read again.
+func requestBody(req *http.Request) ([]byte, bool, error) {
+	if (req.Body == nil) || (req.Body == http.NoBody) {
+		return nil, true, nil
+	}
+
+	if req.GetBody == nil {
+		return nil, false, nil
+	}
+
+	rc, err := req.GetBody()
+	if err != nil {
+		return nil, false, err
+	}
+	defer rc.Close()
+
+	body, err := io.ReadAll(rc)
+	return body, err == nil, err
+}
+
+// matchHost returns true if hosts is empty or contains the host of the URL.
+func matchHost(hosts []string, u *url.URL) bool {
+	if len(hosts) == 0 {
+		return true
+	}
+
+	for _, host := range hosts {
+		if strings.EqualFold(host, u.Host) || strings.EqualFold(host, u.Hostname()) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// signV4 signs the request with AWS Signature Version 4.
+// All the headers of the request and the Host header are signed.
+func signV4(req *http.Request, payloadHash, accessKeyID, secretAccessKey, region, service string, t time.Time) {
+	t = t.UTC()
+	var (
+		amzDate = t.Format("20060102T150405Z")
+		date    = t.Format("20060102")
+		scope   = date + "/" + region + "/" + service + "/aws4_request"
+	)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	headers := map[string]string{"host": host}
+	for key, values := range req.Header {
+		key = strings.ToLower(key)
+		if key == "authorization" {
+			continue
+		}
+
+		trimmed := make([]string, len(values))
+		for i, value := range values {
+			trimmed[i] = strings.Join(strings.Fields(value), " ")
+		}
+		headers[key] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalPath := uriEncode(req.URL.Path, false)
+	if canonicalPath == "" {
+		canonicalPath = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalPath,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery returns the query sorted by key and value with the keys and values URI-encoded.
+func canonicalQuery(query url.Values) string {
+	var params []string
+	for key, values := range query {
+		for _, value := range values {
+			params = append(params, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// uriEncode encodes all the characters except the unreserved ones,
+// the slashes are encoded only if encodeSlash is true.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9'),
+			c == '-', c == '_', c == '.', c == '~', (c == '/') && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
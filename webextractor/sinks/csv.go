@@ -0,0 +1,115 @@
+package sinks
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/gonzxlez/colibri"
+)
+
+// WriteCSV writes the flattened rows of the outputs as CSV, see the Flatten method of colibri.Output.
+// The first record is the header with the paths of the values sorted alphabetically.
+// Maps and slices are written as JSON text.
+func WriteCSV(w io.Writer, outputs []*colibri.Output) error {
+	var rows []map[string]any
+	for _, output := range outputs {
+		if output != nil {
+			rows = append(rows, output.Flatten()...)
+		}
+	}
+	return writeCSV(w, rows)
+}
+
+// CSV stores the flattened rows of the outputs and writes them as CSV when it is closed,
+// since the header is not known until all the outputs have been extracted.
+// The outputs with errors are not written. See the WriteCSV function and the colibri.Sink interface.
+type CSV struct {
+	// W specifies the writer of the CSV.
+	W io.Writer
+
+	mu   sync.Mutex
+	rows []map[string]any
+}
+
+// NewCSV returns a new CSV structure that writes the outputs to w.
+func NewCSV(w io.Writer) *CSV {
+	return &CSV{W: w}
+}
+
+func (c *CSV) Write(_ *colibri.Rules, output *colibri.Output, err error) error {
+	if (err != nil) || (output == nil) {
+		return nil
+	}
+
+	rows := output.Flatten()
+
+	c.mu.Lock()
+	c.rows = append(c.rows, rows...)
+	c.mu.Unlock()
+	return nil
+}
+
+// Close writes the CSV and flushes the writer, the writer is not closed.
+func (c *CSV) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	err := writeCSV(c.W, c.rows)
+	c.rows = nil
+
+	if err != nil {
+		return err
+	}
+	return flushWriter(c.W)
+}
+
+func writeCSV(w io.Writer, rows []map[string]any) error {
+	columns := make(map[string]bool)
+	for _, row := range rows {
+		for column := range row {
+			columns[column] = true
+		}
+	}
+
+	header := make([]string, 0, len(columns))
+	for column := range columns {
+		header = append(header, column)
+	}
+	sort.Strings(header)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(header))
+	for _, row := range rows {
+		for i, column := range header {
+			record[i] = csvValue(row[column])
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]any, []any:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,184 @@
+package sinks
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gonzxlez/colibri"
+	"github.com/gonzxlez/colibri/webextractor"
+)
+
+func TestCSV(t *testing.T) {
+	rules, output := testOutput()
+	output.Data = map[string]any{
+		"title": "Example, Domain",
+		"products": []any{
+			map[string]any{"name": "A", "price": 10.5},
+			map[string]any{"name": "B", "price": 2},
+		},
+	}
+
+	want := "products.name,products.price,title\n" +
+		"A,10.5,\"Example, Domain\"\n" +
+		"B,2,\"Example, Domain\"\n" +
+		",,Other\n"
+
+	other := &colibri.Output{Data: map[string]any{"title": "Other"}}
+
+	t.Run("WriteCSV", func(t *testing.T) {
+		var b strings.Builder
+		if err := WriteCSV(&b, []*colibri.Output{output, nil, other}); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := b.String(); got != want {
+			t.Fatalf(gotWantFormat, got, want)
+		}
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		var b strings.Builder
+		c := NewCSV(&b)
+
+		if err := c.Write(rules, output, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := c.Write(rules, nil, errors.New("test err")); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := c.Write(rules, other, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := b.String(); got != want {
+			t.Fatalf(gotWantFormat, got, want)
+		}
+	})
+}
+
+func TestCSVFollowed(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<html><head><title>Index</title></head><body><a href="/a">A</a><a href="/b">B</a></body></html>`))
+		default:
+			w.Write([]byte(`<html><head><title>Page ` + r.URL.Path[1:] + `</title></head></html>`))
+		}
+	}))
+	defer ts.Close()
+
+	we, err := webextractor.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	we.Delay = nil     // Deactivate Delay
+	we.RobotsTxt = nil // Deactivate RobotsTxt
+
+	var rules colibri.Rules
+	err = rules.UnmarshalJSON([]byte(`{
+		"URL": "` + ts.URL + `",
+		"Selectors": {
+			"title": "//title",
+			"pages": {
+				"Expr": "//a/@href",
+				"All": true,
+				"Follow": true,
+				"Selectors": {"title": "//title"}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := we.Extract(&rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The followed outputs contain their metadata.
+	followed := output.Data["pages"].([]any)[0].(map[string]any)
+	if _, ok := followed["meta"]; !ok {
+		t.Fatalf(gotWantFormat, followed, "the metadata of the output")
+	}
+
+	var b strings.Builder
+	if err := WriteCSV(&b, []*colibri.Output{output}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "pages.title,title\n" +
+		"Page a,Index\n" +
+		"Page b,Index\n"
+
+	if got := b.String(); got != want {
+		t.Fatalf(gotWantFormat, got, want)
+	}
+}
+
+// ownedWriter is a writer owned by the caller of the sinks.
+type ownedWriter struct {
+	strings.Builder
+	flushed, closed bool
+}
+
+func (w *ownedWriter) Flush() error {
+	w.flushed = true
+	return nil
+}
+
+func (w *ownedWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestCloseWriter(t *testing.T) {
+	rules, output := testOutput()
+
+	newTemplate := func(w *ownedWriter) colibri.Sink {
+		tmpl, _ := NewTemplate(w, "{{.URL}}\n")
+		return tmpl
+	}
+
+	tests := []struct {
+		Name string
+		New  func(w *ownedWriter) colibri.Sink
+	}{
+		{"CSV", func(w *ownedWriter) colibri.Sink { return NewCSV(w) }},
+		{"Parquet", func(w *ownedWriter) colibri.Sink { return NewParquet(w) }},
+		{"Template", newTemplate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			w := &ownedWriter{}
+			sink := tt.New(w)
+
+			if err := sink.Write(rules, output, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := sink.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if !w.flushed {
+				t.Fatal("writer not flushed")
+			} else if w.closed {
+				t.Fatal("writer closed")
+			} else if w.Len() == 0 {
+				t.Fatal("nothing written")
+			}
+		})
+	}
+}
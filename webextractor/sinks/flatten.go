@@ -0,0 +1,11 @@
+package sinks
+
+import "github.com/gonzxlez/colibri"
+
+// Flatten returns the nested maps of data as a single map whose keys
+// are the keys of each level joined by sep (e.g. "product_price").
+// The rest of the values, including slices, are not modified.
+// See the colibri.FlattenMap function.
+func Flatten(data map[string]any, sep string) map[string]any {
+	return colibri.FlattenMap(data, sep)
+}
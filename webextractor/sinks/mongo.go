@@ -0,0 +1,163 @@
+package sinks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/gonzxlez/colibri"
+)
+
+// ErrCollectionIsEmpty is returned when the name of the collection is empty.
+var ErrCollectionIsEmpty = errors.New("collection is empty")
+
+// Collections inserts documents into the collections of a document database such as MongoDB.
+// The MongoDB driver can be adapted to this interface with the InsertMany method of a Collection.
+type Collections interface {
+	// Insert inserts the documents into the collection.
+	Insert(ctx context.Context, collection string, documents []map[string]any) error
+}
+
+// Mongo inserts each output as a document with the fields "url", "response", "data" and "error".
+//
+// The outputs of the URLs followed by the selectors are embedded in the document or,
+// if FollowCollection is not empty, inserted into that collection with a "parent" field
+// that contains the URL of the document in which they were found.
+// The embedded outputs are replaced by their URL.
+// See the colibri.Sink interface.
+type Mongo struct {
+	// Collections specifies the database.
+	Collections Collections
+
+	// Collection specifies the name of the collection of the outputs.
+	Collection string
+
+	// FollowCollection specifies the name of the collection of the followed outputs.
+	// If it is empty, the followed outputs are embedded in the documents.
+	FollowCollection string
+}
+
+// NewMongo returns a new Mongo structure that inserts the outputs into the collection.
+func NewMongo(collections Collections, collection string) *Mongo {
+	return &Mongo{Collections: collections, Collection: collection}
+}
+
+func (mongo *Mongo) Write(rules *colibri.Rules, output *colibri.Output, err error) error {
+	if mongo.Collection == "" {
+		return ErrCollectionIsEmpty
+	}
+
+	var (
+		record   = NewRecord(rules, output, err)
+		document = map[string]any{"url": record.URL}
+		followed []map[string]any
+	)
+
+	if record.Response != nil {
+		document["response"] = record.Response
+	}
+
+	if record.Data != nil {
+		if mongo.FollowCollection == "" {
+			document["data"] = record.Data
+		} else {
+			document["data"] = extractFollowed(record.URL, record.Data, &followed)
+		}
+	}
+
+	if record.Error != nil {
+		document["error"] = errorValue(record.Error)
+	}
+
+	ctx := context.Background()
+	if rules != nil {
+		ctx = rules.Context()
+	}
+
+	if err := mongo.Collections.Insert(ctx, mongo.Collection, []map[string]any{document}); err != nil {
+		return err
+	}
+
+	if len(followed) == 0 {
+		return nil
+	}
+	return mongo.Collections.Insert(ctx, mongo.FollowCollection, followed)
+}
+
+// Close does nothing, the database is not closed since it is not owned by the sink.
+func (mongo *Mongo) Close() error {
+	return nil
+}
+
+// extractFollowed returns a copy of the value in which the followed outputs are replaced by their URL,
+// the followed outputs are appended to the documents with their URL and the parent URL.
+func extractFollowed(parent string, value any, documents *[]map[string]any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		if u, ok := followedURL(v); ok {
+			document := make(map[string]any, len(v)+2)
+			for key, value := range v {
+				document[key] = value
+			}
+			document["url"] = u
+			document["parent"] = parent
+
+			if data, ok := v["data"].(map[string]any); ok {
+				document["data"] = extractFollowed(u, data, documents)
+			}
+
+			*documents = append(*documents, document)
+			return u
+		}
+
+		result := make(map[string]any, len(v))
+		for key, value := range v {
+			result[key] = extractFollowed(parent, value, documents)
+		}
+		return result
+
+	case []any:
+		result := make([]any, len(v))
+		for i, value := range v {
+			result[i] = extractFollowed(parent, value, documents)
+		}
+		return result
+	}
+	return value
+}
+
+// followedURL returns the URL of the value if it is a followed output,
+// see the Serializable method of colibri.Output. The outputs are recognized by
+// the URL of their response and their data, the rest of their keys, e.g. meta, are optional.
+func followedURL(value map[string]any) (string, bool) {
+	if _, ok := value["data"]; !ok {
+		return "", false
+	}
+
+	response, ok := value["response"].(map[string]any)
+	if !ok {
+		return "", false
+	}
+
+	u, ok := response["url"].(string)
+	return u, ok
+}
+
+// errorValue returns the error of the record as a value that does not depend on the json.Marshaler interface.
+func errorValue(value any) any {
+	m, ok := value.(json.Marshaler)
+	if !ok {
+		return value
+	}
+
+	b, err := m.MarshalJSON()
+	if err != nil {
+		return nil
+	}
+
+	var result any
+	if err := json.Unmarshal(b, &result); err != nil {
+		return nil
+	}
+	return result
+}
@@ -0,0 +1,113 @@
+package sinks
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testCollections map[string][]map[string]any
+
+func (collections testCollections) Insert(_ context.Context, collection string, documents []map[string]any) error {
+	collections[collection] = append(collections[collection], documents...)
+	return nil
+}
+
+func TestMongo(t *testing.T) {
+	rules, output := testOutput()
+	output.Data = map[string]any{
+		"title": "Example Domain",
+		"links": []any{
+			map[string]any{
+				"response": map[string]any{"url": "https://example.com/a"},
+				"data": map[string]any{
+					"next": []any{
+						map[string]any{
+							"response": map[string]any{"url": "https://example.com/b"},
+							"data":     map[string]any{"title": "B"},
+							"meta":     map[string]any{"attempts": 1},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("Embedded", func(t *testing.T) {
+		collections := testCollections{}
+		mongo := NewMongo(collections, "outputs")
+
+		if err := mongo.Write(rules, output, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := mongo.Write(rules, nil, errors.New("test err")); err != nil {
+			t.Fatal(err)
+		}
+
+		want := testCollections{
+			"outputs": {
+				{"url": "https://example.com", "response": map[string]any{"code": 200}, "data": output.Data},
+				{"url": "https://example.com", "error": "test err"},
+			},
+		}
+
+		if !reflect.DeepEqual(collections, want) {
+			t.Fatalf(gotWantFormat, collections, want)
+		}
+	})
+
+	t.Run("FollowCollection", func(t *testing.T) {
+		collections := testCollections{}
+		mongo := NewMongo(collections, "outputs")
+		mongo.FollowCollection = "followed"
+
+		if err := mongo.Write(rules, output, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		want := testCollections{
+			"outputs": {
+				{
+					"url":      "https://example.com",
+					"response": map[string]any{"code": 200},
+					"data": map[string]any{
+						"title": "Example Domain",
+						"links": []any{"https://example.com/a"},
+					},
+				},
+			},
+			"followed": {
+				{
+					"url":      "https://example.com/b",
+					"parent":   "https://example.com/a",
+					"response": map[string]any{"url": "https://example.com/b"},
+					"data":     map[string]any{"title": "B"},
+					"meta":     map[string]any{"attempts": 1},
+				},
+				{
+					"url":      "https://example.com/a",
+					"parent":   "https://example.com",
+					"response": map[string]any{"url": "https://example.com/a"},
+					"data":     map[string]any{"next": []any{"https://example.com/b"}},
+				},
+			},
+		}
+
+		if !reflect.DeepEqual(collections, want) {
+			t.Fatalf(gotWantFormat, collections, want)
+		}
+
+		if _, ok := output.Data["links"].([]any)[0].(map[string]any); !ok {
+			t.Fatal("output data modified")
+		}
+	})
+
+	t.Run("ErrCollectionIsEmpty", func(t *testing.T) {
+		err := NewMongo(testCollections{}, "").Write(rules, output, nil)
+		if !errors.Is(err, ErrCollectionIsEmpty) {
+			t.Fatalf(gotWantFormat, err, ErrCollectionIsEmpty)
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package sinks
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/gonzxlez/colibri"
+	"github.com/gonzxlez/colibri/webextractor"
+)
+
+const (
+	// DefaultObjectKey default template of the object keys of the outputs.
+	DefaultObjectKey = "{{.Host}}/{{.Date}}/{{.Hash}}.json"
+
+	// DefaultBatchKey default template of the object keys of the batches.
+	DefaultBatchKey = "{{.Date}}/{{.Hash}}.ndjson"
+)
+
+// ObjectKey contains the data available in the templates of the object keys.
+type ObjectKey struct {
+	// Host of the URL of the rules,
+	// in a batch it is the host of the first output.
+	Host string
+
+	// Date in which the object is stored in the format "2006-01-02".
+	Date string
+
+	// Time in which the object is stored.
+	Time time.Time
+
+	// Hash is the hex-encoded SHA-256 hash of the content of the object.
+	Hash string
+}
+
+// Objects stores the outputs as JSON Records in a webextractor.Storage,
+// such as webextractor.S3Storage. Each output is stored in its own object or,
+// if BatchSize is greater than 1, the outputs are stored in batches of NDJSON objects.
+// See the colibri.Sink interface.
+type Objects struct {
+	// Storage specifies where the objects are stored.
+	Storage webextractor.Storage
+
+	// Key specifies the text/template of the object keys, see the ObjectKey structure.
+	// If it is empty, DefaultObjectKey or DefaultBatchKey is used.
+	Key string
+
+	// BatchSize specifies the number of outputs stored in each object.
+	// The last batch is stored by Close.
+	BatchSize int
+
+	mu       sync.Mutex
+	tmpl     *template.Template
+	batch    bytes.Buffer
+	batchLen int
+	host     string
+}
+
+// NewObjects returns a new Objects structure that stores the outputs in storage.
+func NewObjects(storage webextractor.Storage) *Objects {
+	return &Objects{Storage: storage}
+}
+
+func (objects *Objects) Write(rules *colibri.Rules, output *colibri.Output, err error) error {
+	record := NewRecord(rules, output, err)
+
+	b, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	var host string
+	if (rules != nil) && (rules.URL != nil) {
+		host = rules.URL.Host
+	}
+
+	objects.mu.Lock()
+	defer objects.mu.Unlock()
+
+	if objects.BatchSize <= 1 {
+		return objects.store(host, b)
+	}
+
+	if objects.batchLen == 0 {
+		objects.host = host
+	}
+
+	objects.batch.Write(b)
+	objects.batch.WriteByte('\n')
+	objects.batchLen++
+
+	if objects.batchLen < objects.BatchSize {
+		return nil
+	}
+	return objects.flush()
+}
+
+// Flush stores the outputs of the current batch.
+// See the colibri.Flusher interface.
+func (objects *Objects) Flush() error {
+	objects.mu.Lock()
+	defer objects.mu.Unlock()
+
+	if objects.batchLen == 0 {
+		return nil
+	}
+	return objects.flush()
+}
+
+// Close stores the outputs of the last batch.
+func (objects *Objects) Close() error {
+	return objects.Flush()
+}
+
+// flush stores the batch and resets it.
+func (objects *Objects) flush() error {
+	err := objects.store(objects.host, objects.batch.Bytes())
+
+	objects.batch.Reset()
+	objects.batchLen = 0
+	objects.host = ""
+	return err
+}
+
+// store stores the content in the object with the key of the template.
+func (objects *Objects) store(host string, content []byte) error {
+	if objects.tmpl == nil {
+		text := objects.Key
+		if text == "" {
+			text = DefaultObjectKey
+			if objects.BatchSize > 1 {
+				text = DefaultBatchKey
+			}
+		}
+
+		tmpl, err := template.New("key").Parse(text)
+		if err != nil {
+			return err
+		}
+		objects.tmpl = tmpl
+	}
+
+	var (
+		now = time.Now().UTC()
+		sum = sha256.Sum256(content)
+		key strings.Builder
+	)
+
+	err := objects.tmpl.Execute(&key, &ObjectKey{
+		Host: host,
+		Date: now.Format(time.DateOnly),
+		Time: now,
+		Hash: hex.EncodeToString(sum[:]),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = objects.Storage.Store(key.String(), bytes.NewReader(content))
+	return err
+}
@@ -0,0 +1,121 @@
+package sinks
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type testStorage struct {
+	mu      sync.Mutex
+	objects map[string]string
+}
+
+func (storage *testStorage) Store(name string, r io.Reader) (string, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if storage.objects == nil {
+		storage.objects = make(map[string]string)
+	}
+	storage.objects[name] = string(b)
+	return name, nil
+}
+
+func TestObjects(t *testing.T) {
+	rules, output := testOutput()
+
+	t.Run("Objects", func(t *testing.T) {
+		storage := &testStorage{}
+		objects := NewObjects(storage)
+
+		if err := objects.Write(rules, output, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := objects.Write(rules, nil, errors.New("test err")); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := objects.Flush(); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := objects.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(storage.objects) != 2 {
+			t.Fatalf(prefixGotWantFormat, "Objects", len(storage.objects), 2)
+		}
+
+		for key, content := range storage.objects {
+			if !strings.HasPrefix(key, "example.com/") || !strings.HasSuffix(key, ".json") {
+				t.Fatalf(prefixGotWantFormat, "Key", key, "example.com/<date>/<hash>.json")
+			}
+
+			var record map[string]any
+			if err := json.Unmarshal([]byte(content), &record); err != nil {
+				t.Fatal(err)
+			}
+
+			if record["url"] != "https://example.com" {
+				t.Fatalf(prefixGotWantFormat, "URL", record["url"], "https://example.com")
+			}
+		}
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		storage := &testStorage{}
+		objects := NewObjects(storage)
+		objects.BatchSize = 2
+		objects.Key = "{{.Host}}/{{.Hash}}.ndjson"
+
+		for i := 0; i < 3; i++ {
+			if err := objects.Write(rules, output, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if len(storage.objects) != 1 {
+			t.Fatalf(prefixGotWantFormat, "Objects before Close", len(storage.objects), 1)
+		}
+
+		if err := objects.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(storage.objects) != 2 {
+			t.Fatalf(prefixGotWantFormat, "Objects", len(storage.objects), 2)
+		}
+
+		var lines int
+		for key, content := range storage.objects {
+			if !strings.HasPrefix(key, "example.com/") || !strings.HasSuffix(key, ".ndjson") {
+				t.Fatalf(prefixGotWantFormat, "Key", key, "example.com/<hash>.ndjson")
+			}
+			lines += strings.Count(content, "\n")
+		}
+
+		if lines != 3 {
+			t.Fatalf(prefixGotWantFormat, "Lines", lines, 3)
+		}
+	})
+
+	t.Run("InvalidKey", func(t *testing.T) {
+		objects := NewObjects(&testStorage{})
+		objects.Key = "{{.Host"
+
+		if err := objects.Write(rules, output, nil); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
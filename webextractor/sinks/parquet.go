@@ -0,0 +1,219 @@
+package sinks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/gonzxlez/colibri"
+	"github.com/parquet-go/parquet-go"
+)
+
+// WriteParquet writes the flattened rows of the outputs as a Parquet file,
+// see the Flatten method of colibri.Output.
+//
+// The schema is inferred from the values: the columns whose values are all booleans,
+// integers or numbers are stored as BOOLEAN, INT64 or DOUBLE and the rest as UTF-8 strings,
+// in which maps and slices are written as JSON text. All columns are optional.
+// The file is compressed with Snappy.
+func WriteParquet(w io.Writer, outputs []*colibri.Output) error {
+	var rows []map[string]any
+	for _, output := range outputs {
+		if output != nil {
+			rows = append(rows, output.Flatten()...)
+		}
+	}
+	return writeParquet(w, rows)
+}
+
+// Parquet stores the flattened rows of the outputs and writes them as a Parquet file
+// when it is closed, since the schema is not known until all the outputs have been extracted.
+// The outputs with errors are not written. See the WriteParquet function and the colibri.Sink interface.
+type Parquet struct {
+	// W specifies the writer of the Parquet file.
+	W io.Writer
+
+	mu   sync.Mutex
+	rows []map[string]any
+}
+
+// NewParquet returns a new Parquet structure that writes the outputs to w.
+func NewParquet(w io.Writer) *Parquet {
+	return &Parquet{W: w}
+}
+
+func (p *Parquet) Write(_ *colibri.Rules, output *colibri.Output, err error) error {
+	if (err != nil) || (output == nil) {
+		return nil
+	}
+
+	rows := output.Flatten()
+
+	p.mu.Lock()
+	p.rows = append(p.rows, rows...)
+	p.mu.Unlock()
+	return nil
+}
+
+// Close writes the Parquet file and flushes the writer, the writer is not closed.
+func (p *Parquet) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	err := writeParquet(p.W, p.rows)
+	p.rows = nil
+
+	if err != nil {
+		return err
+	}
+	return flushWriter(p.W)
+}
+
+// parquetKind is the inferred type of a column.
+type parquetKind int
+
+const (
+	parquetNull parquetKind = iota
+	parquetBoolean
+	parquetInt64
+	parquetDouble
+	parquetString
+)
+
+func writeParquet(w io.Writer, rows []map[string]any) error {
+	kinds := make(map[string]parquetKind)
+	for _, row := range rows {
+		for column, value := range row {
+			kinds[column] = mergeKind(kinds[column], kindOf(value))
+		}
+	}
+
+	columns := make([]string, 0, len(kinds))
+	for column := range kinds {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	group := make(parquet.Group, len(columns))
+	for _, column := range columns {
+		switch kinds[column] {
+		case parquetBoolean:
+			group[column] = parquet.Optional(parquet.Leaf(parquet.BooleanType))
+		case parquetInt64:
+			group[column] = parquet.Optional(parquet.Int(64))
+		case parquetDouble:
+			group[column] = parquet.Optional(parquet.Leaf(parquet.DoubleType))
+		default:
+			group[column] = parquet.Optional(parquet.String())
+		}
+	}
+
+	pw := parquet.NewWriter(w, parquet.NewSchema("output", group), parquet.Compression(&parquet.Snappy))
+
+	buf := make([]parquet.Row, 0, len(rows))
+	for _, row := range rows {
+		prow := make(parquet.Row, len(columns))
+		for i, column := range columns {
+			value, ok := row[column]
+			if !ok || (value == nil) {
+				prow[i] = parquet.Value{}.Level(0, 0, i)
+				continue
+			}
+			prow[i] = parquetValue(kinds[column], value).Level(0, 1, i)
+		}
+		buf = append(buf, prow)
+	}
+
+	if _, err := pw.WriteRows(buf); err != nil {
+		return err
+	}
+	return pw.Close()
+}
+
+// kindOf returns the type of the value.
+func kindOf(value any) parquetKind {
+	switch value.(type) {
+	case nil:
+		return parquetNull
+	case bool:
+		return parquetBoolean
+	case int, int8, int16, int32, int64, uint8, uint16, uint32:
+		return parquetInt64
+	case float32, float64:
+		return parquetDouble
+	}
+	return parquetString
+}
+
+// mergeKind returns the type of a column that contains values of both types.
+func mergeKind(a, b parquetKind) parquetKind {
+	switch {
+	case a == b, b == parquetNull:
+		return a
+	case a == parquetNull:
+		return b
+	case (a == parquetInt64 && b == parquetDouble) || (a == parquetDouble && b == parquetInt64):
+		return parquetDouble
+	}
+	return parquetString
+}
+
+// parquetValue returns the value converted to the type of the column.
+func parquetValue(kind parquetKind, value any) parquet.Value {
+	switch kind {
+	case parquetBoolean:
+		return parquet.BooleanValue(value.(bool))
+
+	case parquetInt64:
+		return parquet.Int64Value(toInt64(value))
+
+	case parquetDouble:
+		if kindOf(value) == parquetInt64 {
+			return parquet.DoubleValue(float64(toInt64(value)))
+		}
+		return parquet.DoubleValue(toFloat64(value))
+	}
+
+	switch v := value.(type) {
+	case string:
+		return parquet.ByteArrayValue([]byte(v))
+	case map[string]any, []any:
+		b, _ := json.Marshal(v)
+		return parquet.ByteArrayValue(b)
+	}
+	return parquet.ByteArrayValue([]byte(fmt.Sprint(value)))
+}
+
+func toInt64(value any) int64 {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	}
+	return 0
+}
+
+func toFloat64(value any) float64 {
+	switch v := value.(type) {
+	case float32:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
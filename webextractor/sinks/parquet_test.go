@@ -0,0 +1,123 @@
+package sinks
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/gonzxlez/colibri"
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestParquet(t *testing.T) {
+	rules, output := testOutput()
+	output.Data = map[string]any{
+		"title": "Example Domain",
+		"products": []any{
+			map[string]any{"name": "A", "price": 10.5, "stock": 3, "sale": true},
+			map[string]any{"name": "B", "price": 2, "stock": 1, "sale": false},
+		},
+	}
+	other := &colibri.Output{Data: map[string]any{"title": "Other", "tags": []any{}}}
+
+	wantColumns := map[string]parquet.Kind{
+		"products.name":  parquet.ByteArray,
+		"products.price": parquet.Double,
+		"products.sale":  parquet.Boolean,
+		"products.stock": parquet.Int64,
+		"title":          parquet.ByteArray,
+	}
+
+	wantRows := []map[string]any{
+		{"products.name": "A", "products.price": 10.5, "products.sale": true, "products.stock": int64(3), "title": "Example Domain"},
+		{"products.name": "B", "products.price": 2.0, "products.sale": false, "products.stock": int64(1), "title": "Example Domain"},
+		{"products.name": nil, "products.price": nil, "products.sale": nil, "products.stock": nil, "title": "Other"},
+	}
+
+	check := func(t *testing.T, b []byte) {
+		f, err := parquet.OpenFile(bytes.NewReader(b), int64(len(b)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		columns := make(map[string]parquet.Kind)
+		for _, field := range f.Schema().Fields() {
+			columns[field.Name()] = field.Type().Kind()
+			if !field.Optional() {
+				t.Fatalf(prefixGotWantFormat, field.Name(), "required", "optional")
+			}
+		}
+
+		if !reflect.DeepEqual(columns, wantColumns) {
+			t.Fatalf(prefixGotWantFormat, "Columns", columns, wantColumns)
+		}
+
+		var (
+			fields = f.Schema().Fields()
+			rows   = make([]parquet.Row, 10)
+			got    []map[string]any
+		)
+
+		r := parquet.NewReader(f)
+		n, err := r.ReadRows(rows)
+		if (err != nil) && !errors.Is(err, io.EOF) {
+			t.Fatal(err)
+		}
+
+		for _, row := range rows[:n] {
+			m := make(map[string]any)
+			for _, value := range row {
+				name := fields[value.Column()].Name()
+				switch {
+				case value.IsNull():
+					m[name] = nil
+				case value.Kind() == parquet.ByteArray:
+					m[name] = string(value.ByteArray())
+				case value.Kind() == parquet.Boolean:
+					m[name] = value.Boolean()
+				case value.Kind() == parquet.Int64:
+					m[name] = value.Int64()
+				case value.Kind() == parquet.Double:
+					m[name] = value.Double()
+				}
+			}
+			got = append(got, m)
+		}
+
+		if !reflect.DeepEqual(got, wantRows) {
+			t.Fatalf(prefixGotWantFormat, "Rows", got, wantRows)
+		}
+	}
+
+	t.Run("WriteParquet", func(t *testing.T) {
+		var b bytes.Buffer
+		if err := WriteParquet(&b, []*colibri.Output{output, nil, other}); err != nil {
+			t.Fatal(err)
+		}
+		check(t, b.Bytes())
+	})
+
+	t.Run("Parquet", func(t *testing.T) {
+		var b bytes.Buffer
+		p := NewParquet(&b)
+
+		if err := p.Write(rules, output, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := p.Write(rules, nil, errors.New("test err")); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := p.Write(rules, other, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := p.Close(); err != nil {
+			t.Fatal(err)
+		}
+		check(t, b.Bytes())
+	})
+}
@@ -0,0 +1,58 @@
+// sinks are implementations of the colibri.Sink interface that send the outputs
+// of the extractions to other services.
+package sinks
+
+import (
+	"encoding/json"
+
+	"github.com/gonzxlez/colibri"
+)
+
+// Record is the representation of an output written by the sinks.
+type Record struct {
+	// URL of the rules.
+	URL string `json:"url"`
+
+	// Response contains the serializable value of the response.
+	Response map[string]any `json:"response,omitempty"`
+
+	// Data contains the data extracted by the selectors.
+	Data map[string]any `json:"data,omitempty"`
+
+	// Error contains the error of the extraction.
+	// If the error implements json.Marshaler it is stored as is, otherwise its message is stored.
+	Error any `json:"error,omitempty"`
+}
+
+// NewRecord returns the record of the output or the error of the extraction of the rules.
+func NewRecord(rules *colibri.Rules, output *colibri.Output, err error) *Record {
+	record := &Record{}
+	if (rules != nil) && (rules.URL != nil) {
+		record.URL = rules.URL.String()
+	}
+
+	if output != nil {
+		if output.Response != nil {
+			record.Response = output.Response.Serializable()
+		}
+		record.Data = output.Data
+	}
+
+	if err != nil {
+		if m, ok := err.(json.Marshaler); ok {
+			record.Error = m
+		} else {
+			record.Error = err.Error()
+		}
+	}
+	return record
+}
+
+// flushWriter flushes the writer if it implements colibri.Flusher, e.g. a *bufio.Writer.
+// The writers of the sinks are owned by the caller, so they are not closed.
+func flushWriter(w any) error {
+	if f, ok := w.(colibri.Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
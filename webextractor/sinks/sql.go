@@ -0,0 +1,235 @@
+package sinks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gonzxlez/colibri"
+)
+
+const (
+	// ColumnURL is the name of the column that stores the URL of the rules.
+	ColumnURL = "url"
+
+	// ColumnError is the name of the column that stores the error of the extraction.
+	ColumnError = "error"
+
+	// ColumnSeparator separates the names of the nested selectors in the column names.
+	ColumnSeparator = "_"
+)
+
+// ErrTableIsEmpty is returned when the name of the table is empty.
+var ErrTableIsEmpty = errors.New("table is empty")
+
+// SQL inserts each output as a row of a table through database/sql.
+// The columns are the names of the selectors of the output data, the names of the nested
+// selectors are joined by ColumnSeparator (see Flatten). The row also contains the URL
+// of the rules in ColumnURL and the error of the extraction in ColumnError.
+//
+// Maps and slices are stored as JSON text.
+// See the colibri.Sink interface.
+type SQL struct {
+	// DB specifies the database.
+	DB *sql.DB
+
+	// Table specifies the name of the table.
+	Table string
+
+	// Columns maps the flattened selector names to column names.
+	// The selectors that are not in Columns are stored in a column with the name of the selector,
+	// in which the characters other than letters, digits and underscores are replaced by underscores.
+	Columns map[string]string
+
+	// CreateTable specifies whether the table and the columns that do not exist
+	// are created automatically. The columns are created with the TEXT type.
+	CreateTable bool
+
+	// Placeholder returns the placeholder of the nth parameter of a statement, starting at 1.
+	// If it is nil, "?" is used. For PostgreSQL use DollarPlaceholder.
+	Placeholder func(n int) string
+
+	// Quote returns the quoted identifier.
+	// If it is nil, the identifier is enclosed in double quotes.
+	Quote func(ident string) string
+
+	mu      sync.Mutex
+	columns map[string]bool // existing columns of the table
+}
+
+// NewSQL returns a new SQL structure that inserts the outputs in the table of db.
+func NewSQL(db *sql.DB, table string) *SQL {
+	return &SQL{DB: db, Table: table}
+}
+
+func (s *SQL) Write(rules *colibri.Rules, output *colibri.Output, err error) error {
+	if s.Table == "" {
+		return ErrTableIsEmpty
+	}
+
+	var (
+		record = NewRecord(rules, output, err)
+		row    = make(map[string]any)
+	)
+
+	for name, value := range Flatten(record.Data, ColumnSeparator) {
+		row[s.column(name)] = sqlValue(value)
+	}
+
+	row[ColumnURL] = record.URL
+	row[ColumnError] = nil
+	if record.Error != nil {
+		row[ColumnError] = sqlValue(record.Error)
+	}
+
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	ctx := context.Background()
+	if rules != nil {
+		ctx = rules.Context()
+	}
+
+	if s.CreateTable {
+		if err := s.createTable(ctx, columns); err != nil {
+			return err
+		}
+	}
+
+	var (
+		quoted       = make([]string, len(columns))
+		placeholders = make([]string, len(columns))
+		args         = make([]any, len(columns))
+	)
+	for i, column := range columns {
+		quoted[i] = s.quote(column)
+		placeholders[i] = s.placeholder(i + 1)
+		args[i] = row[column]
+	}
+
+	query := "INSERT INTO " + s.quote(s.Table) +
+		" (" + strings.Join(quoted, ", ") + ") VALUES (" + strings.Join(placeholders, ", ") + ")"
+
+	_, err = s.DB.ExecContext(ctx, query, args...)
+	return err
+}
+
+// Close does nothing, the database is not closed since it is not owned by the sink.
+func (s *SQL) Close() error {
+	return nil
+}
+
+// DollarPlaceholder returns the placeholder of the nth parameter in the "$n" format.
+func DollarPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// createTable creates the table if it does not exist and adds the columns that it does not have.
+func (s *SQL) createTable(ctx context.Context, columns []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.columns == nil {
+		definitions := make([]string, len(columns))
+		for i, column := range columns {
+			definitions[i] = s.quote(column) + " TEXT"
+		}
+
+		query := "CREATE TABLE IF NOT EXISTS " + s.quote(s.Table) + " (" + strings.Join(definitions, ", ") + ")"
+		if _, err := s.DB.ExecContext(ctx, query); err != nil {
+			return err
+		}
+
+		existing, err := s.tableColumns(ctx)
+		if err != nil {
+			return err
+		}
+		s.columns = existing
+	}
+
+	for _, column := range columns {
+		if s.columns[column] {
+			continue
+		}
+
+		query := "ALTER TABLE " + s.quote(s.Table) + " ADD COLUMN " + s.quote(column) + " TEXT"
+		if _, err := s.DB.ExecContext(ctx, query); err != nil {
+			return err
+		}
+		s.columns[column] = true
+	}
+	return nil
+}
+
+// tableColumns returns the columns of the table.
+func (s *SQL) tableColumns(ctx context.Context) (map[string]bool, error) {
+	rows, err := s.DB.QueryContext(ctx, "SELECT * FROM "+s.quote(s.Table)+" WHERE 1 = 0")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]bool, len(names))
+	for _, name := range names {
+		columns[name] = true
+	}
+	return columns, rows.Close()
+}
+
+// column returns the name of the column of the flattened selector name.
+func (s *SQL) column(name string) string {
+	if column, ok := s.Columns[name]; ok {
+		return column
+	}
+
+	return strings.Map(func(r rune) rune {
+		if (r == '_') || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func (s *SQL) placeholder(n int) string {
+	if s.Placeholder != nil {
+		return s.Placeholder(n)
+	}
+	return "?"
+}
+
+func (s *SQL) quote(ident string) string {
+	if s.Quote != nil {
+		return s.Quote(ident)
+	}
+	return `"` + strings.ReplaceAll(ident, `"`, `""`) + `"`
+}
+
+// sqlValue returns a value that can be used as a parameter of a statement.
+func sqlValue(value any) any {
+	switch v := value.(type) {
+	case nil, string, bool, int, int64, float64, []byte, time.Time:
+		return v
+	case json.Number:
+		return v.String()
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	return string(b)
+}
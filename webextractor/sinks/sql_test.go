@@ -0,0 +1,208 @@
+package sinks
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testDriver is a database/sql driver that stores the columns of a single table
+// and the rows inserted in it.
+type testDriver struct {
+	mu      sync.Mutex
+	columns []string
+	rows    []map[string]any
+	queries []string
+}
+
+var (
+	testDriverDB  = &testDriver{}
+	testIdentExpr = regexp.MustCompile(`"([^"]+)"`)
+)
+
+func init() {
+	sql.Register("colibri-test", testDriverDB)
+}
+
+func (d *testDriver) Open(name string) (driver.Conn, error) { return &testConn{d}, nil }
+
+type testConn struct{ d *testDriver }
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) { return &testStmt{c.d, query}, nil }
+func (c *testConn) Close() error                              { return nil }
+func (c *testConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type testStmt struct {
+	d     *testDriver
+	query string
+}
+
+func (s *testStmt) Close() error  { return nil }
+func (s *testStmt) NumInput() int { return -1 }
+
+func (s *testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	d := s.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.queries = append(d.queries, s.query)
+
+	var idents []string
+	for _, match := range testIdentExpr.FindAllStringSubmatch(s.query, -1) {
+		idents = append(idents, match[1])
+	}
+
+	switch {
+	case strings.HasPrefix(s.query, "CREATE TABLE IF NOT EXISTS"):
+		if d.columns == nil {
+			d.columns = idents[1:]
+		}
+
+	case strings.HasPrefix(s.query, "ALTER TABLE"):
+		d.columns = append(d.columns, idents[1])
+
+	case strings.HasPrefix(s.query, "INSERT INTO"):
+		row := make(map[string]any)
+		for i, column := range idents[1:] {
+			if !contains(d.columns, column) {
+				return nil, errors.New("no such column: " + column)
+			}
+			row[column] = args[i]
+		}
+		d.rows = append(d.rows, row)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	return &testRows{columns: append([]string(nil), s.d.columns...)}, nil
+}
+
+type testRows struct{ columns []string }
+
+func (r *testRows) Columns() []string              { return r.columns }
+func (r *testRows) Close() error                   { return nil }
+func (r *testRows) Next(dest []driver.Value) error { return io.EOF }
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSQL(t *testing.T) {
+	db, err := sql.Open("colibri-test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	s := NewSQL(db, "outputs")
+	s.CreateTable = true
+	s.Columns = map[string]string{"product_price": "price"}
+
+	rules, output := testOutput()
+	output.Data = map[string]any{
+		"title":   "Example Domain",
+		"product": map[string]any{"price": 10.5, "tags": []any{"a", "b"}},
+	}
+
+	if err := s.Write(rules, output, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	output.Data = map[string]any{"meta-description": "Example"}
+	if err := s.Write(rules, output, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Write(rules, nil, errors.New("test err")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	wantColumns := []string{"error", "price", "product_tags", "title", "url", "meta_description"}
+	if !reflect.DeepEqual(testDriverDB.columns, wantColumns) {
+		t.Fatalf(prefixGotWantFormat, "Columns", testDriverDB.columns, wantColumns)
+	}
+
+	wantRows := []map[string]any{
+		{
+			"error":        nil,
+			"price":        10.5,
+			"product_tags": `["a","b"]`,
+			"title":        "Example Domain",
+			"url":          "https://example.com",
+		},
+		{
+			"error":            nil,
+			"meta_description": "Example",
+			"url":              "https://example.com",
+		},
+		{
+			"error": "test err",
+			"url":   "https://example.com",
+		},
+	}
+	if !reflect.DeepEqual(testDriverDB.rows, wantRows) {
+		t.Fatalf(prefixGotWantFormat, "Rows", testDriverDB.rows, wantRows)
+	}
+
+	var creates int
+	for _, query := range testDriverDB.queries {
+		if strings.HasPrefix(query, "CREATE TABLE") {
+			creates++
+		}
+	}
+	if creates != 1 {
+		t.Fatalf(prefixGotWantFormat, "CREATE TABLE", creates, 1)
+	}
+
+	t.Run("ErrTableIsEmpty", func(t *testing.T) {
+		err := NewSQL(db, "").Write(rules, output, nil)
+		if !errors.Is(err, ErrTableIsEmpty) {
+			t.Fatalf(gotWantFormat, err, ErrTableIsEmpty)
+		}
+	})
+}
+
+func TestFlatten(t *testing.T) {
+	data := map[string]any{
+		"title": "Example",
+		"product": map[string]any{
+			"price": 10.5,
+			"seller": map[string]any{
+				"name": "Colibri",
+			},
+		},
+		"empty": map[string]any{},
+		"tags":  []any{"a", "b"},
+	}
+
+	got := Flatten(data, "_")
+	want := map[string]any{
+		"title":               "Example",
+		"product_price":       10.5,
+		"product_seller_name": "Colibri",
+		"empty":               map[string]any{},
+		"tags":                []any{"a", "b"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf(gotWantFormat, got, want)
+	}
+}
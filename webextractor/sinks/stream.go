@@ -0,0 +1,138 @@
+package sinks
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/gonzxlez/colibri"
+)
+
+// DefaultTopic default template of the topics of the messages.
+const DefaultTopic = "colibri"
+
+// Publisher publishes messages to a streaming platform such as Kafka or NATS.
+// The clients of these platforms can be adapted to this interface with a few lines,
+// e.g. with the WriteMessages method of a kafka-go Writer or the Publish method of a NATS connection.
+type Publisher interface {
+	// Publish publishes the message with the key to the topic.
+	// The key can be ignored by platforms that do not support message keys.
+	Publish(ctx context.Context, topic string, key, value []byte) error
+}
+
+// Topic contains the data available in the templates of the topics.
+type Topic struct {
+	// Host of the URL of the rules.
+	Host string
+
+	// Selector is the name of the selector of the message, it is empty if Split is false.
+	Selector string
+}
+
+// Stream publishes each output as a JSON Record to a topic, the key of the messages
+// is the host of the URL of the rules, so the outputs of a host keep their order in a partition.
+// See the colibri.Sink interface.
+type Stream struct {
+	// Publisher specifies the streaming platform.
+	Publisher Publisher
+
+	// Topic specifies the text/template of the topics, see the Topic structure.
+	// If it is empty, DefaultTopic is used.
+	Topic string
+
+	// Split specifies whether the value of each selector is published in its own message,
+	// in which the Data field of the Record only contains that selector.
+	// The errors are published in a message without selector.
+	Split bool
+
+	mu   sync.Mutex
+	tmpl *template.Template
+}
+
+// NewStream returns a new Stream structure that publishes the outputs to the topic.
+func NewStream(publisher Publisher, topic string) *Stream {
+	return &Stream{Publisher: publisher, Topic: topic}
+}
+
+func (stream *Stream) Write(rules *colibri.Rules, output *colibri.Output, err error) error {
+	var (
+		record = NewRecord(rules, output, err)
+		ctx    = context.Background()
+		host   string
+	)
+
+	if rules != nil {
+		ctx = rules.Context()
+		if rules.URL != nil {
+			host = rules.URL.Host
+		}
+	}
+
+	if !stream.Split || (record.Error != nil) {
+		return stream.publish(ctx, &Topic{Host: host}, record)
+	}
+
+	names := make([]string, 0, len(record.Data))
+	for name := range record.Data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	data := record.Data
+	for _, name := range names {
+		record.Data = map[string]any{name: data[name]}
+		if err := stream.publish(ctx, &Topic{Host: host, Selector: name}, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close does nothing, the publisher is not closed since it is not owned by the sink.
+func (stream *Stream) Close() error {
+	return nil
+}
+
+// publish publishes the record to the topic of the template.
+func (stream *Stream) publish(ctx context.Context, topic *Topic, record *Record) error {
+	tmpl, err := stream.template()
+	if err != nil {
+		return err
+	}
+
+	var name strings.Builder
+	if err := tmpl.Execute(&name, topic); err != nil {
+		return err
+	}
+
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return stream.Publisher.Publish(ctx, name.String(), []byte(topic.Host), value)
+}
+
+// template returns the parsed template of the topics.
+func (stream *Stream) template() (*template.Template, error) {
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+
+	if stream.tmpl != nil {
+		return stream.tmpl, nil
+	}
+
+	text := stream.Topic
+	if text == "" {
+		text = DefaultTopic
+	}
+
+	tmpl, err := template.New("topic").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	stream.tmpl = tmpl
+	return tmpl, nil
+}
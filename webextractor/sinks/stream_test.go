@@ -0,0 +1,88 @@
+package sinks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type testMessage struct {
+	Topic string
+	Key   string
+	Data  map[string]any
+	Error any
+}
+
+type testPublisher struct {
+	mu       sync.Mutex
+	messages []testMessage
+}
+
+func (p *testPublisher) Publish(_ context.Context, topic string, key, value []byte) error {
+	var record Record
+	if err := json.Unmarshal(value, &record); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.messages = append(p.messages, testMessage{topic, string(key), record.Data, record.Error})
+	return nil
+}
+
+func TestStream(t *testing.T) {
+	rules, output := testOutput()
+
+	tests := []struct {
+		Name  string
+		Topic string
+		Split bool
+		Want  []testMessage
+	}{
+		{
+			Name: "Default",
+			Want: []testMessage{
+				{Topic: "colibri", Key: "example.com", Data: map[string]any{"title": "Example Domain", "price": 10.5}},
+				{Topic: "colibri", Key: "example.com", Error: "test err"},
+			},
+		},
+		{
+			Name:  "Split",
+			Topic: "crawl.{{.Host}}.{{or .Selector \"errors\"}}",
+			Split: true,
+			Want: []testMessage{
+				{Topic: "crawl.example.com.price", Key: "example.com", Data: map[string]any{"price": 10.5}},
+				{Topic: "crawl.example.com.title", Key: "example.com", Data: map[string]any{"title": "Example Domain"}},
+				{Topic: "crawl.example.com.errors", Key: "example.com", Error: "test err"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			publisher := &testPublisher{}
+			stream := NewStream(publisher, tt.Topic)
+			stream.Split = tt.Split
+
+			if err := stream.Write(rules, output, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := stream.Write(rules, nil, errors.New("test err")); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := stream.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(publisher.messages, tt.Want) {
+				t.Fatalf(gotWantFormat, publisher.messages, tt.Want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package sinks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/gonzxlez/colibri"
+)
+
+// TemplateFuncs are the functions available in the templates of NewTemplate.
+//
+//   - json returns the JSON encoding of the value.
+//   - join joins the elements of a slice with the separator.
+var TemplateFuncs = template.FuncMap{
+	"json": func(value any) (string, error) {
+		b, err := json.Marshal(value)
+		return string(b), err
+	},
+	"join": func(sep string, value any) string {
+		values, ok := value.([]any)
+		if !ok {
+			return fmt.Sprint(value)
+		}
+
+		elems := make([]string, len(values))
+		for i, v := range values {
+			elems[i] = fmt.Sprint(v)
+		}
+		return strings.Join(elems, sep)
+	},
+}
+
+// Template renders each output with a text/template and writes the result to a writer,
+// the template is executed with the Record of the output.
+// It can be used to generate Markdown reports, HTML digests or CSV lines.
+// See the colibri.Sink interface.
+type Template struct {
+	// W specifies the writer of the rendered outputs.
+	W io.Writer
+
+	// Template specifies the template.
+	Template *template.Template
+
+	mu sync.Mutex
+}
+
+// NewTemplate returns a new Template structure that renders the outputs with the text
+// of the template, which can use the TemplateFuncs.
+func NewTemplate(w io.Writer, text string) (*Template, error) {
+	tmpl, err := template.New("output").Funcs(TemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{W: w, Template: tmpl}, nil
+}
+
+func (tmpl *Template) Write(rules *colibri.Rules, output *colibri.Output, err error) error {
+	record := NewRecord(rules, output, err)
+
+	tmpl.mu.Lock()
+	defer tmpl.mu.Unlock()
+
+	return tmpl.Template.Execute(tmpl.W, record)
+}
+
+// Close flushes the writer, the writer is not closed.
+func (tmpl *Template) Close() error {
+	tmpl.mu.Lock()
+	defer tmpl.mu.Unlock()
+
+	return flushWriter(tmpl.W)
+}
@@ -0,0 +1,41 @@
+package sinks
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTemplate(t *testing.T) {
+	rules, output := testOutput()
+	output.Data["tags"] = []any{"a", "b"}
+
+	var b strings.Builder
+	tmpl, err := NewTemplate(&b, "{{if .Error}}- {{.URL}}: {{.Error}}\n{{else}}"+
+		"- [{{.Data.title}}]({{.URL}}) {{.Data.price}} {{join \", \" .Data.tags}} {{json .Data.tags}}\n{{end}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tmpl.Write(rules, output, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tmpl.Write(rules, nil, errors.New("test err")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tmpl.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "- [Example Domain](https://example.com) 10.5 a, b [\"a\",\"b\"]\n" +
+		"- https://example.com: test err\n"
+	if got := b.String(); got != want {
+		t.Fatalf(gotWantFormat, got, want)
+	}
+
+	if _, err := NewTemplate(&b, "{{.URL"); err == nil {
+		t.Fatal("expected error")
+	}
+}
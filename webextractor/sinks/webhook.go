@@ -0,0 +1,143 @@
+package sinks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gonzxlez/colibri"
+)
+
+const (
+	// SignatureHeader is the header that contains the HMAC-SHA256 signature of the webhook body,
+	// in the format "sha256=<hex>".
+	SignatureHeader = "X-Colibri-Signature"
+
+	// DefaultWebhookRetries default number of retries of the webhook requests.
+	DefaultWebhookRetries = 3
+
+	// DefaultWebhookBackoff default wait time before the first retry,
+	// the wait time is doubled on each retry.
+	DefaultWebhookBackoff = time.Second
+)
+
+// ErrWebhookStatus is returned when the webhook responds with a status code other than 2xx.
+var ErrWebhookStatus = errors.New("unexpected webhook status code")
+
+// Webhook sends each output as a JSON Record in the body of a POST request.
+// The requests that fail or are answered with a 429 or 5xx status code are retried.
+// See the colibri.Sink interface.
+type Webhook struct {
+	// URL of the webhook.
+	URL string
+
+	// Secret specifies the key used to sign the body of the requests, see SignatureHeader.
+	// If it is empty, the requests are not signed.
+	Secret []byte
+
+	// Header contains additional headers of the requests.
+	Header http.Header
+
+	// Client specifies the HTTP client, if it is nil http.DefaultClient is used.
+	Client *http.Client
+
+	// Retries specifies the maximum number of retries.
+	Retries int
+
+	// Backoff specifies the wait time before the first retry.
+	Backoff time.Duration
+}
+
+// NewWebhook returns a new Webhook structure that sends the outputs to the URL
+// signing them with the secret.
+func NewWebhook(url string, secret []byte) *Webhook {
+	return &Webhook{
+		URL:     url,
+		Secret:  secret,
+		Retries: DefaultWebhookRetries,
+		Backoff: DefaultWebhookBackoff,
+	}
+}
+
+func (webhook *Webhook) Write(rules *colibri.Rules, output *colibri.Output, err error) error {
+	record := NewRecord(rules, output, err)
+
+	body, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if rules != nil {
+		ctx = rules.Context()
+	}
+
+	wait := webhook.Backoff
+	for i := 0; ; i++ {
+		retry, err := webhook.send(ctx, body)
+		if !retry || (i >= webhook.Retries) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// Close does nothing, the outputs are sent by Write.
+func (webhook *Webhook) Close() error {
+	return nil
+}
+
+// Sign returns the signature of the body with the secret, see SignatureHeader.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// send sends the body and returns true if the request must be retried.
+func (webhook *Webhook) send(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	for key, values := range webhook.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if len(webhook.Secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(webhook.Secret, body))
+	}
+
+	client := webhook.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	resp.Body.Close()
+
+	if (resp.StatusCode >= 200) && (resp.StatusCode <= 299) {
+		return false, nil
+	}
+
+	retry := (resp.StatusCode == http.StatusTooManyRequests) || (resp.StatusCode >= 500)
+	return retry, fmt.Errorf("%w: %d", ErrWebhookStatus, resp.StatusCode)
+}
@@ -0,0 +1,112 @@
+package sinks
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gonzxlez/colibri"
+	"github.com/gonzxlez/colibri/colibritest"
+)
+
+const (
+	gotWantFormat       = "got %v, want %v"
+	prefixGotWantFormat = "%v: got %v, want %v"
+)
+
+func mustNewURL(rawURL string) *url.URL {
+	u, _ := url.Parse(rawURL)
+	return u
+}
+
+func testOutput() (*colibri.Rules, *colibri.Output) {
+	rules := &colibri.Rules{URL: mustNewURL("https://example.com")}
+	output := &colibri.Output{
+		Response: &colibritest.Response{
+			RequestURL: mustNewURL("https://example.com"),
+			Serialized: map[string]any{"code": 200},
+		},
+		Data: map[string]any{
+			"title": "Example Domain",
+			"price": 10.5,
+		},
+	}
+	return rules, output
+}
+
+func TestWebhook(t *testing.T) {
+	var (
+		secret   = []byte("secret")
+		requests int
+		records  []map[string]any
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests++; requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		if got, want := r.Header.Get(SignatureHeader), Sign(secret, body); got != want {
+			t.Errorf(prefixGotWantFormat, "Signature", got, want)
+		}
+
+		var record map[string]any
+		if err := json.Unmarshal(body, &record); err != nil {
+			t.Error(err)
+		}
+		records = append(records, record)
+	}))
+	defer ts.Close()
+
+	webhook := NewWebhook(ts.URL, secret)
+	webhook.Backoff = time.Millisecond
+
+	rules, output := testOutput()
+	if err := webhook.Write(rules, output, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := webhook.Write(rules, nil, errors.New("test err")); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []map[string]any{
+		{
+			"url":      "https://example.com",
+			"response": map[string]any{"code": float64(200)},
+			"data":     map[string]any{"title": "Example Domain", "price": 10.5},
+		},
+		{
+			"url":   "https://example.com",
+			"error": "test err",
+		},
+	}
+
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf(gotWantFormat, records, want)
+	}
+
+	if err := webhook.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("ErrWebhookStatus", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer ts.Close()
+
+		err := NewWebhook(ts.URL, nil).Write(rules, output, nil)
+		if !errors.Is(err, ErrWebhookStatus) {
+			t.Fatalf(gotWantFormat, err, ErrWebhookStatus)
+		}
+	})
+}
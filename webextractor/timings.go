@@ -0,0 +1,155 @@
+package webextractor
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings contains the durations of the phases of a request, obtained with net/http/httptrace.
+// The durations of the phases that did not occur are zero, e.g. DNS and Connect
+// when the connection is reused. The phases of the redirects are added together.
+type Timings struct {
+	// DNS is the duration of the resolution of the host addresses.
+	DNS time.Duration
+
+	// Connect is the duration of the establishment of the TCP connection.
+	Connect time.Duration
+
+	// TLS is the duration of the TLS handshake.
+	TLS time.Duration
+
+	// TTFB is the time elapsed from the start of the request until the first byte of the response.
+	TTFB time.Duration
+
+	// Total is the time elapsed from the start of the request until the body is read or closed.
+	Total time.Duration
+}
+
+// Serializable returns the durations in milliseconds.
+func (t Timings) Serializable() map[string]any {
+	return map[string]any{
+		"dns":     fromDuration(t.DNS),
+		"connect": fromDuration(t.Connect),
+		"tls":     fromDuration(t.TLS),
+		"ttfb":    fromDuration(t.TTFB),
+		"total":   fromDuration(t.Total),
+	}
+}
+
+// timer measures the Timings of a request.
+type timer struct {
+	mu                                   sync.Mutex
+	start, dnsStart, connStart, tlsStart time.Time
+	timings                              Timings
+}
+
+func newTimer() *timer {
+	return &timer{start: time.Now()}
+}
+
+// reset restarts the measures, e.g. when the request is retried.
+func (t *timer) reset() {
+	t.mu.Lock()
+	t.start = time.Now()
+	t.timings = Timings{}
+	t.mu.Unlock()
+}
+
+// trace returns the hooks that measure the phases of the requests.
+func (t *timer) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { t.mark(&t.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { t.since(&t.timings.DNS, &t.dnsStart) },
+		ConnectStart:         func(_, _ string) { t.mark(&t.connStart) },
+		ConnectDone:          func(_, _ string, _ error) { t.since(&t.timings.Connect, &t.connStart) },
+		TLSHandshakeStart:    func() { t.mark(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.since(&t.timings.TLS, &t.tlsStart) },
+		GotFirstResponseByte: t.firstByte,
+	}
+}
+
+func (t *timer) mark(start *time.Time) {
+	t.mu.Lock()
+	*start = time.Now()
+	t.mu.Unlock()
+}
+
+// since adds the time elapsed since start to d.
+func (t *timer) since(d *time.Duration, start *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if start.IsZero() {
+		return
+	}
+	*d += time.Since(*start)
+	*start = time.Time{}
+}
+
+// firstByte records the TTFB, the last response of the redirects is kept.
+func (t *timer) firstByte() {
+	t.mu.Lock()
+	t.timings.TTFB = time.Since(t.start)
+	t.mu.Unlock()
+}
+
+// done records the Total duration, only the first call is recorded.
+func (t *timer) done() {
+	t.mu.Lock()
+	if t.timings.Total == 0 {
+		t.timings.Total = time.Since(t.start)
+	}
+	t.mu.Unlock()
+}
+
+func (t *timer) get() Timings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.timings
+}
+
+// body returns a ReadCloser that records the Total duration
+// when the end of rc is reached or it is closed.
+func (t *timer) body(rc io.ReadCloser) io.ReadCloser {
+	return &timedReadCloser{rc: rc, t: t}
+}
+
+type timedReadCloser struct {
+	rc io.ReadCloser
+	t  *timer
+}
+
+func (trc *timedReadCloser) Read(p []byte) (int, error) {
+	n, err := trc.rc.Read(p)
+	if err == io.EOF {
+		trc.t.done()
+	}
+	return n, err
+}
+
+func (trc *timedReadCloser) Close() error {
+	trc.t.done()
+	return trc.rc.Close()
+}
+
+func fromDuration(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// traceDNS calls the DNS hooks of the trace of the context around the lookup,
+// it is used when the addresses are obtained by the Resolver of the Client.
+func traceDNS(trace *httptrace.ClientTrace, host string, lookup func() error) error {
+	if (trace != nil) && (trace.DNSStart != nil) {
+		trace.DNSStart(httptrace.DNSStartInfo{Host: host})
+	}
+
+	err := lookup()
+	if (trace != nil) && (trace.DNSDone != nil) {
+		trace.DNSDone(httptrace.DNSDoneInfo{Err: err})
+	}
+	return err
+}
@@ -0,0 +1,94 @@
+package webextractor
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"time"
+)
+
+// TLSInfo contains the details of the TLS connection of a response.
+type TLSInfo struct {
+	// Version is the TLS version of the connection, e.g. "TLS 1.3".
+	Version string
+
+	// CipherSuite is the name of the cipher suite of the connection.
+	CipherSuite string
+
+	// ServerName is the server name sent by the client in the handshake.
+	ServerName string
+
+	// Certificates contains the certificate chain sent by the server, the first one is the leaf certificate.
+	Certificates []CertificateInfo
+}
+
+// CertificateInfo contains the details of a certificate.
+type CertificateInfo struct {
+	Subject   string
+	Issuer    string
+	DNSNames  []string
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// Expires returns the expiry time of the leaf certificate, returns the zero time if there are no certificates.
+func (info *TLSInfo) Expires() time.Time {
+	if len(info.Certificates) == 0 {
+		return time.Time{}
+	}
+	return info.Certificates[0].NotAfter
+}
+
+// Serializable returns the details of the connection, the times are formatted as RFC 3339.
+func (info *TLSInfo) Serializable() map[string]any {
+	certificates := make([]map[string]any, 0, len(info.Certificates))
+	for _, cert := range info.Certificates {
+		certificates = append(certificates, map[string]any{
+			"subject":   cert.Subject,
+			"issuer":    cert.Issuer,
+			"dnsNames":  cert.DNSNames,
+			"notBefore": cert.NotBefore.UTC().Format(time.RFC3339),
+			"notAfter":  cert.NotAfter.UTC().Format(time.RFC3339),
+		})
+	}
+
+	m := map[string]any{
+		"version":      info.Version,
+		"cipherSuite":  info.CipherSuite,
+		"serverName":   info.ServerName,
+		"certificates": certificates,
+	}
+
+	if expires := info.Expires(); !expires.IsZero() {
+		m["expires"] = expires.UTC().Format(time.RFC3339)
+	}
+	return m
+}
+
+// newTLSInfo returns the details of the connection state, returns nil if the connection is not TLS.
+func newTLSInfo(state *tls.ConnectionState) *TLSInfo {
+	if state == nil {
+		return nil
+	}
+
+	info := &TLSInfo{
+		Version:      tls.VersionName(state.Version),
+		CipherSuite:  tls.CipherSuiteName(state.CipherSuite),
+		ServerName:   state.ServerName,
+		Certificates: make([]CertificateInfo, 0, len(state.PeerCertificates)),
+	}
+
+	for _, cert := range state.PeerCertificates {
+		info.Certificates = append(info.Certificates, newCertificateInfo(cert))
+	}
+	return info
+}
+
+func newCertificateInfo(cert *x509.Certificate) CertificateInfo {
+	return CertificateInfo{
+		Subject:   cert.Subject.String(),
+		Issuer:    cert.Issuer.String(),
+		DNSNames:  cert.DNSNames,
+		NotBefore: cert.NotBefore,
+		NotAfter:  cert.NotAfter,
+	}
+}
@@ -0,0 +1,252 @@
+package webextractor
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"math"
+	"net/url"
+	"sync"
+)
+
+// VisitedURLs stores the visited URLs in memory.
+// See the colibri.Visited interface.
+type VisitedURLs struct {
+	rw   sync.RWMutex
+	urls map[string]struct{}
+}
+
+// NewVisitedURLs returns a new VisitedURLs structure.
+func NewVisitedURLs() *VisitedURLs {
+	return &VisitedURLs{urls: make(map[string]struct{})}
+}
+
+func (visited *VisitedURLs) Visit(u *url.URL) bool {
+	key := u.String()
+
+	visited.rw.Lock()
+	defer visited.rw.Unlock()
+
+	if _, ok := visited.urls[key]; ok {
+		return true
+	}
+	visited.urls[key] = struct{}{}
+	return false
+}
+
+func (visited *VisitedURLs) Clear() {
+	visited.rw.Lock()
+	clear(visited.urls)
+	visited.rw.Unlock()
+}
+
+// MarshalJSON returns the JSON representation of the visited URLs.
+func (visited *VisitedURLs) MarshalJSON() ([]byte, error) {
+	visited.rw.RLock()
+	urls := make([]string, 0, len(visited.urls))
+	for u := range visited.urls {
+		urls = append(urls, u)
+	}
+	visited.rw.RUnlock()
+
+	return json.Marshal(urls)
+}
+
+// UnmarshalJSON adds the URLs stored with MarshalJSON.
+func (visited *VisitedURLs) UnmarshalJSON(b []byte) error {
+	var urls []string
+	if err := json.Unmarshal(b, &urls); err != nil {
+		return err
+	}
+
+	visited.rw.Lock()
+	for _, u := range urls {
+		visited.urls[u] = struct{}{}
+	}
+	visited.rw.Unlock()
+	return nil
+}
+
+// SeenHashes stores the hashes of the response bodies in memory.
+// See the colibri.Duplicates interface.
+type SeenHashes struct {
+	rw     sync.RWMutex
+	hashes map[string]struct{}
+}
+
+// NewSeenHashes returns a new SeenHashes structure.
+func NewSeenHashes() *SeenHashes {
+	return &SeenHashes{hashes: make(map[string]struct{})}
+}
+
+func (seen *SeenHashes) Seen(hash string) bool {
+	seen.rw.Lock()
+	defer seen.rw.Unlock()
+
+	if _, ok := seen.hashes[hash]; ok {
+		return true
+	}
+	seen.hashes[hash] = struct{}{}
+	return false
+}
+
+func (seen *SeenHashes) Clear() {
+	seen.rw.Lock()
+	clear(seen.hashes)
+	seen.rw.Unlock()
+}
+
+// MarshalJSON returns the JSON representation of the seen hashes.
+func (seen *SeenHashes) MarshalJSON() ([]byte, error) {
+	seen.rw.RLock()
+	hashes := make([]string, 0, len(seen.hashes))
+	for hash := range seen.hashes {
+		hashes = append(hashes, hash)
+	}
+	seen.rw.RUnlock()
+
+	return json.Marshal(hashes)
+}
+
+// UnmarshalJSON adds the hashes stored with MarshalJSON.
+func (seen *SeenHashes) UnmarshalJSON(b []byte) error {
+	var hashes []string
+	if err := json.Unmarshal(b, &hashes); err != nil {
+		return err
+	}
+
+	seen.rw.Lock()
+	for _, hash := range hashes {
+		seen.hashes[hash] = struct{}{}
+	}
+	seen.rw.Unlock()
+	return nil
+}
+
+// ErrBloomFilterSize is returned when restoring a Bloom filter of a different size.
+var ErrBloomFilterSize = errors.New("bloom filter size does not match")
+
+// BloomFilter stores the visited URLs in a Bloom filter.
+// It uses a fixed amount of memory regardless of the number of URLs,
+// at the cost of reporting some URLs that were never visited as visited.
+// See the colibri.Visited interface.
+type BloomFilter struct {
+	rw   sync.RWMutex
+	bits []uint64
+	m    uint64 // number of bits
+	k    uint64 // number of hash functions
+}
+
+// NewBloomFilter returns a new BloomFilter structure sized to store n URLs
+// with a false positive probability of fpRate.
+func NewBloomFilter(n uint64, fpRate float64) *BloomFilter {
+	if n == 0 {
+		n = 1
+	}
+
+	if (fpRate <= 0) || (fpRate >= 1) {
+		fpRate = 0.01
+	}
+
+	m := uint64(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k == 0 {
+		k = 1
+	}
+
+	return &BloomFilter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+func (bf *BloomFilter) Visit(u *url.URL) bool {
+	h1, h2 := bloomHash(u.String())
+
+	bf.rw.Lock()
+	defer bf.rw.Unlock()
+
+	visited := true
+	for i := uint64(0); i < bf.k; i++ {
+		bit := (h1 + i*h2) % bf.m
+		if bf.bits[bit/64]&(1<<(bit%64)) == 0 {
+			visited = false
+			bf.bits[bit/64] |= 1 << (bit % 64)
+		}
+	}
+	return visited
+}
+
+func (bf *BloomFilter) Clear() {
+	bf.rw.Lock()
+	clear(bf.bits)
+	bf.rw.Unlock()
+}
+
+type bloomFilterJSON struct {
+	M    uint64 `json:"m"`
+	K    uint64 `json:"k"`
+	Bits []byte `json:"bits"`
+}
+
+// MarshalJSON returns the JSON representation of the filter.
+func (bf *BloomFilter) MarshalJSON() ([]byte, error) {
+	bf.rw.RLock()
+	data := bloomFilterJSON{
+		M:    bf.m,
+		K:    bf.k,
+		Bits: make([]byte, 8*len(bf.bits)),
+	}
+	for i, word := range bf.bits {
+		binary.LittleEndian.PutUint64(data.Bits[8*i:], word)
+	}
+	bf.rw.RUnlock()
+
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON restores the filter stored with MarshalJSON.
+// Returns ErrBloomFilterSize if the stored filter has a different size.
+func (bf *BloomFilter) UnmarshalJSON(b []byte) error {
+	var data bloomFilterJSON
+	if err := json.Unmarshal(b, &data); err != nil {
+		return err
+	}
+
+	bf.rw.Lock()
+	defer bf.rw.Unlock()
+
+	if (data.M != bf.m) || (data.K != bf.k) || (len(data.Bits) != 8*len(bf.bits)) {
+		return ErrBloomFilterSize
+	}
+
+	for i := range bf.bits {
+		bf.bits[i] = binary.LittleEndian.Uint64(data.Bits[8*i:])
+	}
+	return nil
+}
+
+// bloomHash returns two independent hashes of the key
+// used to derive the k positions of the filter (double hashing).
+func bloomHash(key string) (uint64, uint64) {
+	h := fnv.New128a()
+	h.Write([]byte(key))
+	sum := h.Sum(nil)
+
+	h1 := mix64(binary.BigEndian.Uint64(sum[:8]))
+	h2 := mix64(binary.BigEndian.Uint64(sum[8:])) | 1
+	return h1, h2
+}
+
+// mix64 spreads the bits of x, FNV hashes of similar keys
+// differ mostly in their low bits.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,178 @@
+package webextractor
+
+import (
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/gonzxlez/colibri"
+)
+
+func TestVisitedURLs(t *testing.T) {
+	var (
+		visited = NewVisitedURLs()
+		u       = mustNewURL("https://pkg.go.dev")
+	)
+
+	if visited.Visit(u) {
+		t.Fatal("URL visited")
+	}
+
+	if !visited.Visit(u) {
+		t.Fatal("URL not visit")
+	}
+
+	visited.Clear()
+
+	if visited.Visit(u) {
+		t.Fatal("Uncleaned")
+	}
+}
+
+func TestBloomFilter(t *testing.T) {
+	const (
+		n      = 10000
+		fpRate = 0.01
+	)
+
+	bf := NewBloomFilter(n, fpRate)
+	for i := 0; i < n; i++ {
+		bf.Visit(mustNewURL("https://example.com/" + strconv.Itoa(i)))
+	}
+
+	for i := 0; i < n; i++ {
+		if !bf.Visit(mustNewURL("https://example.com/" + strconv.Itoa(i))) {
+			t.Fatal("false negative")
+		}
+	}
+
+	// Visit also stores the URLs, a few probes keep the filter close to n.
+	const probes = n / 10
+
+	var falsePositives int
+	for i := n; i < n+probes; i++ {
+		if bf.Visit(mustNewURL("https://example.com/" + strconv.Itoa(i))) {
+			falsePositives++
+		}
+	}
+
+	if rate := float64(falsePositives) / probes; rate > 2*fpRate {
+		t.Fatalf(prefixGotWantFormat, "False positive rate", rate, fpRate)
+	}
+
+	bf.Clear()
+
+	if bf.Visit(mustNewURL("https://example.com/0")) {
+		t.Fatal("Uncleaned")
+	}
+}
+
+func TestWithVisited(t *testing.T) {
+	ts := testServer()
+	defer ts.Close()
+
+	we, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	we.Delay = nil     // Deactivate Delay
+	we.RobotsTxt = nil // Deactivate RobotsTxt
+	we.Visited = NewBloomFilter(100, 0.01)
+
+	rules := &colibri.Rules{
+		Method: "GET",
+		URL:    mustNewURL(ts.URL + "/html"),
+		Selectors: []*colibri.Selector{
+			{Name: "links", Expr: "//a/@href", All: true, Follow: true},
+		},
+	}
+
+	output, err := we.Extract(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if links := output.Data["links"].([]any); len(links) != 3 {
+		t.Fatalf(prefixGotWantFormat, "Followed", len(links), 3)
+	}
+
+	if _, err := we.Extract(rules); err != colibri.ErrAlreadyVisited {
+		t.Fatalf(gotWantFormat, err, colibri.ErrAlreadyVisited)
+	}
+}
+
+func TestSeenHashes(t *testing.T) {
+	seen := NewSeenHashes()
+
+	if seen.Seen("hash") {
+		t.Fatal("hash seen")
+	}
+
+	if !seen.Seen("hash") {
+		t.Fatal("hash not seen")
+	}
+
+	seen.Clear()
+
+	if seen.Seen("hash") {
+		t.Fatal("Uncleaned")
+	}
+}
+
+func TestWithDuplicates(t *testing.T) {
+	ts := testServer()
+	defer ts.Close()
+
+	we, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	we.Delay = nil     // Deactivate Delay
+	we.RobotsTxt = nil // Deactivate RobotsTxt
+	we.Duplicates = NewSeenHashes()
+
+	rules := &colibri.Rules{
+		Method:    "GET",
+		URL:       mustNewURL(ts.URL + "/html"),
+		Selectors: []*colibri.Selector{{Name: "title", Expr: "//title"}},
+	}
+
+	output, err := we.Extract(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hash := output.Response.Serializable()["hash"]; hash != testHash(htmlBody+"\n") {
+		t.Fatalf(prefixGotWantFormat, "Hash", hash, testHash(htmlBody+"\n"))
+	}
+
+	// Same content, different URL.
+	rules.URL = mustNewURL(ts.URL + "/html?mirror=1")
+	if _, err := we.Extract(rules); err != colibri.ErrDuplicateContent {
+		t.Fatalf(gotWantFormat, err, colibri.ErrDuplicateContent)
+	}
+
+	t.Run("Hash", func(t *testing.T) {
+		resp, err := we.Do(&colibri.Rules{Method: "GET", URL: mustNewURL(ts.URL + "/text")})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		hash, err := resp.(colibri.Hasher).Hash()
+		if err != nil {
+			t.Fatal(err)
+		} else if hash != testHash(textBody+"\n") {
+			t.Fatalf(gotWantFormat, hash, testHash(textBody+"\n"))
+		}
+
+		// The body can still be read.
+		b, err := io.ReadAll(resp.Body())
+		if err != nil {
+			t.Fatal(err)
+		} else if string(b) != textBody+"\n" {
+			t.Fatal("not equal")
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package colibri
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+)
+
+var (
+	// ErrWorkflowIsNil is returned when the workflow is nil.
+	ErrWorkflowIsNil = errors.New("workflow is nil")
+
+	// ErrInvalidForEach is returned when the ForEach value of a step is not a list.
+	ErrInvalidForEach = errors.New("ForEach value is not a list")
+)
+
+// ItemVar is the variable of the element of the ForEach list of a step, see the Step structure.
+const ItemVar = "item"
+
+// Workflow is an ordered list of steps, e.g. search, detail pages and API calls,
+// in which the steps use the values extracted by the previous steps.
+type Workflow struct {
+	// Steps contains the steps, they are run in order.
+	Steps []*Step `json:"steps"`
+}
+
+// Step is a step of a Workflow.
+//
+// The data extracted by the previous steps is available as variables named after the steps,
+// e.g. ${search.total}, see WithVars.
+type Step struct {
+	// Name is the name of the step and of the variable with its data.
+	Name string `json:"name"`
+
+	// Rules specifies the rules of the step.
+	Rules *Rules `json:"rules"`
+
+	// ForEach is the path of a list of the data of the previous steps, e.g. "search.links".
+	// If it is not empty, the rules are extracted for each element of the list, that is available
+	// as the ${item} variable, and the data of the step is the list of the data of each element,
+	// nil for the elements that do not obtain data. The step does not obtain data if the list is empty
+	// or none of its elements obtains data.
+	// If the rules do not have a URL, the element is the URL, relative URLs are resolved
+	// with the URL of the previous step.
+	ForEach string `json:"forEach,omitempty"`
+}
+
+// RunWorkflow runs the steps of the workflow in order and returns the data of each step by name.
+//
+// The errors are stored by step name and, for the steps with ForEach, by the index of the element.
+// The workflow stops at the first step that does not obtain data.
+func (c *Colibri) RunWorkflow(workflow *Workflow) (map[string]any, error) {
+	if workflow == nil {
+		return nil, ErrWorkflowIsNil
+	}
+
+	var (
+		results = make(map[string]any)
+		lastURL *url.URL
+		errs    error
+	)
+	for _, step := range workflow.Steps {
+		if step.Rules == nil {
+			errs = AddError(errs, step.Name, ErrRulesIsNil)
+			break
+		}
+
+		if step.ForEach == "" {
+			out, err := c.runStep(step, results, nil, lastURL)
+			if err != nil {
+				errs = AddError(errs, step.Name, err)
+			}
+
+			if (out == nil) || (out.Data == nil) {
+				break
+			}
+
+			results[step.Name] = out.Data
+			lastURL = out.Response.URL()
+			continue
+		}
+
+		value, _ := lookupVar(results, step.ForEach)
+		items, ok := value.([]any)
+		if !ok {
+			errs = AddError(errs, step.Name, ErrInvalidForEach)
+			break
+		}
+
+		// The data of each element is stored by index, nil if the element does not obtain data,
+		// so the indices match the ones of the errors.
+		var (
+			data    = make([]any, len(items))
+			found   bool
+			stepErr error
+		)
+		for i, item := range items {
+			// The elements without data of a previous step with ForEach are skipped.
+			if item == nil {
+				continue
+			}
+
+			out, err := c.runStep(step, results, item, lastURL)
+			if err != nil {
+				stepErr = AddError(stepErr, strconv.Itoa(i), err)
+			}
+
+			if (out != nil) && (out.Data != nil) {
+				data[i] = out.Data
+				found = true
+			}
+		}
+
+		if stepErr != nil {
+			errs = AddError(errs, step.Name, stepErr)
+		}
+
+		if !found {
+			break
+		}
+		results[step.Name] = data
+	}
+	return results, errs
+}
+
+// runStep extracts the rules of the step with the data of the previous steps and the ForEach item as variables.
+func (c *Colibri) runStep(step *Step, results map[string]any, item any, lastURL *url.URL) (*Output, error) {
+	rules := step.Rules.Clone()
+	defer ReleaseRules(rules)
+
+	vars := make(map[string]any, len(results)+1)
+	for name, data := range results {
+		vars[name] = data
+	}
+
+	if item != nil {
+		vars[ItemVar] = item
+
+		if rules.URL == nil {
+			u, err := ToURL(item)
+			if err != nil {
+				return nil, err
+			}
+
+			if !u.IsAbs() && (lastURL != nil) {
+				u = lastURL.ResolveReference(u)
+			}
+			rules.URL = u
+		}
+	}
+
+	rules.SetContext(WithVars(rules.Context(), vars))
+	return c.Extract(rules)
+}
@@ -0,0 +1,122 @@
+package colibri
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRunWorkflow(t *testing.T) {
+	c := New()
+	c.Client = &testClient{}
+	c.Parser = &testParser{}
+
+	number := []*Selector{{Name: "number", Expr: "!number"}}
+	workflow := &Workflow{
+		Steps: []*Step{
+			{Name: "first", Rules: &Rules{URL: mustNewURL("http://example.com"), Selectors: number}},
+			{Name: "second", ForEach: "first.number", Rules: &Rules{Selectors: number}},
+			{Name: "third", Rules: &Rules{URL: mustNewURL("http://example.com/${first.number}"), Selectors: number}},
+		},
+	}
+
+	results, err := c.RunWorkflow(workflow)
+
+	var errs *Errs
+	if !errors.As(err, &errs) {
+		t.Fatalf("got %v, want *Errs", err)
+	} else if e, _ := errs.Get("second"); !errors.Is(e, ErrInvalidForEach) {
+		t.Fatalf("got %v, want %v", e, ErrInvalidForEach)
+	}
+
+	want := map[string]any{"first": map[string]any{"number": 505}}
+	if !reflect.DeepEqual(results, want) {
+		t.Fatalf("got %v, want %v", results, want)
+	}
+
+	if _, err := c.RunWorkflow(nil); !errors.Is(err, ErrWorkflowIsNil) {
+		t.Fatalf("got %v, want %v", err, ErrWorkflowIsNil)
+	}
+}
+
+// listNode is a testNode whose FindAll finds the paths "fail" and "ok", none with "!empty".
+type listNode struct{ testNode }
+
+func (node *listNode) FindAll(selector *Selector) ([]Node, error) {
+	if selector.Expr == "!empty" {
+		return nil, nil
+	}
+	return []Node{&testNode{value: "fail"}, &testNode{value: "ok"}}, nil
+}
+
+type listParser struct{ testParser }
+
+func (p *listParser) Parse(_ *Rules, _ Response) (Node, error) { return &listNode{}, nil }
+
+// failClient is a testClient whose requests to the "/fail" path fail.
+type failClient struct{ testClient }
+
+func (client *failClient) Do(c *Colibri, rules *Rules) (Response, error) {
+	if rules.URL.Path == "/fail" {
+		return nil, errors.New("test err")
+	}
+	return client.testClient.Do(c, rules)
+}
+
+func TestRunWorkflowForEach(t *testing.T) {
+	c := New()
+	c.Client = &failClient{}
+	c.Parser = &listParser{}
+
+	number := []*Selector{{Name: "number", Expr: "!number"}}
+	first := &Step{Name: "first", Rules: &Rules{
+		URL: mustNewURL("http://example.com"),
+		Selectors: []*Selector{
+			{Name: "links", Expr: "//a", All: true},
+			{Name: "empty", Expr: "!empty", All: true},
+		},
+	}}
+
+	t.Run("Index", func(t *testing.T) {
+		results, err := c.RunWorkflow(&Workflow{Steps: []*Step{
+			first,
+			{Name: "second", ForEach: "first.links", Rules: &Rules{Selectors: number}},
+			{Name: "third", ForEach: "second", Rules: &Rules{URL: mustNewURL("http://example.com/${item.number}"), Selectors: number}},
+		}})
+
+		var errs *Errs
+		if !errors.As(err, &errs) {
+			t.Fatalf("got %v, want *Errs", err)
+		} else if e, _ := errs.Get("second"); e == nil {
+			t.Fatalf("got %v, want %v", e, "error")
+		} else if e, _ := e.(*Errs).Get("0"); e == nil {
+			t.Fatalf("got %v, want %v", e, "error of the index 0")
+		} else if e, _ := errs.Get("third"); e != nil {
+			t.Fatalf("got %v, want %v", e, nil)
+		}
+
+		want := []any{nil, map[string]any{"number": 505}}
+		if !reflect.DeepEqual(results["second"], want) {
+			t.Fatalf("got %v, want %v", results["second"], want)
+		} else if !reflect.DeepEqual(results["third"], want) {
+			t.Fatalf("got %v, want %v", results["third"], want)
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		results, err := c.RunWorkflow(&Workflow{Steps: []*Step{
+			first,
+			{Name: "second", ForEach: "first.empty", Rules: &Rules{Selectors: number}},
+			{Name: "third", Rules: &Rules{URL: mustNewURL("http://example.com"), Selectors: number}},
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := results["second"]; ok {
+			t.Fatalf("got %v, want %v", results, "no second step")
+		} else if _, ok := results["third"]; ok {
+			t.Fatalf("got %v, want %v", results, "no third step")
+		}
+	})
+}